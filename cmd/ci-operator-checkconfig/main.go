@@ -21,8 +21,14 @@ import (
 
 type tagSet map[api.ImageStreamTagReference][]*config.Info
 
+// targetSet records where images promoted to external registries come from
+type targetSet map[string][]*config.Info
+
 type promotedTag struct {
-	tag      api.ImageStreamTagReference
+	tag api.ImageStreamTagReference
+	// target is the pull spec of an image promoted to a promotion target,
+	// set instead of the tag
+	target   string
 	repoInfo *config.Info
 }
 
@@ -34,11 +40,12 @@ type options struct {
 }
 
 func (o *options) parse() error {
-	var registryDir string
+	var registryDir, versionsDir string
 
 	fs := flag.NewFlagSet("", flag.ExitOnError)
 
 	fs.StringVar(&registryDir, "registry", "", "Path to the step registry directory")
+	fs.StringVar(&versionsDir, "registry-versions", "", "Path to a directory holding a snapshot of the step registry for every version components can be pinned to")
 	fs.UintVar(&o.maxConcurrency, "concurrency", uint(runtime.GOMAXPROCS(0)), "Maximum number of concurrent in-flight goroutines.")
 
 	o.Options.Bind(fs)
@@ -47,7 +54,7 @@ func (o *options) parse() error {
 		return fmt.Errorf("failed to parse flags: %w", err)
 	}
 
-	if err := o.loadResolver(registryDir); err != nil {
+	if err := o.loadResolver(registryDir, versionsDir); err != nil {
 		return fmt.Errorf("failed to load registry: %w", err)
 	}
 	if err := o.Options.Validate(); err != nil {
@@ -80,8 +87,13 @@ func (o *options) validate() (ret []error) {
 		}()
 	}
 	seen := tagSet{}
+	seenTargets := targetSet{}
 	go func() {
 		for i := range seenCh {
+			if i.target != "" {
+				seenTargets[i.target] = append(seenTargets[i.target], i.repoInfo)
+				continue
+			}
 			seen[i.tag] = append(seen[i.tag], i.repoInfo)
 		}
 		doneCh <- struct{}{}
@@ -108,10 +120,11 @@ func (o *options) validate() (ret []error) {
 	<-doneCh
 	close(doneCh)
 	ret = append(ret, validateTags(seen)...)
+	ret = append(ret, validateTargets(seenTargets)...)
 	return
 }
 
-func (o *options) loadResolver(path string) error {
+func (o *options) loadResolver(path, versionsPath string) error {
 	if path == "" {
 		return nil
 	}
@@ -119,7 +132,13 @@ func (o *options) loadResolver(path string) error {
 	if err != nil {
 		return err
 	}
-	o.resolver = registry.NewResolver(refs, chains, workflows, observers)
+	var versions registry.VersionByName
+	if versionsPath != "" {
+		if versions, err = load.RegistryVersions(versionsPath, load.RegistryFlag(0)); err != nil {
+			return err
+		}
+	}
+	o.resolver = registry.NewVersionedResolver(refs, chains, workflows, observers, versions)
 	return nil
 }
 
@@ -141,7 +160,10 @@ func (o *options) validateConfiguration(
 		return err
 	}
 	for _, tag := range release.PromotedTags(configuration) {
-		seenCh <- promotedTag{tag, repoInfo}
+		seenCh <- promotedTag{tag: tag, repoInfo: repoInfo}
+	}
+	for _, image := range release.PromotedTargetImages(configuration) {
+		seenCh <- promotedTag{target: image.PullSpec(), repoInfo: repoInfo}
 	}
 	if configuration.PromotionConfiguration != nil && configuration.PromotionConfiguration.RegistryOverride != "" {
 		return errors.New("setting promotion.registry_override is not allowed")
@@ -155,19 +177,34 @@ func validateTags(seen tagSet) []error {
 		if len(infos) <= 1 {
 			continue
 		}
-		formatted := []string{}
-		for _, info := range infos {
-			identifier := fmt.Sprintf("%s/%s@%s", info.Org, info.Repo, info.Branch)
-			if info.Variant != "" {
-				identifier = fmt.Sprintf("%s [%s]", identifier, info.Variant)
-			}
-			formatted = append(formatted, identifier)
+		dupes = append(dupes, fmt.Errorf("output tag %s is promoted from more than one place: %v", tag.ISTagName(), formatInfos(infos)))
+	}
+	return dupes
+}
+
+func validateTargets(seen targetSet) []error {
+	var dupes []error
+	for target, infos := range seen {
+		if len(infos) <= 1 {
+			continue
 		}
-		dupes = append(dupes, fmt.Errorf("output tag %s is promoted from more than one place: %v", tag.ISTagName(), strings.Join(formatted, ", ")))
+		dupes = append(dupes, fmt.Errorf("output image %s is promoted from more than one place: %v", target, formatInfos(infos)))
 	}
 	return dupes
 }
 
+func formatInfos(infos []*config.Info) string {
+	formatted := []string{}
+	for _, info := range infos {
+		identifier := fmt.Sprintf("%s/%s@%s", info.Org, info.Repo, info.Branch)
+		if info.Variant != "" {
+			identifier = fmt.Sprintf("%s [%s]", identifier, info.Variant)
+		}
+		formatted = append(formatted, identifier)
+	}
+	return strings.Join(formatted, ", ")
+}
+
 func main() {
 	o := options{}
 	if err := o.parse(); err != nil {
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
@@ -20,6 +21,8 @@ import (
 	"k8s.io/test-infra/prow/simplifypath"
 
 	"github.com/openshift/ci-tools/pkg/load/agents"
+	"github.com/openshift/ci-tools/pkg/registry"
+	"github.com/openshift/ci-tools/pkg/registry/impact"
 	registryserver "github.com/openshift/ci-tools/pkg/registry/server"
 	"github.com/openshift/ci-tools/pkg/webreg"
 )
@@ -27,6 +30,7 @@ import (
 type options struct {
 	configPath             string
 	registryPath           string
+	registryVersionsPath   string
 	logLevel               string
 	address                string
 	port                   int
@@ -47,6 +51,7 @@ func gatherOptions() (options, error) {
 	fs := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
 	fs.StringVar(&o.configPath, "config", "", "Path to config dirs")
 	fs.StringVar(&o.registryPath, "registry", "", "Path to registry dirs")
+	fs.StringVar(&o.registryVersionsPath, "registry-versions", "", "Path to a directory holding a snapshot of the registry for every version components can be pinned to, in subdirectories named like the versions")
 	fs.StringVar(&o.logLevel, "log-level", "info", "Level at which to log output.")
 	fs.StringVar(&o.address, "address", ":8080", "DEPRECATED: Address to run server on")
 	fs.StringVar(&o.uiAddress, "ui-address", ":8082", "DEPRECATED: Address to run the registry UI on")
@@ -86,6 +91,11 @@ func validateOptions(o options) error {
 		}
 		return fmt.Errorf("Error getting stat info for --registry directory: %w", err)
 	}
+	if o.registryVersionsPath != "" {
+		if _, err := os.Stat(o.registryVersionsPath); err != nil {
+			return fmt.Errorf("--registry-versions points to a nonexistent directory: %w", err)
+		}
+	}
 	if o.validateOnly && o.flatRegistry {
 		return errors.New("--validate-only and --flat-registry flags cannot be set simultaneously")
 	}
@@ -106,6 +116,55 @@ func getRegistryGeneration(agent agents.RegistryAgent) http.HandlerFunc {
 	}
 }
 
+// getImpact lists what would change if the registry component in the
+// `step` query changed; the `type` query is needed when a reference, chain
+// and workflow share the name
+func getImpact(configAgent agents.ConfigAgent, registryAgent agents.RegistryAgent) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" {
+			w.WriteHeader(http.StatusNotImplemented)
+			_, _ = w.Write([]byte(http.StatusText(http.StatusNotImplemented)))
+			return
+		}
+		step := r.URL.Query().Get("step")
+		if step == "" {
+			metrics.RecordError("invalid query", configresolverMetrics.ErrorRate)
+			registryserver.MissingQuery(w, "step")
+			return
+		}
+		logger := logrus.WithField("step", step)
+		refs, chains, workflows, _, _ := registryAgent.GetRegistryComponents()
+		graph, err := registry.NewGraph(refs, chains, workflows)
+		if err != nil {
+			metrics.RecordError("failed to build registry graph", configresolverMetrics.ErrorRate)
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprintf(w, "failed to build registry graph: %v", err)
+			logger.WithError(err).Error("failed to build registry graph")
+			return
+		}
+		node, err := impact.Find(graph, r.URL.Query().Get("type"), step)
+		if err != nil {
+			metrics.RecordError("component not found", configresolverMetrics.ErrorRate)
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprintf(w, "failed to find component: %v", err)
+			logger.WithError(err).Warning("failed to find component")
+			return
+		}
+		raw, err := json.MarshalIndent(impact.Analyze(node, workflows, configAgent.GetAll()), "", "  ")
+		if err != nil {
+			metrics.RecordError("failed to marshal impact", configresolverMetrics.ErrorRate)
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprintf(w, "failed to marshal impact to JSON: %v", err)
+			logger.WithError(err).Error("failed to marshal impact to JSON")
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write(raw); err != nil {
+			logger.WithError(err).Error("Failed to write response")
+		}
+	}
+}
+
 // l and v keep the tree legible
 func l(fragment string, children ...simplifypath.Node) simplifypath.Node {
 	return simplifypath.L(fragment, children...)
@@ -128,7 +187,7 @@ func main() {
 		logrus.Fatalf("Failed to get config agent: %v", err)
 	}
 
-	registryAgent, err := agents.NewRegistryAgent(o.registryPath, agents.WithRegistryMetrics(configresolverMetrics.ErrorRate), agents.WithRegistryFlat(o.flatRegistry))
+	registryAgent, err := agents.NewRegistryAgent(o.registryPath, agents.WithRegistryMetrics(configresolverMetrics.ErrorRate), agents.WithRegistryFlat(o.flatRegistry), agents.WithRegistryVersions(o.registryVersionsPath))
 	if err != nil {
 		logrus.Fatalf("Failed to get registry agent: %v", err)
 	}
@@ -143,6 +202,7 @@ func main() {
 		l("resolve"),
 		l("configGeneration"),
 		l("registryGeneration"),
+		l("impact"),
 	))
 
 	uisimplifier := simplifypath.NewSimplifier(l("", // shadow element mimicing the root
@@ -158,6 +218,7 @@ func main() {
 		l("reference"),
 		l("chain"),
 		l("workflow"),
+		l("versions"),
 	))
 	handler := metrics.TraceHandler(simplifier, configresolverMetrics.HTTPRequestDuration, configresolverMetrics.HTTPResponseSize)
 	uihandler := metrics.TraceHandler(uisimplifier, configresolverMetrics.HTTPRequestDuration, configresolverMetrics.HTTPResponseSize)
@@ -168,6 +229,7 @@ func main() {
 	http.HandleFunc("/resolve", handler(registryserver.ResolveLiteralConfig(registryAgent, configresolverMetrics)).ServeHTTP)
 	http.HandleFunc("/configGeneration", handler(getConfigGeneration(configAgent)).ServeHTTP)
 	http.HandleFunc("/registryGeneration", handler(getRegistryGeneration(registryAgent)).ServeHTTP)
+	http.HandleFunc("/impact", handler(getImpact(configAgent, registryAgent)).ServeHTTP)
 	http.HandleFunc("/readyz", func(_ http.ResponseWriter, _ *http.Request) {})
 	interrupts.ListenAndServe(&http.Server{Addr: ":" + strconv.Itoa(o.port)}, o.gracePeriod)
 	uiServer := &http.Server{
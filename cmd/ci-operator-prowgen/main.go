@@ -34,8 +34,9 @@ type options struct {
 	toDir         string
 	toReleaseRepo bool
 
-	registryPath string
-	resolver     registry.Resolver
+	registryPath         string
+	registryVersionsPath string
+	resolver             registry.Resolver
 
 	help bool
 }
@@ -50,6 +51,7 @@ func bindOptions(flag *flag.FlagSet) *options {
 	flag.BoolVar(&opt.toReleaseRepo, "to-release-repo", false, "If set, it behaves like --to-dir=$GOPATH/src/github.com/openshift/release/ci-operator/jobs")
 
 	flag.StringVar(&opt.registryPath, "registry", "", "Path to the step registry directory")
+	flag.StringVar(&opt.registryVersionsPath, "registry-versions", "", "Path to a directory holding a snapshot of the step registry for every version components can be pinned to")
 
 	flag.BoolVar(&opt.help, "h", false, "Show help for ci-operator-prowgen")
 
@@ -94,7 +96,13 @@ func (o *options) process() error {
 		if err != nil {
 			return fmt.Errorf("failed to load registry: %w", err)
 		}
-		o.resolver = registry.NewResolver(refs, chains, workflows, observers)
+		var versions registry.VersionByName
+		if o.registryVersionsPath != "" {
+			if versions, err = load.RegistryVersions(o.registryVersionsPath, load.RegistryFlag(0)); err != nil {
+				return fmt.Errorf("failed to load registry versions: %w", err)
+			}
+		}
+		o.resolver = registry.NewVersionedResolver(refs, chains, workflows, observers, versions)
 	}
 	return nil
 }
@@ -13,6 +13,7 @@ import (
 	"io"
 	"io/fs"
 	"io/ioutil"
+	"net/http"
 	"os"
 	"os/exec"
 	"path"
@@ -26,6 +27,8 @@ import (
 	"github.com/bombsimon/logrusr"
 	"github.com/ghodss/yaml"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 
 	appsv1 "k8s.io/api/apps/v1"
 	authapi "k8s.io/api/authorization/v1"
@@ -69,14 +72,18 @@ import (
 	"github.com/openshift/ci-tools/pkg/api"
 	"github.com/openshift/ci-tools/pkg/api/nsttl"
 	"github.com/openshift/ci-tools/pkg/defaults"
+	"github.com/openshift/ci-tools/pkg/events"
 	"github.com/openshift/ci-tools/pkg/interrupt"
 	"github.com/openshift/ci-tools/pkg/junit"
 	"github.com/openshift/ci-tools/pkg/lease"
 	"github.com/openshift/ci-tools/pkg/load"
 	"github.com/openshift/ci-tools/pkg/registry/server"
+	"github.com/openshift/ci-tools/pkg/release/resolver"
 	"github.com/openshift/ci-tools/pkg/results"
 	"github.com/openshift/ci-tools/pkg/secrets"
 	"github.com/openshift/ci-tools/pkg/steps"
+	"github.com/openshift/ci-tools/pkg/steps/release"
+	"github.com/openshift/ci-tools/pkg/tracing"
 	"github.com/openshift/ci-tools/pkg/util"
 	"github.com/openshift/ci-tools/pkg/validation"
 )
@@ -89,6 +96,10 @@ but a caller may select one or more targets (image names or test names) to limit
 steps that those targets depend on. The build creates a new project to run the builds in
 and can automatically clean up the project when the build completes.
 
+The [release-diff] target is never run by default: when selected, the initial and latest
+release payloads are compared and the component images, commits and RHCOS versions that
+changed between them are reported as JUnit test cases and an HTML artifact.
+
 ci-operator leverages declarative OpenShift builds and images to reuse previously compiled
 artifacts. It makes building multiple images that share one or more common base layers
 simple as well as running tests that depend on those images.
@@ -163,6 +174,10 @@ map.
 
 const (
 	leaseAcquireTimeout = 120 * time.Minute
+	// leaseBackendBoskos manages leases with the lease server
+	leaseBackendBoskos = "boskos"
+	// leaseBackendConfigMap manages leases with ConfigMaps in the build cluster
+	leaseBackendConfigMap = "configmap"
 )
 
 var (
@@ -343,6 +358,9 @@ type options struct {
 	verbose bool
 	help    bool
 	print   bool
+	plan    bool
+
+	planFormat string
 
 	writeParams string
 	artifactDir string
@@ -367,7 +385,20 @@ type options struct {
 	leaseServer                string
 	leaseServerCredentialsFile string
 	leaseAcquireTimeout        time.Duration
+	leaseBackend               string
+	leaseNamespace             string
+	leasePriorityClass         string
+	leaseQuotaConfigPath       string
+	leaseQuota                 *lease.QuotaConfig
 	leaseClient                lease.Client
+	eventSink                  string
+	buildBackend               string
+
+	releaseResolutionFile     string
+	releaseResolutionCache    string
+	releaseResolutionTTL      time.Duration
+	releaseResolutionFallback time.Duration
+	releaseResolver           resolver.Resolver
 
 	givePrAuthorAccessToNamespace bool
 	impersonateUser               string
@@ -376,11 +407,12 @@ type options struct {
 	resolverAddress string
 	resolverClient  server.ResolverClient
 
-	registryPath string
-	org          string
-	repo         string
-	branch       string
-	variant      string
+	registryPath         string
+	registryVersionsPath string
+	org                  string
+	repo                 string
+	branch               string
+	variant              string
 
 	injectTest string
 
@@ -392,12 +424,17 @@ type options struct {
 	pushSecretPath string
 	pushSecret     *coreapi.Secret
 
+	signingKeyPath         string
+	signingKeyPasswordPath string
+	signingSecret          *coreapi.Secret
+
 	uploadSecretPath string
 	uploadSecret     *coreapi.Secret
 
 	cloneAuthConfig *steps.CloneAuthConfig
 
 	resultsOptions results.Options
+	tracingOptions tracing.Options
 
 	censor *secrets.DynamicCensor
 
@@ -406,6 +443,10 @@ type options struct {
 
 	multiStageParamOverrides stringSlice
 	dependencyOverrides      stringSlice
+
+	local            bool
+	localImageValues stringSlice
+	localImages      steps.LocalImages
 }
 
 func bindOptions(flag *flag.FlagSet) *options {
@@ -423,11 +464,24 @@ func bindOptions(flag *flag.FlagSet) *options {
 	flag.StringVar(&opt.leaseServer, "lease-server", leaseServerAddress, "Address of the server that manages leases. Required if any test is configured to acquire a lease.")
 	flag.StringVar(&opt.leaseServerCredentialsFile, "lease-server-credentials-file", "", "The path to credentials file used to access the lease server. The content is of the form <username>:<password>.")
 	flag.DurationVar(&opt.leaseAcquireTimeout, "lease-acquire-timeout", leaseAcquireTimeout, "Maximum amount of time to wait for lease acquisition")
+	flag.StringVar(&opt.leaseBackend, "lease-backend", leaseBackendBoskos, fmt.Sprintf("Backend that manages leases: %q uses the lease server, %q keeps the state of the resources in ConfigMaps in --lease-namespace.", leaseBackendBoskos, leaseBackendConfigMap))
+	flag.StringVar(&opt.leaseNamespace, "lease-namespace", "", "Namespace containing one ConfigMap per resource type, whose keys are the resources. Required with --lease-backend=configmap.")
+	flag.StringVar(&opt.eventSink, "event-sink", "", "URL to POST the events emitted while steps execute to, one JSON event per request. Events are always written to $ARTIFACTS/"+api.CIOperatorStepEventsFilename+".")
+	flag.StringVar(&opt.leasePriorityClass, "lease-priority-class", "", "Priority class of the job, scaling its share of contended resources as configured in --lease-quota-config.")
+	flag.StringVar(&opt.leaseQuotaConfigPath, "lease-quota-config", "", "Path to the configuration of the weights and limits used to share contended resources between organizations and repositories. Leases are acquired on a first-come first-served basis without it.")
+	flag.StringVar(&opt.buildBackend, "build-backend", string(steps.BuildBackendOpenShift), fmt.Sprintf("How images are built: %q uses the Build API, %q runs buildah in pods as the builder service account, for clusters which do not serve the Build API and cannot build the root image from the repository.", steps.BuildBackendOpenShift, steps.BuildBackendBuildah))
+	flag.StringVar(&opt.releaseResolutionFile, "release-resolution-file", "", "Path to a file recording the pull specs of candidate, prerelease and official releases. When set, releases are only resolved from it, without network access.")
+	flag.StringVar(&opt.releaseResolutionCache, "release-resolution-cache", "", "Path to a file in which the pull specs releases are resolved to are recorded, to be reused by later runs.")
+	flag.DurationVar(&opt.releaseResolutionTTL, "release-resolution-ttl", 0, "How long a pull spec a release was resolved to is reused without asking the release controllers or Cincinnati again.")
+	flag.DurationVar(&opt.releaseResolutionFallback, "release-resolution-fallback", 0, "When the release controllers or Cincinnati fail, how old the last pull spec a release was resolved to may be to be used instead. Zero disables the fallback.")
 	flag.StringVar(&opt.registryPath, "registry", "", "Path to the step registry directory")
+	flag.StringVar(&opt.registryVersionsPath, "registry-versions", "", "Path to a directory holding a snapshot of the step registry for every version components can be pinned to, used with --registry")
 	flag.StringVar(&opt.configSpecPath, "config", "", "The configuration file. If not specified the CONFIG_SPEC environment variable or the configresolver will be used.")
 	flag.StringVar(&opt.unresolvedConfigPath, "unresolved-config", "", "The configuration file, before resolution. If not specified the UNRESOLVED_CONFIG environment variable will be used, if set.")
 	flag.Var(&opt.targets, "target", "One or more targets in the configuration to build. Only steps that are required for this target will be run.")
 	flag.BoolVar(&opt.print, "print-graph", opt.print, "Print a directed graph of the build steps and exit. Intended for use with the golang digraph utility.")
+	flag.BoolVar(&opt.plan, "plan", opt.plan, "Print the steps that would run, with the pods they would create, the leases they would acquire and the images that would be promoted, and exit. No cluster is accessed.")
+	flag.StringVar(&opt.planFormat, "plan-format", "yaml", "The format of the plan printed with --plan, either yaml or json.")
 
 	// add to the graph of things we run or create
 	flag.Var(&opt.templatePaths, "template", "A set of paths to optional templates to add as stages to this job. Each template is expected to contain at least one restart=Never pod. Parameters are filled from environment or from the automatic parameters generated by the operator.")
@@ -453,6 +507,8 @@ func bindOptions(flag *flag.FlagSet) *options {
 	flag.StringVar(&opt.gitRef, "git-ref", "", "Populate the job spec from this local Git reference. If JOB_SPEC is set, the refs field will be overwritten.")
 	flag.BoolVar(&opt.givePrAuthorAccessToNamespace, "give-pr-author-access-to-namespace", true, "Give view access to the temporarily created namespace to the PR author.")
 	flag.StringVar(&opt.impersonateUser, "as", "", "Username to impersonate")
+	flag.BoolVar(&opt.local, "local", false, "Run multi-stage tests on a plain Kubernetes cluster (e.g. kind or podman). Nothing is built or imported, images used by the test steps must be provided with --local-image.")
+	flag.Var(&opt.localImageValues, "local-image", "A repeatable option used to provide the pull spec of an image used by multi-stage test steps when running with --local. This parameter should be in the format NAME=PULLSPEC, where NAME is a tag in the pipeline image stream or an image stream tag, e.g. --local-image=src=quay.io/org/src:latest --local-image=stable:cli=quay.io/openshift/origin-cli:latest.")
 
 	// flags needed for the configresolver
 	flag.StringVar(&opt.resolverAddress, "resolver-address", configResolverAddress, "Address of configresolver")
@@ -465,6 +521,8 @@ func bindOptions(flag *flag.FlagSet) *options {
 
 	flag.StringVar(&opt.pullSecretPath, "image-import-pull-secret", "", "A set of dockercfg credentials used to import images for the tag_specification.")
 	flag.StringVar(&opt.pushSecretPath, "image-mirror-push-secret", "", "A set of dockercfg credentials used to mirror images for the promotion.")
+	flag.StringVar(&opt.signingKeyPath, "image-signing-key", "", "A cosign private key used to sign promoted images and attest their SBOM. Images are not signed when not set.")
+	flag.StringVar(&opt.signingKeyPasswordPath, "image-signing-key-password", "", "A file holding the password of the cosign private key.")
 	flag.StringVar(&opt.uploadSecretPath, "gcs-upload-secret", "", "GCS credentials used to upload logs and artifacts.")
 
 	flag.StringVar(&opt.hiveKubeconfigPath, "hive-kubeconfig", "", "Path to the kubeconfig file to use for requests to Hive.")
@@ -473,6 +531,7 @@ func bindOptions(flag *flag.FlagSet) *options {
 	flag.Var(&opt.dependencyOverrides, "dependency-override-param", "A repeatable option used to override dependencies with external pull specs. This parameter should be in the format ENVVARNAME=PULLSPEC, e.g. --dependency-override-param=OO_INDEX=registry.mydomain.com:5000/pushed/myimage. This would override the value for the OO_INDEX environment variable for any tests/steps that currently have that dependency configured.")
 
 	opt.resultsOptions.Bind(flag)
+	opt.tracingOptions.Bind(flag)
 	return opt
 }
 
@@ -510,6 +569,50 @@ func (o *options) Complete() error {
 	if o.unresolvedConfigPath != "" && o.configSpecPath != "" {
 		return errors.New("cannot set --config and --unresolved-config at the same time")
 	}
+	if o.plan && o.local {
+		return errors.New("cannot set --plan and --local at the same time")
+	}
+	if o.planFormat != "yaml" && o.planFormat != "json" {
+		return fmt.Errorf("invalid --plan-format %q: must be yaml or json", o.planFormat)
+	}
+	if backend := steps.BuildBackend(o.buildBackend); backend != steps.BuildBackendOpenShift && backend != steps.BuildBackendBuildah {
+		return fmt.Errorf("invalid --build-backend %q: must be %s or %s", o.buildBackend, steps.BuildBackendOpenShift, steps.BuildBackendBuildah)
+	}
+	if o.releaseResolutionFile != "" {
+		if o.releaseResolutionCache != "" {
+			return errors.New("cannot set --release-resolution-file and --release-resolution-cache at the same time")
+		}
+		if o.releaseResolver, err = resolver.NewFromFile(o.releaseResolutionFile); err != nil {
+			return fmt.Errorf("invalid --release-resolution-file: %w", err)
+		}
+	} else if o.releaseResolver, err = resolver.NewCaching(resolver.NewLive(&http.Client{}), o.releaseResolutionCache, o.releaseResolutionTTL, o.releaseResolutionFallback); err != nil {
+		return fmt.Errorf("invalid --release-resolution-cache: %w", err)
+	}
+	if len(o.localImageValues.values) > 0 && !o.local {
+		return errors.New("cannot set --local-image unless running with --local")
+	}
+	if o.local {
+		if o.promote {
+			return errors.New("cannot promote images when running with --local")
+		}
+		if o.localImages, err = steps.ParseLocalImages(o.localImageValues.values); err != nil {
+			return err
+		}
+	}
+	switch o.leaseBackend {
+	case leaseBackendBoskos:
+	case leaseBackendConfigMap:
+		if o.leaseNamespace == "" {
+			return fmt.Errorf("--lease-namespace is required with --lease-backend=%s", leaseBackendConfigMap)
+		}
+	default:
+		return fmt.Errorf("invalid --lease-backend %q: must be %s or %s", o.leaseBackend, leaseBackendBoskos, leaseBackendConfigMap)
+	}
+	if o.leaseQuotaConfigPath != "" {
+		if o.leaseQuota, err = lease.LoadQuotaConfig(o.leaseQuotaConfigPath); err != nil {
+			return fmt.Errorf("invalid --lease-quota-config: %w", err)
+		}
+	}
 	if o.unresolvedConfigPath != "" && o.resolverAddress == "" {
 		return errors.New("cannot request resolved config with --unresolved-config unless providing --resolver-address")
 	}
@@ -529,7 +632,7 @@ func (o *options) Complete() error {
 		}
 		config, err = o.resolverClient.ConfigWithTest(info, injectTest)
 	} else {
-		config, err = load.Config(o.configSpecPath, o.unresolvedConfigPath, o.registryPath, o.resolverClient, info)
+		config, err = load.Config(o.configSpecPath, o.unresolvedConfigPath, o.registryPath, o.registryVersionsPath, o.resolverClient, info)
 	}
 
 	if err != nil {
@@ -634,6 +737,12 @@ func (o *options) Complete() error {
 		o.templates = append(o.templates, template)
 	}
 
+	if o.plan {
+		// planning never accesses a cluster, so the cluster config is not
+		// needed and may not be available
+		return o.completeParams()
+	}
+
 	clusterConfig, err := util.LoadClusterConfig()
 	if err != nil {
 		return fmt.Errorf("failed to load cluster config: %w", err)
@@ -660,6 +769,14 @@ func (o *options) Complete() error {
 			return fmt.Errorf("could not get push secret %s from path %s: %w", api.RegistryPushCredentialsCICentralSecret, o.pushSecretPath, err)
 		}
 	}
+	if o.signingKeyPasswordPath != "" && o.signingKeyPath == "" {
+		return errors.New("--image-signing-key-password requires --image-signing-key")
+	}
+	if o.signingKeyPath != "" {
+		if o.signingSecret, err = getSigningSecret(o.signingKeyPath, o.signingKeyPasswordPath); err != nil {
+			return fmt.Errorf("could not get signing secret %s: %w", api.ImageSigningKeySecret, err)
+		}
+	}
 
 	if o.uploadSecretPath != "" {
 		gcsSecretName := resolveGCSCredentialsSecret(o.jobSpec)
@@ -676,6 +793,10 @@ func (o *options) Complete() error {
 		o.hiveKubeconfig = kubeConfig
 	}
 
+	return o.completeParams()
+}
+
+func (o *options) completeParams() error {
 	if err := overrideMultiStageParams(o); err != nil {
 		return err
 	}
@@ -795,6 +916,19 @@ func (o *options) Report(errs ...error) {
 		return
 	}
 
+	if recorder, ok := o.leaseClient.(lease.AcquisitionRecorder); ok {
+		for _, acquisition := range recorder.Acquisitions() {
+			reporter.ReportLease(results.LeaseRequest{
+				Org:           o.jobSpec.Metadata.Org,
+				Repo:          o.jobSpec.Metadata.Repo,
+				PriorityClass: o.leasePriorityClass,
+				ResourceType:  acquisition.ResourceType,
+				WaitSeconds:   acquisition.Wait.Seconds(),
+				Denied:        acquisition.Denied,
+			})
+		}
+	}
+
 	errorToReport := excludeContextCancelledErrors(errs)
 	for _, err := range errorToReport {
 		reporter.Report(err)
@@ -816,14 +950,22 @@ func (o *options) Run() []error {
 		cancel()
 	}
 	var leaseClient *lease.Client
-	if o.leaseServer != "" && o.leaseServerCredentialsFile != "" {
+	if o.leaseBackend == leaseBackendConfigMap || o.leaseServer != "" && o.leaseServerCredentialsFile != "" {
 		leaseClient = &o.leaseClient
 	}
 
-	o.resolveConsoleHost()
-
 	// load the graph from the configuration
-	buildSteps, postSteps, err := defaults.FromConfig(ctx, o.configSpec, &o.graphConfig, o.jobSpec, o.templates, o.writeParams, o.promote, o.clusterConfig, leaseClient, o.targets.values, o.cloneAuthConfig, o.pullSecret, o.pushSecret, o.censor, o.hiveKubeconfig, o.consoleHost)
+	var buildSteps, postSteps []api.Step
+	var err error
+	switch {
+	case o.plan:
+		buildSteps, postSteps, err = defaults.FromConfigPlan(ctx, o.configSpec, &o.graphConfig, o.jobSpec, o.templates, o.writeParams, o.promote, o.targets.values, o.cloneAuthConfig, o.pullSecret, o.pushSecret, o.signingSecret, o.censor, o.releaseResolver)
+	case o.local:
+		buildSteps, err = defaults.FromConfigLocal(o.configSpec, o.jobSpec, o.clusterConfig, leaseClient, o.localImages, o.censor)
+	default:
+		o.resolveConsoleHost()
+		buildSteps, postSteps, err = defaults.FromConfig(ctx, o.configSpec, &o.graphConfig, o.jobSpec, o.templates, o.writeParams, o.promote, o.clusterConfig, leaseClient, o.targets.values, o.cloneAuthConfig, o.pullSecret, o.pushSecret, o.signingSecret, o.censor, o.hiveKubeconfig, o.consoleHost, steps.BuildBackend(o.buildBackend), o.releaseResolver)
+	}
 	if err != nil {
 		return []error{results.ForReason("defaulting_config").WithError(err).Errorf("failed to generate steps from config: %v", err)}
 	}
@@ -851,6 +993,13 @@ func (o *options) Run() []error {
 		return []error{results.ForReason("building_graph").WithError(err).Errorf("could not build execution graph: %v", err)}
 	}
 
+	if o.plan {
+		if err := o.printPlan(os.Stdout, nodes, postSteps); err != nil {
+			return []error{fmt.Errorf("could not print plan: %w", err)}
+		}
+		return nil
+	}
+
 	if err := printExecutionOrder(nodes); err != nil {
 		return []error{fmt.Errorf("could not print execution order: %w", err)}
 	}
@@ -875,7 +1024,30 @@ func (o *options) Run() []error {
 		return []error{results.ForReason("initializing_namespace").WithError(err).Errorf("could not initialize namespace: %v", err)}
 	}
 
-	return interrupt.New(handler, o.saveNamespaceArtifacts).Run(func() []error {
+	shutdownTracing, err := o.tracingOptions.Setup(ctx, o.jobSpec)
+	if err != nil {
+		logrus.WithError(err).Warn("Unable to export traces.")
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			logrus.WithError(err).Warn("Unable to export traces.")
+		}
+	}()
+	ctx, span := tracing.Start(ctx, o.jobSpec.Job, trace.WithAttributes(attribute.String("namespace", o.namespace)))
+	var runErrs []error
+	defer func() { tracing.End(span, utilerrors.NewAggregate(runErrs)) }()
+
+	stepEvents, err := o.stepEventRecorder()
+	if err != nil {
+		logrus.WithError(err).Warn("Unable to record step events.")
+	} else if stepEvents != nil {
+		defer stepEvents.Close()
+		ctx = events.WithRecorder(ctx, stepEvents)
+	}
+
+	runErrs = interrupt.New(handler, o.saveNamespaceArtifacts).Run(func() []error {
 		if leaseClient != nil {
 			if err := o.initializeLeaseClient(); err != nil {
 				return []error{fmt.Errorf("failed to create the lease client: %w", err)}
@@ -928,15 +1100,21 @@ func (o *options) Run() []error {
 		eventRecorder.Event(runtimeObject, coreapi.EventTypeNormal, "CiJobSucceeded", eventJobDescription(o.jobSpec, o.namespace))
 		return nil
 	})
+	return runErrs
 }
 
 // runStep mostly duplicates steps.runStep. The latter uses an *api.StepNode though and we only have an api.Step for the PostSteps
 // so we can not re-use it.
 func runStep(ctx context.Context, step api.Step) (api.CIOperatorStepDetails, error) {
+	ctx = events.WithStep(ctx, step.Name())
+	ctx, span := tracing.Start(ctx, step.Name(), trace.WithAttributes(attribute.String("description", step.Description())))
 	start := time.Now()
+	events.Record(ctx, events.Event{Time: start, Type: events.StepStarted})
 	err := step.Run(ctx)
+	tracing.End(span, err)
 	duration := time.Since(start)
 	failed := err != nil
+	events.Record(ctx, events.Event{Time: start.Add(duration), Type: events.StepFinished, Failed: failed, Reasons: results.Reasons(err)})
 
 	var subSteps []api.CIOperatorStepDetailInfo
 	if x, ok := step.(steps.SubStepReporter); ok {
@@ -1027,6 +1205,7 @@ func (o *options) resolveInputs(steps []api.Step) error {
 	// TODO: instead of mutating this here, we should pass the parts of graph execution that are resolved
 	// after the graph is created but before it is run down into the run step.
 	o.jobSpec.SetNamespace(o.namespace)
+	o.jobSpec.SetInputHash(o.inputHash)
 
 	// If we can resolve the field, use it. If not, don't.
 	if o.consoleHost != "" {
@@ -1039,6 +1218,9 @@ func (o *options) resolveInputs(steps []api.Step) error {
 }
 
 func (o *options) initializeNamespace() error {
+	if o.local {
+		return o.initializeLocalNamespace()
+	}
 	// We have to keep the project client because it return a project for a projectCreationRequest, ctrlruntimeclient can not do dark magic like that
 	projectGetter, err := projectclientset.NewForConfig(o.clusterConfig)
 	if err != nil {
@@ -1212,7 +1394,7 @@ func (o *options) initializeNamespace() error {
 
 	}
 
-	for _, secret := range []*coreapi.Secret{o.pullSecret, o.pushSecret, o.uploadSecret} {
+	for _, secret := range []*coreapi.Secret{o.pullSecret, o.pushSecret, o.signingSecret, o.uploadSecret} {
 		if secret != nil {
 			secret.Immutable = utilpointer.BoolPtr(true)
 			if err := client.Create(ctx, secret); err != nil && !kerrors.IsAlreadyExists(err) {
@@ -1306,6 +1488,58 @@ func (o *options) initializeNamespace() error {
 	return nil
 }
 
+// initializeLocalNamespace creates the test namespace on a plain Kubernetes
+// cluster, which has neither projects nor the image API.
+func (o *options) initializeLocalNamespace() error {
+	client, err := ctrlruntimeclient.New(o.clusterConfig, ctrlruntimeclient.Options{})
+	if err != nil {
+		return fmt.Errorf("failed to construct client: %w", err)
+	}
+	ctx := context.Background()
+
+	logrus.Debugf("Creating namespace %s", o.namespace)
+	for {
+		ns := &coreapi.Namespace{ObjectMeta: meta.ObjectMeta{
+			Name:        o.namespace,
+			Annotations: map[string]string{"openshift.io/description": jobDescription(o.jobSpec)},
+		}}
+		if err := client.Create(ctx, ns); err != nil {
+			if !kerrors.IsAlreadyExists(err) {
+				return fmt.Errorf("could not set up namespace for test: %w", err)
+			}
+			if err := client.Get(ctx, ctrlruntimeclient.ObjectKey{Name: o.namespace}, ns); err != nil {
+				if kerrors.IsNotFound(err) {
+					continue
+				}
+				return fmt.Errorf("could not get namespace for test: %w", err)
+			}
+		}
+		if ns.Status.Phase == coreapi.NamespaceTerminating {
+			logrus.Info("Waiting for namespace to finish terminating before creating another")
+			time.Sleep(3 * time.Second)
+			continue
+		}
+		break
+	}
+	client = ctrlruntimeclient.NewNamespacedClient(client, o.namespace)
+
+	for _, secret := range []*coreapi.Secret{o.pullSecret, o.uploadSecret} {
+		if secret != nil {
+			secret.Immutable = utilpointer.BoolPtr(true)
+			if err := client.Create(ctx, secret); err != nil && !kerrors.IsAlreadyExists(err) {
+				return fmt.Errorf("couldn't create secret %s: %w", secret.Name, err)
+			}
+		}
+	}
+
+	for _, secret := range o.secrets {
+		if _, err := util.UpsertImmutableSecret(ctx, client, secret); err != nil {
+			return fmt.Errorf("could not update secret %s: %w", secret.Name, err)
+		}
+	}
+	return nil
+}
+
 func generateAuthorAccessRoleBinding(namespace string, authors []string) *rbacapi.RoleBinding {
 	var subjects []rbacapi.Subject
 	authorSet := sets.NewString(authors...)
@@ -1353,20 +1587,19 @@ func pdb(labelKey, namespace string) (*policyv1beta1.PodDisruptionBudget, crcont
 //
 // Example from k8s:
 //
-// "metadata": {
-// 	"repo-commit": "253f03e0055b6649f8b25e84122748d39a284141",
-// 	"node_os_image": "cos-stable-65-10323-64-0",
-// 	"repos": {
-// 		"k8s.io/kubernetes": "master:1c04caa04325e1f64d9a15714ad61acdd2a81013,71936:353a0b391d6cb0c26e1c0c6b180b300f64039e0e",
-// 		"k8s.io/release": "master"
-// 	},
-// 	"infra-commit": "de7741746",
-// 	"repo": "k8s.io/kubernetes",
-// 	"master_os_image": "cos-stable-65-10323-64-0",
-// 	"job-version": "v1.14.0-alpha.0.1012+253f03e0055b66",
-// 	"pod": "dd8d320f-ff64-11e8-b091-0a580a6c02ef"
-// }
-//
+//	"metadata": {
+//		"repo-commit": "253f03e0055b6649f8b25e84122748d39a284141",
+//		"node_os_image": "cos-stable-65-10323-64-0",
+//		"repos": {
+//			"k8s.io/kubernetes": "master:1c04caa04325e1f64d9a15714ad61acdd2a81013,71936:353a0b391d6cb0c26e1c0c6b180b300f64039e0e",
+//			"k8s.io/release": "master"
+//		},
+//		"infra-commit": "de7741746",
+//		"repo": "k8s.io/kubernetes",
+//		"master_os_image": "cos-stable-65-10323-64-0",
+//		"job-version": "v1.14.0-alpha.0.1012+253f03e0055b66",
+//		"pod": "dd8d320f-ff64-11e8-b091-0a580a6c02ef"
+//	}
 type prowResultMetadata struct {
 	Revision      string            `json:"revision"`
 	RepoCommit    string            `json:"repo-commit"`
@@ -1664,14 +1897,31 @@ func loadLeaseCredentials(leaseServerCredentialsFile string) (string, func() []b
 }
 
 func (o *options) initializeLeaseClient() error {
-	var err error
+	identity := lease.Identity{Org: o.jobSpec.Metadata.Org, Repo: o.jobSpec.Metadata.Repo, PriorityClass: o.leasePriorityClass}
 	owner := o.namespace + "-" + o.jobSpec.JobNameHash()
-	username, passwordGetter, err := loadLeaseCredentials(o.leaseServerCredentialsFile)
-	if err != nil {
-		return fmt.Errorf("failed to load lease credentials: %w", err)
+	if o.leaseQuota != nil {
+		// other clients sharing the quota account for our leases by the
+		// identity recorded in the owner
+		owner = identity.Owner(owner)
+	}
+	switch o.leaseBackend {
+	case leaseBackendConfigMap:
+		client, err := ctrlruntimeclient.New(o.clusterConfig, ctrlruntimeclient.Options{})
+		if err != nil {
+			return fmt.Errorf("failed to construct client for the lease namespace: %w", err)
+		}
+		o.leaseClient = lease.NewConfigMapClient(owner, o.leaseNamespace, client, 60, o.leaseAcquireTimeout)
+	default:
+		username, passwordGetter, err := loadLeaseCredentials(o.leaseServerCredentialsFile)
+		if err != nil {
+			return fmt.Errorf("failed to load lease credentials: %w", err)
+		}
+		if o.leaseClient, err = lease.NewClient(owner, o.leaseServer, username, passwordGetter, 60, o.leaseAcquireTimeout); err != nil {
+			return fmt.Errorf("failed to create the lease client: %w", err)
+		}
 	}
-	if o.leaseClient, err = lease.NewClient(owner, o.leaseServer, username, passwordGetter, 60, o.leaseAcquireTimeout); err != nil {
-		return fmt.Errorf("failed to create the lease client: %w", err)
+	if o.leaseQuota != nil {
+		o.leaseClient = lease.NewFairShareClient(o.leaseClient, identity, o.leaseQuota, o.leaseAcquireTimeout)
 	}
 	t := time.NewTicker(30 * time.Second)
 	go func() {
@@ -1689,6 +1939,23 @@ func (o *options) initializeLeaseClient() error {
 	return nil
 }
 
+// stepEventRecorder creates the recorder for the events emitted while steps
+// execute, writing them to the artifact directory and sending them to the
+// sink, if either is configured.
+func (o *options) stepEventRecorder() (*events.StreamRecorder, error) {
+	var out io.Writer = ioutil.Discard
+	if artifactDir, set := api.Artifacts(); set {
+		f, err := os.OpenFile(filepath.Join(artifactDir, api.CIOperatorStepEventsFilename), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open the step event stream: %w", err)
+		}
+		out = f
+	} else if o.eventSink == "" {
+		return nil, nil
+	}
+	return events.NewStreamRecorder(out, o.censor, o.eventSink), nil
+}
+
 // eventJobDescription returns a string representing the pull requests and authors description, to be used in events.
 func eventJobDescription(jobSpec *api.JobSpec, namespace string) string {
 	var pulls []string
@@ -1848,6 +2115,134 @@ func printDigraph(w io.Writer, steps []api.Step) error {
 	return nil
 }
 
+// executionPlan describes what ci-operator would do for a configuration,
+// without running anything.
+type executionPlan struct {
+	InputHash string     `json:"input_hash"`
+	Namespace string     `json:"namespace"`
+	Steps     []stepPlan `json:"steps"`
+	// Promotion lists the image stream tags that would be promoted
+	Promotion []string `json:"promotion,omitempty"`
+}
+
+// stepPlan describes what a step would do when run.
+type stepPlan struct {
+	Name         string   `json:"name"`
+	Description  string   `json:"description"`
+	InputHash    string   `json:"input_hash,omitempty"`
+	Dependencies []string `json:"dependencies,omitempty"`
+	// Leases are acquired before the step runs
+	Leases []api.StepLease `json:"leases,omitempty"`
+	// Secrets lists the secrets mounted into the pods of the step
+	Secrets []string      `json:"secrets,omitempty"`
+	Pods    []coreapi.Pod `json:"pods,omitempty"`
+}
+
+func (o *options) printPlan(w io.Writer, nodes []*api.StepNode, postSteps []api.Step) error {
+	ordered, err := topologicalSort(nodes)
+	if err != nil {
+		return fmt.Errorf("could not sort nodes: %w", err)
+	}
+	dependencies := map[string][]string{}
+	for _, step := range *calculateGraph(nodes) {
+		// a step may depend on another through more than one link
+		dependencies[step.StepName] = sets.NewString(step.Dependencies...).List()
+	}
+	plan := executionPlan{InputHash: o.inputHash, Namespace: o.namespace}
+	for _, node := range ordered {
+		step, err := planStep(node.Step, o.jobSpec)
+		if err != nil {
+			return err
+		}
+		step.Dependencies = dependencies[step.Name]
+		plan.Steps = append(plan.Steps, step)
+	}
+	plan.Steps = orderByDependencies(plan.Steps)
+	for _, postStep := range postSteps {
+		step, err := planStep(postStep, o.jobSpec)
+		if err != nil {
+			return err
+		}
+		plan.Steps = append(plan.Steps, step)
+	}
+	if o.promote {
+		for _, tag := range release.PromotedTags(o.configSpec) {
+			plan.Promotion = append(plan.Promotion, tag.ISTagName())
+		}
+	}
+
+	var raw []byte
+	if o.planFormat == "json" {
+		raw, err = json.MarshalIndent(plan, "", "  ")
+	} else {
+		raw, err = yaml.Marshal(plan)
+	}
+	if err != nil {
+		return fmt.Errorf("could not marshal plan: %w", err)
+	}
+	_, err = fmt.Fprintln(w, string(raw))
+	return err
+}
+
+// orderByDependencies orders the steps so that every step comes after the
+// steps it depends on, keeping the order of the steps otherwise.
+func orderByDependencies(steps []stepPlan) []stepPlan {
+	var ordered []stepPlan
+	done := sets.NewString()
+	for len(steps) > 0 {
+		var waiting []stepPlan
+		for _, step := range steps {
+			if done.HasAll(step.Dependencies...) {
+				ordered = append(ordered, step)
+				done.Insert(step.Name)
+			} else {
+				waiting = append(waiting, step)
+			}
+		}
+		if len(waiting) == len(steps) {
+			// dependencies outside of the graph, nothing left to order
+			return append(ordered, waiting...)
+		}
+		steps = waiting
+	}
+	return ordered
+}
+
+func planStep(step api.Step, jobSpec *api.JobSpec) (stepPlan, error) {
+	ret := stepPlan{Name: step.Name(), Description: step.Description()}
+	inputs, err := step.Inputs()
+	if err != nil {
+		return ret, fmt.Errorf("could not determine inputs for step %s: %w", step.Name(), err)
+	}
+	if len(inputs) > 0 {
+		ret.InputHash = inputHash(inputs)
+	}
+	if reporter, ok := step.(steps.LeaseReporter); ok {
+		ret.Leases = reporter.Leases()
+	}
+	planner, ok := step.(steps.PodPlanner)
+	if !ok {
+		return ret, nil
+	}
+	if jobSpec.DecorationConfig == nil {
+		logrus.Warnf("Cannot determine pods for step %s: the job spec has no decoration config.", step.Name())
+		return ret, nil
+	}
+	if ret.Pods, err = planner.PlannedPods(); err != nil {
+		return ret, fmt.Errorf("could not determine pods for step %s: %w", step.Name(), err)
+	}
+	secrets := sets.NewString()
+	for _, pod := range ret.Pods {
+		for _, volume := range pod.Spec.Volumes {
+			if volume.Secret != nil {
+				secrets.Insert(volume.Secret.SecretName)
+			}
+		}
+	}
+	ret.Secrets = secrets.List()
+	return ret, nil
+}
+
 func printExecutionOrder(nodes []*api.StepNode) error {
 	ordered, err := topologicalSort(nodes)
 	if err != nil {
@@ -1999,6 +2394,30 @@ func getDockerConfigSecret(name, filename string) (*coreapi.Secret, error) {
 	}, nil
 }
 
+// getSigningSecret creates the secret holding the key promoted images are
+// signed with and its password, if the key is encrypted.
+func getSigningSecret(keyPath, passwordPath string) (*coreapi.Secret, error) {
+	files := map[string]string{api.ImageSigningKeyFilename: keyPath}
+	if passwordPath != "" {
+		files[api.ImageSigningPasswordFilename] = passwordPath
+	}
+	secret := &coreapi.Secret{
+		Data: map[string][]byte{},
+		ObjectMeta: meta.ObjectMeta{
+			Name: api.ImageSigningKeySecret,
+		},
+		Type: coreapi.SecretTypeOpaque,
+	}
+	for key, filename := range files {
+		src, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return nil, fmt.Errorf("could not read file %s: %w", filename, err)
+		}
+		secret.Data[key] = src
+	}
+	return secret, nil
+}
+
 func getSecret(name, filename string) (*coreapi.Secret, error) {
 	src, err := ioutil.ReadFile(filename)
 	if err != nil {
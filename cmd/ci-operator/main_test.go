@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
@@ -10,6 +11,7 @@ import (
 	"path/filepath"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 
@@ -25,6 +27,7 @@ import (
 	imagev1 "github.com/openshift/api/image/v1"
 
 	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/defaults"
 	"github.com/openshift/ci-tools/pkg/results"
 	"github.com/openshift/ci-tools/pkg/secrets"
 	"github.com/openshift/ci-tools/pkg/steps"
@@ -395,7 +398,7 @@ func TestBuildPartialGraph(t *testing.T) {
 						},
 						To: api.PipelineImageStreamTagReference("oc-bin-image"),
 					},
-					&api.ReleaseBuildConfiguration{}, api.ResourceConfiguration{}, nil, nil, nil,
+					&api.ReleaseBuildConfiguration{}, api.ResourceConfiguration{}, nil, nil, nil, nil,
 				),
 				steps.OutputImageTagStep(api.OutputImageTagStepConfiguration{From: api.PipelineImageStreamTagReference("oc-bin-image")}, nil, nil),
 				steps.ImagesReadyStep(steps.OutputImageTagStep(api.OutputImageTagStepConfiguration{From: api.PipelineImageStreamTagReference("oc-bin-image")}, nil, nil).Creates()),
@@ -870,3 +873,66 @@ func TestGenerateAuthorAccessRoleBinding(t *testing.T) {
 		})
 	}
 }
+
+func TestPrintPlan(t *testing.T) {
+	config := &api.ReleaseBuildConfiguration{
+		Metadata: api.Metadata{Org: "org", Repo: "repo", Branch: "master"},
+		InputConfiguration: api.InputConfiguration{
+			BuildRootImage: &api.BuildRootImageConfiguration{
+				ImageStreamTagReference: &api.ImageStreamTagReference{Namespace: "ci", Name: "root", Tag: "latest"},
+			},
+		},
+		Images: []api.ProjectDirectoryImageBuildStepConfiguration{{
+			From: "src",
+			To:   "component",
+		}},
+		Tests: []api.TestStepConfiguration{{
+			As: "e2e",
+			MultiStageTestConfigurationLiteral: &api.MultiStageTestConfigurationLiteral{
+				Test: []api.LiteralTestStep{{
+					As:        "e2e",
+					From:      "component",
+					Commands:  "make e2e",
+					Resources: api.ResourceRequirements{Requests: api.ResourceList{"cpu": "100m"}},
+				}},
+				Leases: []api.StepLease{{ResourceType: "aws-quota-slice", Env: "LEASED_RESOURCE", Count: 1}},
+			},
+		}},
+		PromotionConfiguration: &api.PromotionConfiguration{Namespace: "ocp", Name: "4.10"},
+		Resources:              api.ResourceConfiguration{"*": {Requests: api.ResourceList{"cpu": "100m"}}},
+	}
+	jobSpec := &api.JobSpec{
+		JobSpec: downwardapi.JobSpec{
+			Job:       "branch-ci-org-repo-master-images",
+			BuildID:   "1",
+			ProwJobID: "prowjob",
+			Type:      prowapi.PostsubmitJob,
+			Refs:      &prowapi.Refs{Org: "org", Repo: "repo", BaseRef: "master", BaseSHA: "deadbeef"},
+			DecorationConfig: &prowapi.DecorationConfig{
+				Timeout:     &prowapi.Duration{Duration: time.Hour},
+				GracePeriod: &prowapi.Duration{Duration: time.Minute},
+				UtilityImages: &prowapi.UtilityImages{
+					Sidecar:    "sidecar",
+					Entrypoint: "entrypoint",
+				},
+			},
+		},
+	}
+	jobSpec.SetNamespace("ci-op-plan")
+	graphConfig := defaults.FromConfigStatic(config)
+	censor := secrets.NewDynamicCensor()
+	buildSteps, postSteps, err := defaults.FromConfigPlan(context.Background(), config, &graphConfig, jobSpec, nil, "", true, nil, nil, nil, nil, nil, &censor, nil)
+	if err != nil {
+		t.Fatalf("failed to plan steps: %v", err)
+	}
+	nodes, err := api.BuildPartialGraph(buildSteps, []string{"[images]", "e2e"})
+	if err != nil {
+		t.Fatalf("failed to build graph: %v", err)
+	}
+	o := &options{configSpec: config, jobSpec: jobSpec, namespace: "ci-op-plan", inputHash: "hash", promote: true, planFormat: "yaml"}
+	var out bytes.Buffer
+	if err := o.printPlan(&out, nodes, postSteps); err != nil {
+		t.Fatalf("failed to print plan: %v", err)
+	}
+	testhelper.CompareWithFixture(t, out.Bytes())
+}
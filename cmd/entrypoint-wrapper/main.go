@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"flag"
@@ -27,6 +28,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/wait"
 	coreclientset "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/retry"
 
 	"github.com/openshift/ci-tools/pkg/steps"
 	"github.com/openshift/ci-tools/pkg/util"
@@ -68,6 +70,9 @@ type options struct {
 	dstPath string
 	cmd     []string
 	client  coreclientset.SecretInterface
+	// base is the content of the shared directory when the step started,
+	// used to determine the changes made by this step
+	base map[string][]byte
 }
 
 func bindOptions(flag *flag.FlagSet) *options {
@@ -105,9 +110,14 @@ func (o *options) run() error {
 	if err := copyDir(o.dstPath, o.srcPath); err != nil {
 		return fmt.Errorf("failed to copy secret mount: %w", err)
 	}
+	base, err := util.SecretFromDir(o.dstPath)
+	if err != nil {
+		return fmt.Errorf("failed to read secret mount: %w", err)
+	}
+	o.base = base.Data
 	var errs []error
 	ctx, cancel := context.WithCancel(context.Background())
-	go uploadKubeconfig(ctx, o.client, o.name, o.dstPath, o.dry)
+	go uploadKubeconfig(ctx, o.client, o.name, o.dstPath, o.base, o.dry)
 	if err := execCmd(o.cmd); err != nil {
 		errs = append(errs, fmt.Errorf("failed to execute wrapped command: %w", err))
 	}
@@ -115,7 +125,7 @@ func (o *options) run() error {
 	// that the best-effort upload of the kubeconfig can exit now and so as
 	// not to race with the post-execution one
 	cancel()
-	if err := createSecret(o.client, o.name, o.dstPath, o.dry); err != nil {
+	if err := createSecret(o.client, o.name, o.dstPath, o.base, o.dry); err != nil {
 		errs = append(errs, fmt.Errorf("failed to create/update secret: %w", err))
 	}
 	return utilerrors.NewAggregate(errs)
@@ -269,7 +279,12 @@ func manageKubeconfig(proc *exec.Cmd) error {
 	return nil
 }
 
-func createSecret(client coreclientset.SecretInterface, name, dir string, dry bool) error {
+// createSecret stores the content of the shared directory in the secret. Only
+// the changes this step made relative to base are applied to the current
+// content of the secret, so steps running in parallel do not overwrite each
+// other's files. When parallel steps write the same file, the step which
+// finishes last wins.
+func createSecret(client coreclientset.SecretInterface, name, dir string, base map[string][]byte, dry bool) error {
 	if _, err := os.Stat(dir); err != nil {
 		if os.IsNotExist(err) {
 			return nil
@@ -290,16 +305,45 @@ func createSecret(client coreclientset.SecretInterface, name, dir string, dry bo
 		if err != nil {
 			return fmt.Errorf("failed to log secret: %w", err)
 		}
-	} else if _, err := client.Update(context.TODO(), secret, metav1.UpdateOptions{}); err != nil {
+	} else if err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		current, err := client.Get(context.TODO(), name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		secret.ResourceVersion = current.ResourceVersion
+		secret.Data = mergeSharedDir(base, current.Data, secret.Data)
+		_, err = client.Update(context.TODO(), secret, metav1.UpdateOptions{})
+		return err
+	}); err != nil {
 		return fmt.Errorf("failed to update secret: %w", err)
 	}
 	return nil
 }
 
+// mergeSharedDir applies the difference between base and updated, which is
+// the set of changes made by a step, to current.
+func mergeSharedDir(base, current, updated map[string][]byte) map[string][]byte {
+	ret := make(map[string][]byte, len(current))
+	for name, data := range current {
+		ret[name] = data
+	}
+	for name, data := range updated {
+		if previous, ok := base[name]; !ok || !bytes.Equal(previous, data) {
+			ret[name] = data
+		}
+	}
+	for name := range base {
+		if _, ok := updated[name]; !ok {
+			delete(ret, name)
+		}
+	}
+	return ret
+}
+
 // uploadKubeconfig will do a best-effort attempt at uploading a kubeconfig
 // file if one does not exist at the time we start running but one does get
 // created while executing the command
-func uploadKubeconfig(ctx context.Context, client coreclientset.SecretInterface, name, dir string, dry bool) {
+func uploadKubeconfig(ctx context.Context, client coreclientset.SecretInterface, name, dir string, base map[string][]byte, dry bool) {
 	if _, err := os.Stat(path.Join(dir, "kubeconfig")); err == nil {
 		// kubeconfig already exists, no need to do anything
 		return
@@ -310,7 +354,7 @@ func uploadKubeconfig(ctx context.Context, client coreclientset.SecretInterface,
 			return false, nil
 		}
 		// kubeconfig exists, we can upload it
-		uploadErr = createSecret(client, name, dir, dry)
+		uploadErr = createSecret(client, name, dir, base, dry)
 		return uploadErr == nil, nil // retry errors
 	}, ctx.Done()); !errors.Is(err, wait.ErrWaitTimeout) {
 		log.Printf("Failed to upload $KUBECONFIG: %v: %v\n", err, uploadErr)
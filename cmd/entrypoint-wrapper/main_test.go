@@ -0,0 +1,45 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMergeSharedDir(t *testing.T) {
+	for _, tc := range []struct {
+		name                   string
+		base, current, updated map[string][]byte
+		expected               map[string][]byte
+	}{{
+		name:     "no concurrent changes, step changes are applied",
+		base:     map[string][]byte{"kubeconfig": []byte("old"), "removed": []byte("data")},
+		current:  map[string][]byte{"kubeconfig": []byte("old"), "removed": []byte("data")},
+		updated:  map[string][]byte{"kubeconfig": []byte("new"), "added": []byte("data")},
+		expected: map[string][]byte{"kubeconfig": []byte("new"), "added": []byte("data")},
+	}, {
+		name:     "files written by a parallel step are kept",
+		base:     map[string][]byte{"kubeconfig": []byte("config")},
+		current:  map[string][]byte{"kubeconfig": []byte("config"), "other-step": []byte("data")},
+		updated:  map[string][]byte{"kubeconfig": []byte("config"), "this-step": []byte("data")},
+		expected: map[string][]byte{"kubeconfig": []byte("config"), "other-step": []byte("data"), "this-step": []byte("data")},
+	}, {
+		name:     "files this step did not change are not reverted",
+		base:     map[string][]byte{"metadata.json": []byte("old")},
+		current:  map[string][]byte{"metadata.json": []byte("changed by other step")},
+		updated:  map[string][]byte{"metadata.json": []byte("old")},
+		expected: map[string][]byte{"metadata.json": []byte("changed by other step")},
+	}, {
+		name:     "last writer wins for the same file",
+		base:     map[string][]byte{},
+		current:  map[string][]byte{"results": []byte("other step")},
+		updated:  map[string][]byte{"results": []byte("this step")},
+		expected: map[string][]byte{"results": []byte("this step")},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expected, mergeSharedDir(tc.base, tc.current, tc.updated)); diff != "" {
+				t.Errorf("unexpected result: %s", diff)
+			}
+		})
+	}
+}
@@ -52,8 +52,9 @@ type options struct {
 	noRegistry        bool
 	noClusterProfiles bool
 
-	releaseRepoPath string
-	rehearsalLimit  int
+	releaseRepoPath      string
+	registryVersionsPath string
+	rehearsalLimit       int
 }
 
 func gatherOptions() (options, error) {
@@ -68,6 +69,7 @@ func gatherOptions() (options, error) {
 	o.kubernetesOptions.AddFlags(fs)
 	fs.BoolVar(&o.noTemplates, "no-templates", false, "If true, do not attempt to compare templates")
 	fs.BoolVar(&o.noRegistry, "no-registry", false, "If true, do not attempt to compare step registry content")
+	fs.StringVar(&o.registryVersionsPath, "registry-versions", "", "Path to a directory holding a snapshot of the step registry for every version components can be pinned to")
 	fs.BoolVar(&o.noClusterProfiles, "no-cluster-profiles", false, "If true, do not attempt to compare cluster profiles")
 
 	fs.IntVar(&o.rehearsalLimit, "rehearsal-limit", 35, "Upper limit of jobs attempted to rehearse (if more jobs are being touched, only this many will be rehearsed)")
@@ -203,6 +205,7 @@ func rehearseMain() error {
 	var chains registry.ChainByName
 	var workflows registry.WorkflowByName
 	var observers registry.ObserverByName
+	var versions registry.VersionByName
 
 	if !o.noRegistry {
 		refs, chains, workflows, _, _, observers, err = load.Registry(filepath.Join(o.releaseRepoPath, config.RegistryPath), load.RegistryFlag(0))
@@ -221,6 +224,12 @@ func rehearseMain() error {
 			return fmt.Errorf(misconfigurationOutput)
 		}
 	}
+	if o.registryVersionsPath != "" {
+		if versions, err = load.RegistryVersions(o.registryVersionsPath, load.RegistryFlag(0)); err != nil {
+			logger.WithError(err).Error("could not load step registry versions")
+			return fmt.Errorf(misconfigurationOutput)
+		}
+	}
 	if len(changedRegistrySteps) != 0 {
 		var names []string
 		for _, step := range changedRegistrySteps {
@@ -304,7 +313,7 @@ func rehearseMain() error {
 	toRehearse.AddAll(presubmitsForRegistry, config.ChangedRegistryContent)
 	periodicsToRehearse.AddAll(periodicsForRegistry, config.ChangedRegistryContent)
 
-	resolver := registry.NewResolver(refs, chains, workflows, observers)
+	resolver := registry.NewVersionedResolver(refs, chains, workflows, observers, versions)
 	jobConfigurer := rehearse.NewJobConfigurer(prConfig.CiOperator, resolver, prNumber, loggers, rehearsalTemplates.Names, rehearsalClusterProfiles.Names, jobSpec.Refs)
 	imagestreamtags, presubmitsToRehearse, err := jobConfigurer.ConfigurePresubmitRehearsals(toRehearse)
 	if err != nil {
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/test-infra/prow/flagutil"
+	"k8s.io/test-infra/prow/interrupts"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	imagev1 "github.com/openshift/api/image/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/config"
+	"github.com/openshift/ci-tools/pkg/promotion"
+	"github.com/openshift/ci-tools/pkg/promotion/history"
+	"github.com/openshift/ci-tools/pkg/util"
+)
+
+type options struct {
+	imageStreamsRaw  flagutil.Strings
+	ciOperatorConfig string
+	rollbackTo       string
+	dryRun           bool
+
+	// streams are the ImageStreams to operate on
+	streams []ctrlruntimeclient.ObjectKey
+	// tags restricts the tags to roll back, nil means all of them
+	tags map[ctrlruntimeclient.ObjectKey]sets.String
+}
+
+func gatherOptions() (*options, error) {
+	o := &options{}
+	fs := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	fs.Var(&o.imageStreamsRaw, "image-stream", "An ImageStream images are promoted into, in namespace/name format. Can be passed multiple times.")
+	fs.StringVar(&o.ciOperatorConfig, "ci-operator-config", "", "Path to a ci-operator configuration: only the tags it promotes are operated on.")
+	fs.StringVar(&o.rollbackTo, "rollback-to", "", "The build ID or URL of the job whose promotion to restore. Without it, the promotion history is printed.")
+	fs.BoolVar(&o.dryRun, "dry-run", true, "Whether to only print the tags which would be restored")
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		return nil, fmt.Errorf("failed to parse flags: %w", err)
+	}
+	return o, nil
+}
+
+func (o *options) complete() error {
+	seen := sets.NewString()
+	add := func(key ctrlruntimeclient.ObjectKey) {
+		if !seen.Has(key.String()) {
+			seen.Insert(key.String())
+			o.streams = append(o.streams, key)
+		}
+	}
+	var errs []error
+	for _, raw := range o.imageStreamsRaw.Strings() {
+		parts := strings.Split(raw, "/")
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			errs = append(errs, fmt.Errorf("--image-stream value %s was not in namespace/name format", raw))
+			continue
+		}
+		add(ctrlruntimeclient.ObjectKey{Namespace: parts[0], Name: parts[1]})
+	}
+	if o.ciOperatorConfig != "" {
+		if err := config.OperateOnCIOperatorConfig(o.ciOperatorConfig, func(configuration *api.ReleaseBuildConfiguration, _ *config.Info) error {
+			o.tags = tagsByStream(promotion.AllPromotionImageStreamTags(configuration))
+			return nil
+		}); err != nil {
+			errs = append(errs, fmt.Errorf("could not load --ci-operator-config: %w", err))
+		} else if len(o.tags) == 0 {
+			errs = append(errs, fmt.Errorf("the configuration in %s does not promote into an image stream", o.ciOperatorConfig))
+		}
+		for key := range o.tags {
+			add(key)
+		}
+	}
+	if o.tags != nil {
+		// streams the configuration does not promote into have no tags to operate on
+		for _, key := range o.streams {
+			if o.tags[key] == nil {
+				o.tags[key] = sets.NewString()
+			}
+		}
+	}
+	if len(o.streams) == 0 && len(errs) == 0 {
+		errs = append(errs, fmt.Errorf("at least one of --image-stream or --ci-operator-config must be set"))
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// tagsByStream groups imagestreamtags in namespace/name:tag format by stream
+func tagsByStream(isTags sets.String) map[ctrlruntimeclient.ObjectKey]sets.String {
+	tags := map[ctrlruntimeclient.ObjectKey]sets.String{}
+	for _, isTag := range isTags.List() {
+		slashSplit := strings.SplitN(isTag, "/", 2)
+		if len(slashSplit) != 2 {
+			continue
+		}
+		colonSplit := strings.SplitN(slashSplit[1], ":", 2)
+		if len(colonSplit) != 2 {
+			continue
+		}
+		key := ctrlruntimeclient.ObjectKey{Namespace: slashSplit[0], Name: colonSplit[0]}
+		if tags[key] == nil {
+			tags[key] = sets.NewString()
+		}
+		tags[key].Insert(colonSplit[1])
+	}
+	return tags
+}
+
+// rollback restores the tags of the stream to their state after the promotion
+// by the job. The stream is updated once, so either all tags are restored or
+// none are.
+func rollback(ctx context.Context, client ctrlruntimeclient.Client, key ctrlruntimeclient.ObjectKey, job string, tags sets.String, dryRun bool) error {
+	logger := logrus.WithField("image-stream", key.String())
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		stream := &imagev1.ImageStream{}
+		if err := client.Get(ctx, key, stream); err != nil {
+			return fmt.Errorf("could not get image stream %s: %w", key, err)
+		}
+		restored, skipped, err := history.Rollback(stream, job, tags, metav1.Now())
+		if err != nil {
+			return err
+		}
+		for _, tag := range skipped {
+			logger.WithField("tag", tag).Warn("Tag was created after the promotion by the job or its history since was forgotten, not restoring it.")
+		}
+		if len(restored) == 0 {
+			logger.Info("Nothing to restore.")
+			return nil
+		}
+		logger = logger.WithField("tags", strings.Join(restored, ", "))
+		if dryRun {
+			logger.Info("Would restore tags, but running in dry-run mode.")
+			return nil
+		}
+		if err := client.Update(ctx, stream); err != nil {
+			return err
+		}
+		logger.Info("Restored tags.")
+		return nil
+	})
+}
+
+func printHistory(ctx context.Context, client ctrlruntimeclient.Client, key ctrlruntimeclient.ObjectKey, tags sets.String) error {
+	stream := &imagev1.ImageStream{}
+	if err := client.Get(ctx, key, stream); err != nil {
+		return fmt.Errorf("could not get image stream %s: %w", key, err)
+	}
+	records, err := history.Load(stream)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(w, "%s\ntime\tjob\tbuild id\ttag\tdigest\tprevious digest\t\n", key)
+	for _, record := range records {
+		job := record.Job
+		if record.RolledBackTo != "" {
+			job = fmt.Sprintf("rollback to %s", record.RolledBackTo)
+		}
+		for _, tag := range sets.StringKeySet(record.Tags).List() {
+			if tags != nil && !tags.Has(tag) {
+				continue
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t\n", record.Time.Format(time.RFC3339), job, record.BuildID, tag, record.Tags[tag].Digest, record.Tags[tag].PreviousDigest)
+		}
+	}
+	return w.Flush()
+}
+
+func main() {
+	o, err := gatherOptions()
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to gather options")
+	}
+	if err := o.complete(); err != nil {
+		logrus.WithError(err).Fatal("Invalid options")
+	}
+	if err := imagev1.AddToScheme(scheme.Scheme); err != nil {
+		logrus.WithError(err).Fatal("Failed to add imagev1 to scheme")
+	}
+	clusterConfig, err := util.LoadClusterConfig()
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to load cluster config")
+	}
+	client, err := ctrlruntimeclient.New(clusterConfig, ctrlruntimeclient.Options{})
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to create client")
+	}
+	ctx := interrupts.Context()
+
+	var errs []error
+	for _, key := range o.streams {
+		if o.rollbackTo == "" {
+			if err := printHistory(ctx, client, key, o.tags[key]); err != nil {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		if err := rollback(ctx, client, key, o.rollbackTo, o.tags[key], o.dryRun); err != nil {
+			errs = append(errs, fmt.Errorf("could not roll back %s: %w", key, err))
+		}
+	}
+	if err := utilerrors.NewAggregate(errs); err != nil {
+		logrus.WithError(err).Fatal("Failed to operate on the promotion history")
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/kubernetes/scheme"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	imagev1 "github.com/openshift/api/image/v1"
+
+	"github.com/openshift/ci-tools/pkg/promotion/history"
+)
+
+func TestTagsByStream(t *testing.T) {
+	expected := map[ctrlruntimeclient.ObjectKey]sets.String{
+		{Namespace: "ocp", Name: "4.10"}: sets.NewString("cli", "installer"),
+		{Namespace: "ci", Name: "tool"}:  sets.NewString("latest"),
+	}
+	if diff := cmp.Diff(expected, tagsByStream(sets.NewString("ocp/4.10:cli", "ocp/4.10:installer", "ci/tool:latest", "invalid"))); diff != "" {
+		t.Errorf("unexpected tags: %s", diff)
+	}
+}
+
+func TestRollback(t *testing.T) {
+	if err := imagev1.AddToScheme(scheme.Scheme); err != nil {
+		t.Fatalf("failed to register imagev1 scheme: %v", err)
+	}
+	key := ctrlruntimeclient.ObjectKey{Namespace: "ocp", Name: "4.10"}
+	newStream := func() *imagev1.ImageStream {
+		stream := &imagev1.ImageStream{
+			ObjectMeta: metav1.ObjectMeta{Namespace: key.Namespace, Name: key.Name},
+			Status: imagev1.ImageStreamStatus{
+				Tags: []imagev1.NamedTagEventList{
+					{Tag: "cli", Items: []imagev1.TagEvent{{Image: "sha256:cli-2"}}},
+					{Tag: "installer", Items: []imagev1.TagEvent{{Image: "sha256:installer-2"}}},
+				},
+			},
+		}
+		for _, record := range []history.Record{
+			{BuildID: "1", Tags: map[string]history.TagRecord{"cli": {Digest: "sha256:cli-1"}, "installer": {Digest: "sha256:installer-1"}}},
+			{BuildID: "2", Tags: map[string]history.TagRecord{"cli": {Digest: "sha256:cli-2", PreviousDigest: "sha256:cli-1"}, "installer": {Digest: "sha256:installer-2", PreviousDigest: "sha256:installer-1"}}},
+		} {
+			if err := history.AddRecord(stream, record); err != nil {
+				t.Fatalf("failed to add record: %v", err)
+			}
+		}
+		return stream
+	}
+	var testCases = []struct {
+		name         string
+		job          string
+		tags         sets.String
+		dryRun       bool
+		expectedTags []string
+		expectedErr  bool
+	}{
+		{
+			name:         "all tags are restored",
+			job:          "1",
+			expectedTags: []string{"cli", "installer"},
+		},
+		{
+			name:         "only selected tags are restored",
+			job:          "1",
+			tags:         sets.NewString("installer"),
+			expectedTags: []string{"installer"},
+		},
+		{
+			name:   "dry run changes nothing",
+			job:    "1",
+			dryRun: true,
+		},
+		{
+			name:        "unknown job",
+			job:         "3",
+			expectedErr: true,
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			client := fakectrlruntimeclient.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(newStream()).Build()
+			err := rollback(context.Background(), client, key, testCase.job, testCase.tags, testCase.dryRun)
+			if (err != nil) != testCase.expectedErr {
+				t.Fatalf("expected error %t, got %v", testCase.expectedErr, err)
+			}
+			stream := &imagev1.ImageStream{}
+			if err := client.Get(context.Background(), key, stream); err != nil {
+				t.Fatalf("failed to get stream: %v", err)
+			}
+			var tags []string
+			for _, tag := range stream.Spec.Tags {
+				tags = append(tags, tag.Name)
+			}
+			if diff := cmp.Diff(testCase.expectedTags, tags); diff != "" {
+				t.Errorf("unexpected restored tags: %s", diff)
+			}
+		})
+	}
+}
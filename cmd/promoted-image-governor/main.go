@@ -47,8 +47,9 @@ type options struct {
 	openshiftMappingConfigPath string
 	openshiftMappingConfig     *OpenshiftMappingConfig
 
-	explainsRaw flagutil.Strings
-	explains    map[api.ImageStreamTagReference]string
+	explainsRaw    flagutil.Strings
+	explains       map[api.ImageStreamTagReference]string
+	explainTargets map[string]string
 
 	logLevel string
 }
@@ -64,7 +65,7 @@ func parseOptions() *options {
 	fs.StringVar(&opts.releaseControllerMirrorConfigDir, "release-controller-mirror-config-dir", "", "Path to the release controller mirror config directory")
 	fs.StringVar(&opts.openshiftMappingDir, "openshift-mapping-dir", "", "Path to the openshift mapping directory")
 	fs.StringVar(&opts.openshiftMappingConfigPath, "openshift-mapping-config", "", "Path to the openshift mapping config file")
-	fs.Var(&opts.explainsRaw, "explain", "An imagestreamtag to explain its existence. It must be in namespace/name:tag format (e.G `ci/clonerefs:latest`). Can be passed multiple times.")
+	fs.Var(&opts.explainsRaw, "explain", "An imagestreamtag to explain its existence. It must be in namespace/name:tag format (e.G `ci/clonerefs:latest`), or the pull spec of an image promoted to an external registry (e.G `quay.io/org/clonerefs:latest`). Can be passed multiple times.")
 	if err := fs.Parse(os.Args[1:]); err != nil {
 		logrus.WithError(err).Fatal("could not parse args")
 	}
@@ -111,8 +112,13 @@ func (o *options) validate() error {
 	}
 
 	o.explains = map[api.ImageStreamTagReference]string{}
+	o.explainTargets = map[string]string{}
 	var errs []error
 	for _, val := range o.explainsRaw.Strings() {
+		if isExternalPullSpec(val) {
+			o.explainTargets[val] = explanationUnknown
+			continue
+		}
 		slashSplit := strings.Split(val, "/")
 		if len(slashSplit) != 2 {
 			errs = append(errs, fmt.Errorf("--explain value %s was not in namespace/name:tag format", val))
@@ -132,6 +138,24 @@ func (o *options) validate() error {
 	return utilerrors.NewAggregate(errs)
 }
 
+// isExternalPullSpec determines whether the value is the pull spec of an image
+// in an external registry rather than an imagestreamtag: the first component
+// of a pull spec is a registry domain, which namespaces cannot look like.
+func isExternalPullSpec(val string) bool {
+	slashSplit := strings.Split(val, "/")
+	return len(slashSplit) > 2 || (len(slashSplit) == 2 && strings.ContainsAny(slashSplit[0], ".:"))
+}
+
+// explainTargets records the configuration promoting each of the explained
+// images to a promotion target.
+func explainTargets(cfg *api.ReleaseBuildConfiguration, explains map[string]string) {
+	for _, image := range release.PromotedTargetImages(cfg) {
+		if _, ok := explains[image.PullSpec()]; ok {
+			explains[image.PullSpec()] = cfg.Metadata.AsString()
+		}
+	}
+}
+
 const (
 	explanationUnknown = "unknown"
 	appCIContextName   = string(api.ClusterAPPCI)
@@ -447,6 +471,7 @@ func main() {
 				opts.explains[isTagRef] = cfg.Metadata.AsString()
 			}
 		}
+		explainTargets(cfg, opts.explainTargets)
 		return nil
 	}); err != nil {
 		logrus.WithField("path", abs).Fatal("failed to operate on CI Operator's config directory")
@@ -515,7 +540,7 @@ func main() {
 
 	ctx := interrupts.Context()
 
-	if len(opts.explains) > 0 {
+	if len(opts.explains) > 0 || len(opts.explainTargets) > 0 {
 		w := tabwriter.NewWriter(os.Stdout, 20, 30, 1, ' ', tabwriter.AlignRight)
 		fmt.Fprintf(w, "tag\texplanation\t\n")
 		for tag, e := range opts.explains {
@@ -533,6 +558,14 @@ func main() {
 			}
 			fmt.Fprintf(w, "%s\t%s\t\n", tag.ISTagName(), e)
 		}
+		for _, pullSpec := range sets.StringKeySet(opts.explainTargets).List() {
+			e := opts.explainTargets[pullSpec]
+			if e == explanationUnknown {
+				// we cannot look into external registries, only at what is configured to be promoted there
+				e = "not promoted to by any configuration"
+			}
+			fmt.Fprintf(w, "%s\t%s\t\n", pullSpec, e)
+		}
 		w.Flush()
 		return
 	}
@@ -477,3 +477,51 @@ func TestDeleteTagsOnBuildFarm(t *testing.T) {
 		})
 	}
 }
+
+func TestIsExternalPullSpec(t *testing.T) {
+	testCases := []struct {
+		val      string
+		expected bool
+	}{
+		{val: "ci/clonerefs:latest"},
+		{val: "quay.io/org/clonerefs:latest", expected: true},
+		{val: "docker.io/clonerefs:latest", expected: true},
+		{val: "localhost:5000/clonerefs:latest", expected: true},
+		{val: "clonerefs:latest"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.val, func(t *testing.T) {
+			if actual := isExternalPullSpec(tc.val); actual != tc.expected {
+				t.Errorf("expected %t, got %t", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestExplainTargets(t *testing.T) {
+	cfg := &api.ReleaseBuildConfiguration{
+		Metadata: api.Metadata{Org: "org", Repo: "repo", Branch: "master"},
+		Images:   []api.ProjectDirectoryImageBuildStepConfiguration{{To: "clonerefs"}},
+		PromotionConfiguration: &api.PromotionConfiguration{
+			Namespace: "ci",
+			Tag:       "latest",
+			Targets: []api.PromotionTarget{{
+				Registry:    "quay.io",
+				Repository:  "org/${component}",
+				Credentials: api.PromotionCredentials{Namespace: "test-credentials", Name: "quay"},
+			}},
+		},
+	}
+	explains := map[string]string{
+		"quay.io/org/clonerefs:latest": explanationUnknown,
+		"quay.io/org/other:latest":     explanationUnknown,
+	}
+	explainTargets(cfg, explains)
+	expected := map[string]string{
+		"quay.io/org/clonerefs:latest": "org/repo@master",
+		"quay.io/org/other:latest":     explanationUnknown,
+	}
+	if diff := cmp.Diff(expected, explains); diff != "" {
+		t.Errorf("unexpected explanations: %s", diff)
+	}
+}
@@ -0,0 +1,140 @@
+// registry-impact lists the workflows, chains, ci-operator configurations and
+// generated Prow jobs which would change if a component of the step registry
+// changed, so that owners of widely used steps know what an edit affects.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/openshift/ci-tools/pkg/load"
+	"github.com/openshift/ci-tools/pkg/registry"
+	"github.com/openshift/ci-tools/pkg/registry/impact"
+)
+
+const (
+	formatText = "text"
+	formatJSON = "json"
+)
+
+type options struct {
+	registry     string
+	configDir    string
+	step         string
+	stepType     string
+	outputFormat string
+}
+
+func gatherOptions() (options, error) {
+	o := options{}
+	fs := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	fs.StringVar(&o.registry, "registry", "", "Path to the step registry directory.")
+	fs.StringVar(&o.configDir, "config-dir", "", "Path to the ci-operator configuration directory.")
+	fs.StringVar(&o.step, "step", "", "Name of the reference, chain or workflow to analyze.")
+	fs.StringVar(&o.stepType, "type", "", fmt.Sprintf("Type of the component to analyze: %s, %s or %s. Only needed when components of several types share the name.", registry.Reference, registry.Chain, registry.Workflow))
+	fs.StringVar(&o.outputFormat, "output-format", formatText, fmt.Sprintf("Format of the output: %s or %s.", formatText, formatJSON))
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		return options{}, fmt.Errorf("could not parse input: %w", err)
+	}
+	return o, nil
+}
+
+func (o *options) validate() error {
+	if o.registry == "" {
+		return errors.New("--registry is required")
+	}
+	if o.configDir == "" {
+		return errors.New("--config-dir is required")
+	}
+	if o.step == "" {
+		return errors.New("--step is required")
+	}
+	switch o.outputFormat {
+	case formatText, formatJSON:
+	default:
+		return fmt.Errorf("--output-format must be one of %s or %s", formatText, formatJSON)
+	}
+	return nil
+}
+
+func render(format string, result impact.Impact) ([]byte, error) {
+	if format == formatJSON {
+		return json.MarshalIndent(result, "", "  ")
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "Changing %s %s affects %d jobs in %d ci-operator configurations.\n", result.Type, result.Name, len(result.Jobs), len(result.Configs))
+	for _, section := range []struct {
+		title string
+		items []string
+	}{
+		{title: "Workflows", items: result.Workflows},
+		{title: "Chains", items: result.Chains},
+		{title: "Configurations", items: result.Configs},
+		{title: "Jobs", items: result.Jobs},
+	} {
+		fmt.Fprintf(&b, "\n%s (%d):\n", section.title, len(section.items))
+		for _, item := range section.items {
+			fmt.Fprintf(&b, "  %s\n", item)
+		}
+	}
+	for _, counts := range []struct {
+		title  string
+		counts map[string]int
+	}{
+		{title: "Jobs by organization", counts: result.JobsByOrg},
+		{title: "Jobs by cluster profile", counts: result.JobsByClusterProfile},
+	} {
+		fmt.Fprintf(&b, "\n%s:\n", counts.title)
+		var keys []string
+		for key := range counts.counts {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			fmt.Fprintf(&b, "  %s: %d\n", key, counts.counts[key])
+		}
+	}
+	return []byte(b.String()), nil
+}
+
+func main() {
+	o, err := gatherOptions()
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to gather options")
+	}
+	if err := o.validate(); err != nil {
+		logrus.WithError(err).Fatal("Invalid options")
+	}
+
+	refs, chains, workflows, _, _, _, err := load.Registry(o.registry, load.RegistryFlag(0))
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to load registry")
+	}
+	graph, err := registry.NewGraph(refs, chains, workflows)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to build registry graph")
+	}
+	node, err := impact.Find(graph, o.stepType, o.step)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to find component")
+	}
+	configs, err := load.FromPathByOrgRepo(o.configDir)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to load ci-operator configuration")
+	}
+
+	raw, err := render(o.outputFormat, impact.Analyze(node, workflows, configs))
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to render impact")
+	}
+	if _, err := os.Stdout.Write(raw); err != nil {
+		logrus.WithError(err).Fatal("Failed to write impact")
+	}
+}
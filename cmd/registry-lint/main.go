@@ -0,0 +1,180 @@
+// registry-lint checks the step registry for likely mistakes and bad practice
+// and reports them as text, JSON or SARIF, which code review tools show as
+// annotations on the files.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+
+	"github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/config"
+	"github.com/openshift/ci-tools/pkg/load"
+	"github.com/openshift/ci-tools/pkg/registry/lint"
+)
+
+const (
+	formatText  = "text"
+	formatJSON  = "json"
+	formatSARIF = "sarif"
+)
+
+type options struct {
+	registry     string
+	configDir    string
+	lintConfig   string
+	outputFormat string
+	output       string
+	pathPrefix   string
+}
+
+func gatherOptions() (options, error) {
+	o := options{}
+	fs := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	fs.StringVar(&o.registry, "registry", "", "Path to the step registry directory.")
+	fs.StringVar(&o.configDir, "config-dir", "", "Path to the ci-operator configuration directory. Tests configured there count as users of the registry components they reference.")
+	fs.StringVar(&o.lintConfig, "lint-config", "", "Path to a file configuring the rules which are run and where their findings are suppressed.")
+	fs.StringVar(&o.outputFormat, "output-format", formatText, fmt.Sprintf("Format of the findings: %s, %s or %s.", formatText, formatJSON, formatSARIF))
+	fs.StringVar(&o.output, "output", "", "Path to write the findings to, standard output when empty.")
+	fs.StringVar(&o.pathPrefix, "path-prefix", "", "Prefix for the paths of files in the findings, such as the path of the registry in its repository.")
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		return options{}, fmt.Errorf("could not parse input: %w", err)
+	}
+	return o, nil
+}
+
+func (o *options) validate() error {
+	if o.registry == "" {
+		return errors.New("--registry is required")
+	}
+	switch o.outputFormat {
+	case formatText, formatJSON, formatSARIF:
+	default:
+		return fmt.Errorf("--output-format must be one of %s, %s or %s", formatText, formatJSON, formatSARIF)
+	}
+	return nil
+}
+
+func loadLintConfig(path string) (lint.Config, error) {
+	var ret lint.Config
+	if path == "" {
+		return ret, nil
+	}
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return ret, fmt.Errorf("could not read lint configuration: %w", err)
+	}
+	if err := yaml.UnmarshalStrict(raw, &ret); err != nil {
+		return ret, fmt.Errorf("could not parse lint configuration: %w", err)
+	}
+	return ret, nil
+}
+
+func loadTests(configDir string) ([]api.MultiStageTestConfiguration, error) {
+	var ret []api.MultiStageTestConfiguration
+	if configDir == "" {
+		return ret, nil
+	}
+	if err := config.OperateOnCIOperatorConfigDir(configDir, func(configuration *api.ReleaseBuildConfiguration, _ *config.Info) error {
+		for _, test := range configuration.Tests {
+			if test.MultiStageTestConfiguration != nil {
+				ret = append(ret, *test.MultiStageTestConfiguration)
+			}
+		}
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("could not load ci-operator configuration: %w", err)
+	}
+	return ret, nil
+}
+
+func render(format string, rules []lint.Rule, findings []lint.Finding) ([]byte, error) {
+	switch format {
+	case formatJSON:
+		if findings == nil {
+			findings = []lint.Finding{}
+		}
+		return json.MarshalIndent(findings, "", "  ")
+	case formatSARIF:
+		return lint.SARIF(rules, findings)
+	}
+	var ret []byte
+	for _, finding := range findings {
+		location := finding.Path
+		if finding.Line > 0 {
+			location = fmt.Sprintf("%s:%d", finding.Path, finding.Line)
+		}
+		ret = append(ret, fmt.Sprintf("%s: %s: [%s] %s\n", location, finding.Level, finding.Rule, finding.Message)...)
+	}
+	return ret, nil
+}
+
+func main() {
+	o, err := gatherOptions()
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to gather options")
+	}
+	if err := o.validate(); err != nil {
+		logrus.WithError(err).Fatal("Invalid options")
+	}
+	lintConfig, err := loadLintConfig(o.lintConfig)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to load lint configuration")
+	}
+	rules := lint.DefaultRules(lintConfig)
+	if err := lintConfig.Validate(rules); err != nil {
+		logrus.WithError(err).Fatal("Invalid lint configuration")
+	}
+
+	refs, chains, workflows, _, _, _, err := load.Registry(o.registry, load.RegistryFlag(0))
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to load registry")
+	}
+	paths, err := lint.PathsIn(o.registry)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to load registry")
+	}
+	tests, err := loadTests(o.configDir)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to load tests")
+	}
+
+	findings := lint.Lint(lint.Registry{
+		References: refs,
+		Chains:     chains,
+		Workflows:  workflows,
+		Tests:      tests,
+		Paths:      paths,
+	}, rules, lintConfig)
+	var failed bool
+	for i := range findings {
+		findings[i].Path = path.Join(o.pathPrefix, findings[i].Path)
+		failed = failed || findings[i].Level == lint.LevelError
+	}
+
+	raw, err := render(o.outputFormat, rules, findings)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to render findings")
+	}
+	if o.output == "" {
+		_, err = os.Stdout.Write(raw)
+	} else {
+		err = ioutil.WriteFile(o.output, raw, 0644)
+	}
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to write findings")
+	}
+	logrus.Infof("Found %d problems in the registry.", len(findings))
+	if failed {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/yaml"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/config"
+	"github.com/openshift/ci-tools/pkg/github"
+)
+
+// inputMismatches compares the FROM and COPY --from references in the
+// Dockerfile of an image with the replacements declared by the inputs of its
+// build. It returns the references to the CI registry that no input replaces,
+// so they are pulled at build time, and the replacements that match no
+// reference, so they have no effect.
+func inputMismatches(image api.ProjectDirectoryImageBuildStepConfiguration, dockerfile []byte) (unreplaced, unused []string, err error) {
+	dockerfile, err = applyReplacementsToDockerfile(dockerfile, &image)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to apply replacements to Dockerfile: %w", err)
+	}
+	references, err := extractReplacementCandidatesFromDockerfile(dockerfile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to extract source images from dockerfile: %w", err)
+	}
+	if image.From != "" {
+		// the last FROM is replaced by the `from` image
+		references.Delete(string(image.From))
+	}
+	replaced := sets.NewString()
+	for _, input := range image.Inputs {
+		replaced.Insert(input.As...)
+	}
+	for _, reference := range references.List() {
+		if registryRegex.MatchString(reference) && !replaced.Has(reference) {
+			unreplaced = append(unreplaced, reference)
+		}
+	}
+	if unusedReplacements := replaced.Difference(references); unusedReplacements.Len() > 0 {
+		unused = unusedReplacements.List()
+	}
+	return unreplaced, unused, nil
+}
+
+// fixInputs adds the replacements for the references of the Dockerfile no
+// input replaces and removes the replacements which match no reference.
+func fixInputs(config *api.ReleaseBuildConfiguration, idx int, dockerfile []byte, unused []string) error {
+	image := &config.Images[idx]
+	dockerfile, err := applyReplacementsToDockerfile(dockerfile, image)
+	if err != nil {
+		return fmt.Errorf("failed to apply replacements to Dockerfile: %w", err)
+	}
+	foundTags, err := ensureReplacement(image, dockerfile)
+	if err != nil {
+		return fmt.Errorf("failed to ensure replacements: %w", err)
+	}
+	addBaseImages(config, foundTags)
+
+	toRemove := sets.NewString(unused...)
+	for name, input := range image.Inputs {
+		var as []string
+		for _, value := range input.As {
+			if !toRemove.Has(value) {
+				as = append(as, value)
+			}
+		}
+		if len(as) == 0 && len(input.Paths) == 0 {
+			delete(image.Inputs, name)
+			continue
+		}
+		input.As = as
+		image.Inputs[name] = input
+	}
+	return nil
+}
+
+// inputValidator checks that the inputs of every image build match the
+// FROM and COPY --from references in its Dockerfile. Mismatches are reported
+// as errors or, when fixing them, corrected in the configuration.
+func inputValidator(
+	githubFileGetterFactory func(org, repo, branch string, opts ...github.Opt) github.FileGetter,
+	writer func([]byte) error,
+	fix bool,
+	credentials *usernameToken,
+) func(*api.ReleaseBuildConfiguration, *config.Info) error {
+	return func(config *api.ReleaseBuildConfiguration, info *config.Info) error {
+		if len(config.Images) == 0 {
+			return nil
+		}
+
+		originalConfig, err := yaml.Marshal(config)
+		if err != nil {
+			return fmt.Errorf("failed to marshal config for comparison: %w", err)
+		}
+
+		var getter github.FileGetter
+		if credentials == nil {
+			getter = githubFileGetterFactory(info.Org, info.Repo, info.Branch)
+		} else {
+			getter = githubFileGetterFactory(info.Org, info.Repo, info.Branch, github.WithAuthentication(credentials.username, credentials.token))
+		}
+
+		var mismatches []string
+		for idx, image := range config.Images {
+			dockerfile, err := getDockerfile(getter, image)
+			if err != nil {
+				return err
+			}
+			// an empty Dockerfile might mean that we do not have the
+			// permissions to read it, so there is nothing to compare
+			if len(dockerfile) == 0 {
+				continue
+			}
+			unreplaced, unused, err := inputMismatches(image, dockerfile)
+			if err != nil {
+				return fmt.Errorf("failed to validate the inputs of image %s: %w", image.To, err)
+			}
+			if fix {
+				if err := fixInputs(config, idx, dockerfile, unused); err != nil {
+					return fmt.Errorf("failed to fix the inputs of image %s: %w", image.To, err)
+				}
+				continue
+			}
+			for _, reference := range unreplaced {
+				mismatches = append(mismatches, fmt.Sprintf("image %s: %s is referenced by the Dockerfile, but not replaced by any input", image.To, reference))
+			}
+			for _, reference := range unused {
+				mismatches = append(mismatches, fmt.Sprintf("image %s: %s is replaced by an input, but not referenced by the Dockerfile", image.To, reference))
+			}
+		}
+		if len(mismatches) > 0 {
+			var message bytes.Buffer
+			fmt.Fprintf(&message, "%s: the inputs of image builds do not match their Dockerfiles:", info.Filename)
+			for _, mismatch := range mismatches {
+				fmt.Fprintf(&message, "\n  * %s", mismatch)
+			}
+			return errors.New(message.String())
+		}
+
+		newConfig, err := yaml.Marshal(config)
+		if err != nil {
+			return fmt.Errorf("failed to marshal new config: %w", err)
+		}
+		if bytes.Equal(originalConfig, newConfig) {
+			return nil
+		}
+		if err := writer(newConfig); err != nil {
+			return fmt.Errorf("failed to write %s: %w", info.Filename, err)
+		}
+		return nil
+	}
+}
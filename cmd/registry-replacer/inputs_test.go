@@ -0,0 +1,128 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/config"
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestInputMismatches(t *testing.T) {
+	testCases := []struct {
+		name               string
+		image              api.ProjectDirectoryImageBuildStepConfiguration
+		dockerfile         string
+		expectedUnreplaced []string
+		expectedUnused     []string
+	}{
+		{
+			name: "inputs match the Dockerfile",
+			image: api.ProjectDirectoryImageBuildStepConfiguration{
+				ProjectDirectoryImageBuildInputs: api.ProjectDirectoryImageBuildInputs{
+					Inputs: map[string]api.ImageBuildInputs{
+						"ocp_builder_rhel-8-golang-1.17": {As: []string{"registry.ci.openshift.org/ocp/builder:rhel-8-golang-1.17"}},
+						"ocp_4.10_base":                  {As: []string{"registry.ci.openshift.org/ocp/4.10:base"}},
+					},
+				},
+			},
+			dockerfile: `FROM registry.ci.openshift.org/ocp/builder:rhel-8-golang-1.17 AS builder
+RUN make
+FROM registry.ci.openshift.org/ocp/4.10:base
+COPY --from=builder /go/bin/tool /usr/bin/tool`,
+		},
+		{
+			name: "the last FROM is replaced by the from image",
+			image: api.ProjectDirectoryImageBuildStepConfiguration{
+				From: "base",
+			},
+			dockerfile: `FROM registry.ci.openshift.org/ocp/4.10:base`,
+		},
+		{
+			name: "COPY --from an image is not replaced",
+			image: api.ProjectDirectoryImageBuildStepConfiguration{
+				From: "base",
+			},
+			dockerfile: `FROM registry.ci.openshift.org/ocp/4.10:base
+COPY --from=registry.ci.openshift.org/ocp/4.10:cli /usr/bin/oc /usr/bin/oc`,
+			expectedUnreplaced: []string{"registry.ci.openshift.org/ocp/4.10:cli"},
+		},
+		{
+			name: "inputs drifted from the Dockerfile",
+			image: api.ProjectDirectoryImageBuildStepConfiguration{
+				ProjectDirectoryImageBuildInputs: api.ProjectDirectoryImageBuildInputs{
+					Inputs: map[string]api.ImageBuildInputs{
+						"ocp_builder_rhel-8-golang-1.16": {As: []string{"registry.ci.openshift.org/ocp/builder:rhel-8-golang-1.16"}},
+					},
+				},
+			},
+			dockerfile: `FROM registry.ci.openshift.org/ocp/builder:rhel-8-golang-1.17 AS builder
+RUN make
+FROM quay.io/org/base:latest
+COPY --from=builder /go/bin/tool /usr/bin/tool`,
+			expectedUnreplaced: []string{"registry.ci.openshift.org/ocp/builder:rhel-8-golang-1.17"},
+			expectedUnused:     []string{"registry.ci.openshift.org/ocp/builder:rhel-8-golang-1.16"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			unreplaced, unused, err := inputMismatches(tc.image, []byte(tc.dockerfile))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.expectedUnreplaced, unreplaced); diff != "" {
+				t.Errorf("unexpected unreplaced references: %s", diff)
+			}
+			if diff := cmp.Diff(tc.expectedUnused, unused); diff != "" {
+				t.Errorf("unexpected unused replacements: %s", diff)
+			}
+		})
+	}
+}
+
+func TestInputValidator(t *testing.T) {
+	newConfig := func() *api.ReleaseBuildConfiguration {
+		return &api.ReleaseBuildConfiguration{
+			Images: []api.ProjectDirectoryImageBuildStepConfiguration{{
+				To: "tool",
+				ProjectDirectoryImageBuildInputs: api.ProjectDirectoryImageBuildInputs{
+					Inputs: map[string]api.ImageBuildInputs{
+						"ocp_builder_rhel-8-golang-1.16": {As: []string{"registry.ci.openshift.org/ocp/builder:rhel-8-golang-1.16"}},
+						"src":                            {Paths: []api.ImageSourcePath{{SourcePath: "/src", DestinationDir: "."}}},
+					},
+				},
+			}},
+		}
+	}
+	files := map[string][]byte{"Dockerfile": []byte(`FROM registry.ci.openshift.org/ocp/builder:rhel-8-golang-1.17`)}
+	info := &config.Info{Filename: "org-repo-master.yaml"}
+
+	t.Run("mismatches are reported", func(t *testing.T) {
+		_, fileGetter := fakeGithubFileGetterFactory(files)
+		fakeWriter := &fakeWriter{}
+		err := inputValidator(fileGetter, fakeWriter.Write, false, nil)(newConfig(), info)
+		expected := `org-repo-master.yaml: the inputs of image builds do not match their Dockerfiles:
+  * image tool: registry.ci.openshift.org/ocp/builder:rhel-8-golang-1.17 is referenced by the Dockerfile, but not replaced by any input
+  * image tool: registry.ci.openshift.org/ocp/builder:rhel-8-golang-1.16 is replaced by an input, but not referenced by the Dockerfile`
+		if err == nil {
+			t.Fatal("expected an error, got none")
+		}
+		if diff := cmp.Diff(expected, err.Error()); diff != "" {
+			t.Errorf("unexpected error: %s", diff)
+		}
+		if fakeWriter.data != nil {
+			t.Errorf("expected no write, got %s", string(fakeWriter.data))
+		}
+	})
+
+	t.Run("mismatches are fixed", func(t *testing.T) {
+		_, fileGetter := fakeGithubFileGetterFactory(files)
+		fakeWriter := &fakeWriter{}
+		if err := inputValidator(fileGetter, fakeWriter.Write, true, nil)(newConfig(), info); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		testhelper.CompareWithFixture(t, fakeWriter.data)
+	})
+}
@@ -53,6 +53,9 @@ type options struct {
 	applyReplacements                            bool
 	ensureCorrectPromotionDockerfileIngoredRepos *flagutil.Strings
 	registryPath                                 string
+	registryVersionsPath                         string
+	validateInputs                               bool
+	fixInputs                                    bool
 	flagutil.GitHubOptions
 }
 
@@ -73,6 +76,9 @@ func gatherOptions() (*options, error) {
 	flag.BoolVar(&o.applyReplacements, "apply-replacements", true, "If we should apply Dockerfile image replacements. You will probably always leave this as the default, and it's mostly used by tests that validate that base image pruning doesn't botch things. Note: If not applying replacements we will also skip unused replacement pruning.")
 	flag.BoolVar(&o.pruneOCPBuilderReplacements, "prune-ocp-builder-replacements", false, "If all replacements that target the ocp/builder imagestream should be removed")
 	flag.StringVar(&o.registryPath, "registry", "", "Path to the step registry directory")
+	flag.StringVar(&o.registryVersionsPath, "registry-versions", "", "Path to a directory holding a snapshot of the step registry for every version components can be pinned to")
+	flag.BoolVar(&o.validateInputs, "validate-inputs", false, "Instead of replacing registry references, check that the inputs of every image build match the FROM and COPY --from references in its Dockerfile and report mismatches")
+	flag.BoolVar(&o.fixInputs, "fix-inputs", false, "With --validate-inputs, fix the inputs of image builds instead of reporting mismatches")
 	flag.Parse()
 
 	var errs []error
@@ -80,6 +86,10 @@ func gatherOptions() (*options, error) {
 		errs = append(errs, errors.New("--config-dir is mandatory"))
 	}
 
+	if o.fixInputs && !o.validateInputs {
+		errs = append(errs, errors.New("--fix-inputs requires --validate-inputs"))
+	}
+
 	if o.createPR {
 		if o.githubUserName == "" {
 			errs = append(errs, errors.New("--github-user-name was unset, it is required when --create-pr is set"))
@@ -139,7 +149,7 @@ func main() {
 		}
 	}
 
-	resolver, err := loadResolver(opts.registryPath)
+	resolver, err := loadResolver(opts.registryPath, opts.registryVersionsPath)
 	if err != nil {
 		logrus.WithError(err).Fatal("failed to load resolver")
 	}
@@ -156,11 +166,20 @@ func main() {
 			}
 			go func(filename string) {
 				defer sem.Release(1)
+				writer := func(data []byte) error {
+					return ioutil.WriteFile(filename, data, 0644)
+				}
+				if opts.validateInputs {
+					if err := inputValidator(github.FileGetterFactory, writer, opts.fixInputs, credentials)(config, info); err != nil {
+						errLock.Lock()
+						errs = append(errs, err)
+						errLock.Unlock()
+					}
+					return
+				}
 				if err := replacer(
 					github.FileGetterFactory,
-					func(data []byte) error {
-						return ioutil.WriteFile(filename, data, 0644)
-					},
+					writer,
 					opts.pruneUnusedReplacements,
 					opts.pruneOCPBuilderReplacements,
 					opts.pruneUnusedBaseImages,
@@ -195,12 +214,12 @@ func main() {
 		return
 	}
 
-	if err := upsertPR(githubClient, opts.configDir, opts.githubUserName, secret.GetSecret(opts.TokenPath), opts.selfApprove, opts.pruneUnusedReplacements, opts.ensureCorrectPromotionDockerfile); err != nil {
+	if err := upsertPR(githubClient, opts.configDir, opts.githubUserName, secret.GetSecret(opts.TokenPath), opts.selfApprove, opts.pruneUnusedReplacements, opts.ensureCorrectPromotionDockerfile, opts.fixInputs); err != nil {
 		logrus.WithError(err).Fatal("Failed to create PR")
 	}
 }
 
-func loadResolver(path string) (registry.Resolver, error) {
+func loadResolver(path, versionsPath string) (registry.Resolver, error) {
 	if path == "" {
 		return nil, nil
 	}
@@ -208,7 +227,13 @@ func loadResolver(path string) (registry.Resolver, error) {
 	if err != nil {
 		return nil, err
 	}
-	return registry.NewResolver(refs, chains, workflows, observers), nil
+	var versions registry.VersionByName
+	if versionsPath != "" {
+		if versions, err = load.RegistryVersions(versionsPath, load.RegistryFlag(0)); err != nil {
+			return nil, err
+		}
+	}
+	return registry.NewVersionedResolver(refs, chains, workflows, observers, versions), nil
 }
 
 type usernameToken struct {
@@ -263,20 +288,9 @@ func replacer(
 			var hasNonEmptyDockerfile bool
 
 			for idx, image := range config.Images {
-				var dockerfile []byte
-				if image.DockerfileLiteral != nil {
-					dockerfile = []byte(*image.DockerfileLiteral)
-				} else {
-					dockerFilePath := "Dockerfile"
-					if image.DockerfilePath != "" {
-						dockerFilePath = image.DockerfilePath
-					}
-
-					var err error
-					dockerfile, err = getter(filepath.Join(image.ContextDir, dockerFilePath))
-					if err != nil {
-						return fmt.Errorf("failed to get dockerfile %s: %w", image.DockerfilePath, err)
-					}
+				dockerfile, err := getDockerfile(getter, image)
+				if err != nil {
+					return err
 				}
 
 				hasNonEmptyDockerfile = hasNonEmptyDockerfile || len(dockerfile) > 0
@@ -290,19 +304,7 @@ func replacer(
 				if err != nil {
 					return fmt.Errorf("failed to ensure replacements: %w", err)
 				}
-				for _, foundTag := range foundTags {
-					if config.BaseImages == nil {
-						config.BaseImages = map[string]api.ImageStreamTagReference{}
-					}
-					if _, exists := config.BaseImages[foundTag.String()]; exists {
-						continue
-					}
-					config.BaseImages[foundTag.String()] = api.ImageStreamTagReference{
-						Namespace: foundTag.org,
-						Name:      foundTag.repo,
-						Tag:       foundTag.tag,
-					}
-				}
+				addBaseImages(config, foundTags)
 
 				replacementCandidates, err := extractReplacementCandidatesFromDockerfile(dockerfile)
 				if err != nil {
@@ -358,6 +360,40 @@ func replacer(
 	}
 }
 
+// getDockerfile returns the Dockerfile the image is built from.
+func getDockerfile(getter github.FileGetter, image api.ProjectDirectoryImageBuildStepConfiguration) ([]byte, error) {
+	if image.DockerfileLiteral != nil {
+		return []byte(*image.DockerfileLiteral), nil
+	}
+	dockerFilePath := "Dockerfile"
+	if image.DockerfilePath != "" {
+		dockerFilePath = image.DockerfilePath
+	}
+	dockerfile, err := getter(filepath.Join(image.ContextDir, dockerFilePath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dockerfile %s: %w", image.DockerfilePath, err)
+	}
+	return dockerfile, nil
+}
+
+// addBaseImages adds the base images the replacements of the found tags
+// refer to, if the configuration does not have them yet.
+func addBaseImages(config *api.ReleaseBuildConfiguration, foundTags []orgRepoTag) {
+	for _, foundTag := range foundTags {
+		if config.BaseImages == nil {
+			config.BaseImages = map[string]api.ImageStreamTagReference{}
+		}
+		if _, exists := config.BaseImages[foundTag.String()]; exists {
+			continue
+		}
+		config.BaseImages[foundTag.String()] = api.ImageStreamTagReference{
+			Namespace: foundTag.org,
+			Name:      foundTag.repo,
+			Tag:       foundTag.tag,
+		}
+	}
+}
+
 var registryRegex = regexp.MustCompile(`registry\.(|svc\.)ci\.openshift\.org/\S+`)
 
 type orgRepoTag struct{ org, repo, tag string }
@@ -439,7 +475,7 @@ func orgRepoTagFromPullString(pullString string) (orgRepoTag, error) {
 	return res, nil
 }
 
-func upsertPR(gc pgithub.Client, dir, githubUsername string, token []byte, selfApprove, pruneUnusedReplacements, ensureCorrectPromotionDockerfile bool) error {
+func upsertPR(gc pgithub.Client, dir, githubUsername string, token []byte, selfApprove, pruneUnusedReplacements, ensureCorrectPromotionDockerfile, fixInputs bool) error {
 	if err := os.Chdir(dir); err != nil {
 		return fmt.Errorf("failed to chdir into %s: %w", dir, err)
 	}
@@ -482,6 +518,10 @@ func upsertPR(gc pgithub.Client, dir, githubUsername string, token []byte, selfA
 * Adds a replacement of all FROM registry.ci.openshift.org/anything directives found in any Dockerfile
   to make sure all images are pulled from the build cluster registry`
 
+	if fixInputs {
+		prBody += "\n* Removes replacements of image build inputs that do not match any FROM or COPY --from directive in the Dockerfile"
+	}
+
 	if pruneUnusedReplacements {
 		prBody += "\n* Prunes existing replacements that do not match any FROM directive in the Dockerfile"
 	}
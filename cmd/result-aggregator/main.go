@@ -32,10 +32,27 @@ var (
 		},
 		[]string{"job_name", "type", "state", "reason", "cluster"},
 	)
+	leaseWait = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "ci_operator_lease_wait_seconds",
+			Help:    "time taken to acquire leases, sorted by org, resource type and priority class",
+			Buckets: []float64{1, 10, 60, 300, 900, 1800, 3600, 7200},
+		},
+		[]string{"org", "resource_type", "priority_class"},
+	)
+	leaseDenials = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "ci_operator_lease_denials",
+			Help: "number of lease acquisitions held back by the lease quota, sorted by org, resource type and priority class",
+		},
+		[]string{"org", "resource_type", "priority_class"},
+	)
 )
 
 func init() {
 	prometheus.MustRegister(errorRate)
+	prometheus.MustRegister(leaseWait)
+	prometheus.MustRegister(leaseDenials)
 }
 
 type options struct {
@@ -104,6 +121,31 @@ func withErrorRate(request *results.Request) {
 	errorRate.With(labels).Inc()
 }
 
+func validateLeaseRequest(request *results.LeaseRequest) error {
+	if request.JobName == "" {
+		return fmt.Errorf("job_name field in request is empty")
+	}
+	if request.ResourceType == "" {
+		return fmt.Errorf("resource_type field in request is empty")
+	}
+	if request.WaitSeconds < 0 {
+		return fmt.Errorf("wait_seconds field in request is negative")
+	}
+	return nil
+}
+
+func withLeaseMetrics(request *results.LeaseRequest) {
+	labels := prometheus.Labels{
+		"org":            request.Org,
+		"resource_type":  request.ResourceType,
+		"priority_class": request.PriorityClass,
+	}
+	leaseWait.With(labels).Observe(request.WaitSeconds)
+	if request.Denied {
+		leaseDenials.With(labels).Inc()
+	}
+}
+
 type validator interface {
 	Validate(username, password string) bool
 }
@@ -148,6 +190,35 @@ func handleCIOperatorResult() http.HandlerFunc {
 	}
 }
 
+func handleCIOperatorLease() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		bytes, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			handleError(w, fmt.Errorf("unable to ready request body: %w", err))
+			return
+		}
+
+		request := &results.LeaseRequest{}
+		if err := json.Unmarshal(bytes, request); err != nil {
+			handleError(w, fmt.Errorf("unable to decode request body: %w", err))
+			return
+		}
+
+		if err := validateLeaseRequest(request); err != nil {
+			handleError(w, err)
+			return
+		}
+
+		withLeaseMetrics(request)
+
+		w.WriteHeader(http.StatusOK)
+
+		log.WithFields(log.Fields{"request": request, "duration": time.Since(start).String()}).Info("Request processed")
+	}
+}
+
 func main() {
 	o, err := gatherOptions()
 	if err != nil {
@@ -167,6 +238,7 @@ func main() {
 	validator := &multi{delegates: []validator{&passwdFile{file: o.passwdFile}}}
 
 	http.Handle("/result", loginHandler(validator, handleCIOperatorResult()))
+	http.Handle("/lease", loginHandler(validator, handleCIOperatorLease()))
 	metrics.ExposeMetrics("result-aggregator", prowConfig.PushGateway{}, flagutil.DefaultMetricsPort)
 
 	interrupts.ListenAndServe(&http.Server{Addr: o.address}, o.gracePeriod)
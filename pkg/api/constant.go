@@ -10,6 +10,13 @@ const (
 	RegistryPushCredentialsCICentralSecret          = "registry-push-credentials-ci-central"
 	RegistryPushCredentialsCICentralSecretMountPath = "/etc/push-secret"
 
+	// ImageSigningKeySecret holds the cosign key promoted images are signed
+	// with and, optionally, its password
+	ImageSigningKeySecret          = "image-signing-key"
+	ImageSigningKeySecretMountPath = "/etc/signing-key"
+	ImageSigningKeyFilename        = "cosign.key"
+	ImageSigningPasswordFilename   = "cosign.password"
+
 	GCSUploadCredentialsSecret          = "gce-sa-credentials-gcs-publisher"
 	GCSUploadCredentialsSecretMountPath = "/secrets/gcs"
 
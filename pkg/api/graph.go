@@ -556,6 +556,10 @@ func (c *CIOperatorStepDetailInfo) UnmarshalJSON(data []byte) error {
 
 const CIOperatorStepGraphJSONFilename = "ci-operator-step-graph.json"
 
+// CIOperatorStepEventsFilename is the artifact holding the newline-delimited
+// JSON stream of events emitted while steps execute.
+const CIOperatorStepEventsFilename = "ci-operator-step-events.ndjson"
+
 // StepGraphJSONURL takes a base url like https://storage.googleapis.com/origin-ci-test/pr-logs/pull/openshift_ci-tools/999/pull-ci-openshift-ci-tools-master-validate-vendor/1283812971092381696
 // and returns the full url for the step graph json document.
 func StepGraphJSONURL(baseJobURL string) string {
@@ -24,6 +24,9 @@ type JobSpec struct {
 	namespace     string
 	BaseNamespace string
 
+	// inputHash identifies the inputs of the job
+	inputHash string
+
 	// if set, any new artifacts will be a child of this object
 	owner *meta.OwnerReference
 
@@ -45,6 +48,16 @@ func (s *JobSpec) SetNamespace(namespace string) {
 	s.namespace = namespace
 }
 
+// InputHash returns the hash of all inputs to the job. Must not be
+// evaluated at step construction time because its unset there
+func (s *JobSpec) InputHash() string {
+	return s.inputHash
+}
+
+func (s *JobSpec) SetInputHash(inputHash string) {
+	s.inputHash = inputHash
+}
+
 func (s *JobSpec) RawSpec() string {
 	return s.rawSpec
 }
@@ -143,6 +143,16 @@ func BuildCacheFor(metadata Metadata) ImageStreamTagReference {
 	}
 }
 
+// LayerCacheFor returns the repository the layers built for an image are
+// cached in, to be reused by later builds of the image.
+func LayerCacheFor(metadata Metadata, image PipelineImageStreamTagReference) string {
+	name := fmt.Sprintf("%s-%s-%s", metadata.Org, metadata.Repo, metadata.Branch)
+	if metadata.Variant != "" {
+		name = fmt.Sprintf("%s-%s", name, metadata.Variant)
+	}
+	return fmt.Sprintf("%s/build-cache/%s-%s", ServiceDomainAPPCIRegistry, name, image)
+}
+
 func ImageVersionLabel(fromTag PipelineImageStreamTagReference) string {
 	return fmt.Sprintf("io.openshift.ci.from.%s", fromTag)
 }
@@ -273,6 +273,32 @@ func TestMetadata_TestNameFromJobName(t *testing.T) {
 	}
 }
 
+func TestLayerCacheFor(t *testing.T) {
+	testCases := []struct {
+		name     string
+		metadata Metadata
+		expected string
+	}{
+		{
+			name:     "no variant",
+			metadata: Metadata{Org: "org", Repo: "repo", Branch: "master"},
+			expected: "registry.ci.openshift.org/build-cache/org-repo-master-image",
+		},
+		{
+			name:     "variant",
+			metadata: Metadata{Org: "org", Repo: "repo", Branch: "master", Variant: "rhel"},
+			expected: "registry.ci.openshift.org/build-cache/org-repo-master-rhel-image",
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			if diff := cmp.Diff(testCase.expected, LayerCacheFor(testCase.metadata, "image")); diff != "" {
+				t.Errorf("unexpected repository: %s", diff)
+			}
+		})
+	}
+}
+
 func TestFlavorForBranch(t *testing.T) {
 	testCases := []struct {
 		name     string
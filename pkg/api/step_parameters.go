@@ -0,0 +1,129 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// patterns caches the compiled patterns of parameters, which are validated
+// for every test using a step.
+var patterns sync.Map
+
+// compilePattern compiles a pattern once and returns the cached regular
+// expression afterwards.
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	if re, ok := patterns.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	patterns.Store(pattern, re)
+	return re, nil
+}
+
+// ValidateValue checks a value of the parameter against its type, allowed
+// values and pattern. Parameters without constraints accept any value and
+// the empty value, which leaves a parameter unset, is always accepted.
+func (p StepParameter) ValidateValue(value string) error {
+	if value == "" {
+		return nil
+	}
+	switch p.Type {
+	case "", StepParameterTypeString, StepParameterTypeEnum:
+	case StepParameterTypeBool:
+		// environment variables are compared as strings by the
+		// commands of the steps, so only one spelling is accepted
+		if value != "true" && value != "false" {
+			return fmt.Errorf("%q is not a bool, must be true or false", value)
+		}
+	case StepParameterTypeInt:
+		if _, err := strconv.Atoi(value); err != nil {
+			return fmt.Errorf("%q is not an int", value)
+		}
+	case StepParameterTypeDuration:
+		if _, err := time.ParseDuration(value); err != nil {
+			return fmt.Errorf("%q is not a duration", value)
+		}
+	default:
+		return fmt.Errorf("unknown type %q", p.Type)
+	}
+	if len(p.AllowedValues) != 0 {
+		var allowed bool
+		for _, v := range p.AllowedValues {
+			if v == value {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return fmt.Errorf("%q is not one of %s", value, strings.Join(p.AllowedValues, ", "))
+		}
+	}
+	if p.Pattern != "" {
+		re, err := compilePattern(p.Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid pattern: %w", err)
+		}
+		if !re.MatchString(value) {
+			return fmt.Errorf("%q does not match %s", value, p.Pattern)
+		}
+	}
+	return nil
+}
+
+// ValidateDefinition checks that the constraints of the parameter are
+// consistent and that its default value satisfies them.
+func (p StepParameter) ValidateDefinition() error {
+	switch p.Type {
+	case "", StepParameterTypeString, StepParameterTypeBool, StepParameterTypeInt, StepParameterTypeDuration:
+	case StepParameterTypeEnum:
+		if len(p.AllowedValues) == 0 {
+			return errors.New("`allowed_values` must be set for an enum")
+		}
+	default:
+		return fmt.Errorf("unknown type %q, must be one of %s, %s, %s, %s or %s", p.Type, StepParameterTypeString, StepParameterTypeBool, StepParameterTypeInt, StepParameterTypeEnum, StepParameterTypeDuration)
+	}
+	if p.Pattern != "" {
+		if p.Type != "" && p.Type != StepParameterTypeString {
+			return errors.New("`pattern` can only be set for a string")
+		}
+		if _, err := compilePattern(p.Pattern); err != nil {
+			return fmt.Errorf("invalid `pattern`: %w", err)
+		}
+	}
+	for _, value := range p.AllowedValues {
+		if err := p.ValidateValue(value); err != nil {
+			return fmt.Errorf("invalid value in `allowed_values`: %w", err)
+		}
+	}
+	if p.Default != nil {
+		if err := p.ValidateValue(*p.Default); err != nil {
+			return fmt.Errorf("invalid default: %w", err)
+		}
+	}
+	return nil
+}
+
+// Constraint describes the values the parameter accepts, empty if any.
+func (p StepParameter) Constraint() string {
+	var constraints []string
+	switch p.Type {
+	case "", StepParameterTypeString, StepParameterTypeEnum:
+	default:
+		constraints = append(constraints, string(p.Type))
+	}
+	if len(p.AllowedValues) != 0 {
+		constraints = append(constraints, "one of "+strings.Join(p.AllowedValues, ", "))
+	}
+	if p.Pattern != "" {
+		constraints = append(constraints, "matching "+p.Pattern)
+	}
+	return strings.Join(constraints, ", ")
+}
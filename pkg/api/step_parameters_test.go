@@ -0,0 +1,112 @@
+package api
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"k8s.io/utils/pointer"
+
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestStepParameterValidateValue(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		param    StepParameter
+		value    string
+		expected error
+	}{{
+		name:  "untyped parameter accepts anything",
+		param: StepParameter{Name: "P"},
+		value: "anything",
+	}, {
+		name:  "empty value is always accepted",
+		param: StepParameter{Name: "P", Type: StepParameterTypeInt},
+	}, {
+		name:  "bool",
+		param: StepParameter{Name: "FIPS_ENABLED", Type: StepParameterTypeBool},
+		value: "true",
+	}, {
+		name:     "misspelled bool",
+		param:    StepParameter{Name: "FIPS_ENABLED", Type: StepParameterTypeBool},
+		value:    "ture",
+		expected: errors.New(`"ture" is not a bool, must be true or false`),
+	}, {
+		name:     "bool in another spelling",
+		param:    StepParameter{Name: "FIPS_ENABLED", Type: StepParameterTypeBool},
+		value:    "True",
+		expected: errors.New(`"True" is not a bool, must be true or false`),
+	}, {
+		name:     "int",
+		param:    StepParameter{Name: "P", Type: StepParameterTypeInt},
+		value:    "1.5",
+		expected: errors.New(`"1.5" is not an int`),
+	}, {
+		name:  "duration",
+		param: StepParameter{Name: "P", Type: StepParameterTypeDuration},
+		value: "1h30m",
+	}, {
+		name:     "invalid duration",
+		param:    StepParameter{Name: "P", Type: StepParameterTypeDuration},
+		value:    "90",
+		expected: errors.New(`"90" is not a duration`),
+	}, {
+		name:     "enum",
+		param:    StepParameter{Name: "P", Type: StepParameterTypeEnum, AllowedValues: []string{"ovn", "sdn"}},
+		value:    "calico",
+		expected: errors.New(`"calico" is not one of ovn, sdn`),
+	}, {
+		name:     "pattern",
+		param:    StepParameter{Name: "P", Type: StepParameterTypeString, Pattern: "^4\\.[0-9]+$"},
+		value:    "4.10.1",
+		expected: errors.New(`"4.10.1" does not match ^4\.[0-9]+$`),
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expected, tc.param.ValidateValue(tc.value), testhelper.EquateErrorMessage); diff != "" {
+				t.Errorf("unexpected error: %s", diff)
+			}
+		})
+	}
+}
+
+func TestStepParameterValidateDefinition(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		param    StepParameter
+		expected error
+	}{{
+		name:  "valid enum with default",
+		param: StepParameter{Name: "P", Type: StepParameterTypeEnum, AllowedValues: []string{"ovn", "sdn"}, Default: pointer.StringPtr("ovn")},
+	}, {
+		name:  "empty default is valid",
+		param: StepParameter{Name: "P", Type: StepParameterTypeBool, Default: pointer.StringPtr("")},
+	}, {
+		name:     "unknown type",
+		param:    StepParameter{Name: "P", Type: "float"},
+		expected: errors.New(`unknown type "float", must be one of string, bool, int, enum or duration`),
+	}, {
+		name:     "enum without values",
+		param:    StepParameter{Name: "P", Type: StepParameterTypeEnum},
+		expected: errors.New("`allowed_values` must be set for an enum"),
+	}, {
+		name:     "pattern on a bool",
+		param:    StepParameter{Name: "P", Type: StepParameterTypeBool, Pattern: "true"},
+		expected: errors.New("`pattern` can only be set for a string"),
+	}, {
+		name:     "allowed values of the wrong type",
+		param:    StepParameter{Name: "P", Type: StepParameterTypeInt, AllowedValues: []string{"1", "two"}},
+		expected: errors.New(`invalid value in ` + "`allowed_values`" + `: "two" is not an int`),
+	}, {
+		name:     "invalid default",
+		param:    StepParameter{Name: "P", Type: StepParameterTypeBool, Default: pointer.StringPtr("yes")},
+		expected: errors.New(`invalid default: "yes" is not a bool, must be true or false`),
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expected, tc.param.ValidateDefinition(), testhelper.EquateErrorMessage); diff != "" {
+				t.Errorf("unexpected error: %s", diff)
+			}
+		})
+	}
+}
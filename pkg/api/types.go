@@ -235,6 +235,64 @@ type UnresolvedRelease struct {
 	Prerelease *Prerelease `json:"prerelease,omitempty"`
 	// Release describes a released payload
 	Release *Release `json:"release,omitempty"`
+	// Composition describes a payload composed from a release
+	// and images of components taken from other sources
+	Composition *ReleaseComposition `json:"composition,omitempty"`
+}
+
+// ReleaseComposition describes a release payload assembled from a base
+// release, with the images of some of its components overridden.
+type ReleaseComposition struct {
+	// Base is the release the composed payload starts from.
+	Base ReleaseCompositionBase `json:"base"`
+	// Overrides replace the images of components of the base release.
+	Overrides []ComponentOverride `json:"overrides,omitempty"`
+}
+
+// ReleaseCompositionBase describes the release a composed payload starts
+// from, which can be any release but a composition itself.
+type ReleaseCompositionBase struct {
+	// Integration describes an integration stream which we can create a payload out of
+	Integration *Integration `json:"integration,omitempty"`
+	// Candidate describes a candidate release payload
+	Candidate *Candidate `json:"candidate,omitempty"`
+	// Prerelease describes a yet-to-be released payload
+	Prerelease *Prerelease `json:"prerelease,omitempty"`
+	// Release describes a released payload
+	Release *Release `json:"release,omitempty"`
+}
+
+// UnresolvedRelease returns the base as a release to resolve.
+func (b ReleaseCompositionBase) UnresolvedRelease() UnresolvedRelease {
+	return UnresolvedRelease{
+		Integration: b.Integration,
+		Candidate:   b.Candidate,
+		Prerelease:  b.Prerelease,
+		Release:     b.Release,
+	}
+}
+
+// ComponentOverride replaces the images of components of a release,
+// either with the images from an integration stream or with a literal
+// pull spec.
+type ComponentOverride struct {
+	// Components are the names of the components to override, as
+	// they are tagged in the release.
+	Components []string `json:"components"`
+	// Namespace is the namespace of the integration stream the
+	// images are taken from, by the tags named like the components.
+	Namespace string `json:"namespace,omitempty"`
+	// Name is the name of the integration stream the images are
+	// taken from.
+	Name string `json:"name,omitempty"`
+	// PullSpec is the image to use for the single component.
+	PullSpec string `json:"pull_spec,omitempty"`
+}
+
+// CompositionBaseName is the name the base release of a composed
+// release is imported or snapshot under.
+func CompositionBaseName(name string) string {
+	return fmt.Sprintf("%s-base", name)
 }
 
 // Integration is an ImageStream holding the latest images from development builds of OCP.
@@ -468,6 +526,73 @@ type PromotionConfiguration struct {
 	// promotion does not imply output artifacts are being created
 	// for posterity.
 	DisableBuildCache bool `json:"disable_build_cache,omitempty"`
+
+	// Targets are additional destinations the images are promoted
+	// to, usually repositories in external registries. Each target
+	// is pushed to with its own credentials.
+	Targets []PromotionTarget `json:"targets,omitempty"`
+}
+
+// PromotionTarget describes where images are promoted to in a
+// registry other than the central CI registry. The repository and
+// tag are templates in which ${component} is replaced with the name
+// of each promoted image.
+type PromotionTarget struct {
+	// Registry is the domain of the registry, like quay.io.
+	Registry string `json:"registry"`
+
+	// Repository is the repository images are promoted to, like
+	// my-org/${component}.
+	Repository string `json:"repository"`
+
+	// Tag is the tag images are promoted as. Defaults to latest.
+	Tag string `json:"tag,omitempty"`
+
+	// Credentials reference the secret holding the dockercfg
+	// credentials which can push to the repository.
+	Credentials PromotionCredentials `json:"credentials"`
+}
+
+// PromotionCredentialsNamespace is the only namespace the credentials for
+// promotion targets can be read from, so that configurations cannot copy
+// other secrets on the build cluster into test namespaces.
+const PromotionCredentialsNamespace = "test-credentials"
+
+// PromotionCredentials reference a secret of the
+// kubernetes.io/dockerconfigjson type on the build cluster.
+type PromotionCredentials struct {
+	// Namespace is where the secret exists, which must be
+	// test-credentials.
+	Namespace string `json:"namespace"`
+	// Name is the name of the secret.
+	Name string `json:"name"`
+}
+
+// Image returns the pull spec of an image promoted to the target.
+func (t PromotionTarget) Image(component string) PromotionTargetImage {
+	tag := t.Tag
+	if tag == "" {
+		tag = "latest"
+	}
+	return PromotionTargetImage{
+		Registry:    t.Registry,
+		Repository:  strings.ReplaceAll(t.Repository, ComponentFormatReplacement, component),
+		Tag:         strings.ReplaceAll(tag, ComponentFormatReplacement, component),
+		Credentials: t.Credentials,
+	}
+}
+
+// PromotionTargetImage is an image promoted to a promotion target.
+type PromotionTargetImage struct {
+	Registry    string
+	Repository  string
+	Tag         string
+	Credentials PromotionCredentials
+}
+
+// PullSpec returns the pull spec of the image
+func (i PromotionTargetImage) PullSpec() string {
+	return fmt.Sprintf("%s/%s:%s", i.Registry, i.Repository, i.Tag)
 }
 
 // StepConfiguration holds one step configuration.
@@ -660,6 +785,13 @@ type TestStepConfiguration struct {
 	// Timeout overrides maximum prowjob duration
 	Timeout *prowv1.Duration `json:"timeout,omitempty"`
 
+	// CacheResults allows a passing result of the test to be reused by
+	// later runs with the exact same inputs instead of running the test
+	// again. Results are cached in the test namespace, so they are kept
+	// for as long as the namespace is. Only tests that do not depend on a
+	// cluster can cache their results.
+	CacheResults bool `json:"cache_results,omitempty"`
+
 	// Only one of the following can be not-null.
 	ContainerTestConfiguration                                *ContainerTestConfiguration                                `json:"container,omitempty"`
 	MultiStageTestConfiguration                               *MultiStageTestConfiguration                               `json:"steps,omitempty"`
@@ -756,6 +888,9 @@ type RegistryChain struct {
 	Environment []StepParameter `json:"env,omitempty"`
 	// Leases lists resources that should be acquired for the test.
 	Leases []StepLease `json:"leases,omitempty"`
+	// Parallel defines if the steps of the chain should run concurrently
+	// instead of in the order they are defined.
+	Parallel bool `json:"parallel,omitempty"`
 }
 
 // RegistryWorkflowConfig is the struct that workflow references are unmarshalled into.
@@ -871,6 +1006,82 @@ type LiteralTestStep struct {
 	// RunAsScript defines if this step should be executed as a script mounted
 	// in the test container instead of being executed directly via bash
 	RunAsScript *bool `json:"run_as_script,omitempty"`
+	// Retry defines if and how this step should be retried when it fails
+	// for a reason which is known to be transient.
+	Retry *StepRetryPolicy `json:"retry,omitempty"`
+	// When defines conditions which must all be met for this step to run.
+	// Steps whose conditions are not met are skipped.
+	When *StepCondition `json:"when,omitempty"`
+	// ParallelGroup names a group of consecutive steps which are run
+	// concurrently. Steps of a parallel chain are put into a group named
+	// after the chain.
+	ParallelGroup string `json:"parallel_group,omitempty"`
+	// Produces lists the files this step writes to the shared directory
+	// for later steps to use.
+	Produces []string `json:"produces,omitempty"`
+	// Consumes lists the files in the shared directory this step needs,
+	// which a step running before it must produce. The kubeconfig and
+	// kubeadmin-password files may also be provided by ci-operator, as for
+	// tests which claim a cluster.
+	Consumes []string `json:"consumes,omitempty"`
+}
+
+// StepCondition defines when a step is executed. All conditions that are set
+// must be met for the step to run.
+type StepCondition struct {
+	// Env lists parameters which must have the given values.
+	Env []StepEnvCondition `json:"env,omitempty"`
+	// SharedDirFiles lists files which must exist in the shared directory.
+	SharedDirFiles []string `json:"shared_dir_files,omitempty"`
+	// Steps lists previous steps which must have finished with the given
+	// results.
+	Steps []StepResultCondition `json:"steps,omitempty"`
+}
+
+// StepEnvCondition requires a parameter to have a specific value.
+type StepEnvCondition struct {
+	// Name of the parameter.
+	Name string `json:"name"`
+	// Equals is the value the parameter must have.
+	Equals string `json:"equals"`
+}
+
+// StepResultCondition requires a previous step to have a specific result.
+type StepResultCondition struct {
+	// Name of the previous step.
+	Name string `json:"name"`
+	// Result the previous step must have finished with.
+	Result StepResult `json:"result"`
+}
+
+// StepResult is the outcome of a step in a multi-stage test.
+type StepResult string
+
+const (
+	// StepResultSucceeded means the step ran and succeeded.
+	StepResultSucceeded StepResult = "succeeded"
+	// StepResultFailed means the step ran and failed.
+	StepResultFailed StepResult = "failed"
+	// StepResultSkipped means the step did not run because its conditions
+	// were not met.
+	StepResultSkipped StepResult = "skipped"
+)
+
+// StepRetryPolicy configures how a failed step is retried. When neither
+// ExitCodes nor LogPatterns are set, any failure is considered retryable.
+type StepRetryPolicy struct {
+	// Attempts is the maximum number of times the step will be executed,
+	// including the first execution.
+	Attempts int `json:"attempts"`
+	// Backoff is how long we will wait before starting the next attempt.
+	Backoff *prowv1.Duration `json:"backoff,omitempty"`
+	// ExitCodes lists the exit codes of the test container for which the
+	// failure is considered retryable.
+	ExitCodes []int32 `json:"exit_codes,omitempty"`
+	// LogPatterns lists regular expressions which make the failure retryable
+	// when they match the termination message of the test container, which
+	// contains the tail of its logs.
+	LogPatterns []string `json:"log_patterns,omitempty"`
 }
 
 // StepParameter is a variable set by the test, with an optional default.
@@ -881,8 +1092,29 @@ type StepParameter struct {
 	Default *string `json:"default,omitempty"`
 	// Documentation is a textual description of the parameter.
 	Documentation string `json:"documentation,omitempty"`
+	// Type constrains the values of the parameter: one of `string`,
+	// `bool`, `int`, `enum` and `duration`. Any string is allowed
+	// when not set, `bool` parameters are either `true` or `false`.
+	Type StepParameterType `json:"type,omitempty"`
+	// AllowedValues lists the values the parameter can be set to,
+	// required for `enum` parameters.
+	AllowedValues []string `json:"allowed_values,omitempty"`
+	// Pattern is a regular expression the values of a `string`
+	// parameter must match.
+	Pattern string `json:"pattern,omitempty"`
 }
 
+// StepParameterType is the type of the values of a parameter.
+type StepParameterType string
+
+const (
+	StepParameterTypeString   StepParameterType = "string"
+	StepParameterTypeBool     StepParameterType = "bool"
+	StepParameterTypeInt      StepParameterType = "int"
+	StepParameterTypeEnum     StepParameterType = "enum"
+	StepParameterTypeDuration StepParameterType = "duration"
+)
+
 // CredentialReference defines a secret to mount into a step and where to mount it.
 type CredentialReference struct {
 	// Namespace is where the source secret exists.
@@ -1606,12 +1838,34 @@ type ProjectDirectoryImageBuildStepConfiguration struct {
 	// promoted unless explicitly targeted. Use for builds which
 	// are invoked only when testing certain parts of the repo.
 	Optional bool `json:"optional,omitempty"`
+
+	// Architectures are the architectures the image is built for. The
+	// image is built once per architecture, on nodes of that architecture,
+	// and `to` becomes a manifest list of the resulting images. When not
+	// set, the image is built once, for the architecture of the node the
+	// build runs on. Images in the pipeline only exist for one architecture,
+	// so images built for several cannot use `from` or `inputs`: the base
+	// image is named in the Dockerfile and pulled for each architecture.
+	Architectures []ReleaseArchitecture `json:"architectures,omitempty"`
+
+	// UseBuildCache enables the reuse of the layers built for this image
+	// by earlier jobs, as long as the base image and the instructions of
+	// the Dockerfile leading up to a layer did not change. The cache is
+	// refreshed by the jobs which promote the image. Layers are only
+	// cached when images are built with the buildah build backend.
+	UseBuildCache bool `json:"use_build_cache,omitempty"`
 }
 
 func (config ProjectDirectoryImageBuildStepConfiguration) TargetName() string {
 	return string(config.To)
 }
 
+// ArchitectureImage is the pipeline image that holds the variant of an image
+// built for one of its architectures.
+func ArchitectureImage(image PipelineImageStreamTagReference, architecture ReleaseArchitecture) PipelineImageStreamTagReference {
+	return PipelineImageStreamTagReference(fmt.Sprintf("%s-%s", image, architecture))
+}
+
 // ProjectDirectoryImageBuildInputs holds inputs for an image build from the repo under test
 type ProjectDirectoryImageBuildInputs struct {
 	// ContextDir is the directory in the project
@@ -1733,6 +1987,10 @@ const (
 	// used to hold built or imported release payload images
 	ReleaseImageStream = "release"
 
+	// ReleaseDiffTarget is the target comparing the initial
+	// and latest release payloads, requested explicitly
+	ReleaseDiffTarget = "[release-diff]"
+
 	ComponentFormatReplacement = "${component}"
 )
 
@@ -165,6 +165,26 @@ func (in *ClusterTestConfiguration) DeepCopy() *ClusterTestConfiguration {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ComponentOverride) DeepCopyInto(out *ComponentOverride) {
+	*out = *in
+	if in.Components != nil {
+		in, out := &in.Components, &out.Components
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ComponentOverride.
+func (in *ComponentOverride) DeepCopy() *ComponentOverride {
+	if in == nil {
+		return nil
+	}
+	out := new(ComponentOverride)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ContainerTestConfiguration) DeepCopyInto(out *ContainerTestConfiguration) {
 	*out = *in
@@ -521,6 +541,26 @@ func (in *LiteralTestStep) DeepCopyInto(out *LiteralTestStep) {
 		*out = new(bool)
 		**out = **in
 	}
+	if in.Retry != nil {
+		in, out := &in.Retry, &out.Retry
+		*out = new(StepRetryPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.When != nil {
+		in, out := &in.When, &out.When
+		*out = new(StepCondition)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Produces != nil {
+		in, out := &in.Produces, &out.Produces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Consumes != nil {
+		in, out := &in.Consumes, &out.Consumes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LiteralTestStep.
@@ -1099,6 +1139,11 @@ func (in *ProjectDirectoryImageBuildInputs) DeepCopy() *ProjectDirectoryImageBui
 func (in *ProjectDirectoryImageBuildStepConfiguration) DeepCopyInto(out *ProjectDirectoryImageBuildStepConfiguration) {
 	*out = *in
 	in.ProjectDirectoryImageBuildInputs.DeepCopyInto(&out.ProjectDirectoryImageBuildInputs)
+	if in.Architectures != nil {
+		in, out := &in.Architectures, &out.Architectures
+		*out = make([]ReleaseArchitecture, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProjectDirectoryImageBuildStepConfiguration.
@@ -1126,6 +1171,11 @@ func (in *PromotionConfiguration) DeepCopyInto(out *PromotionConfiguration) {
 			(*out)[key] = val
 		}
 	}
+	if in.Targets != nil {
+		in, out := &in.Targets, &out.Targets
+		*out = make([]PromotionTarget, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PromotionConfiguration.
@@ -1138,6 +1188,51 @@ func (in *PromotionConfiguration) DeepCopy() *PromotionConfiguration {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PromotionCredentials) DeepCopyInto(out *PromotionCredentials) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PromotionCredentials.
+func (in *PromotionCredentials) DeepCopy() *PromotionCredentials {
+	if in == nil {
+		return nil
+	}
+	out := new(PromotionCredentials)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PromotionTarget) DeepCopyInto(out *PromotionTarget) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PromotionTarget.
+func (in *PromotionTarget) DeepCopy() *PromotionTarget {
+	if in == nil {
+		return nil
+	}
+	out := new(PromotionTarget)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PromotionTargetImage) DeepCopyInto(out *PromotionTargetImage) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PromotionTargetImage.
+func (in *PromotionTargetImage) DeepCopy() *PromotionTargetImage {
+	if in == nil {
+		return nil
+	}
+	out := new(PromotionTargetImage)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PullSpecSubstitution) DeepCopyInto(out *PullSpecSubstitution) {
 	*out = *in
@@ -1404,6 +1499,64 @@ func (in *ReleaseBuildConfiguration) DeepCopy() *ReleaseBuildConfiguration {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReleaseComposition) DeepCopyInto(out *ReleaseComposition) {
+	*out = *in
+	in.Base.DeepCopyInto(&out.Base)
+	if in.Overrides != nil {
+		in, out := &in.Overrides, &out.Overrides
+		*out = make([]ComponentOverride, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReleaseComposition.
+func (in *ReleaseComposition) DeepCopy() *ReleaseComposition {
+	if in == nil {
+		return nil
+	}
+	out := new(ReleaseComposition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReleaseCompositionBase) DeepCopyInto(out *ReleaseCompositionBase) {
+	*out = *in
+	if in.Integration != nil {
+		in, out := &in.Integration, &out.Integration
+		*out = new(Integration)
+		**out = **in
+	}
+	if in.Candidate != nil {
+		in, out := &in.Candidate, &out.Candidate
+		*out = new(Candidate)
+		**out = **in
+	}
+	if in.Prerelease != nil {
+		in, out := &in.Prerelease, &out.Prerelease
+		*out = new(Prerelease)
+		**out = **in
+	}
+	if in.Release != nil {
+		in, out := &in.Release, &out.Release
+		*out = new(Release)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReleaseCompositionBase.
+func (in *ReleaseCompositionBase) DeepCopy() *ReleaseCompositionBase {
+	if in == nil {
+		return nil
+	}
+	out := new(ReleaseCompositionBase)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ReleaseConfiguration) DeepCopyInto(out *ReleaseConfiguration) {
 	*out = *in
@@ -1537,6 +1690,36 @@ func (in *SourceStepConfiguration) DeepCopy() *SourceStepConfiguration {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StepCondition) DeepCopyInto(out *StepCondition) {
+	*out = *in
+	if in.Env != nil {
+		in, out := &in.Env, &out.Env
+		*out = make([]StepEnvCondition, len(*in))
+		copy(*out, *in)
+	}
+	if in.SharedDirFiles != nil {
+		in, out := &in.SharedDirFiles, &out.SharedDirFiles
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Steps != nil {
+		in, out := &in.Steps, &out.Steps
+		*out = make([]StepResultCondition, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StepCondition.
+func (in *StepCondition) DeepCopy() *StepCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(StepCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *StepConfiguration) DeepCopyInto(out *StepConfiguration) {
 	*out = *in
@@ -1657,6 +1840,21 @@ func (in *StepDependency) DeepCopy() *StepDependency {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StepEnvCondition) DeepCopyInto(out *StepEnvCondition) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StepEnvCondition.
+func (in *StepEnvCondition) DeepCopy() *StepEnvCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(StepEnvCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *StepLease) DeepCopyInto(out *StepLease) {
 	*out = *in
@@ -1695,6 +1893,11 @@ func (in *StepParameter) DeepCopyInto(out *StepParameter) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.AllowedValues != nil {
+		in, out := &in.AllowedValues, &out.AllowedValues
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StepParameter.
@@ -1707,6 +1910,51 @@ func (in *StepParameter) DeepCopy() *StepParameter {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StepResultCondition) DeepCopyInto(out *StepResultCondition) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StepResultCondition.
+func (in *StepResultCondition) DeepCopy() *StepResultCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(StepResultCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StepRetryPolicy) DeepCopyInto(out *StepRetryPolicy) {
+	*out = *in
+	if in.Backoff != nil {
+		in, out := &in.Backoff, &out.Backoff
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.ExitCodes != nil {
+		in, out := &in.ExitCodes, &out.ExitCodes
+		*out = make([]int32, len(*in))
+		copy(*out, *in)
+	}
+	if in.LogPatterns != nil {
+		in, out := &in.LogPatterns, &out.LogPatterns
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StepRetryPolicy.
+func (in *StepRetryPolicy) DeepCopy() *StepRetryPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(StepRetryPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in TestDependencies) DeepCopyInto(out *TestDependencies) {
 	{
@@ -1903,6 +2151,11 @@ func (in *UnresolvedRelease) DeepCopyInto(out *UnresolvedRelease) {
 		*out = new(Release)
 		**out = **in
 	}
+	if in.Composition != nil {
+		in, out := &in.Composition, &out.Composition
+		*out = new(ReleaseComposition)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UnresolvedRelease.
@@ -6,7 +6,6 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
-	"net/http"
 	"strings"
 	"time"
 
@@ -34,10 +33,8 @@ import (
 	"github.com/openshift/ci-tools/pkg/api"
 	testimagestreamtagimportv1 "github.com/openshift/ci-tools/pkg/api/testimagestreamtagimport/v1"
 	"github.com/openshift/ci-tools/pkg/lease"
-	"github.com/openshift/ci-tools/pkg/release"
-	"github.com/openshift/ci-tools/pkg/release/candidate"
 	"github.com/openshift/ci-tools/pkg/release/official"
-	"github.com/openshift/ci-tools/pkg/release/prerelease"
+	"github.com/openshift/ci-tools/pkg/release/resolver"
 	"github.com/openshift/ci-tools/pkg/results"
 	"github.com/openshift/ci-tools/pkg/secrets"
 	"github.com/openshift/ci-tools/pkg/steps"
@@ -67,10 +64,12 @@ func FromConfig(
 	leaseClient *lease.Client,
 	requiredTargets []string,
 	cloneAuthConfig *steps.CloneAuthConfig,
-	pullSecret, pushSecret *coreapi.Secret,
+	pullSecret, pushSecret, signingSecret *coreapi.Secret,
 	censor *secrets.DynamicCensor,
 	hiveKubeconfig *rest.Config,
 	consoleHost string,
+	buildBackend steps.BuildBackend,
+	releaseResolver resolver.Resolver,
 ) ([]api.Step, []api.Step, error) {
 	crclient, err := ctrlruntimeclient.NewWithWatch(clusterConfig, ctrlruntimeclient.Options{})
 	crclient = secretrecordingclient.Wrap(crclient, censor)
@@ -78,12 +77,6 @@ func FromConfig(
 		return nil, nil, fmt.Errorf("failed to construct client: %w", err)
 	}
 	client := loggingclient.New(crclient)
-	buildGetter, err := buildclientset.NewForConfig(clusterConfig)
-	if err != nil {
-		return nil, nil, fmt.Errorf("could not get build client for cluster config: %w", err)
-	}
-	buildClient := steps.NewBuildClient(client, buildGetter.RESTClient())
-
 	templateGetter, err := templateclientset.NewForConfig(clusterConfig)
 	if err != nil {
 		return nil, nil, fmt.Errorf("could not get template client for cluster config: %w", err)
@@ -97,6 +90,22 @@ func FromConfig(
 
 	podClient := steps.NewPodClient(client, clusterConfig, coreGetter.RESTClient())
 
+	manifestListPusher := steps.NewManifestListPusher(steps.BuilderTokenSource(coreGetter, jobSpec.Namespace))
+	var buildClient steps.BuildClient
+	switch buildBackend {
+	case steps.BuildBackendBuildah:
+		if err := validateBuildahBuilds(graphConf); err != nil {
+			return nil, nil, err
+		}
+		buildClient = steps.NewPodBuildClient(podClient, manifestListPusher)
+	default:
+		buildGetter, err := buildclientset.NewForConfig(clusterConfig)
+		if err != nil {
+			return nil, nil, fmt.Errorf("could not get build client for cluster config: %w", err)
+		}
+		buildClient = steps.NewBuildClient(client, buildGetter.RESTClient(), manifestListPusher)
+	}
+
 	var hiveClient ctrlruntimeclient.WithWatch
 	if hiveKubeconfig != nil {
 		hiveClient, err = ctrlruntimeclient.NewWithWatch(hiveKubeconfig, ctrlruntimeclient.Options{})
@@ -105,7 +114,19 @@ func FromConfig(
 		}
 	}
 
-	return fromConfig(ctx, config, graphConf, jobSpec, templates, paramFile, promote, client, buildClient, templateClient, podClient, leaseClient, hiveClient, &http.Client{}, requiredTargets, cloneAuthConfig, pullSecret, pushSecret, api.NewDeferredParameters(nil), censor, consoleHost)
+	return fromConfig(ctx, config, graphConf, jobSpec, templates, paramFile, promote, client, buildClient, templateClient, podClient, leaseClient, hiveClient, releaseResolver, requiredTargets, cloneAuthConfig, pullSecret, pushSecret, signingSecret, api.NewDeferredParameters(nil), censor, consoleHost)
+}
+
+// validateBuildahBuilds rejects the builds the buildah backend cannot run:
+// it only assembles the context directory from images and secrets and does
+// not clone the repository like builds with a git source.
+func validateBuildahBuilds(graphConf *api.GraphConfiguration) error {
+	for _, step := range graphConf.Steps {
+		if step.ProjectDirectoryImageBuildInputs != nil {
+			return fmt.Errorf("the %s build backend cannot build the root image from the repository, use build_root.image_stream_tag or build_root.from_repository instead of build_root.project_image", steps.BuildBackendBuildah)
+		}
+	}
+	return nil
 }
 
 func fromConfig(
@@ -122,10 +143,10 @@ func fromConfig(
 	podClient steps.PodClient,
 	leaseClient *lease.Client,
 	hiveClient ctrlruntimeclient.WithWatch,
-	httpClient release.HTTPClient,
+	releaseResolver resolver.Resolver,
 	requiredTargets []string,
 	cloneAuthConfig *steps.CloneAuthConfig,
-	pullSecret, pushSecret *coreapi.Secret,
+	pullSecret, pushSecret, signingSecret *coreapi.Secret,
 	params *api.DeferredParameters,
 	censor *secrets.DynamicCensor,
 	consoleHost string,
@@ -142,6 +163,8 @@ func fromConfig(
 	var overridableSteps, buildSteps, postSteps []api.Step
 	var imageStepLinks []api.StepLink
 	var hasReleaseStep bool
+	// releases are the names of the release payloads the job imports or assembles
+	releases := sets.NewString()
 	resolver := rootImageResolver(client, ctx, promote)
 	imageConfigs := graphConf.InputImages()
 	rawSteps, err := runtimeStepConfigsForBuild(ctx, client, config, jobSpec, ioutil.ReadFile, resolver, imageConfigs, time.Second, consoleHost)
@@ -149,6 +172,9 @@ func fromConfig(
 		return nil, nil, fmt.Errorf("failed to get steps from configuration: %w", err)
 	}
 	rawSteps = append(graphConf.Steps, rawSteps...)
+	// promotedTags maps the images to the tags they are promoted to; the names
+	// of the promoted tags are not needed here, and finding them cannot fail
+	promotedTags, _ := releasesteps.PromotedTagsWithRequiredImages(config, requiredNames)
 	for _, rawStep := range rawSteps {
 		if testStep := rawStep.TestStepConfiguration; testStep != nil {
 			steps, testHasReleaseStep, err := stepForTest(ctx, config, params, podClient, leaseClient, templateClient, client, hiveClient, jobSpec, inputImages, testStep, &imageConfigs, pullSecret, censor)
@@ -170,19 +196,9 @@ func fromConfig(
 			// this is a disgusting hack but the simplest implementation until we
 			// factor release steps into something more reusable
 			hasReleaseStep = true
+			releases.Insert(resolveConfig.Name)
 			var value string
-			var overrideCLIReleaseExtractImage *coreapi.ObjectReference
-			var overrideCLIResolveErr error
-			switch {
-			case resolveConfig.Integration != nil:
-				overrideCLIReleaseExtractImage, overrideCLIResolveErr = resolveCLIOverrideImage(api.ReleaseArchitectureAMD64, resolveConfig.Integration.Name)
-			case resolveConfig.Candidate != nil:
-				overrideCLIReleaseExtractImage, overrideCLIResolveErr = resolveCLIOverrideImage(resolveConfig.Candidate.Architecture, resolveConfig.Candidate.Version)
-			case resolveConfig.Release != nil:
-				overrideCLIReleaseExtractImage, overrideCLIResolveErr = resolveCLIOverrideImage(resolveConfig.Release.Architecture, resolveConfig.Release.Version)
-			case resolveConfig.Prerelease != nil:
-				overrideCLIReleaseExtractImage, overrideCLIResolveErr = resolveCLIOverrideImage(resolveConfig.Prerelease.Architecture, resolveConfig.Prerelease.VersionBounds.Lower)
-			}
+			overrideCLIReleaseExtractImage, overrideCLIResolveErr := cliOverrideImageFor(resolveConfig.UnresolvedRelease)
 			if overrideCLIResolveErr != nil {
 				return nil, nil, results.ForReason("resolving_cli_override").ForError(fmt.Errorf("failed to resolve override CLI image for release %s: %w", resolveConfig.Name, overrideCLIResolveErr))
 			}
@@ -208,12 +224,30 @@ func fromConfig(
 						addProvidesForStep(s, params)
 					}
 					imageStepLinks = append(imageStepLinks, snapshot.Creates()...)
-				case resolveConfig.Candidate != nil:
-					value, err = candidate.ResolvePullSpec(httpClient, *resolveConfig.Candidate)
-				case resolveConfig.Release != nil:
-					value, _, err = official.ResolvePullSpecAndVersion(httpClient, *resolveConfig.Release)
-				case resolveConfig.Prerelease != nil:
-					value, err = prerelease.ResolvePullSpec(httpClient, *resolveConfig.Prerelease)
+				case resolveConfig.Composition != nil:
+					composition := resolveConfig.Composition
+					baseName := api.CompositionBaseName(resolveConfig.Name)
+					logrus.Infof("Composing release %s from release %s with %d overrides", resolveConfig.Name, baseName, len(composition.Overrides))
+					var base api.Step
+					if composition.Base.Integration != nil {
+						base = releasesteps.ReleaseSnapshotStep(baseName, *composition.Base.Integration, podClient, jobSpec)
+					} else {
+						basePullSpec, err := releaseResolver.Resolve(composition.Base.UnresolvedRelease())
+						if err != nil {
+							return nil, nil, results.ForReason("resolving_release").ForError(fmt.Errorf("failed to resolve release %s: %w", baseName, err))
+						}
+						logrus.Infof("Resolved release %s to %s", baseName, basePullSpec)
+						base = releasesteps.ImportReleaseStep(baseName, api.ReleaseConfiguration{Name: baseName}.TargetName(), basePullSpec, false, config.Resources, podClient, jobSpec, pullSecret, overrideCLIReleaseExtractImage)
+					}
+					compose := releasesteps.ReleaseCompositionStep(resolveConfig.Name, composition.Overrides, podClient, jobSpec)
+					assemble := releasesteps.AssembleReleaseStep(resolveConfig.Name, &api.ReleaseTagConfiguration{}, config.Resources, podClient, jobSpec)
+					for _, s := range []api.Step{base, compose, assemble} {
+						buildSteps = append(buildSteps, s)
+						addProvidesForStep(s, params)
+					}
+					imageStepLinks = append(imageStepLinks, compose.Creates()...)
+				default:
+					value, err = releaseResolver.Resolve(resolveConfig.UnresolvedRelease)
 				}
 				if err != nil {
 					return nil, nil, results.ForReason("resolving_release").ForError(fmt.Errorf("failed to resolve release %s: %w", resolveConfig.Name, err))
@@ -246,7 +280,12 @@ func fromConfig(
 		} else if rawStep.IndexGeneratorStepConfiguration != nil {
 			step = steps.IndexGeneratorStep(*rawStep.IndexGeneratorStepConfiguration, config, config.Resources, buildClient, jobSpec, pullSecret)
 		} else if rawStep.ProjectDirectoryImageBuildStepConfiguration != nil {
-			step = steps.ProjectDirectoryImageBuildStep(*rawStep.ProjectDirectoryImageBuildStepConfiguration, config, config.Resources, buildClient, jobSpec, pullSecret)
+			var layerCacheSecret *coreapi.Secret
+			if _, promoted := promotedTags[string(rawStep.ProjectDirectoryImageBuildStepConfiguration.To)]; promote && promoted {
+				// the jobs which promote the image refresh its layer cache
+				layerCacheSecret = pushSecret
+			}
+			step = steps.ProjectDirectoryImageBuildStep(*rawStep.ProjectDirectoryImageBuildStepConfiguration, config, config.Resources, buildClient, jobSpec, pullSecret, layerCacheSecret)
 		} else if rawStep.ProjectDirectoryImageBuildInputs != nil {
 			step = steps.GitSourceStep(*rawStep.ProjectDirectoryImageBuildInputs, config.Resources, buildClient, jobSpec, cloneAuthConfig, pullSecret)
 		} else if rawStep.RPMImageInjectionStepConfiguration != nil {
@@ -290,6 +329,7 @@ func fromConfig(
 				}
 				overridableSteps = append(overridableSteps, releaseStep)
 				addProvidesForStep(releaseStep, params)
+				releases.Insert(name)
 			}
 		}
 		step, ok := checkForFullyQualifiedStep(step, params)
@@ -335,6 +375,17 @@ func fromConfig(
 		addProvidesForStep(step, params)
 	}
 
+	if requiredNames.Has(api.ReleaseDiffTarget) {
+		// comparing the payloads is only done when asked for, as the job
+		// would otherwise need both of them even when it does not use them
+		if !releases.HasAll(api.InitialReleaseName, api.LatestReleaseName) {
+			return nil, nil, fmt.Errorf("target %s requires the %s and %s releases to be configured", api.ReleaseDiffTarget, api.InitialReleaseName, api.LatestReleaseName)
+		}
+		step := releasesteps.ReleaseDiffStep(api.InitialReleaseName, api.LatestReleaseName, config.Resources, podClient, jobSpec, pullSecret, censor)
+		buildSteps = append(buildSteps, step)
+		addProvidesForStep(step, params)
+	}
+
 	if !hasReleaseStep {
 		step := releasesteps.StableImagesTagStep(client, jobSpec)
 		buildSteps = append(buildSteps, step)
@@ -352,7 +403,7 @@ func fromConfig(
 		if config.PromotionConfiguration == nil {
 			return nil, nil, fmt.Errorf("cannot promote images, no promotion configuration defined")
 		}
-		postSteps = append(postSteps, releasesteps.PromotionStep(config, requiredNames, jobSpec, podClient, pushSecret))
+		postSteps = append(postSteps, releasesteps.PromotionStep(config, requiredNames, jobSpec, podClient, pushSecret, signingSecret))
 	}
 
 	return append(overridableSteps, buildSteps...), postSteps, nil
@@ -405,6 +456,9 @@ func stepForTest(
 			testSteps = append(testSteps, importStep)
 			addProvidesForStep(step, params)
 		}
+		if c.CacheResults {
+			step = steps.CachedStep(step, client, jobSpec)
+		}
 		testSteps = append(testSteps, step)
 		newSteps := stepsForStepImages(client, jobSpec, inputImages, test, imageConfigs)
 		return append(testSteps, newSteps...), hasReleaseStep, nil
@@ -430,6 +484,9 @@ func stepForTest(
 	if c.ClusterClaim != nil {
 		step = steps.ClusterClaimStep(c.As, c.ClusterClaim, hiveClient, client, jobSpec, step, censor)
 	}
+	if c.CacheResults {
+		step = steps.CachedStep(step, client, jobSpec)
+	}
 	return []api.Step{step}, hasReleaseStep, nil
 }
 
@@ -927,6 +984,24 @@ func getClusterPoolPullSpec(ctx context.Context, claim *api.ClusterClaim, hiveCl
 	return clusterImageSet.Spec.ReleaseImage, nil
 }
 
+// cliOverrideImageFor determines the CLI image to extract a release with,
+// if the release is not for amd64
+func cliOverrideImageFor(release api.UnresolvedRelease) (*coreapi.ObjectReference, error) {
+	switch {
+	case release.Integration != nil:
+		return resolveCLIOverrideImage(api.ReleaseArchitectureAMD64, release.Integration.Name)
+	case release.Candidate != nil:
+		return resolveCLIOverrideImage(release.Candidate.Architecture, release.Candidate.Version)
+	case release.Release != nil:
+		return resolveCLIOverrideImage(release.Release.Architecture, release.Release.Version)
+	case release.Prerelease != nil:
+		return resolveCLIOverrideImage(release.Prerelease.Architecture, release.Prerelease.VersionBounds.Lower)
+	case release.Composition != nil:
+		return cliOverrideImageFor(release.Composition.Base.UnresolvedRelease())
+	}
+	return nil, nil
+}
+
 func resolveCLIOverrideImage(architecture api.ReleaseArchitecture, version string) (*coreapi.ObjectReference, error) {
 	if architecture == "" || architecture == api.ReleaseArchitectureAMD64 {
 		return nil, nil
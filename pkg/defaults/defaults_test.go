@@ -28,6 +28,7 @@ import (
 	testimagestreamtagimportv1 "github.com/openshift/ci-tools/pkg/api/testimagestreamtagimport/v1"
 	"github.com/openshift/ci-tools/pkg/lease"
 	"github.com/openshift/ci-tools/pkg/release"
+	"github.com/openshift/ci-tools/pkg/release/resolver"
 	"github.com/openshift/ci-tools/pkg/secrets"
 	"github.com/openshift/ci-tools/pkg/steps"
 	"github.com/openshift/ci-tools/pkg/steps/loggingclient"
@@ -979,7 +980,7 @@ func TestFromConfig(t *testing.T) {
 		},
 	}, {
 		name: "release",
-		tags: []string{"initial", "latest", "release"},
+		tags: []string{"initial", "latest", "release", "composed-base", "composed"},
 	}, {
 		name: "from",
 		tags: []string{"latest"},
@@ -1004,7 +1005,7 @@ func TestFromConfig(t *testing.T) {
 			t.Fatal(err)
 		}
 	}
-	buildClient := steps.NewBuildClient(client, nil)
+	buildClient := steps.NewBuildClient(client, nil, nil)
 	var templateClient steps.TemplateClient
 	podClient := steps.NewPodClient(client, nil, nil)
 
@@ -1041,7 +1042,6 @@ func TestFromConfig(t *testing.T) {
 	hiveClient := fakectrlruntimeclient.NewClientBuilder().WithScheme(scheme).WithObjects(&clusterPool, &imageset).Build()
 
 	var leaseClient *lease.Client
-	var requiredTargets []string
 	var cloneAuthConfig *steps.CloneAuthConfig
 	pullSecret, pushSecret := &coreapi.Secret{}, &coreapi.Secret{}
 	for _, tc := range []struct {
@@ -1050,6 +1050,7 @@ func TestFromConfig(t *testing.T) {
 		refs           *prowapi.Refs
 		paramFiles     string
 		promote        bool
+		targets        []string
 		templates      []*templateapi.Template
 		env            api.Parameters
 		params         map[string]string
@@ -1192,6 +1193,33 @@ func TestFromConfig(t *testing.T) {
 			"RELEASE_IMAGE_INITIAL": "public_docker_image_repository:initial",
 			"RELEASE_IMAGE_LATEST":  "public_docker_image_repository:latest",
 		},
+	}, {
+		name: "tag specification with release diff",
+		config: api.ReleaseBuildConfiguration{
+			InputConfiguration: api.InputConfiguration{
+				ReleaseTagConfiguration: &api.ReleaseTagConfiguration{
+					Name:      "tag_specification",
+					Namespace: ns,
+				},
+			},
+		},
+		targets: []string{"[release-diff]"},
+		expectedSteps: []string{
+			"[release:initial]",
+			"[release:latest]",
+			"[release-inputs]",
+			"[release-diff]",
+			"[images]",
+		},
+		expectedParams: map[string]string{
+			"IMAGE_FORMAT":          "public_docker_image_repository/ns/stable:${component}",
+			"RELEASE_IMAGE_INITIAL": "public_docker_image_repository:initial",
+			"RELEASE_IMAGE_LATEST":  "public_docker_image_repository:latest",
+		},
+	}, {
+		name:        "release diff without releases",
+		targets:     []string{"[release-diff]"},
+		expectedErr: fmt.Errorf("target [release-diff] requires the initial and latest releases to be configured"),
 	}, {
 		name: "tag specification with input",
 		config: api.ReleaseBuildConfiguration{
@@ -1231,6 +1259,25 @@ func TestFromConfig(t *testing.T) {
 		expectedParams: map[string]string{
 			utils.ReleaseImageEnv("release"): "public_docker_image_repository:release",
 		},
+	}, {
+		name: "compose release",
+		config: api.ReleaseBuildConfiguration{
+			InputConfiguration: api.InputConfiguration{
+				Releases: map[string]api.UnresolvedRelease{
+					"composed": {Composition: &api.ReleaseComposition{
+						Base: api.ReleaseCompositionBase{Release: &api.Release{Version: "4.1.0"}},
+						Overrides: []api.ComponentOverride{
+							{Components: []string{"installer"}, PullSpec: "quay.io/team/installer:latest"},
+						},
+					}},
+				},
+			},
+		},
+		expectedSteps: []string{"[release:composed-base]", "[release-composition:composed]", "[release:composed]", "[images]"},
+		expectedParams: map[string]string{
+			utils.ReleaseImageEnv("composed-base"): "public_docker_image_repository:composed-base",
+			utils.ReleaseImageEnv("composed"):      "public_docker_image_repository:composed",
+		},
 	}, {
 		name: "resolve release with input",
 		config: api.ReleaseBuildConfiguration{
@@ -1445,10 +1492,13 @@ func TestFromConfig(t *testing.T) {
 				params.Add(k, func() (string, error) { return v, nil })
 			}
 			graphConf := FromConfigStatic(&tc.config)
-			configSteps, post, err := fromConfig(context.Background(), &tc.config, &graphConf, &jobSpec, tc.templates, tc.paramFiles, tc.promote, client, buildClient, templateClient, podClient, leaseClient, hiveClient, httpClient, requiredTargets, cloneAuthConfig, pullSecret, pushSecret, params, &secrets.DynamicCensor{}, "")
-			if diff := cmp.Diff(tc.expectedErr, err); diff != "" {
+			configSteps, post, err := fromConfig(context.Background(), &tc.config, &graphConf, &jobSpec, tc.templates, tc.paramFiles, tc.promote, client, buildClient, templateClient, podClient, leaseClient, hiveClient, resolver.NewLive(httpClient), tc.targets, cloneAuthConfig, pullSecret, pushSecret, nil, params, &secrets.DynamicCensor{}, "")
+			if diff := cmp.Diff(tc.expectedErr, err, testhelper.EquateErrorMessage); diff != "" {
 				t.Errorf("unexpected error: %v", diff)
 			}
+			if err != nil {
+				return
+			}
 			var stepNames, postNames []string
 
 			for _, s := range configSteps {
@@ -1485,3 +1535,61 @@ func TestFromConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateBuildahBuilds(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		steps       []api.StepConfiguration
+		expectedErr error
+	}{{
+		name: "builds from images",
+		steps: []api.StepConfiguration{
+			{InputImageTagStepConfiguration: &api.InputImageTagStepConfiguration{InputImage: api.InputImage{To: api.PipelineImageStreamTagReferenceRoot}}},
+			{ProjectDirectoryImageBuildStepConfiguration: &api.ProjectDirectoryImageBuildStepConfiguration{To: "image"}},
+		},
+	}, {
+		name: "root image built from the repository",
+		steps: []api.StepConfiguration{
+			{ProjectDirectoryImageBuildInputs: &api.ProjectDirectoryImageBuildInputs{DockerfilePath: "Dockerfile.root"}},
+		},
+		expectedErr: fmt.Errorf("the buildah build backend cannot build the root image from the repository, use build_root.image_stream_tag or build_root.from_repository instead of build_root.project_image"),
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateBuildahBuilds(&api.GraphConfiguration{Steps: tc.steps})
+			if diff := cmp.Diff(tc.expectedErr, err, testhelper.EquateErrorMessage); diff != "" {
+				t.Errorf("unexpected error: %s", diff)
+			}
+		})
+	}
+}
+
+func TestFromConfigLocal(t *testing.T) {
+	config := &api.ReleaseBuildConfiguration{
+		Tests: []api.TestStepConfiguration{{
+			As:                         "unit",
+			ContainerTestConfiguration: &api.ContainerTestConfiguration{From: "src"},
+		}, {
+			As:                                 "claim",
+			ClusterClaim:                       &api.ClusterClaim{Product: api.ReleaseProductOCP, Version: "4.10", Cloud: api.CloudAWS, Owner: "dpp"},
+			MultiStageTestConfigurationLiteral: &api.MultiStageTestConfigurationLiteral{Test: []api.LiteralTestStep{{As: "e2e"}}},
+		}, {
+			As: "profile",
+			MultiStageTestConfigurationLiteral: &api.MultiStageTestConfigurationLiteral{
+				ClusterProfile: api.ClusterProfileAWS,
+				Test:           []api.LiteralTestStep{{As: "e2e"}},
+			},
+		}, {
+			As:                                 "local",
+			MultiStageTestConfigurationLiteral: &api.MultiStageTestConfigurationLiteral{Test: []api.LiteralTestStep{{As: "e2e"}}},
+		}},
+	}
+	jobSpec := &api.JobSpec{}
+	jobSpec.SetNamespace("ns")
+	var names []string
+	for _, step := range fromConfigLocal(config, jobSpec, nil, nil, api.NewDeferredParameters(nil), nil) {
+		names = append(names, step.Name())
+	}
+	if diff := cmp.Diff([]string{"local"}, names); diff != "" {
+		t.Errorf("unexpected steps: %s", diff)
+	}
+}
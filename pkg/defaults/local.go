@@ -0,0 +1,89 @@
+package defaults
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	coreclientset "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/rest"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/lease"
+	"github.com/openshift/ci-tools/pkg/secrets"
+	"github.com/openshift/ci-tools/pkg/steps"
+	"github.com/openshift/ci-tools/pkg/steps/loggingclient"
+	"github.com/openshift/ci-tools/pkg/steps/secretrecordingclient"
+)
+
+// FromConfigLocal generates the execution graph for running tests on a plain
+// Kubernetes cluster. Only multi-stage tests are supported, as everything else
+// requires builds and image streams. Nothing is built or imported: the images
+// used by the test steps are taken from the provided pull specs.
+func FromConfigLocal(
+	config *api.ReleaseBuildConfiguration,
+	jobSpec *api.JobSpec,
+	clusterConfig *rest.Config,
+	leaseClient *lease.Client,
+	images steps.LocalImages,
+	censor *secrets.DynamicCensor,
+) ([]api.Step, error) {
+	crclient, err := ctrlruntimeclient.NewWithWatch(clusterConfig, ctrlruntimeclient.Options{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to construct client: %w", err)
+	}
+	client := loggingclient.New(secretrecordingclient.Wrap(crclient, censor))
+	coreGetter, err := coreclientset.NewForConfig(clusterConfig)
+	if err != nil {
+		return nil, fmt.Errorf("could not get core client for cluster config: %w", err)
+	}
+	podClient := steps.NewPodClient(client, clusterConfig, coreGetter.RESTClient())
+	return fromConfigLocal(config, jobSpec, podClient, leaseClient, api.NewDeferredParameters(nil), images), nil
+}
+
+func fromConfigLocal(
+	config *api.ReleaseBuildConfiguration,
+	jobSpec *api.JobSpec,
+	podClient steps.PodClient,
+	leaseClient *lease.Client,
+	params *api.DeferredParameters,
+	images steps.LocalImages,
+) []api.Step {
+	params.Add("JOB_NAME", func() (string, error) { return jobSpec.Job, nil })
+	params.Add("JOB_NAME_HASH", func() (string, error) { return jobSpec.JobNameHash(), nil })
+	params.Add("JOB_NAME_SAFE", func() (string, error) { return strings.Replace(jobSpec.Job, "_", "-", -1), nil })
+	params.Add("NAMESPACE", func() (string, error) { return jobSpec.Namespace(), nil })
+	var ret []api.Step
+	for i := range config.Tests {
+		test := &config.Tests[i]
+		literal := test.MultiStageTestConfigurationLiteral
+		if literal == nil {
+			logrus.Infof("Skipping test %s: only multi-stage tests can be run locally", test.As)
+			continue
+		}
+		if test.ClusterClaim != nil {
+			logrus.Infof("Skipping test %s: cluster claims are not supported when running locally", test.As)
+			continue
+		}
+		if literal.ClusterProfile != "" {
+			// the secret of the profile and the release the cluster
+			// is installed from are only provided in CI
+			logrus.Infof("Skipping test %s: cluster profiles are not supported when running locally", test.As)
+			continue
+		}
+		testParams := params
+		leases := api.LeasesForTest(literal)
+		if len(leases) != 0 {
+			testParams = api.NewDeferredParameters(params)
+		}
+		step := steps.LocalMultiStageTestStep(*test, config, testParams, podClient, jobSpec, leases, images)
+		if len(leases) != 0 {
+			step = steps.LeaseStep(leaseClient, leases, step, jobSpec.Namespace)
+			addProvidesForStep(step, testParams)
+		}
+		ret = append(ret, step)
+	}
+	return ret
+}
@@ -0,0 +1,71 @@
+package defaults
+
+import (
+	"context"
+
+	coreapi "k8s.io/api/core/v1"
+	kapierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	imagev1 "github.com/openshift/api/image/v1"
+	templateapi "github.com/openshift/api/template/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/release/resolver"
+	"github.com/openshift/ci-tools/pkg/secrets"
+	"github.com/openshift/ci-tools/pkg/steps"
+	"github.com/openshift/ci-tools/pkg/steps/loggingclient"
+)
+
+// FromConfigPlan generates the execution graph like FromConfig does, but
+// without access to a cluster. The steps can be inspected but not run.
+// Image stream tags referenced by the configuration are assumed to exist,
+// except for the build cache, so the build root is never replaced by it.
+func FromConfigPlan(
+	ctx context.Context,
+	config *api.ReleaseBuildConfiguration,
+	graphConf *api.GraphConfiguration,
+	jobSpec *api.JobSpec,
+	templates []*templateapi.Template,
+	paramFile string,
+	promote bool,
+	requiredTargets []string,
+	cloneAuthConfig *steps.CloneAuthConfig,
+	pullSecret, pushSecret, signingSecret *coreapi.Secret,
+	censor *secrets.DynamicCensor,
+	releaseResolver resolver.Resolver,
+) ([]api.Step, []api.Step, error) {
+	client := loggingclient.New(&planClient{
+		WithWatch: fakectrlruntimeclient.NewClientBuilder().Build(),
+		cache:     api.BuildCacheFor(config.Metadata),
+	})
+	buildClient := steps.NewBuildClient(client, nil, nil)
+	templateClient := steps.NewTemplateClient(client, nil)
+	podClient := steps.NewPodClient(client, nil, nil)
+	if promote && pushSecret == nil {
+		// nothing is pushed when planning, so the credentials are not needed
+		pushSecret = &coreapi.Secret{ObjectMeta: metav1.ObjectMeta{Name: api.RegistryPushCredentialsCICentralSecret}}
+	}
+	return fromConfig(ctx, config, graphConf, jobSpec, templates, paramFile, promote, client, buildClient, templateClient, podClient, nil, nil, releaseResolver, requiredTargets, cloneAuthConfig, pullSecret, pushSecret, signingSecret, api.NewDeferredParameters(nil), censor, "")
+}
+
+// planClient answers requests for image stream tags as if they existed,
+// except for the build cache.
+type planClient struct {
+	ctrlruntimeclient.WithWatch
+	cache api.ImageStreamTagReference
+}
+
+func (c *planClient) Get(ctx context.Context, key ctrlruntimeclient.ObjectKey, obj ctrlruntimeclient.Object) error {
+	if _, ok := obj.(*imagev1.ImageStreamTag); !ok {
+		return c.WithWatch.Get(ctx, key, obj)
+	}
+	if key.Namespace == c.cache.Namespace && key.Name == c.cache.Name+":"+c.cache.Tag {
+		return kapierrors.NewNotFound(imagev1.Resource("imagestreamtags"), key.Name)
+	}
+	obj.SetNamespace(key.Namespace)
+	obj.SetName(key.Name)
+	return nil
+}
@@ -0,0 +1,189 @@
+// Package events implements the stream of events ci-operator emits while it
+// executes steps, so that their progress can be followed while the job runs.
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"k8s.io/test-infra/prow/secretutil"
+)
+
+// Type is the kind of event that occurred.
+type Type string
+
+const (
+	StepStarted   Type = "step_started"
+	StepFinished  Type = "step_finished"
+	PodScheduled  Type = "pod_scheduled"
+	ImagePushed   Type = "image_pushed"
+	LeaseAcquired Type = "lease_acquired"
+)
+
+// Event describes progress in the execution of a step.
+type Event struct {
+	Time time.Time `json:"time"`
+	Type Type      `json:"type"`
+	// Step is the name of the step the event occurred in
+	Step string `json:"step,omitempty"`
+	// Failed is set for a step which finished with an error
+	Failed bool `json:"failed,omitempty"`
+	// Reasons are the reasons a step failed with, as reported to the
+	// aggregation server
+	Reasons []string `json:"reasons,omitempty"`
+	// Details holds event-specific data, like the name of a pod or image
+	Details map[string]string `json:"details,omitempty"`
+}
+
+// Recorder receives events.
+type Recorder interface {
+	Record(event Event)
+}
+
+type recorderKey struct{}
+type stepKey struct{}
+
+// WithRecorder returns a context which carries the recorder, so that events
+// can be recorded wherever the context is available.
+func WithRecorder(ctx context.Context, recorder Recorder) context.Context {
+	return context.WithValue(ctx, recorderKey{}, recorder)
+}
+
+// WithStep returns a context attributing the events recorded with it to the
+// step.
+func WithStep(ctx context.Context, step string) context.Context {
+	return context.WithValue(ctx, stepKey{}, step)
+}
+
+// Record sends an event to the recorder carried by the context, if any. The
+// time and step of the event are filled in when not set.
+func Record(ctx context.Context, event Event) {
+	recorder, ok := ctx.Value(recorderKey{}).(Recorder)
+	if !ok {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	if step, ok := ctx.Value(stepKey{}).(string); ok && event.Step == "" {
+		event.Step = step
+	}
+	recorder.Record(event)
+}
+
+// sinkBuffer is how many events can be waiting to be sent to the sink before
+// new ones are dropped, so that a slow sink never blocks execution
+const sinkBuffer = 1000
+
+// StreamRecorder writes events as newline-delimited JSON and optionally sends
+// each of them to an HTTP sink.
+type StreamRecorder struct {
+	lock   sync.Mutex
+	out    io.Writer
+	censor secretutil.Censorer
+
+	sink    string
+	client  *http.Client
+	pending chan []byte
+	done    chan struct{}
+	closed  bool
+}
+
+// NewStreamRecorder creates a recorder writing to `out`. When `sink` is set,
+// events are also POSTed to it, one per request. Close must be called to
+// flush the events still waiting to be sent and to close `out`.
+func NewStreamRecorder(out io.Writer, censor secretutil.Censorer, sink string) *StreamRecorder {
+	r := &StreamRecorder{out: out, censor: censor, sink: sink}
+	if sink != "" {
+		r.client = &http.Client{Timeout: 10 * time.Second}
+		r.pending = make(chan []byte, sinkBuffer)
+		r.done = make(chan struct{})
+		go r.send()
+	}
+	return r
+}
+
+func (r *StreamRecorder) Record(event Event) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		logrus.WithError(err).Debug("Could not marshal event.")
+		return
+	}
+	r.censor.Censor(&data)
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.closed {
+		return
+	}
+	if _, err := r.out.Write(append(data, '\n')); err != nil {
+		logrus.WithError(err).Debug("Could not write event.")
+	}
+	if r.pending == nil {
+		return
+	}
+	select {
+	case r.pending <- data:
+	default:
+		logrus.Debugf("Dropping %s event, too many are waiting to be sent.", event.Type)
+	}
+}
+
+func (r *StreamRecorder) send() {
+	defer close(r.done)
+	for data := range r.pending {
+		if err := r.post(data); err != nil {
+			logrus.WithError(err).Debug("Could not send event.")
+		}
+	}
+}
+
+func (r *StreamRecorder) post(data []byte) error {
+	resp, err := r.client.Post(r.sink, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("sink responded with %d: %s", resp.StatusCode, string(body))
+	}
+	return nil
+}
+
+// Close waits for the events still pending to be sent to the sink, then
+// syncs and closes the output if it is a file. Events recorded afterwards
+// are dropped.
+func (r *StreamRecorder) Close() {
+	r.lock.Lock()
+	if r.closed {
+		r.lock.Unlock()
+		return
+	}
+	r.closed = true
+	if r.pending != nil {
+		close(r.pending)
+	}
+	r.lock.Unlock()
+	if r.done != nil {
+		<-r.done
+	}
+	if f, ok := r.out.(interface{ Sync() error }); ok {
+		if err := f.Sync(); err != nil {
+			logrus.WithError(err).Debug("Could not sync events.")
+		}
+	}
+	if f, ok := r.out.(io.Closer); ok {
+		if err := f.Close(); err != nil {
+			logrus.WithError(err).Debug("Could not close events.")
+		}
+	}
+}
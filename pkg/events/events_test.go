@@ -0,0 +1,96 @@
+package events
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"k8s.io/test-infra/prow/secretutil"
+)
+
+type fakeRecorder struct {
+	events []Event
+}
+
+func (r *fakeRecorder) Record(event Event) {
+	r.events = append(r.events, event)
+}
+
+func TestRecord(t *testing.T) {
+	// no recorder, nothing happens
+	Record(context.Background(), Event{Type: StepStarted})
+
+	recorder := &fakeRecorder{}
+	ctx := WithStep(WithRecorder(context.Background(), recorder), "step")
+	Record(ctx, Event{Type: PodScheduled, Details: map[string]string{"pod": "pod"}})
+	Record(ctx, Event{Type: ImagePushed, Step: "other"})
+	if len(recorder.events) != 2 {
+		t.Fatalf("expected two events, got %v", recorder.events)
+	}
+	if recorder.events[0].Time.IsZero() {
+		t.Error("expected the time of the event to be set")
+	}
+	if step := recorder.events[0].Step; step != "step" {
+		t.Errorf("expected the event to be attributed to the step, got %q", step)
+	}
+	if step := recorder.events[1].Step; step != "other" {
+		t.Errorf("expected the step set on the event to be kept, got %q", step)
+	}
+}
+
+func TestStreamRecorder(t *testing.T) {
+	var lock sync.Mutex
+	var received []string
+	sink := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("failed to read event: %v", err)
+		}
+		lock.Lock()
+		received = append(received, string(raw))
+		lock.Unlock()
+	}))
+	defer sink.Close()
+
+	censor := secretutil.NewCensorer()
+	censor.Refresh("secret")
+	out, err := os.Create(filepath.Join(t.TempDir(), "events.json"))
+	if err != nil {
+		t.Fatalf("failed to create output: %v", err)
+	}
+	recorder := NewStreamRecorder(out, censor, sink.URL)
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	recorder.Record(Event{Time: now, Type: StepStarted, Step: "unit"})
+	recorder.Record(Event{Time: now, Type: StepFinished, Step: "unit", Failed: true, Reasons: []string{"executing_test"}, Details: map[string]string{"token": "secret"}})
+	recorder.Close()
+	// recording after the recorder was closed is dropped
+	recorder.Record(Event{Time: now, Type: ImagePushed})
+	if _, err := out.Write([]byte("\n")); err == nil {
+		t.Error("expected the output to be closed")
+	}
+
+	expected := []string{
+		`{"time":"2021-01-01T00:00:00Z","type":"step_started","step":"unit"}`,
+		`{"time":"2021-01-01T00:00:00Z","type":"step_finished","step":"unit","failed":true,"reasons":["executing_test"],"details":{"token":"******"}}`,
+	}
+	if diff := cmp.Diff(expected, received); diff != "" {
+		t.Errorf("unexpected events sent to the sink: %s", diff)
+	}
+	written, err := ioutil.ReadFile(out.Name())
+	if err != nil {
+		t.Fatalf("failed to read output: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(written)), "\n")
+	if diff := cmp.Diff(expected, lines); diff != "" {
+		t.Errorf("unexpected events written: %s", diff)
+	}
+}
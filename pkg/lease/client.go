@@ -32,6 +32,8 @@ var ErrNotFound = boskos.ErrNotFound
 
 type Metrics struct {
 	Free, Leased int
+	// Owners holds the number of resources leased by each owner
+	Owners map[string]int
 }
 
 // Client manages resource leases, acquiring, releasing, and keeping them
@@ -171,5 +173,6 @@ func (c *client) Metrics(rtype string) (Metrics, error) {
 	return Metrics{
 		Free:   metrics.Current[freeState],
 		Leased: metrics.Current[leasedState],
+		Owners: metrics.Owners,
 	}, nil
 }
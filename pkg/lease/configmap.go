@@ -0,0 +1,235 @@
+package lease
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	coreapi "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/boskos/common"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// configMapPollInterval is how often acquisition is retried while no
+	// resource is free
+	configMapPollInterval = 3 * time.Second
+	// configMapStaleLeaseAge is how long a lease can go without a heartbeat
+	// before it is considered abandoned and the resource free again
+	configMapStaleLeaseAge = 10 * time.Minute
+)
+
+// configMapLease is the state of a leased resource, stored as the value of
+// its key in the ConfigMap. Free resources have an empty value.
+type configMapLease struct {
+	Owner      string    `json:"owner"`
+	LastUpdate time.Time `json:"last_update"`
+}
+
+// configMapBoskos implements the Boskos operations on top of ConfigMaps, so
+// that leases can be used without a Boskos server. Each resource type is a
+// ConfigMap named after it, whose keys are the names of the resources.
+type configMapBoskos struct {
+	owner     string
+	namespace string
+	client    ctrlruntimeclient.Client
+	now       func() time.Time
+	poll      time.Duration
+
+	lock sync.Mutex
+	// types records the resource type of each resource we leased
+	types map[string]string
+}
+
+// NewConfigMapClient creates a client that leases resources with the
+// specified owner, keeping the state of the resources in ConfigMaps in the
+// namespace instead of a Boskos server. A resource type is declared by
+// creating a ConfigMap with its name, with one key with an empty value for
+// each resource.
+func NewConfigMapClient(owner, namespace string, client ctrlruntimeclient.Client, retries int, acquireTimeout time.Duration) Client {
+	randId = func() string {
+		return strconv.Itoa(rand.Int())
+	}
+	return newClient(newConfigMapBoskos(owner, namespace, client), retries, acquireTimeout)
+}
+
+func newConfigMapBoskos(owner, namespace string, client ctrlruntimeclient.Client) *configMapBoskos {
+	return &configMapBoskos{
+		owner:     owner,
+		namespace: namespace,
+		client:    client,
+		now:       time.Now,
+		poll:      configMapPollInterval,
+		types:     map[string]string{},
+	}
+}
+
+// isFree determines whether a resource in the ConfigMap can be leased.
+func (c *configMapBoskos) isFree(value string) bool {
+	if value == "" {
+		return true
+	}
+	var l configMapLease
+	if err := json.Unmarshal([]byte(value), &l); err != nil {
+		return false
+	}
+	return c.now().Sub(l.LastUpdate) > configMapStaleLeaseAge
+}
+
+func (c *configMapBoskos) leasedValue() (string, error) {
+	raw, err := json.Marshal(configMapLease{Owner: c.owner, LastUpdate: c.now()})
+	return string(raw), err
+}
+
+// update modifies the resource type's ConfigMap, retrying on conflicts.
+func (c *configMapBoskos) update(rtype string, mutate func(data map[string]string) error) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		cm := &coreapi.ConfigMap{}
+		if err := c.client.Get(context.TODO(), ctrlruntimeclient.ObjectKey{Namespace: c.namespace, Name: rtype}, cm); err != nil {
+			// a missing type will not appear while we wait, unlike a free resource
+			if kerrors.IsNotFound(err) {
+				return fmt.Errorf("unknown resource type %s: no ConfigMap %s/%s", rtype, c.namespace, rtype)
+			}
+			return fmt.Errorf("failed to get resources of type %s: %w", rtype, err)
+		}
+		if cm.Data == nil {
+			cm.Data = map[string]string{}
+		}
+		if err := mutate(cm.Data); err != nil {
+			return err
+		}
+		return c.client.Update(context.TODO(), cm)
+	})
+}
+
+func (c *configMapBoskos) AcquireWaitWithPriority(ctx context.Context, rtype, _, _, _ string) (*common.Resource, error) {
+	for {
+		var name string
+		err := c.update(rtype, func(data map[string]string) error {
+			var names []string
+			for n := range data {
+				names = append(names, n)
+			}
+			sort.Strings(names)
+			for _, n := range names {
+				if !c.isFree(data[n]) {
+					continue
+				}
+				value, err := c.leasedValue()
+				if err != nil {
+					return err
+				}
+				data[n] = value
+				name = n
+				return nil
+			}
+			return ErrNotFound
+		})
+		if err == nil {
+			c.lock.Lock()
+			c.types[name] = rtype
+			c.lock.Unlock()
+			return &common.Resource{Name: name, Type: rtype, State: leasedState, Owner: c.owner}, nil
+		}
+		if err != ErrNotFound {
+			return nil, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(c.poll):
+		}
+	}
+}
+
+// typeOf returns the type of a resource we leased.
+func (c *configMapBoskos) typeOf(name string) (string, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	rtype, ok := c.types[name]
+	if !ok {
+		return "", fmt.Errorf("resource %s was not leased by %s", name, c.owner)
+	}
+	return rtype, nil
+}
+
+// mutateOwned changes the value of a resource, if we still own its lease.
+func (c *configMapBoskos) mutateOwned(name string, value func() (string, error)) error {
+	rtype, err := c.typeOf(name)
+	if err != nil {
+		return err
+	}
+	return c.update(rtype, func(data map[string]string) error {
+		var l configMapLease
+		if err := json.Unmarshal([]byte(data[name]), &l); err != nil || l.Owner != c.owner {
+			return fmt.Errorf("resource %s is no longer leased by %s", name, c.owner)
+		}
+		v, err := value()
+		if err != nil {
+			return err
+		}
+		data[name] = v
+		return nil
+	})
+}
+
+func (c *configMapBoskos) UpdateOne(name, _ string, _ *common.UserData) error {
+	return c.mutateOwned(name, c.leasedValue)
+}
+
+func (c *configMapBoskos) ReleaseOne(name, _ string) error {
+	if err := c.mutateOwned(name, func() (string, error) { return "", nil }); err != nil {
+		return err
+	}
+	c.lock.Lock()
+	delete(c.types, name)
+	c.lock.Unlock()
+	return nil
+}
+
+// ReleaseAll attempts to release every resource we leased, so a failure
+// does not keep the others leased until they are stale.
+func (c *configMapBoskos) ReleaseAll(dest string) error {
+	c.lock.Lock()
+	var names []string
+	for name := range c.types {
+		names = append(names, name)
+	}
+	c.lock.Unlock()
+	sort.Strings(names)
+	var errs []error
+	for _, name := range names {
+		if err := c.ReleaseOne(name, dest); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+func (c *configMapBoskos) Metric(rtype string) (common.Metric, error) {
+	metric := common.NewMetric(rtype)
+	cm := &coreapi.ConfigMap{}
+	if err := c.client.Get(context.TODO(), ctrlruntimeclient.ObjectKey{Namespace: c.namespace, Name: rtype}, cm); err != nil {
+		return metric, fmt.Errorf("failed to get resources of type %s: %w", rtype, err)
+	}
+	for _, value := range cm.Data {
+		if c.isFree(value) {
+			metric.Current[freeState]++
+			continue
+		}
+		metric.Current[leasedState]++
+		var l configMapLease
+		if err := json.Unmarshal([]byte(value), &l); err == nil {
+			metric.Owners[l.Owner]++
+		}
+	}
+	return metric, nil
+}
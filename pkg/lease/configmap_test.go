@@ -0,0 +1,177 @@
+package lease
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	coreapi "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestConfigMapClient(t *testing.T) {
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	stale := `{"owner":"other","last_update":"2020-12-31T23:00:00Z"}`
+	leased := `{"owner":"other","last_update":"2020-12-31T23:59:00Z"}`
+	ours := `{"owner":"owner","last_update":"2021-01-01T00:00:00Z"}`
+	for _, tc := range []struct {
+		name         string
+		data         map[string]string
+		n            uint
+		expected     []string
+		expectedErr  error
+		expectedData map[string]string
+	}{{
+		name:         "free resources are leased in order",
+		data:         map[string]string{"b": "", "a": "", "c": ""},
+		n:            2,
+		expected:     []string{"a", "b"},
+		expectedData: map[string]string{"a": ours, "b": ours, "c": ""},
+	}, {
+		name:         "leased resources are skipped",
+		data:         map[string]string{"a": leased, "b": ""},
+		n:            1,
+		expected:     []string{"b"},
+		expectedData: map[string]string{"a": leased, "b": ours},
+	}, {
+		name:         "stale leases are taken over",
+		data:         map[string]string{"a": stale},
+		n:            1,
+		expected:     []string{"a"},
+		expectedData: map[string]string{"a": ours},
+	}, {
+		name:         "no free resources",
+		data:         map[string]string{"a": leased},
+		n:            1,
+		expectedErr:  ErrNotFound,
+		expectedData: map[string]string{"a": leased},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			kubeClient := fakectrlruntimeclient.NewClientBuilder().WithObjects(&coreapi.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "leases", Name: "rtype"},
+				Data:       tc.data,
+			}).Build()
+			boskos := newConfigMapBoskos("owner", "leases", kubeClient)
+			boskos.now = func() time.Time { return now }
+			boskos.poll = time.Millisecond
+			randId = func() string { return "random" }
+			client := newClient(boskos, 0, 10*time.Millisecond)
+			names, err := client.Acquire("rtype", tc.n, context.Background(), nil)
+			if err != tc.expectedErr {
+				t.Fatalf("expected error %v, got %v", tc.expectedErr, err)
+			}
+			if diff := cmp.Diff(tc.expected, names); diff != "" {
+				t.Errorf("unexpected leases: %s", diff)
+			}
+			cm := &coreapi.ConfigMap{}
+			if err := kubeClient.Get(context.Background(), ctrlruntimeclient.ObjectKey{Namespace: "leases", Name: "rtype"}, cm); err != nil {
+				t.Fatalf("failed to get resources: %v", err)
+			}
+			if diff := cmp.Diff(tc.expectedData, cm.Data); diff != "" {
+				t.Errorf("unexpected resources: %s", diff)
+			}
+			metrics, err := client.Metrics("rtype")
+			if err != nil {
+				t.Fatalf("failed to get metrics: %v", err)
+			}
+			if free, leased := metrics.Free, metrics.Leased; free+leased != len(tc.data) || leased < len(tc.expected) {
+				t.Errorf("unexpected metrics: %+v", metrics)
+			}
+			if err := client.Heartbeat(); err != nil {
+				t.Fatalf("failed to heartbeat: %v", err)
+			}
+			released, err := client.ReleaseAll()
+			if err != nil {
+				t.Fatalf("failed to release: %v", err)
+			}
+			if len(released) != len(tc.expected) {
+				t.Errorf("expected to release %v, released %v", tc.expected, released)
+			}
+			if err := kubeClient.Get(context.Background(), ctrlruntimeclient.ObjectKey{Namespace: "leases", Name: "rtype"}, cm); err != nil {
+				t.Fatalf("failed to get resources: %v", err)
+			}
+			for _, name := range tc.expected {
+				if cm.Data[name] != "" {
+					t.Errorf("expected %s to be free after release, got %q", name, cm.Data[name])
+				}
+			}
+		})
+	}
+}
+
+func TestConfigMapClientLostLease(t *testing.T) {
+	kubeClient := fakectrlruntimeclient.NewClientBuilder().WithObjects(&coreapi.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "leases", Name: "rtype"},
+		Data:       map[string]string{"a": ""},
+	}).Build()
+	boskos := newConfigMapBoskos("owner", "leases", kubeClient)
+	if _, err := boskos.AcquireWaitWithPriority(context.Background(), "rtype", freeState, leasedState, ""); err != nil {
+		t.Fatalf("failed to acquire: %v", err)
+	}
+	cm := &coreapi.ConfigMap{}
+	if err := kubeClient.Get(context.Background(), ctrlruntimeclient.ObjectKey{Namespace: "leases", Name: "rtype"}, cm); err != nil {
+		t.Fatalf("failed to get resources: %v", err)
+	}
+	cm.Data["a"] = `{"owner":"other","last_update":"2021-01-01T00:00:00Z"}`
+	if err := kubeClient.Update(context.Background(), cm); err != nil {
+		t.Fatalf("failed to update resources: %v", err)
+	}
+	if err := boskos.UpdateOne("a", leasedState, nil); err == nil {
+		t.Error("expected an error updating a lease taken over by another owner, got none")
+	}
+}
+
+func TestConfigMapClientReleaseAllAfterLostLease(t *testing.T) {
+	kubeClient := fakectrlruntimeclient.NewClientBuilder().WithObjects(&coreapi.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "leases", Name: "rtype"},
+		Data:       map[string]string{"a": "", "b": ""},
+	}).Build()
+	boskos := newConfigMapBoskos("owner", "leases", kubeClient)
+	for i := 0; i < 2; i++ {
+		if _, err := boskos.AcquireWaitWithPriority(context.Background(), "rtype", freeState, leasedState, ""); err != nil {
+			t.Fatalf("failed to acquire: %v", err)
+		}
+	}
+	cm := &coreapi.ConfigMap{}
+	if err := kubeClient.Get(context.Background(), ctrlruntimeclient.ObjectKey{Namespace: "leases", Name: "rtype"}, cm); err != nil {
+		t.Fatalf("failed to get resources: %v", err)
+	}
+	other := `{"owner":"other","last_update":"2021-01-01T00:00:00Z"}`
+	cm.Data["a"] = other
+	if err := kubeClient.Update(context.Background(), cm); err != nil {
+		t.Fatalf("failed to update resources: %v", err)
+	}
+	err := boskos.ReleaseAll(freeState)
+	if expected := "resource a is no longer leased by owner"; err == nil || err.Error() != expected {
+		t.Errorf("expected error %q, got %v", expected, err)
+	}
+	if err := kubeClient.Get(context.Background(), ctrlruntimeclient.ObjectKey{Namespace: "leases", Name: "rtype"}, cm); err != nil {
+		t.Fatalf("failed to get resources: %v", err)
+	}
+	if diff := cmp.Diff(map[string]string{"a": other, "b": ""}, cm.Data); diff != "" {
+		t.Errorf("expected the other lease to be released: %s", diff)
+	}
+}
+
+func TestConfigMapClientUnknownType(t *testing.T) {
+	kubeClient := fakectrlruntimeclient.NewClientBuilder().Build()
+	client := newClient(newConfigMapBoskos("owner", "leases", kubeClient), 0, time.Hour)
+	done := make(chan error)
+	go func() {
+		_, err := client.Acquire("rtype", 1, context.Background(), nil)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		expected := "unknown resource type rtype: no ConfigMap leases/rtype"
+		if err == nil || err.Error() != expected {
+			t.Errorf("expected error %q, got %v", expected, err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("acquiring a resource of an unknown type did not fail")
+	}
+}
@@ -0,0 +1,260 @@
+package lease
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/yaml"
+)
+
+// ErrQuotaExceeded is returned when resources could not be leased because the
+// identity acquiring them exceeded its quota for as long as it waited.
+var ErrQuotaExceeded = errors.New("lease quota exceeded")
+
+// quotaPollInterval is how often an identity over its quota checks whether
+// it can acquire resources again
+const quotaPollInterval = 10 * time.Second
+
+// Identity describes on whose behalf resources are leased.
+type Identity struct {
+	Org, Repo string
+	// PriorityClass selects the multiplier applied to the weight of the
+	// identity, as configured in the quota configuration
+	PriorityClass string
+}
+
+// Owner returns the owner to use for leases acquired by this identity, which
+// records the identity so that other clients can account for the resources
+// it holds. Organizations cannot contain dots, so the identity is recovered
+// by splitting on the first two.
+func (i Identity) Owner(base string) string {
+	if i.Org == "" {
+		return base
+	}
+	return strings.Join([]string{base, i.Org, i.Repo}, ".")
+}
+
+func (i Identity) String() string {
+	return i.Org + "/" + i.Repo
+}
+
+// identityFromOwner recovers the identity recorded in a lease owner, or
+// returns false if the owner does not record one.
+func identityFromOwner(owner string) (Identity, bool) {
+	parts := strings.SplitN(owner, ".", 3)
+	if len(parts) != 3 {
+		return Identity{}, false
+	}
+	return Identity{Org: parts[1], Repo: parts[2]}, true
+}
+
+// QuotaConfig configures how contended resources are shared between the
+// organizations and repositories that lease them. Identities are either an
+// organization or an org/repo, the latter taking precedence.
+type QuotaConfig struct {
+	// Weights are the relative shares of contended resources each identity
+	// is entitled to. Identities that are not listed have a weight of 1.
+	Weights map[string]int `json:"weights,omitempty"`
+	// PriorityClasses multiply the weight of the identity acquiring leases
+	// with that priority class. Unknown or empty classes multiply by 1.
+	PriorityClasses map[string]int `json:"priority_classes,omitempty"`
+	// Limits cap the number of resources of each type that an identity can
+	// hold at once, regardless of contention.
+	Limits map[string]map[string]int `json:"limits,omitempty"`
+}
+
+// LoadQuotaConfig loads and validates the quota configuration from a file.
+func LoadQuotaConfig(path string) (*QuotaConfig, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lease quota configuration: %w", err)
+	}
+	var config QuotaConfig
+	if err := yaml.UnmarshalStrict(raw, &config); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal lease quota configuration: %w", err)
+	}
+	return &config, config.Validate()
+}
+
+// Validate ensures weights, multipliers and limits are meaningful.
+func (c *QuotaConfig) Validate() error {
+	for identity, weight := range c.Weights {
+		if weight < 1 {
+			return fmt.Errorf("weights.%s: must be positive, got %d", identity, weight)
+		}
+	}
+	for class, multiplier := range c.PriorityClasses {
+		if multiplier < 1 {
+			return fmt.Errorf("priority_classes.%s: must be positive, got %d", class, multiplier)
+		}
+	}
+	for identity, limits := range c.Limits {
+		for rtype, limit := range limits {
+			if limit < 0 {
+				return fmt.Errorf("limits.%s.%s: must not be negative, got %d", identity, rtype, limit)
+			}
+		}
+	}
+	return nil
+}
+
+func (c *QuotaConfig) weight(identity Identity) int {
+	weight := 1
+	if w, ok := c.Weights[identity.Org]; ok {
+		weight = w
+	}
+	if w, ok := c.Weights[identity.String()]; ok {
+		weight = w
+	}
+	if m, ok := c.PriorityClasses[identity.PriorityClass]; ok {
+		weight *= m
+	}
+	return weight
+}
+
+// limit returns the identity whose usage is limited and the limit, if any.
+func (c *QuotaConfig) limit(identity Identity, rtype string) (string, int, bool) {
+	if l, ok := c.Limits[identity.String()][rtype]; ok {
+		return identity.String(), l, true
+	}
+	if l, ok := c.Limits[identity.Org][rtype]; ok {
+		return identity.Org, l, true
+	}
+	return "", 0, false
+}
+
+// admit determines whether the identity can acquire `n` resources given the
+// current state of the resource type. Resources are only withheld when they
+// are contended: when fewer are free than requested, an identity holding at
+// least its weighted share of the resources, among the identities currently
+// holding any, waits so that the resources released go to the others.
+func (c *QuotaConfig) admit(identity Identity, rtype string, n uint, metrics Metrics) (bool, string) {
+	usage := map[string]int{}
+	weights := map[Identity]int{}
+	for owner, count := range metrics.Owners {
+		id, ok := identityFromOwner(owner)
+		if !ok {
+			continue
+		}
+		usage[id.Org] += count
+		usage[id.String()] += count
+		if id != (Identity{Org: identity.Org, Repo: identity.Repo}) {
+			weights[id] = c.weight(id)
+		}
+	}
+	if limited, limit, ok := c.limit(identity, rtype); ok && usage[limited]+int(n) > limit {
+		return false, fmt.Sprintf("%s holds %d of at most %d %s leases", limited, usage[limited], limit, rtype)
+	}
+	if metrics.Free >= int(n) {
+		return true, ""
+	}
+	weight := c.weight(identity)
+	total := weight
+	for _, w := range weights {
+		total += w
+	}
+	share := int(math.Ceil(float64(metrics.Free+metrics.Leased) * float64(weight) / float64(total)))
+	if held := usage[identity.String()]; held+int(n) > share {
+		return false, fmt.Sprintf("%s holds %d %s leases and its fair share is %d", identity, held, rtype, share)
+	}
+	return true, ""
+}
+
+// Acquisition records how long acquiring leases of a resource type took.
+type Acquisition struct {
+	ResourceType string
+	Wait         time.Duration
+	// Denied is set when the acquisition had to wait because of the quota
+	Denied bool
+}
+
+// AcquisitionRecorder is implemented by clients which record acquisitions.
+type AcquisitionRecorder interface {
+	Acquisitions() []Acquisition
+}
+
+// fairShareClient withholds acquisitions that would exceed the quota of the
+// identity leasing resources.
+type fairShareClient struct {
+	Client
+	identity       Identity
+	quota          *QuotaConfig
+	acquireTimeout time.Duration
+	poll           time.Duration
+	now            func() time.Time
+
+	lock         sync.Mutex
+	acquisitions []Acquisition
+}
+
+// NewFairShareClient wraps a client so that acquisitions honor the quota of
+// the identity leasing the resources. The wrapped client must lease resources
+// with the owner returned by the identity.
+func NewFairShareClient(client Client, identity Identity, quota *QuotaConfig, acquireTimeout time.Duration) Client {
+	return &fairShareClient{
+		Client:         client,
+		identity:       identity,
+		quota:          quota,
+		acquireTimeout: acquireTimeout,
+		poll:           quotaPollInterval,
+		now:            time.Now,
+	}
+}
+
+func (c *fairShareClient) Acquire(rtype string, n uint, ctx context.Context, cancel context.CancelFunc) ([]string, error) {
+	start := c.now()
+	// waiting for the quota counts against the same timeout as waiting for
+	// a free resource, the wrapped client cannot extend the deadline
+	ctx, cancelAcquire := context.WithTimeout(ctx, c.acquireTimeout)
+	defer cancelAcquire()
+	denied, err := c.wait(ctx, rtype, n)
+	var names []string
+	if err == nil {
+		names, err = c.Client.Acquire(rtype, n, ctx, cancel)
+	}
+	c.lock.Lock()
+	c.acquisitions = append(c.acquisitions, Acquisition{ResourceType: rtype, Wait: c.now().Sub(start), Denied: denied})
+	c.lock.Unlock()
+	return names, err
+}
+
+// wait blocks until the quota admits the acquisition or the context is
+// done, returning whether it had to wait at all.
+func (c *fairShareClient) wait(ctx context.Context, rtype string, n uint) (bool, error) {
+	var denied bool
+	for {
+		metrics, err := c.Metrics(rtype)
+		if err != nil {
+			// the server will still arbitrate, so do not fail because of the quota
+			logrus.WithError(err).Warnf("Could not determine usage of %s, ignoring the lease quota.", rtype)
+			return denied, nil
+		}
+		ok, reason := c.quota.admit(c.identity, rtype, n, metrics)
+		if ok {
+			return denied, nil
+		}
+		if !denied {
+			logrus.Infof("Waiting to acquire %s leases: %s.", rtype, reason)
+			denied = true
+		}
+		select {
+		case <-ctx.Done():
+			return denied, fmt.Errorf("%w: %s", ErrQuotaExceeded, reason)
+		case <-time.After(c.poll):
+		}
+	}
+}
+
+func (c *fairShareClient) Acquisitions() []Acquisition {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return append([]Acquisition(nil), c.acquisitions...)
+}
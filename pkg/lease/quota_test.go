@@ -0,0 +1,212 @@
+package lease
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"sigs.k8s.io/boskos/common"
+)
+
+func TestIdentityOwner(t *testing.T) {
+	identity := Identity{Org: "org", Repo: "repo.with.dots"}
+	owner := identity.Owner("ci-op-1234-5678")
+	if owner != "ci-op-1234-5678.org.repo.with.dots" {
+		t.Errorf("unexpected owner: %s", owner)
+	}
+	recovered, ok := identityFromOwner(owner)
+	if !ok || recovered != identity {
+		t.Errorf("expected to recover %v from owner, got %v", identity, recovered)
+	}
+	if owner := (Identity{}).Owner("ci-op-1234-5678"); owner != "ci-op-1234-5678" {
+		t.Errorf("expected no identity in the owner, got %s", owner)
+	}
+	if _, ok := identityFromOwner("ci-op-1234-5678"); ok {
+		t.Error("expected no identity to be recovered")
+	}
+}
+
+func TestQuotaConfigAdmit(t *testing.T) {
+	quota := QuotaConfig{
+		Weights:         map[string]int{"big": 3, "other/special": 2},
+		PriorityClasses: map[string]int{"high": 2},
+		Limits:          map[string]map[string]int{"small": {"rtype": 4}, "small/unlimited": {"rtype": 100}},
+	}
+	for _, tc := range []struct {
+		name     string
+		identity Identity
+		n        uint
+		metrics  Metrics
+		expected bool
+	}{{
+		name:     "uncontended resources are admitted over the fair share",
+		identity: Identity{Org: "big", Repo: "repo"},
+		n:        1,
+		metrics:  Metrics{Free: 1, Leased: 9, Owners: map[string]int{"a.big.repo": 8, "b.other.repo": 1}},
+		expected: true,
+	}, {
+		name:     "contended resources are withheld over the fair share",
+		identity: Identity{Org: "other", Repo: "repo"},
+		n:        1,
+		metrics:  Metrics{Leased: 10, Owners: map[string]int{"a.big.repo": 5, "b.other.repo": 5}},
+	}, {
+		name:     "contended resources are admitted under the fair share",
+		identity: Identity{Org: "big", Repo: "repo"},
+		n:        1,
+		metrics:  Metrics{Leased: 10, Owners: map[string]int{"a.big.repo": 5, "b.other.repo": 5}},
+		expected: true,
+	}, {
+		name:     "identity without leases is admitted",
+		identity: Identity{Org: "new", Repo: "repo"},
+		n:        1,
+		metrics:  Metrics{Leased: 10, Owners: map[string]int{"a.big.repo": 10}},
+		expected: true,
+	}, {
+		name:     "priority class increases the fair share",
+		identity: Identity{Org: "other", Repo: "repo", PriorityClass: "high"},
+		n:        1,
+		metrics:  Metrics{Leased: 12, Owners: map[string]int{"a.small.repo": 6, "b.other.repo": 6}},
+		expected: true,
+	}, {
+		name:     "repository weight takes precedence",
+		identity: Identity{Org: "other", Repo: "special"},
+		n:        1,
+		metrics:  Metrics{Leased: 12, Owners: map[string]int{"a.third.repo": 6, "b.other.special": 6}},
+		expected: true,
+	}, {
+		name:     "organization limit applies to all its repositories",
+		identity: Identity{Org: "small", Repo: "repo"},
+		n:        1,
+		metrics:  Metrics{Free: 10, Leased: 4, Owners: map[string]int{"a.small.repo": 2, "b.small.other": 2}},
+	}, {
+		name:     "repository limit takes precedence",
+		identity: Identity{Org: "small", Repo: "unlimited"},
+		n:        1,
+		metrics:  Metrics{Free: 10, Leased: 4, Owners: map[string]int{"a.small.repo": 2, "b.small.other": 2}},
+		expected: true,
+	}, {
+		name:     "owners without identities do not compete for shares",
+		identity: Identity{Org: "other", Repo: "repo"},
+		n:        1,
+		metrics:  Metrics{Leased: 10, Owners: map[string]int{"ci-op-1234-5678": 9, "b.other.repo": 1}},
+		expected: true,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			if ok, reason := quota.admit(tc.identity, "rtype", tc.n, tc.metrics); ok != tc.expected {
+				t.Errorf("expected admission %t, got %t: %s", tc.expected, ok, reason)
+			}
+		})
+	}
+}
+
+func TestQuotaConfigValidate(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		config   QuotaConfig
+		expected string
+	}{{
+		name: "valid",
+		config: QuotaConfig{
+			Weights:         map[string]int{"org": 2},
+			PriorityClasses: map[string]int{"high": 2},
+			Limits:          map[string]map[string]int{"org/repo": {"rtype": 0}},
+		},
+	}, {
+		name:     "zero weight",
+		config:   QuotaConfig{Weights: map[string]int{"org": 0}},
+		expected: "weights.org: must be positive, got 0",
+	}, {
+		name:     "zero multiplier",
+		config:   QuotaConfig{PriorityClasses: map[string]int{"low": 0}},
+		expected: "priority_classes.low: must be positive, got 0",
+	}, {
+		name:     "negative limit",
+		config:   QuotaConfig{Limits: map[string]map[string]int{"org": {"rtype": -1}}},
+		expected: "limits.org.rtype: must not be negative, got -1",
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			var actual string
+			if err := tc.config.Validate(); err != nil {
+				actual = err.Error()
+			}
+			if actual != tc.expected {
+				t.Errorf("expected error %q, got %q", tc.expected, actual)
+			}
+		})
+	}
+}
+
+// metricsClient is a Boskos client reporting fixed metrics
+type metricsClient struct {
+	fakeClient
+	metric common.Metric
+}
+
+func (c *metricsClient) Metric(string) (common.Metric, error) {
+	return c.metric, nil
+}
+
+func TestFairShareClient(t *testing.T) {
+	var calls []string
+	boskos := &metricsClient{
+		fakeClient: fakeClient{owner: "owner", calls: &calls},
+		metric: common.Metric{
+			Current: map[string]int{leasedState: 2},
+			Owners:  map[string]int{"a.org.repo": 1, "b.other.repo": 1},
+		},
+	}
+	randId = func() string { return "random" }
+	identity := Identity{Org: "org", Repo: "repo"}
+	client := NewFairShareClient(newClient(boskos, 0, time.Minute), identity, &QuotaConfig{}, 10*time.Millisecond).(*fairShareClient)
+	client.poll = time.Millisecond
+
+	if _, err := client.Acquire("rtype", 1, context.Background(), nil); !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("expected the quota to be exceeded, got %v", err)
+	}
+	if len(calls) != 0 {
+		t.Errorf("expected no acquisition over the quota, got %v", calls)
+	}
+
+	boskos.metric.Current[freeState] = 1
+	if _, err := client.Acquire("rtype", 1, context.Background(), nil); err != nil {
+		t.Fatalf("failed to acquire: %v", err)
+	}
+	if diff := cmp.Diff([]string{"acquire owner rtype free leased random"}, calls); diff != "" {
+		t.Errorf("unexpected calls: %s", diff)
+	}
+	acquisitions := client.Acquisitions()
+	if len(acquisitions) != 2 || !acquisitions[0].Denied || acquisitions[1].Denied {
+		t.Errorf("unexpected acquisitions: %+v", acquisitions)
+	}
+}
+
+// deadlineClient records the deadline of the last acquisition
+type deadlineClient struct {
+	Client
+	deadline time.Time
+}
+
+func (c *deadlineClient) Acquire(rtype string, n uint, ctx context.Context, cancel context.CancelFunc) ([]string, error) {
+	c.deadline, _ = ctx.Deadline()
+	return c.Client.Acquire(rtype, n, ctx, cancel)
+}
+
+func TestFairShareClientSingleDeadline(t *testing.T) {
+	boskos := &metricsClient{
+		fakeClient: fakeClient{owner: "owner", calls: &[]string{}},
+		metric:     common.Metric{Current: map[string]int{freeState: 1}},
+	}
+	randId = func() string { return "random" }
+	inner := &deadlineClient{Client: newClient(boskos, 0, time.Hour)}
+	client := NewFairShareClient(inner, Identity{Org: "org", Repo: "repo"}, &QuotaConfig{}, time.Minute)
+	start := time.Now()
+	if _, err := client.Acquire("rtype", 1, context.Background(), nil); err != nil {
+		t.Fatalf("failed to acquire: %v", err)
+	}
+	if inner.deadline.IsZero() || inner.deadline.After(time.Now().Add(time.Minute)) || inner.deadline.Before(start.Add(time.Minute)) {
+		t.Errorf("expected the wrapped client to acquire within the deadline of the quota wait, got %v", inner.deadline)
+	}
+}
@@ -20,6 +20,7 @@ import (
 type RegistryAgent interface {
 	ResolveConfig(config api.ReleaseBuildConfiguration) (api.ReleaseBuildConfiguration, error)
 	GetRegistryComponents() (registry.ReferenceByName, registry.ChainByName, registry.WorkflowByName, map[string]string, api.RegistryMetadata)
+	GetRegistryVersions() registry.VersionByName
 	GetGeneration() int
 	registry.Resolver
 }
@@ -28,6 +29,7 @@ type registryAgent struct {
 	lock          *sync.RWMutex
 	resolver      registry.Resolver
 	registryPath  string
+	versionsPath  string
 	generation    int
 	errorMetrics  *prometheus.CounterVec
 	flags         load.RegistryFlag
@@ -36,6 +38,7 @@ type registryAgent struct {
 	workflows     registry.WorkflowByName
 	documentation map[string]string
 	metadata      api.RegistryMetadata
+	versions      registry.VersionByName
 }
 
 var registryReloadTimeMetric = prometheus.NewHistogram(
@@ -57,6 +60,9 @@ type RegistryAgentOptions struct {
 	// FlatRegistry describes if the registry is flat, which means org/repo/branch info can not be inferred
 	// from the filepath. Defaults to true.
 	FlatRegistry *bool
+	// VersionsPath is the snapshot directory holding the historical
+	// versions of the registry components can be pinned to.
+	VersionsPath string
 }
 
 type RegistryAgentOption func(*RegistryAgentOptions)
@@ -73,6 +79,12 @@ func WithRegistryFlat(v bool) RegistryAgentOption {
 	}
 }
 
+func WithRegistryVersions(path string) RegistryAgentOption {
+	return func(o *RegistryAgentOptions) {
+		o.VersionsPath = path
+	}
+}
+
 // NewRegistryAgent returns a RegistryAgent interface that automatically reloads when
 // the registry is changed on disk.
 func NewRegistryAgent(registryPath string, opts ...RegistryAgentOption) (RegistryAgent, error) {
@@ -92,6 +104,7 @@ func NewRegistryAgent(registryPath string, opts ...RegistryAgentOption) (Registr
 	}
 	a := &registryAgent{
 		registryPath: registryPath,
+		versionsPath: opt.VersionsPath,
 		lock:         &sync.RWMutex{},
 		errorMetrics: opt.ErrorMetric,
 		flags:        flags,
@@ -101,7 +114,15 @@ func NewRegistryAgent(registryPath string, opts ...RegistryAgentOption) (Registr
 		return nil, fmt.Errorf("failed to load registry: %w", err)
 	}
 
-	return a, startWatchers(a.registryPath, a.loadRegistry, a.recordError)
+	if err := startWatchers(a.registryPath, a.loadRegistry, a.recordError); err != nil {
+		return nil, err
+	}
+	if a.versionsPath != "" {
+		if err := startWatchers(a.versionsPath, a.loadRegistry, a.recordError); err != nil {
+			return nil, err
+		}
+	}
+	return a, nil
 }
 
 func (a *registryAgent) recordError(label string) {
@@ -126,6 +147,13 @@ func (a *registryAgent) GetRegistryComponents() (registry.ReferenceByName, regis
 	return a.references, a.chains, a.workflows, a.documentation, a.metadata
 }
 
+// GetRegistryVersions returns the historical versions of the registry
+func (a *registryAgent) GetRegistryVersions() registry.VersionByName {
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+	return a.versions
+}
+
 func (a *registryAgent) loadRegistry() error {
 	logrus.Debug("Reloading registry")
 	duration, err := func() (time.Duration, error) {
@@ -137,12 +165,20 @@ func (a *registryAgent) loadRegistry() error {
 			a.recordError("failed to load ci-operator registry")
 			return time.Duration(0), fmt.Errorf("failed to load ci-operator registry (%w)", err)
 		}
+		var versions registry.VersionByName
+		if a.versionsPath != "" {
+			if versions, err = load.RegistryVersions(a.versionsPath, a.flags); err != nil {
+				a.recordError("failed to load ci-operator registry versions")
+				return time.Duration(0), fmt.Errorf("failed to load ci-operator registry versions (%w)", err)
+			}
+		}
 		a.references = references
 		a.chains = chains
 		a.workflows = workflows
 		a.documentation = documentation
 		a.metadata = metadata
-		a.resolver = registry.NewResolver(references, chains, workflows, observers)
+		a.versions = versions
+		a.resolver = registry.NewVersionedResolver(references, chains, workflows, observers, versions)
 		a.generation++
 		return time.Since(startTime), nil
 	}()
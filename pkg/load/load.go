@@ -96,7 +96,7 @@ func fromPath(path string) (filenameToConfig, error) {
 		errGroup.Go(func() error {
 			ext := filepath.Ext(path)
 			if !info.IsDir() && (ext == ".yml" || ext == ".yaml") {
-				configSpec, err := Config(path, "", "", nil, nil)
+				configSpec, err := Config(path, "", "", "", nil, nil)
 				if err != nil {
 					return fmt.Errorf("failed to load ci-operator config (%w)", err)
 				}
@@ -117,7 +117,7 @@ func fromPath(path string) (filenameToConfig, error) {
 	return configs, utilerrors.NewAggregate([]error{err, errGroup.Wait()})
 }
 
-func Config(path, unresolvedPath, registryPath string, resolver server.ResolverClient, info *api.Metadata) (*api.ReleaseBuildConfiguration, error) {
+func Config(path, unresolvedPath, registryPath, registryVersionsPath string, resolver server.ResolverClient, info *api.Metadata) (*api.ReleaseBuildConfiguration, error) {
 	// Load the standard configuration path, env, or configresolver (in that order of priority)
 	var raw string
 
@@ -174,7 +174,13 @@ func Config(path, unresolvedPath, registryPath string, resolver server.ResolverC
 		if err != nil {
 			return nil, fmt.Errorf("failed to load registry: %w", err)
 		}
-		configSpec, err = registry.ResolveConfig(registry.NewResolver(refs, chains, workflows, observers), configSpec)
+		var versions registry.VersionByName
+		if registryVersionsPath != "" {
+			if versions, err = RegistryVersions(registryVersionsPath, RegistryFlag(0)); err != nil {
+				return nil, fmt.Errorf("failed to load registry versions: %w", err)
+			}
+		}
+		configSpec, err = registry.ResolveConfig(registry.NewVersionedResolver(refs, chains, workflows, observers, versions), configSpec)
 		if err != nil {
 			return nil, fmt.Errorf("failed to resolve configuration: %w", err)
 		}
@@ -352,6 +358,34 @@ func Registry(root string, flags RegistryFlag) (registry.ReferenceByName, regist
 	return references, chains, workflows, documentation, metadata, observers, nil
 }
 
+// RegistryVersions loads the historical versions of the registry from a
+// snapshot directory, which holds a full copy of the registry for every
+// version in a subdirectory named like the version, e.g. as exported from
+// the git tags of the registry.
+func RegistryVersions(root string, flags RegistryFlag) (registry.VersionByName, error) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read registry versions: %w", err)
+	}
+	versions := registry.VersionByName{}
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		references, chains, workflows, _, _, observers, err := Registry(filepath.Join(root, entry.Name()), flags&RegistryFlat)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load registry version %s: %w", entry.Name(), err)
+		}
+		versions[entry.Name()] = registry.Version{
+			References: references,
+			Chains:     chains,
+			Workflows:  workflows,
+			Observers:  observers,
+		}
+	}
+	return versions, nil
+}
+
 func loadReference(bytes []byte, baseDir, prefix string, flat bool) (string, string, api.LiteralTestStep, error) {
 	step := api.RegistryReferenceConfig{}
 	err := yaml.UnmarshalStrict(bytes, &step)
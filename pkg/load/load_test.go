@@ -609,7 +609,7 @@ func TestConfig(t *testing.T) {
 					t.Fatalf("%s: failed to populate env var: %v", testCase.name, err)
 				}
 			}
-			config, err := Config(path, "", "", nil, nil)
+			config, err := Config(path, "", "", "", nil, nil)
 			if err == nil && testCase.expectedError {
 				t.Errorf("%s: expected an error, but got none", testCase.name)
 			}
@@ -973,3 +973,33 @@ func sortByOrgRepoSlices(in *ByOrgRepo) error {
 
 	return utilerrors.NewAggregate(errs)
 }
+
+func TestRegistryVersions(t *testing.T) {
+	versions, err := RegistryVersions("../../test/multistage-registry/versions", RegistryFlag(0))
+	if err != nil {
+		t.Fatalf("failed to load registry versions: %v", err)
+	}
+	expected := registry.VersionByName{
+		"v3": {
+			References: registry.ReferenceByName{
+				"ipi-install-install": {
+					As:       "ipi-install-install",
+					From:     "installer",
+					Commands: "openshift-install create cluster\n",
+					Resources: api.ResourceRequirements{
+						Requests: api.ResourceList{"cpu": "1000m", "memory": "2Gi"},
+					},
+				},
+			},
+			Chains:    registry.ChainByName{},
+			Workflows: registry.WorkflowByName{},
+			Observers: registry.ObserverByName{},
+		},
+	}
+	if diff := cmp.Diff(expected, versions); diff != "" {
+		t.Errorf("unexpected versions: %s", diff)
+	}
+	if _, err := RegistryVersions("../../test/multistage-registry/missing", RegistryFlag(0)); err == nil {
+		t.Error("expected an error for a missing directory, got none")
+	}
+}
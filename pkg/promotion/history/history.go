@@ -0,0 +1,279 @@
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	coreapi "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	prowapi "k8s.io/test-infra/prow/apis/prowjobs/v1"
+	"k8s.io/test-infra/prow/pod-utils/downwardapi"
+	"k8s.io/test-infra/prow/pod-utils/gcs"
+
+	imagev1 "github.com/openshift/api/image/v1"
+)
+
+const (
+	// Annotation is set on the ImageStreams images are promoted into
+	// and holds the serialized history of the promotions.
+	Annotation = "ci.openshift.io/promotion-history"
+	// maxHistoryPerTag bounds the number of promotions remembered for each
+	// tag, so the history of a tag outlives busier tags on shared streams
+	maxHistoryPerTag = 25
+	// maxHistorySize bounds the size of the serialized history, as the size
+	// of annotations is limited
+	maxHistorySize = 128 * 1024
+)
+
+// Record describes one promotion into an ImageStream.
+type Record struct {
+	Time metav1.Time `json:"time"`
+	// Job and BuildID identify the job that promoted the images
+	Job     string `json:"job,omitempty"`
+	BuildID string `json:"build_id,omitempty"`
+	// JobURL links to the results of the job
+	JobURL string `json:"job_url,omitempty"`
+	// Refs are the git refs the images were built from
+	Refs string `json:"refs,omitempty"`
+	// RolledBackTo is set when the promotion was a rollback, to the
+	// identifier of the promotion the tags were restored to
+	RolledBackTo string `json:"rolled_back_to,omitempty"`
+	// Tags holds the digests promoted, by tag
+	Tags map[string]TagRecord `json:"tags"`
+}
+
+// TagRecord describes the promotion of a single tag.
+type TagRecord struct {
+	// Source is the pull spec of the image that was promoted
+	Source string `json:"source,omitempty"`
+	// Digest is the digest of the image the tag points to after the promotion
+	Digest string `json:"digest"`
+	// PreviousDigest is the digest of the image the tag pointed to before the
+	// promotion; it is empty when the promotion created the tag
+	PreviousDigest string `json:"previous_digest,omitempty"`
+	// Truncated is set on the oldest promotion remembered for the tag when
+	// earlier promotions of it were forgotten
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// Matches determines whether the record was created by the job identified by
+// its build ID or URL.
+func (r Record) Matches(job string) bool {
+	return job != "" && (r.BuildID == job || r.JobURL == job)
+}
+
+// RecordFor creates a record for a promotion done by the job.
+func RecordFor(jobSpec *downwardapi.JobSpec, now metav1.Time) Record {
+	record := Record{
+		Time:    now,
+		Job:     jobSpec.Job,
+		BuildID: jobSpec.BuildID,
+		JobURL:  JobURL(jobSpec),
+		Tags:    map[string]TagRecord{},
+	}
+	if jobSpec.Refs != nil {
+		record.Refs = jobSpec.Refs.String()
+	}
+	return record
+}
+
+// JobURL determines the link to the results of the job, when the job uploads
+// them to a bucket.
+func JobURL(jobSpec *downwardapi.JobSpec) string {
+	if jobSpec.DecorationConfig == nil || jobSpec.DecorationConfig.GCSConfiguration == nil || jobSpec.DecorationConfig.GCSConfiguration.Bucket == "" {
+		return ""
+	}
+	switch jobSpec.Type {
+	case prowapi.PeriodicJob, prowapi.PostsubmitJob, prowapi.BatchJob:
+	case prowapi.PresubmitJob:
+		if jobSpec.Refs == nil || len(jobSpec.Refs.Pulls) == 0 {
+			return ""
+		}
+	default:
+		return ""
+	}
+	bucket := strings.TrimPrefix(jobSpec.DecorationConfig.GCSConfiguration.Bucket, "gs://")
+	return fmt.Sprintf("https://prow.ci.openshift.org/view/gs/%s/%s", bucket, gcs.PathForSpec(jobSpec, gcs.NewExplicitRepoPathBuilder()))
+}
+
+// Load returns the promotions recorded on the ImageStream, oldest first.
+func Load(stream *imagev1.ImageStream) ([]Record, error) {
+	raw, ok := stream.Annotations[Annotation]
+	if !ok {
+		return nil, nil
+	}
+	var history []Record
+	if err := json.Unmarshal([]byte(raw), &history); err != nil {
+		return nil, fmt.Errorf("could not parse promotion history of %s/%s: %w", stream.Namespace, stream.Name, err)
+	}
+	return history, nil
+}
+
+// AddRecord adds the promotion to the history recorded on the ImageStream.
+// The oldest promotions of a tag are forgotten when it was promoted too many
+// times, and the oldest promotions altogether when the history grows too large.
+func AddRecord(stream *imagev1.ImageStream, record Record) error {
+	history, err := Load(stream)
+	if err != nil {
+		return err
+	}
+	tags := make(map[string]TagRecord, len(record.Tags))
+	for tag, tagRecord := range record.Tags {
+		tags[tag] = tagRecord
+	}
+	record.Tags = tags
+	history = append(history, record)
+
+	counts := map[string]int{}
+	forgotten := sets.NewString()
+	for i := len(history) - 1; i >= 0; i-- {
+		for tag := range history[i].Tags {
+			counts[tag]++
+			if counts[tag] > maxHistoryPerTag {
+				delete(history[i].Tags, tag)
+				forgotten.Insert(tag)
+			}
+		}
+	}
+	history = truncate(history, forgotten)
+	raw, err := json.Marshal(history)
+	for err == nil && len(raw) > maxHistorySize && len(history) > 1 {
+		history = truncate(history[1:], sets.StringKeySet(history[0].Tags))
+		raw, err = json.Marshal(history)
+	}
+	if err != nil {
+		return fmt.Errorf("could not serialize promotion history: %w", err)
+	}
+	if stream.Annotations == nil {
+		stream.Annotations = map[string]string{}
+	}
+	stream.Annotations[Annotation] = string(raw)
+	return nil
+}
+
+// truncate drops the promotions left without tags and marks the oldest
+// promotion remembered for each of the tags whose earlier promotions were
+// forgotten.
+func truncate(history []Record, forgotten sets.String) []Record {
+	var kept []Record
+	for _, record := range history {
+		if len(record.Tags) == 0 {
+			continue
+		}
+		for tag, tagRecord := range record.Tags {
+			if forgotten.Has(tag) {
+				tagRecord.Truncated = true
+				record.Tags[tag] = tagRecord
+				forgotten.Delete(tag)
+			}
+		}
+		kept = append(kept, record)
+	}
+	return kept
+}
+
+// CurrentDigests returns the digests of the images the tags of the
+// ImageStream currently point to.
+func CurrentDigests(stream *imagev1.ImageStream) map[string]string {
+	digests := map[string]string{}
+	for _, tag := range stream.Status.Tags {
+		if len(tag.Items) == 0 {
+			continue
+		}
+		digests[tag.Tag] = tag.Items[0].Image
+	}
+	return digests
+}
+
+// RollbackDigests determines the digests to restore for the tags of a stream
+// to point to the images they pointed to right after the promotion by the job,
+// undoing every promotion since. Only the tags in the set are considered, or
+// all of them when it is nil. Tags which were created by later promotions, or
+// whose promotions since the job are no longer all remembered, have nothing
+// to be restored to and are returned separately.
+func RollbackDigests(history []Record, job string, tags sets.String) (map[string]string, sets.String, error) {
+	index := -1
+	for i := range history {
+		if history[i].Matches(job) {
+			index = i
+		}
+	}
+	if index == -1 {
+		return nil, nil, fmt.Errorf("no promotion by job %s is recorded", job)
+	}
+	digests := map[string]string{}
+	skipped := sets.NewString()
+	for _, record := range history[index+1:] {
+		for tag, tagRecord := range record.Tags {
+			if tags != nil && !tags.Has(tag) {
+				continue
+			}
+			if _, seen := digests[tag]; seen || skipped.Has(tag) {
+				// the earliest promotion after the job holds what it promoted
+				continue
+			}
+			if tagRecord.PreviousDigest == "" || tagRecord.Truncated {
+				// the tag was created after the job, or the promotions of it
+				// right after the job were forgotten
+				skipped.Insert(tag)
+				continue
+			}
+			digests[tag] = tagRecord.PreviousDigest
+		}
+	}
+	return digests, skipped, nil
+}
+
+// Rollback points the tags of the ImageStream back to the images they pointed
+// to after the promotion by the job and records the rollback in the history.
+// Only the tags in the set are restored, or all of them when it is nil. All
+// tags are restored by a single update of the stream, so the caller must
+// persist it. The tags which could not be restored are returned.
+func Rollback(stream *imagev1.ImageStream, job string, tags sets.String, now metav1.Time) (restored, skipped []string, err error) {
+	history, err := Load(stream)
+	if err != nil {
+		return nil, nil, err
+	}
+	digests, unrestorable, err := RollbackDigests(history, job, tags)
+	if err != nil {
+		return nil, nil, err
+	}
+	current := CurrentDigests(stream)
+	record := Record{Time: now, RolledBackTo: job, Tags: map[string]TagRecord{}}
+	for _, tag := range sets.StringKeySet(digests).List() {
+		if current[tag] == digests[tag] {
+			continue
+		}
+		setSpecTag(stream, tag, digests[tag])
+		record.Tags[tag] = TagRecord{Digest: digests[tag], PreviousDigest: current[tag]}
+		restored = append(restored, tag)
+	}
+	if len(restored) > 0 {
+		if err := AddRecord(stream, record); err != nil {
+			return nil, nil, err
+		}
+	}
+	return restored, unrestorable.List(), nil
+}
+
+// setSpecTag points the tag to an image already in the ImageStream.
+func setSpecTag(stream *imagev1.ImageStream, tag, digest string) {
+	reference := imagev1.TagReference{
+		Name: tag,
+		From: &coreapi.ObjectReference{
+			Kind:      "ImageStreamImage",
+			Name:      fmt.Sprintf("%s@%s", stream.Name, digest),
+			Namespace: stream.Namespace,
+		},
+		ReferencePolicy: imagev1.TagReferencePolicy{Type: imagev1.LocalTagReferencePolicy},
+	}
+	for i := range stream.Spec.Tags {
+		if stream.Spec.Tags[i].Name == tag {
+			stream.Spec.Tags[i] = reference
+			return
+		}
+	}
+	stream.Spec.Tags = append(stream.Spec.Tags, reference)
+}
@@ -0,0 +1,271 @@
+package history
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	coreapi "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	prowapi "k8s.io/test-infra/prow/apis/prowjobs/v1"
+	"k8s.io/test-infra/prow/pod-utils/downwardapi"
+
+	imagev1 "github.com/openshift/api/image/v1"
+)
+
+func streamWithHistory(t *testing.T, history ...Record) *imagev1.ImageStream {
+	stream := &imagev1.ImageStream{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ocp", Name: "4.10"},
+		Status: imagev1.ImageStreamStatus{
+			Tags: []imagev1.NamedTagEventList{
+				{Tag: "cli", Items: []imagev1.TagEvent{{Image: "sha256:cli-3"}}},
+				{Tag: "installer", Items: []imagev1.TagEvent{{Image: "sha256:installer-2"}}},
+				{Tag: "tests", Items: []imagev1.TagEvent{{Image: "sha256:tests-1"}}},
+			},
+		},
+	}
+	for _, record := range history {
+		if err := AddRecord(stream, record); err != nil {
+			t.Fatalf("failed to add record: %v", err)
+		}
+	}
+	return stream
+}
+
+var testHistory = []Record{
+	{BuildID: "1", Tags: map[string]TagRecord{
+		"cli":       {Digest: "sha256:cli-1", PreviousDigest: "sha256:cli-0"},
+		"installer": {Digest: "sha256:installer-1", PreviousDigest: "sha256:installer-0"},
+	}},
+	{BuildID: "2", JobURL: "https://prow.ci.openshift.org/view/gs/origin-ci-test/logs/job/2", Tags: map[string]TagRecord{
+		"cli":       {Digest: "sha256:cli-2", PreviousDigest: "sha256:cli-1"},
+		"installer": {Digest: "sha256:installer-2", PreviousDigest: "sha256:installer-1"},
+	}},
+	{BuildID: "3", Tags: map[string]TagRecord{
+		"cli":   {Digest: "sha256:cli-3", PreviousDigest: "sha256:cli-2"},
+		"tests": {Digest: "sha256:tests-1"},
+	}},
+}
+
+func TestRollbackDigests(t *testing.T) {
+	var testCases = []struct {
+		name            string
+		job             string
+		tags            sets.String
+		history         []Record
+		expected        map[string]string
+		expectedSkipped sets.String
+		expectedErr     bool
+	}{
+		{
+			name:            "rolling back to the latest promotion restores nothing",
+			job:             "3",
+			expected:        map[string]string{},
+			expectedSkipped: sets.NewString(),
+		},
+		{
+			name:            "rolling back one promotion",
+			job:             "https://prow.ci.openshift.org/view/gs/origin-ci-test/logs/job/2",
+			expected:        map[string]string{"cli": "sha256:cli-2"},
+			expectedSkipped: sets.NewString("tests"),
+		},
+		{
+			name:            "rolling back multiple promotions restores the earliest previous digests",
+			job:             "1",
+			expected:        map[string]string{"cli": "sha256:cli-1", "installer": "sha256:installer-1"},
+			expectedSkipped: sets.NewString("tests"),
+		},
+		{
+			name:            "only selected tags are rolled back",
+			job:             "1",
+			tags:            sets.NewString("installer"),
+			expected:        map[string]string{"installer": "sha256:installer-1"},
+			expectedSkipped: sets.NewString(),
+		},
+		{
+			name: "tags whose promotions since the job were forgotten are skipped",
+			job:  "1",
+			history: []Record{
+				{BuildID: "1", Tags: map[string]TagRecord{"cli": {Digest: "sha256:cli-1", PreviousDigest: "sha256:cli-0"}}},
+				{BuildID: "2", Tags: map[string]TagRecord{"tests": {Digest: "sha256:tests-5", PreviousDigest: "sha256:tests-4", Truncated: true}}},
+			},
+			expected:        map[string]string{},
+			expectedSkipped: sets.NewString("tests"),
+		},
+		{
+			name:        "unknown job",
+			job:         "4",
+			expectedErr: true,
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			history := testHistory
+			if testCase.history != nil {
+				history = testCase.history
+			}
+			digests, skipped, err := RollbackDigests(history, testCase.job, testCase.tags)
+			if (err != nil) != testCase.expectedErr {
+				t.Fatalf("expected error %t, got %v", testCase.expectedErr, err)
+			}
+			if diff := cmp.Diff(testCase.expected, digests); diff != "" {
+				t.Errorf("unexpected digests: %s", diff)
+			}
+			if diff := cmp.Diff(testCase.expectedSkipped, skipped); diff != "" {
+				t.Errorf("unexpected skipped tags: %s", diff)
+			}
+		})
+	}
+}
+
+func TestRollback(t *testing.T) {
+	stream := streamWithHistory(t, testHistory...)
+	stream.Spec.Tags = []imagev1.TagReference{{Name: "cli", From: &coreapi.ObjectReference{Kind: "DockerImage", Name: "quay.io/org/cli:latest"}}}
+	now := metav1.NewTime(time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC))
+	restored, skipped, err := Rollback(stream, "1", nil, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// tests was created after the promotion by job 1, so it has nothing to be restored to
+	if diff := cmp.Diff([]string{"cli", "installer"}, restored); diff != "" {
+		t.Errorf("unexpected restored tags: %s", diff)
+	}
+	if diff := cmp.Diff([]string{"tests"}, skipped); diff != "" {
+		t.Errorf("unexpected skipped tags: %s", diff)
+	}
+	expectedSpec := []imagev1.TagReference{
+		{
+			Name:            "cli",
+			From:            &coreapi.ObjectReference{Kind: "ImageStreamImage", Namespace: "ocp", Name: "4.10@sha256:cli-1"},
+			ReferencePolicy: imagev1.TagReferencePolicy{Type: imagev1.LocalTagReferencePolicy},
+		},
+		{
+			Name:            "installer",
+			From:            &coreapi.ObjectReference{Kind: "ImageStreamImage", Namespace: "ocp", Name: "4.10@sha256:installer-1"},
+			ReferencePolicy: imagev1.TagReferencePolicy{Type: imagev1.LocalTagReferencePolicy},
+		},
+	}
+	if diff := cmp.Diff(expectedSpec, stream.Spec.Tags); diff != "" {
+		t.Errorf("unexpected spec tags: %s", diff)
+	}
+	history, err := Load(stream)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedRecord := Record{Time: now, RolledBackTo: "1", Tags: map[string]TagRecord{
+		"cli":       {Digest: "sha256:cli-1", PreviousDigest: "sha256:cli-3"},
+		"installer": {Digest: "sha256:installer-1", PreviousDigest: "sha256:installer-2"},
+	}}
+	if diff := cmp.Diff(expectedRecord, history[len(history)-1]); diff != "" {
+		t.Errorf("unexpected rollback record: %s", diff)
+	}
+}
+
+func TestAddRecordBoundsHistoryPerTag(t *testing.T) {
+	stream := streamWithHistory(t)
+	// a rarely promoted tag shares the stream with one promoted by many jobs
+	if err := AddRecord(stream, Record{BuildID: "rare", Tags: map[string]TagRecord{"tests": {Digest: "sha256:tests-1"}}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i := 0; i < 3*maxHistoryPerTag; i++ {
+		record := Record{BuildID: fmt.Sprintf("busy-%d", i), Tags: map[string]TagRecord{
+			"cli": {Digest: fmt.Sprintf("sha256:cli-%d", i+1), PreviousDigest: fmt.Sprintf("sha256:cli-%d", i)},
+		}}
+		if err := AddRecord(stream, record); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	history, err := Load(stream)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(history) != maxHistoryPerTag+1 {
+		t.Fatalf("expected %d records, got %d", maxHistoryPerTag+1, len(history))
+	}
+	if history[0].BuildID != "rare" {
+		t.Errorf("expected the promotion of the rarely promoted tag to be remembered, first is %s", history[0].BuildID)
+	}
+	if expected := fmt.Sprintf("busy-%d", 2*maxHistoryPerTag); history[1].BuildID != expected {
+		t.Errorf("expected the oldest promotions of the busy tag to be forgotten, first is %s", history[1].BuildID)
+	}
+	if !history[1].Tags["cli"].Truncated {
+		t.Error("expected the oldest promotion remembered for the busy tag to be marked as truncated")
+	}
+
+	// the rarely promoted tag can still be rolled back to, the busy tag cannot
+	digests, skipped, err := RollbackDigests(history, "rare", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(map[string]string{}, digests); diff != "" {
+		t.Errorf("unexpected digests: %s", diff)
+	}
+	if diff := cmp.Diff(sets.NewString("cli"), skipped); diff != "" {
+		t.Errorf("unexpected skipped tags: %s", diff)
+	}
+	if _, _, err := RollbackDigests(history, fmt.Sprintf("busy-%d", 2*maxHistoryPerTag), nil); err != nil {
+		t.Errorf("expected the latest promotions of the busy tag to be remembered: %v", err)
+	}
+}
+
+func TestAddRecordBoundsHistorySize(t *testing.T) {
+	stream := streamWithHistory(t)
+	source := strings.Repeat("x", 1024)
+	for i := 0; i < 2*maxHistorySize/len(source); i++ {
+		record := Record{BuildID: fmt.Sprintf("%d", i), Tags: map[string]TagRecord{
+			fmt.Sprintf("tag-%d", i): {Source: source, Digest: "sha256:digest"},
+		}}
+		if err := AddRecord(stream, record); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if size := len(stream.Annotations[Annotation]); size > maxHistorySize {
+		t.Errorf("expected the history to fit in %d bytes, got %d", maxHistorySize, size)
+	}
+	history, err := Load(stream)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if last := history[len(history)-1].BuildID; last != fmt.Sprintf("%d", 2*maxHistorySize/len(source)-1) {
+		t.Errorf("expected the latest promotion to be remembered, last is %s", last)
+	}
+}
+
+func TestJobURL(t *testing.T) {
+	decoration := &prowapi.DecorationConfig{GCSConfiguration: &prowapi.GCSConfiguration{Bucket: "origin-ci-test"}}
+	var testCases = []struct {
+		name     string
+		spec     downwardapi.JobSpec
+		expected string
+	}{
+		{
+			name: "no decoration",
+			spec: downwardapi.JobSpec{Type: prowapi.PostsubmitJob, Job: "job", BuildID: "1"},
+		},
+		{
+			name:     "postsubmit",
+			spec:     downwardapi.JobSpec{Type: prowapi.PostsubmitJob, Job: "job", BuildID: "1", DecorationConfig: decoration},
+			expected: "https://prow.ci.openshift.org/view/gs/origin-ci-test/logs/job/1",
+		},
+		{
+			name:     "presubmit",
+			spec:     downwardapi.JobSpec{Type: prowapi.PresubmitJob, Job: "job", BuildID: "1", DecorationConfig: decoration, Refs: &prowapi.Refs{Org: "org", Repo: "repo", Pulls: []prowapi.Pull{{Number: 2}}}},
+			expected: "https://prow.ci.openshift.org/view/gs/origin-ci-test/pr-logs/pull/org_repo/2/job/1",
+		},
+		{
+			name: "presubmit without pulls",
+			spec: downwardapi.JobSpec{Type: prowapi.PresubmitJob, Job: "job", BuildID: "1", DecorationConfig: decoration},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			if diff := cmp.Diff(testCase.expected, JobURL(&testCase.spec)); diff != "" {
+				t.Errorf("unexpected job URL: %s", diff)
+			}
+		})
+	}
+}
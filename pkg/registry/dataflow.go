@@ -0,0 +1,45 @@
+package registry
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+// providedFiles are the files ci-operator provides to the steps itself, as
+// for tests which claim a cluster, so steps can consume them when no step
+// produces them.
+var providedFiles = sets.NewString(api.HiveAdminKubeconfigSecretKey, "kubeadmin-password")
+
+// checkDataFlow verifies that the files steps consume from the shared
+// directory are produced by a step which runs before them. Steps in the
+// same parallel group run concurrently, so they cannot consume what the
+// others produce. For partial step lists, as in chains, files which none
+// of the steps produce are expected to be produced before the steps run.
+// Files ci-operator provides are only expected from the steps when one of
+// them produces the file.
+func checkDataFlow(name string, steps []api.LiteralTestStep, partial bool) (ret []error) {
+	producedAnywhere := sets.NewString()
+	for _, step := range steps {
+		producedAnywhere.Insert(step.Produces...)
+	}
+	produced, pending := sets.NewString(), sets.NewString()
+	var group string
+	for _, step := range steps {
+		if step.ParallelGroup == "" || step.ParallelGroup != group {
+			produced = produced.Union(pending)
+			pending = sets.NewString()
+		}
+		group = step.ParallelGroup
+		for _, file := range step.Consumes {
+			if produced.Has(file) || (!producedAnywhere.Has(file) && (partial || providedFiles.Has(file))) {
+				continue
+			}
+			ret = append(ret, fmt.Errorf("%s: step/%s: consumes %s, which no step running before it produces", name, step.As, file))
+		}
+		pending.Insert(step.Produces...)
+	}
+	return ret
+}
@@ -0,0 +1,85 @@
+package registry
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestValidateDataFlow(t *testing.T) {
+	step := func(as string, produces, consumes []string) api.LiteralTestStep {
+		return api.LiteralTestStep{As: as, From: "cli", Commands: "true", Produces: produces, Consumes: consumes}
+	}
+	refs := ReferenceByName{
+		"install":   step("install", []string{"kubeconfig", "metadata.json"}, nil),
+		"configure": step("configure", nil, []string{"kubeconfig"}),
+		"gather":    step("gather", []string{"must-gather.tar"}, []string{"kubeconfig"}),
+		"upload":    step("upload", nil, []string{"must-gather.tar"}),
+		"destroy":   step("destroy", nil, []string{"metadata.json"}),
+	}
+	ref := func(name string) api.TestStep {
+		return api.TestStep{Reference: &name}
+	}
+	chain := func(name string) api.TestStep {
+		return api.TestStep{Chain: &name}
+	}
+
+	for _, tc := range []struct {
+		name      string
+		chains    ChainByName
+		workflows WorkflowByName
+		expected  error
+	}{{
+		name: "files are produced before they are consumed",
+		chains: ChainByName{
+			"install":  {Steps: []api.TestStep{ref("install"), ref("configure")}},
+			"teardown": {Steps: []api.TestStep{ref("gather"), ref("upload"), ref("destroy")}},
+		},
+		workflows: WorkflowByName{
+			"ipi": {Pre: []api.TestStep{chain("install")}, Post: []api.TestStep{chain("teardown")}},
+		},
+	}, {
+		name: "chain consuming a file it produces later is rejected",
+		chains: ChainByName{
+			"install": {Steps: []api.TestStep{ref("configure"), ref("install")}},
+		},
+		expected: errors.New("chain/install: step/configure: consumes kubeconfig, which no step running before it produces"),
+	}, {
+		name: "parallel steps cannot consume what the others produce",
+		chains: ChainByName{
+			"gather": {Steps: []api.TestStep{ref("gather"), ref("upload")}, Parallel: true},
+		},
+		expected: errors.New("chain/gather: step/upload: consumes must-gather.tar, which no step running before it produces"),
+	}, {
+		name: "workflow consuming a file nothing produces is rejected",
+		chains: ChainByName{
+			"teardown": {Steps: []api.TestStep{ref("gather"), ref("upload"), ref("destroy")}},
+		},
+		workflows: WorkflowByName{
+			"ipi": {Post: []api.TestStep{chain("teardown")}},
+		},
+		expected: errors.New("workflow/ipi: step/destroy: consumes metadata.json, which no step running before it produces"),
+	}, {
+		name: "workflow consuming a file ci-operator provides is accepted",
+		workflows: WorkflowByName{
+			"claim": {Test: []api.TestStep{ref("configure")}},
+		},
+	}, {
+		name: "workflow consuming a file ci-operator provides before producing it is rejected",
+		workflows: WorkflowByName{
+			"ipi": {Pre: []api.TestStep{ref("configure"), ref("install")}},
+		},
+		expected: errors.New("workflow/ipi: step/configure: consumes kubeconfig, which no step running before it produces"),
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Validate(refs, tc.chains, tc.workflows, nil)
+			if diff := cmp.Diff(tc.expected, err, testhelper.EquateErrorMessage); diff != "" {
+				t.Errorf("unexpected error: %s", diff)
+			}
+		})
+	}
+}
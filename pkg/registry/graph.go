@@ -2,6 +2,7 @@ package registry
 
 import (
 	"fmt"
+	"sort"
 
 	"github.com/sirupsen/logrus"
 
@@ -19,6 +20,10 @@ const (
 
 var nodeTypes = [3]string{Workflow: "workflow", Reference: "reference", Chain: "chain"}
 
+func (t Type) String() string {
+	return nodeTypes[t]
+}
+
 // Node is an interface that allows a user to identify ancestors and descendants of a step registry element
 type Node interface {
 	// Name returns the name of the registry element a Node refers to
@@ -309,3 +314,31 @@ func NewGraph(stepsByName ReferenceByName, chainsByName ChainByName, workflowsBy
 	}
 	return nodesByName, nil
 }
+
+// AffectedNodes returns a sorted list of all nodes affected by a seed list
+// of changed nodes. Affected node is either a directly changed node or any of
+// its ancestors. Each node is present at most once.
+func AffectedNodes(changed []Node) []Node {
+	all := changed
+	for _, node := range changed {
+		all = append(all, node.Ancestors()...)
+	}
+
+	var worklist []Node
+	seen := sets.NewString()
+	keyFunc := func(node Node) string { return fmt.Sprintf("type=%d name=%s", node.Type(), node.Name()) }
+	for _, node := range all {
+		key := keyFunc(node)
+		if !seen.Has(key) {
+			seen.Insert(key)
+			worklist = append(worklist, node)
+		}
+	}
+	sort.Slice(worklist, func(i, j int) bool {
+		if worklist[i].Name() == worklist[j].Name() {
+			return worklist[i].Type() < worklist[j].Type()
+		}
+		return worklist[i].Name() < worklist[j].Name()
+	})
+	return worklist
+}
@@ -0,0 +1,203 @@
+// Package impact determines what would change if a component of the step
+// registry changed: the chains and workflows which include it, and the
+// ci-operator configurations and generated Prow jobs whose tests run it.
+package impact
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/jobconfig"
+	"github.com/openshift/ci-tools/pkg/load"
+	"github.com/openshift/ci-tools/pkg/registry"
+)
+
+// NoClusterProfile groups the jobs of tests which do not use a cluster profile.
+const NoClusterProfile = "none"
+
+// Impact lists everything which would change if a component changed.
+type Impact struct {
+	// Type is the type of the changed component: reference, chain or workflow.
+	Type string `json:"type"`
+	// Name is the name of the changed component.
+	Name string `json:"name"`
+	// Workflows are the workflows which include the component.
+	Workflows []string `json:"workflows"`
+	// Chains are the chains which include the component.
+	Chains []string `json:"chains"`
+	// Configs are the paths of the ci-operator configurations with
+	// tests running the component, relative to the configuration directory.
+	Configs []string `json:"configs"`
+	// Jobs are the names of the Prow jobs generated for those tests.
+	Jobs []string `json:"jobs"`
+	// JobsByOrg counts the jobs per organization.
+	JobsByOrg map[string]int `json:"jobs_by_org"`
+	// JobsByClusterProfile counts the jobs per cluster profile of their test.
+	JobsByClusterProfile map[string]int `json:"jobs_by_cluster_profile"`
+}
+
+// Find looks up a component in the graph. The type may be empty, in which
+// case the name must identify a single reference, chain or workflow.
+func Find(graph registry.NodeByName, typeName, name string) (registry.Node, error) {
+	byType := map[string]map[string]registry.Node{
+		registry.Reference.String(): graph.References,
+		registry.Chain.String():     graph.Chains,
+		registry.Workflow.String():  graph.Workflows,
+	}
+	if typeName != "" {
+		nodes, ok := byType[typeName]
+		if !ok {
+			return nil, fmt.Errorf("invalid type %q, must be one of %s, %s or %s", typeName, registry.Reference, registry.Chain, registry.Workflow)
+		}
+		node, ok := nodes[name]
+		if !ok {
+			return nil, fmt.Errorf("no %s named %s", typeName, name)
+		}
+		return node, nil
+	}
+	var found []registry.Node
+	for _, t := range []registry.Type{registry.Reference, registry.Chain, registry.Workflow} {
+		if node, ok := byType[t.String()][name]; ok {
+			found = append(found, node)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return nil, fmt.Errorf("no reference, chain or workflow named %s", name)
+	case 1:
+		return found[0], nil
+	}
+	var types []string
+	for _, node := range found {
+		types = append(types, node.Type().String())
+	}
+	return nil, fmt.Errorf("%s is ambiguous, it names a %s, set the type", name, strings.Join(types, " and a "))
+}
+
+// Analyze determines what would change if the component of the node changed.
+// Tests only run a component if they do not pin it or the chain or workflow
+// including it to a version of the registry.
+func Analyze(node registry.Node, workflows registry.WorkflowByName, configs load.ByOrgRepo) Impact {
+	ret := Impact{
+		Type:                 node.Type().String(),
+		Name:                 node.Name(),
+		Workflows:            []string{},
+		Chains:               []string{},
+		Configs:              []string{},
+		Jobs:                 []string{},
+		JobsByOrg:            map[string]int{},
+		JobsByClusterProfile: map[string]int{},
+	}
+	affected := map[registry.Type]sets.String{
+		registry.Reference: sets.NewString(),
+		registry.Chain:     sets.NewString(),
+		registry.Workflow:  sets.NewString(),
+	}
+	for _, n := range registry.AffectedNodes([]registry.Node{node}) {
+		affected[n.Type()].Insert(n.Name())
+		if n == node {
+			continue
+		}
+		switch n.Type() {
+		case registry.Workflow:
+			ret.Workflows = append(ret.Workflows, n.Name())
+		case registry.Chain:
+			ret.Chains = append(ret.Chains, n.Name())
+		}
+	}
+
+	runs := func(steps []api.TestStep) bool {
+		for _, step := range steps {
+			var name, version string
+			var names sets.String
+			switch {
+			case step.Reference != nil:
+				name, version = registry.SplitVersion(*step.Reference)
+				names = affected[registry.Reference]
+			case step.Chain != nil:
+				name, version = registry.SplitVersion(*step.Chain)
+				names = affected[registry.Chain]
+			default:
+				continue
+			}
+			if version == "" && names.Has(name) {
+				return true
+			}
+		}
+		return false
+	}
+	testRuns := func(test *api.MultiStageTestConfiguration) (bool, api.ClusterProfile) {
+		var workflow api.MultiStageTestConfiguration
+		var workflowChanged bool
+		profile := test.ClusterProfile
+		if test.Workflow != nil {
+			name, version := registry.SplitVersion(*test.Workflow)
+			if profile == "" {
+				profile = workflows[name].ClusterProfile
+			}
+			// steps of pinned workflows come from their version
+			if version == "" {
+				workflow = workflows[name]
+				// a workflow including the component does not run it
+				// in tests overriding the phase it is in
+				workflowChanged = node.Type() == registry.Workflow && node.Name() == name
+			}
+		}
+		phases := [][]api.TestStep{test.Pre, test.Test, test.Post}
+		for i, fallback := range [][]api.TestStep{workflow.Pre, workflow.Test, workflow.Post} {
+			if phases[i] == nil {
+				phases[i] = fallback
+			}
+		}
+		changed := workflowChanged || runs(phases[0]) || runs(phases[1]) || runs(phases[2])
+		return changed, profile
+	}
+
+	for org, repos := range configs {
+		for _, repoConfigs := range repos {
+			for _, config := range repoConfigs {
+				var configChanged bool
+				for _, test := range config.Tests {
+					if test.MultiStageTestConfiguration == nil {
+						continue
+					}
+					changed, profile := testRuns(test.MultiStageTestConfiguration)
+					if !changed {
+						continue
+					}
+					configChanged = true
+					ret.Jobs = append(ret.Jobs, config.Metadata.JobName(jobPrefix(test), test.As))
+					ret.JobsByOrg[org]++
+					if profile == "" {
+						profile = NoClusterProfile
+					}
+					ret.JobsByClusterProfile[string(profile)]++
+				}
+				if configChanged {
+					ret.Configs = append(ret.Configs, config.Metadata.RelativePath())
+				}
+			}
+		}
+	}
+	for _, list := range [][]string{ret.Workflows, ret.Chains, ret.Configs, ret.Jobs} {
+		sort.Strings(list)
+	}
+	return ret
+}
+
+// jobPrefix determines the kind of job generated for a test in the same way
+// as ci-operator-prowgen
+func jobPrefix(test api.TestStepConfiguration) string {
+	switch {
+	case test.Cron != nil || test.Interval != nil || test.ReleaseController:
+		return jobconfig.PeriodicPrefix
+	case test.Postsubmit:
+		return jobconfig.PostsubmitPrefix
+	default:
+		return jobconfig.PresubmitPrefix
+	}
+}
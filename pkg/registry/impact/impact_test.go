@@ -0,0 +1,211 @@
+package impact
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"k8s.io/utils/pointer"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/load"
+	"github.com/openshift/ci-tools/pkg/registry"
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func testGraph(t *testing.T) (registry.NodeByName, registry.WorkflowByName) {
+	install, gather, deprovision, other, teardown := "install", "gather", "deprovision", "other", "teardown"
+	refs := registry.ReferenceByName{
+		install:     {As: install},
+		gather:      {As: gather},
+		deprovision: {As: deprovision},
+		other:       {As: other},
+		"ipi":       {As: "ipi"},
+	}
+	chains := registry.ChainByName{
+		teardown: {As: teardown, Steps: []api.TestStep{{Reference: &gather}, {Reference: &deprovision}}},
+	}
+	workflows := registry.WorkflowByName{
+		"ipi": {
+			ClusterProfile: api.ClusterProfileAWS,
+			Pre:            []api.TestStep{{Reference: &install}},
+			Post:           []api.TestStep{{Chain: &teardown}},
+		},
+		"other-workflow": {Test: []api.TestStep{{Reference: &other}}},
+	}
+	graph, err := registry.NewGraph(refs, chains, workflows)
+	if err != nil {
+		t.Fatalf("failed to build graph: %v", err)
+	}
+	return graph, workflows
+}
+
+func TestFind(t *testing.T) {
+	graph, _ := testGraph(t)
+	for _, tc := range []struct {
+		name         string
+		typeName     string
+		step         string
+		expectedType registry.Type
+		expectedErr  error
+	}{{
+		name:         "unique name without a type",
+		step:         "teardown",
+		expectedType: registry.Chain,
+	}, {
+		name:         "ambiguous name with a type",
+		typeName:     "workflow",
+		step:         "ipi",
+		expectedType: registry.Workflow,
+	}, {
+		name:        "ambiguous name without a type",
+		step:        "ipi",
+		expectedErr: errors.New("ipi is ambiguous, it names a reference and a workflow, set the type"),
+	}, {
+		name:        "unknown name",
+		step:        "upi",
+		expectedErr: errors.New("no reference, chain or workflow named upi"),
+	}, {
+		name:        "name of another type",
+		typeName:    "chain",
+		step:        "install",
+		expectedErr: errors.New("no chain named install"),
+	}, {
+		name:        "invalid type",
+		typeName:    "observer",
+		step:        "install",
+		expectedErr: errors.New(`invalid type "observer", must be one of reference, chain or workflow`),
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			node, err := Find(graph, tc.typeName, tc.step)
+			if diff := cmp.Diff(tc.expectedErr, err, testhelper.EquateErrorMessage); diff != "" {
+				t.Fatalf("unexpected error: %s", diff)
+			}
+			if err != nil {
+				return
+			}
+			if node.Type() != tc.expectedType || node.Name() != tc.step {
+				t.Errorf("expected %s %s, got %s %s", tc.expectedType, tc.step, node.Type(), node.Name())
+			}
+		})
+	}
+}
+
+func TestAnalyze(t *testing.T) {
+	graph, workflows := testGraph(t)
+	other := "other"
+	ipi, pinned := "ipi", "ipi@v1"
+	configs := load.ByOrgRepo{
+		"org": {"repo": {{
+			Metadata: api.Metadata{Org: "org", Repo: "repo", Branch: "master"},
+			Tests: []api.TestStepConfiguration{{
+				As:                          "e2e",
+				MultiStageTestConfiguration: &api.MultiStageTestConfiguration{Workflow: &ipi},
+			}, {
+				As: "e2e-custom-post",
+				MultiStageTestConfiguration: &api.MultiStageTestConfiguration{
+					Workflow: &ipi,
+					Post:     []api.TestStep{{Reference: &other}},
+				},
+			}, {
+				As:   "nightly",
+				Cron: pointer.StringPtr("@daily"),
+				MultiStageTestConfiguration: &api.MultiStageTestConfiguration{
+					Workflow:       &ipi,
+					ClusterProfile: api.ClusterProfileGCP,
+				},
+			}, {
+				As:                          "pinned",
+				MultiStageTestConfiguration: &api.MultiStageTestConfiguration{Workflow: &pinned},
+			}, {
+				As:                         "unit",
+				ContainerTestConfiguration: &api.ContainerTestConfiguration{From: "src"},
+			}},
+		}}},
+		"other-org": {"other-repo": {{
+			Metadata: api.Metadata{Org: "other-org", Repo: "other-repo", Branch: "main", Variant: "variant"},
+			Tests: []api.TestStepConfiguration{{
+				As:         "cleanup",
+				Postsubmit: true,
+				MultiStageTestConfiguration: &api.MultiStageTestConfiguration{
+					Test: []api.TestStep{{Chain: pointer.StringPtr("teardown")}},
+				},
+			}},
+		}}},
+	}
+	for _, tc := range []struct {
+		name     string
+		node     registry.Node
+		expected Impact
+	}{{
+		name: "reference in a chain of a workflow",
+		node: graph.References["deprovision"],
+		expected: Impact{
+			Type:      "reference",
+			Name:      "deprovision",
+			Workflows: []string{"ipi"},
+			Chains:    []string{"teardown"},
+			Configs: []string{
+				"org/repo/org-repo-master.yaml",
+				"other-org/other-repo/other-org-other-repo-main__variant.yaml",
+			},
+			Jobs: []string{
+				"branch-ci-other-org-other-repo-main-variant-cleanup",
+				"periodic-ci-org-repo-master-nightly",
+				"pull-ci-org-repo-master-e2e",
+			},
+			JobsByOrg:            map[string]int{"org": 2, "other-org": 1},
+			JobsByClusterProfile: map[string]int{"aws": 1, "gcp": 1, "none": 1},
+		},
+	}, {
+		name: "workflow changes tests overriding its steps",
+		node: graph.Workflows["ipi"],
+		expected: Impact{
+			Type:      "workflow",
+			Name:      "ipi",
+			Workflows: []string{},
+			Chains:    []string{},
+			Configs:   []string{"org/repo/org-repo-master.yaml"},
+			Jobs: []string{
+				"periodic-ci-org-repo-master-nightly",
+				"pull-ci-org-repo-master-e2e",
+				"pull-ci-org-repo-master-e2e-custom-post",
+			},
+			JobsByOrg:            map[string]int{"org": 3},
+			JobsByClusterProfile: map[string]int{"aws": 2, "gcp": 1},
+		},
+	}, {
+		name: "reference used by a test directly",
+		node: graph.References["other"],
+		expected: Impact{
+			Type:                 "reference",
+			Name:                 "other",
+			Workflows:            []string{"other-workflow"},
+			Chains:               []string{},
+			Configs:              []string{"org/repo/org-repo-master.yaml"},
+			Jobs:                 []string{"pull-ci-org-repo-master-e2e-custom-post"},
+			JobsByOrg:            map[string]int{"org": 1},
+			JobsByClusterProfile: map[string]int{"aws": 1},
+		},
+	}, {
+		name: "unused reference",
+		node: graph.References["ipi"],
+		expected: Impact{
+			Type:                 "reference",
+			Name:                 "ipi",
+			Workflows:            []string{},
+			Chains:               []string{},
+			Configs:              []string{},
+			Jobs:                 []string{},
+			JobsByOrg:            map[string]int{},
+			JobsByClusterProfile: map[string]int{},
+		},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expected, Analyze(tc.node, workflows, configs)); diff != "" {
+				t.Errorf("unexpected impact: %s", diff)
+			}
+		})
+	}
+}
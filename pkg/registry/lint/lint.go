@@ -0,0 +1,225 @@
+// Package lint checks the components of the step registry for problems which
+// do not make them invalid, but are likely mistakes or bad practice. Every
+// kind of problem is looked for by a rule. Rules can be disabled and their
+// findings suppressed for single files.
+package lint
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/load"
+	"github.com/openshift/ci-tools/pkg/registry"
+)
+
+// Level is the severity of a finding, named as in SARIF.
+type Level string
+
+const (
+	LevelError   Level = "error"
+	LevelWarning Level = "warning"
+	LevelNote    Level = "note"
+)
+
+// Registry holds the components rules are applied to.
+type Registry struct {
+	References registry.ReferenceByName
+	Chains     registry.ChainByName
+	Workflows  registry.WorkflowByName
+	// Tests are the test configurations using the registry, which
+	// count as users of the components they reference.
+	Tests []api.MultiStageTestConfiguration
+	// Paths maps the names of the files in the registry to their path
+	// relative to the root of the registry.
+	Paths map[string]string
+}
+
+func (r Registry) path(file string) string {
+	if path, ok := r.Paths[file]; ok {
+		return path
+	}
+	return file
+}
+
+func (r Registry) referencePath(name string) string {
+	return r.path(name + load.RefSuffix)
+}
+
+func (r Registry) chainPath(name string) string {
+	return r.path(name + load.ChainSuffix)
+}
+
+// commandsPath is the path of the file holding the commands of a step,
+// which may have any extension
+func (r Registry) commandsPath(name string) string {
+	for _, ext := range []string{".sh", ""} {
+		if path, ok := r.Paths[name+load.CommandsSuffix+ext]; ok {
+			return path
+		}
+	}
+	return r.referencePath(name)
+}
+
+// PathsIn maps the names of all files in a registry to their path
+// relative to its root.
+func PathsIn(root string) (map[string]string, error) {
+	paths := map[string]string{}
+	if err := filepath.WalkDir(root, func(path string, info fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return fmt.Errorf("failed to determine relative path for %s: %w", path, err)
+		}
+		paths[info.Name()] = filepath.ToSlash(rel)
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("failed to list files in the registry: %w", err)
+	}
+	return paths, nil
+}
+
+// Finding is a problem a rule found in a component of the registry.
+type Finding struct {
+	Rule  string `json:"rule"`
+	Level Level  `json:"level"`
+	// Component is the type and name of the component, as in
+	// `reference/ipi-install-install`.
+	Component string `json:"component"`
+	// Path is the file the problem is in, relative to the root of the
+	// registry.
+	Path string `json:"path"`
+	// Line is the line in the file the problem is on, if it is known.
+	Line    int    `json:"line,omitempty"`
+	Message string `json:"message"`
+}
+
+// Rule looks for one kind of problem in the registry.
+type Rule interface {
+	// ID identifies the rule in the configuration and in reports.
+	ID() string
+	// Description explains what the rule looks for.
+	Description() string
+	// Level is the default level of the findings of the rule.
+	Level() Level
+	// Check returns the problems found in the registry. The rule
+	// and level of the findings are filled in by the caller.
+	Check(reg Registry) []Finding
+}
+
+// Config selects the rules which are run and where their findings
+// are ignored.
+type Config struct {
+	// Disabled lists the rules which are not run.
+	Disabled []string `json:"disabled,omitempty"`
+	// Levels overrides the level of the findings of rules.
+	Levels map[string]Level `json:"levels,omitempty"`
+	// Suppressions ignore the findings of rules in some files.
+	Suppressions []Suppression `json:"suppressions,omitempty"`
+	// DeprecatedImages maps images steps should no longer use to their
+	// replacement, which may be empty. Images are either imagestream
+	// tags as in `from` or `namespace/name:tag` as in `from_image`.
+	DeprecatedImages map[string]string `json:"deprecated_images,omitempty"`
+}
+
+// Suppression ignores the findings of rules in files.
+type Suppression struct {
+	// Path is a glob matching paths relative to the root of the registry.
+	Path string `json:"path"`
+	// Rules lists the rules whose findings are ignored, all rules when empty.
+	Rules []string `json:"rules,omitempty"`
+}
+
+// Validate verifies that the configuration only refers to known rules.
+func (c Config) Validate(rules []Rule) error {
+	known := sets.NewString()
+	for _, rule := range rules {
+		known.Insert(rule.ID())
+	}
+	var unknown []string
+	for _, id := range c.Disabled {
+		if !known.Has(id) {
+			unknown = append(unknown, id)
+		}
+	}
+	for id, level := range c.Levels {
+		if !known.Has(id) {
+			unknown = append(unknown, id)
+		}
+		switch level {
+		case LevelError, LevelWarning, LevelNote:
+		default:
+			return fmt.Errorf("invalid level %q for rule %s, must be one of %s, %s or %s", level, id, LevelError, LevelWarning, LevelNote)
+		}
+	}
+	for _, suppression := range c.Suppressions {
+		if _, err := filepath.Match(suppression.Path, ""); err != nil {
+			return fmt.Errorf("invalid path in suppression: %s: %w", suppression.Path, err)
+		}
+		for _, id := range suppression.Rules {
+			if !known.Has(id) {
+				unknown = append(unknown, id)
+			}
+		}
+	}
+	if len(unknown) > 0 {
+		return fmt.Errorf("unknown rules: %v", sets.NewString(unknown...).List())
+	}
+	return nil
+}
+
+func (c Config) suppressed(finding Finding) bool {
+	for _, suppression := range c.Suppressions {
+		if match, _ := filepath.Match(suppression.Path, finding.Path); !match {
+			continue
+		}
+		if len(suppression.Rules) == 0 || sets.NewString(suppression.Rules...).Has(finding.Rule) {
+			return true
+		}
+	}
+	return false
+}
+
+// Lint applies the rules which are not disabled to the registry and returns
+// what they found, except for suppressed findings, ordered by file and line.
+func Lint(reg Registry, rules []Rule, config Config) []Finding {
+	disabled := sets.NewString(config.Disabled...)
+	var ret []Finding
+	for _, rule := range rules {
+		if disabled.Has(rule.ID()) {
+			continue
+		}
+		level := rule.Level()
+		if override, ok := config.Levels[rule.ID()]; ok {
+			level = override
+		}
+		for _, finding := range rule.Check(reg) {
+			finding.Rule, finding.Level = rule.ID(), level
+			if !config.suppressed(finding) {
+				ret = append(ret, finding)
+			}
+		}
+	}
+	sort.SliceStable(ret, func(i, j int) bool {
+		if ret[i].Path != ret[j].Path {
+			return ret[i].Path < ret[j].Path
+		}
+		if ret[i].Line != ret[j].Line {
+			return ret[i].Line < ret[j].Line
+		}
+		if ret[i].Rule != ret[j].Rule {
+			return ret[i].Rule < ret[j].Rule
+		}
+		return ret[i].Message < ret[j].Message
+	})
+	return ret
+}
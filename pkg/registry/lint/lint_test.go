@@ -0,0 +1,204 @@
+package lint
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	prowv1 "k8s.io/test-infra/prow/apis/prowjobs/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/registry"
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func testRegistry() Registry {
+	install, gather, deprovision := "install", "gather", "deprovision"
+	return Registry{
+		References: registry.ReferenceByName{
+			install: {
+				As:          install,
+				From:        "installer",
+				Commands:    "openshift-install create cluster --dir \"${SHARED_DIR}\" --log-level ${LOG_LEVEL}\n",
+				Environment: []api.StepParameter{{Name: "LOG_LEVEL"}, {Name: "UNUSED"}},
+				Timeout:     &prowv1.Duration{Duration: time.Hour},
+			},
+			gather: {
+				As:       gather,
+				From:     "cli",
+				Commands: "oc adm must-gather --dest-dir=\"$ARTIFACT_DIR\"\n",
+			},
+			deprovision: {
+				As:          deprovision,
+				FromImage:   &api.ImageStreamTagReference{Namespace: "ocp", Name: "4.5", Tag: "upi-installer"},
+				Commands:    "openshift-install destroy cluster --dir $SHARED_DIR\n",
+				GracePeriod: &prowv1.Duration{Duration: 10 * time.Minute},
+			},
+			"orphan": {As: "orphan", From: "cli", Commands: "true"},
+		},
+		Chains: registry.ChainByName{
+			"teardown": {As: "teardown", Steps: []api.TestStep{{Reference: &gather}, {Reference: &deprovision}}},
+		},
+		Workflows: registry.WorkflowByName{
+			"ipi": {Pre: []api.TestStep{{Reference: &install}}},
+		},
+		Paths: map[string]string{
+			"install-ref.yaml":        "install/install-ref.yaml",
+			"gather-ref.yaml":         "gather/gather-ref.yaml",
+			"deprovision-ref.yaml":    "deprovision/deprovision-ref.yaml",
+			"deprovision-commands.sh": "deprovision/deprovision-commands.sh",
+			"orphan-ref.yaml":         "orphan/orphan-ref.yaml",
+			"teardown-chain.yaml":     "teardown/teardown-chain.yaml",
+			"install-commands.sh":     "install/install-commands.sh",
+			"gather-commands.sh":      "gather/gather-commands.sh",
+			"orphan-commands.sh":      "orphan/orphan-commands.sh",
+		},
+	}
+}
+
+func TestRules(t *testing.T) {
+	config := Config{DeprecatedImages: map[string]string{"ocp/4.5:upi-installer": "ocp/4.10:upi-installer"}}
+	expected := map[string][]Finding{
+		"unused-steps": {
+			{Component: "reference/orphan", Path: "orphan/orphan-ref.yaml", Message: "step orphan is not used by any chain, workflow or test"},
+			{Component: "chain/teardown", Path: "teardown/teardown-chain.yaml", Message: "chain teardown is not used by any chain, workflow or test"},
+		},
+		"unused-parameters": {
+			{Component: "reference/install", Path: "install/install-ref.yaml", Message: "parameter UNUSED is declared but never read in the commands"},
+		},
+		"missing-grace-period": {
+			{Component: "reference/install", Path: "install/install-ref.yaml", Message: "step sets a timeout but does not set a grace_period to clean up when interrupted"},
+		},
+		"unquoted-variables": {
+			{Component: "reference/deprovision", Path: "deprovision/deprovision-commands.sh", Line: 1, Message: "$SHARED_DIR is not quoted and is subject to word splitting and globbing"},
+			{Component: "reference/install", Path: "install/install-commands.sh", Line: 1, Message: "$LOG_LEVEL is not quoted and is subject to word splitting and globbing"},
+		},
+		"deprecated-images": {
+			{Component: "reference/deprovision", Path: "deprovision/deprovision-ref.yaml", Message: "step runs in the deprecated image ocp/4.5:upi-installer, use ocp/4.10:upi-installer instead"},
+		},
+	}
+	for _, rule := range DefaultRules(config) {
+		t.Run(rule.ID(), func(t *testing.T) {
+			findings := rule.Check(testRegistry())
+			if diff := cmp.Diff(expected[rule.ID()], findings, sortFindings); diff != "" {
+				t.Errorf("unexpected findings: %s", diff)
+			}
+		})
+	}
+}
+
+var sortFindings = cmpopts.SortSlices(func(a, b Finding) bool {
+	if a.Component != b.Component {
+		return a.Component < b.Component
+	}
+	return a.Message < b.Message
+})
+
+func TestUnquotedExpansions(t *testing.T) {
+	commands := `#!/bin/bash
+# comment mentioning $SHARED_DIR
+cp "${SHARED_DIR}/kubeconfig" $ARTIFACT_DIR
+KUBECONFIG=$SHARED_DIR/kubeconfig oc get nodes
+echo 'literal $SHARED_DIR' "quoted $SHARED_DIR" \$SHARED_DIR
+if [[ -f $SHARED_DIR/metadata.json ]]; then
+  cat <<EOF > "${SHARED_DIR}/install-config.yaml"
+dir: $ARTIFACT_DIR
+EOF
+fi
+echo "multi
+line $SHARED_DIR" $(ls ${SHARED_DIR:-/tmp}) $OTHER
+`
+	expected := []expansion{{name: "ARTIFACT_DIR", line: 3}, {name: "SHARED_DIR", line: 12}}
+	actual := unquotedExpansions(commands, sets.NewString(pathVariables...))
+	if diff := cmp.Diff(expected, actual, cmp.AllowUnexported(expansion{})); diff != "" {
+		t.Errorf("unexpected expansions: %s", diff)
+	}
+}
+
+func TestLint(t *testing.T) {
+	rules := DefaultRules(Config{})
+	for _, tc := range []struct {
+		name     string
+		config   Config
+		expected []Finding
+	}{{
+		name: "rules can be disabled, suppressed per file and have their level changed",
+		config: Config{
+			Disabled: []string{"unused-steps", "missing-grace-period"},
+			Levels:   map[string]Level{"unquoted-variables": LevelError},
+			Suppressions: []Suppression{
+				{Path: "deprovision/*"},
+				{Path: "install/*.sh", Rules: []string{"unquoted-variables"}},
+			},
+		},
+		expected: []Finding{
+			{Rule: "unused-parameters", Level: LevelWarning, Component: "reference/install", Path: "install/install-ref.yaml", Message: "parameter UNUSED is declared but never read in the commands"},
+		},
+	}, {
+		name: "all findings are reported in order of their files",
+		config: Config{
+			Disabled: []string{"unused-steps", "unused-parameters"},
+		},
+		expected: []Finding{
+			{Rule: "unquoted-variables", Level: LevelWarning, Component: "reference/deprovision", Path: "deprovision/deprovision-commands.sh", Line: 1, Message: "$SHARED_DIR is not quoted and is subject to word splitting and globbing"},
+			{Rule: "unquoted-variables", Level: LevelWarning, Component: "reference/install", Path: "install/install-commands.sh", Line: 1, Message: "$LOG_LEVEL is not quoted and is subject to word splitting and globbing"},
+			{Rule: "missing-grace-period", Level: LevelWarning, Component: "reference/install", Path: "install/install-ref.yaml", Message: "step sets a timeout but does not set a grace_period to clean up when interrupted"},
+		},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expected, Lint(testRegistry(), rules, tc.config)); diff != "" {
+				t.Errorf("unexpected findings: %s", diff)
+			}
+		})
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	rules := DefaultRules(Config{})
+	for _, tc := range []struct {
+		name     string
+		config   Config
+		expected error
+	}{{
+		name: "valid configuration",
+		config: Config{
+			Disabled:     []string{"unused-steps"},
+			Levels:       map[string]Level{"unquoted-variables": LevelError},
+			Suppressions: []Suppression{{Path: "ipi/*", Rules: []string{"deprecated-images"}}},
+		},
+	}, {
+		name: "unknown rules",
+		config: Config{
+			Disabled:     []string{"unused-step"},
+			Suppressions: []Suppression{{Path: "ipi/*", Rules: []string{"deprecated-image"}}},
+		},
+		expected: errors.New("unknown rules: [deprecated-image unused-step]"),
+	}, {
+		name:     "invalid level",
+		config:   Config{Levels: map[string]Level{"unused-steps": "fatal"}},
+		expected: errors.New(`invalid level "fatal" for rule unused-steps, must be one of error, warning or note`),
+	}, {
+		name:     "invalid glob",
+		config:   Config{Suppressions: []Suppression{{Path: "ipi/["}}},
+		expected: errors.New("invalid path in suppression: ipi/[: syntax error in pattern"),
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expected, tc.config.Validate(rules), testhelper.EquateErrorMessage); diff != "" {
+				t.Errorf("unexpected error: %s", diff)
+			}
+		})
+	}
+}
+
+func TestSARIF(t *testing.T) {
+	rules := DefaultRules(Config{})
+	raw, err := SARIF(rules, Lint(testRegistry(), rules, Config{}))
+	if err != nil {
+		t.Fatalf("failed to render SARIF: %v", err)
+	}
+	testhelper.CompareWithFixture(t, raw, testhelper.WithExtension(".json"))
+}
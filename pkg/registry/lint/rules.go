@@ -0,0 +1,277 @@
+package lint
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/registry"
+)
+
+// DefaultRules returns the built-in rules, configured by the configuration.
+func DefaultRules(config Config) []Rule {
+	return []Rule{
+		unusedSteps{},
+		unusedParameters{},
+		missingGracePeriod{},
+		unquotedVariables{},
+		deprecatedImages{images: config.DeprecatedImages},
+	}
+}
+
+// unusedSteps finds steps and chains nothing uses
+type unusedSteps struct{}
+
+func (unusedSteps) ID() string { return "unused-steps" }
+
+func (unusedSteps) Description() string {
+	return "Steps and chains which are not used by any chain, workflow or test"
+}
+
+func (unusedSteps) Level() Level { return LevelWarning }
+
+func (unusedSteps) Check(reg Registry) (ret []Finding) {
+	usedRefs, usedChains := sets.NewString(), sets.NewString()
+	use := func(steps []api.TestStep) {
+		for _, step := range steps {
+			switch {
+			case step.Reference != nil:
+				name, _ := registry.SplitVersion(*step.Reference)
+				usedRefs.Insert(name)
+			case step.Chain != nil:
+				name, _ := registry.SplitVersion(*step.Chain)
+				usedChains.Insert(name)
+			}
+		}
+	}
+	for _, chain := range reg.Chains {
+		use(chain.Steps)
+	}
+	for _, workflow := range reg.Workflows {
+		use(workflow.Pre)
+		use(workflow.Test)
+		use(workflow.Post)
+	}
+	for _, test := range reg.Tests {
+		use(test.Pre)
+		use(test.Test)
+		use(test.Post)
+	}
+	for name := range reg.References {
+		if !usedRefs.Has(name) {
+			ret = append(ret, Finding{
+				Component: "reference/" + name,
+				Path:      reg.referencePath(name),
+				Message:   fmt.Sprintf("step %s is not used by any chain, workflow or test", name),
+			})
+		}
+	}
+	for name := range reg.Chains {
+		if !usedChains.Has(name) {
+			ret = append(ret, Finding{
+				Component: "chain/" + name,
+				Path:      reg.chainPath(name),
+				Message:   fmt.Sprintf("chain %s is not used by any chain, workflow or test", name),
+			})
+		}
+	}
+	return ret
+}
+
+// unusedParameters finds parameters steps declare but do not read
+type unusedParameters struct{}
+
+func (unusedParameters) ID() string { return "unused-parameters" }
+
+func (unusedParameters) Description() string {
+	return "Parameters which are declared by a step but never read in its commands"
+}
+
+func (unusedParameters) Level() Level { return LevelWarning }
+
+func (unusedParameters) Check(reg Registry) (ret []Finding) {
+	for name, ref := range reg.References {
+		for _, env := range ref.Environment {
+			if !regexp.MustCompile(`\b` + regexp.QuoteMeta(env.Name) + `\b`).MatchString(ref.Commands) {
+				ret = append(ret, Finding{
+					Component: "reference/" + name,
+					Path:      reg.referencePath(name),
+					Message:   fmt.Sprintf("parameter %s is declared but never read in the commands", env.Name),
+				})
+			}
+		}
+	}
+	return ret
+}
+
+// missingGracePeriod finds steps with a timeout, which need time to clean up
+// when they time out, but only get the default grace period. Steps trapping
+// signals without a grace period are already rejected when loading them.
+type missingGracePeriod struct{}
+
+func (missingGracePeriod) ID() string { return "missing-grace-period" }
+
+func (missingGracePeriod) Description() string {
+	return "Steps which set a timeout, but do not set a grace_period to clean up when interrupted"
+}
+
+func (missingGracePeriod) Level() Level { return LevelWarning }
+
+func (missingGracePeriod) Check(reg Registry) (ret []Finding) {
+	for name, ref := range reg.References {
+		if ref.Timeout == nil || ref.GracePeriod != nil {
+			continue
+		}
+		ret = append(ret, Finding{
+			Component: "reference/" + name,
+			Path:      reg.referencePath(name),
+			Message:   "step sets a timeout but does not set a grace_period to clean up when interrupted",
+		})
+	}
+	return ret
+}
+
+// pathVariables are set for every step and hold paths, which break
+// when they are subject to word splitting
+var pathVariables = []string{"SHARED_DIR", "ARTIFACT_DIR", "CLUSTER_PROFILE_DIR", "KUBECONFIG"}
+
+// unquotedVariables finds expansions of variables outside of double quotes
+type unquotedVariables struct{}
+
+func (unquotedVariables) ID() string { return "unquoted-variables" }
+
+func (unquotedVariables) Description() string {
+	return "Parameters, dependencies and paths expanded outside of double quotes in the commands of steps"
+}
+
+func (unquotedVariables) Level() Level { return LevelWarning }
+
+func (unquotedVariables) Check(reg Registry) (ret []Finding) {
+	for name, ref := range reg.References {
+		variables := sets.NewString(pathVariables...)
+		for _, env := range ref.Environment {
+			variables.Insert(env.Name)
+		}
+		for _, dep := range ref.Dependencies {
+			variables.Insert(dep.Env)
+		}
+		for _, e := range unquotedExpansions(ref.Commands, variables) {
+			ret = append(ret, Finding{
+				Component: "reference/" + name,
+				Path:      reg.commandsPath(name),
+				Line:      e.line,
+				Message:   fmt.Sprintf("$%s is not quoted and is subject to word splitting and globbing", e.name),
+			})
+		}
+	}
+	return ret
+}
+
+type expansion struct {
+	name string
+	line int
+}
+
+var (
+	heredocPattern    = regexp.MustCompile(`(^|[^<])<<-?\s*['"]?(\w+)['"]?`)
+	assignmentPattern = regexp.MustCompile(`^\w+=`)
+	identifierPattern = regexp.MustCompile(`^\w+`)
+)
+
+// unquotedExpansions finds expansions of the variables outside of double
+// quotes. This is a heuristic rather than a shell parser: it follows quotes,
+// comments, here-documents, assignments and `[[ ]]` tests, which are not
+// subject to word splitting.
+func unquotedExpansions(commands string, variables sets.String) (ret []expansion) {
+	var single, double, test bool
+	var heredoc string
+	for n, line := range strings.Split(commands, "\n") {
+		if heredoc != "" {
+			if strings.TrimSpace(line) == heredoc {
+				heredoc = ""
+			}
+			continue
+		}
+		wordStart := 0
+	scan:
+		for i := 0; i < len(line); i++ {
+			c := line[i]
+			switch {
+			case single:
+				if c == '\'' {
+					single = false
+				}
+			case c == '\\':
+				i++
+			case double:
+				if c == '"' {
+					double = false
+				}
+			case c == '\'':
+				single = true
+			case c == '"':
+				double = true
+			case strings.ContainsRune(" \t;|&()", rune(c)):
+				wordStart = i + 1
+			case c == '#' && i == wordStart:
+				break scan
+			case strings.HasPrefix(line[i:], "[["):
+				test = true
+				i++
+			case strings.HasPrefix(line[i:], "]]"):
+				test = false
+				i++
+			case c == '$' && !test && !assignmentPattern.MatchString(line[wordStart:i]):
+				name := identifierPattern.FindString(strings.TrimPrefix(line[i+1:], "{"))
+				if variables.Has(name) {
+					ret = append(ret, expansion{name: name, line: n + 1})
+				}
+			}
+		}
+		if !single && !double {
+			if match := heredocPattern.FindStringSubmatch(line); match != nil {
+				heredoc = match[2]
+			}
+		}
+	}
+	return ret
+}
+
+// deprecatedImages finds steps running in images which should no longer be used
+type deprecatedImages struct {
+	images map[string]string
+}
+
+func (deprecatedImages) ID() string { return "deprecated-images" }
+
+func (deprecatedImages) Description() string {
+	return "Steps running in images which are deprecated"
+}
+
+func (deprecatedImages) Level() Level { return LevelWarning }
+
+func (r deprecatedImages) Check(reg Registry) (ret []Finding) {
+	for name, ref := range reg.References {
+		image := ref.From
+		if ref.FromImage != nil {
+			image = fmt.Sprintf("%s/%s:%s", ref.FromImage.Namespace, ref.FromImage.Name, ref.FromImage.Tag)
+		}
+		replacement, deprecated := r.images[image]
+		if !deprecated {
+			continue
+		}
+		message := fmt.Sprintf("step runs in the deprecated image %s", image)
+		if replacement != "" {
+			message += fmt.Sprintf(", use %s instead", replacement)
+		}
+		ret = append(ret, Finding{
+			Component: "reference/" + name,
+			Path:      reg.referencePath(name),
+			Message:   message,
+		})
+	}
+	return ret
+}
@@ -0,0 +1,113 @@
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+	toolName     = "registry-lint"
+)
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name  string      `json:"name"`
+	Rules []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string             `json:"id"`
+	ShortDescription     sarifMessage       `json:"shortDescription"`
+	DefaultConfiguration sarifConfiguration `json:"defaultConfiguration"`
+}
+
+type sarifConfiguration struct {
+	Level Level `json:"level"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	RuleIndex int             `json:"ruleIndex"`
+	Level     Level           `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+// SARIF renders findings as a SARIF 2.1.0 log, which code review tools
+// show as annotations on the files the findings are in.
+func SARIF(rules []Rule, findings []Finding) ([]byte, error) {
+	run := sarifRun{
+		Tool:    sarifTool{Driver: sarifDriver{Name: toolName}},
+		Results: []sarifResult{},
+	}
+	index := map[string]int{}
+	for i, rule := range rules {
+		index[rule.ID()] = i
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{
+			ID:                   rule.ID(),
+			ShortDescription:     sarifMessage{Text: rule.Description()},
+			DefaultConfiguration: sarifConfiguration{Level: rule.Level()},
+		})
+	}
+	for _, finding := range findings {
+		i, ok := index[finding.Rule]
+		if !ok {
+			return nil, fmt.Errorf("finding of unknown rule %s", finding.Rule)
+		}
+		location := sarifLocation{PhysicalLocation: sarifPhysicalLocation{
+			ArtifactLocation: sarifArtifactLocation{URI: finding.Path},
+		}}
+		if finding.Line > 0 {
+			location.PhysicalLocation.Region = &sarifRegion{StartLine: finding.Line}
+		}
+		run.Results = append(run.Results, sarifResult{
+			RuleID:    finding.Rule,
+			RuleIndex: i,
+			Level:     finding.Level,
+			Message:   sarifMessage{Text: finding.Message},
+			Locations: []sarifLocation{location},
+		})
+	}
+	raw, err := json.MarshalIndent(sarifLog{Schema: sarifSchema, Version: sarifVersion, Runs: []sarifRun{run}}, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("could not serialize SARIF log: %w", err)
+	}
+	return raw, nil
+}
@@ -20,23 +20,45 @@ type ObserverByName map[string]api.Observer
 
 // Validate verifies the internal consistency of steps, chains, and workflows.
 // A superset of this validation is performed later when actual test
-// configurations are resolved.
+// configurations are resolved. Chains and workflows are also checked for
+// steps consuming files from the shared directory before they are produced.
+// Only ci-operator configurations can pin components to versions, so chains
+// and workflows pinning their steps are rejected.
 func Validate(stepsByName ReferenceByName, chainsByName ChainByName, workflowsByName WorkflowByName, observersByName ObserverByName) error {
-	reg := registry{stepsByName, chainsByName, workflowsByName, observersByName}
+	reg := registry{stepsByName: stepsByName, chainsByName: chainsByName, workflowsByName: workflowsByName, observersByName: observersByName}
 	var ret []error
-	for k := range chainsByName {
-		if _, err := reg.process([]api.TestStep{{Chain: &k}}, sets.NewString(), stackForChain()); err != nil {
+	for k, v := range chainsByName {
+		if err := checkUnpinned("chain/"+k, v.Steps); err != nil {
 			ret = append(ret, err...)
+			continue
+		}
+		steps, err := reg.process([]api.TestStep{{Chain: &k}}, sets.NewString(), stackForChain())
+		if err != nil {
+			ret = append(ret, err...)
+			continue
 		}
+		ret = append(ret, checkDataFlow("chain/"+k, steps, true)...)
 	}
 	for k, v := range workflowsByName {
+		if err := checkUnpinned("workflow/"+k, v.Pre, v.Test, v.Post); err != nil {
+			ret = append(ret, err...)
+			continue
+		}
 		stack := stackForWorkflow(k, v.Environment, v.Dependencies)
+		var steps []api.LiteralTestStep
+		var failed bool
 		for _, s := range [][]api.TestStep{v.Pre, v.Test, v.Post} {
-			if _, err := reg.process(s, sets.NewString(), stack); err != nil {
+			phase, err := reg.process(s, sets.NewString(), stack)
+			if err != nil {
 				ret = append(ret, err...)
+				failed = true
 			}
+			steps = append(steps, phase...)
 		}
 		ret = append(ret, stack.checkUnused(&stack.records[0])...)
+		if !failed {
+			ret = append(ret, checkDataFlow("workflow/"+k, steps, false)...)
+		}
 	}
 	return utilerrors.NewAggregate(ret)
 }
@@ -49,21 +71,39 @@ type registry struct {
 	chainsByName    ChainByName
 	workflowsByName WorkflowByName
 	observersByName ObserverByName
+	// versions are the historical versions of the registry
+	// components can be pinned to
+	versions VersionByName
 }
 
 func NewResolver(stepsByName ReferenceByName, chainsByName ChainByName, workflowsByName WorkflowByName, observersByName ObserverByName) Resolver {
+	return NewVersionedResolver(stepsByName, chainsByName, workflowsByName, observersByName, nil)
+}
+
+// NewVersionedResolver returns a resolver which also serves historical
+// versions of the registry, for references, chains and workflows which
+// are pinned to a version as in `name@version`.
+func NewVersionedResolver(stepsByName ReferenceByName, chainsByName ChainByName, workflowsByName WorkflowByName, observersByName ObserverByName, versions VersionByName) Resolver {
 	return &registry{
 		stepsByName:     stepsByName,
 		chainsByName:    chainsByName,
 		workflowsByName: workflowsByName,
 		observersByName: observersByName,
+		versions:        versions,
 	}
 }
 
 func (r *registry) Resolve(name string, config api.MultiStageTestConfiguration) (api.MultiStageTestConfigurationLiteral, error) {
 	var resolveErrors []error
+	// steps taken from a pinned workflow are resolved in its version
+	preSource, testSource, postSource := r, r, r
 	if config.Workflow != nil {
-		workflow, ok := r.workflowsByName[*config.Workflow]
+		name, version := SplitVersion(*config.Workflow)
+		source, err := r.pinned(name, version, lookupWorkflow)
+		if err != nil {
+			return api.MultiStageTestConfigurationLiteral{}, fmt.Errorf("invalid workflow %s: %w", *config.Workflow, err)
+		}
+		workflow, ok := source.workflowsByName[name]
 		if !ok {
 			return api.MultiStageTestConfigurationLiteral{}, fmt.Errorf("no workflow named %s", *config.Workflow)
 		}
@@ -72,12 +112,15 @@ func (r *registry) Resolve(name string, config api.MultiStageTestConfiguration)
 		}
 		if config.Pre == nil {
 			config.Pre = workflow.Pre
+			preSource = source
 		}
 		if config.Test == nil {
 			config.Test = workflow.Test
+			testSource = source
 		}
 		if config.Post == nil {
 			config.Post = workflow.Post
+			postSource = source
 		}
 		mergeEnvironments(&config.Environment, workflow.Environment)
 		mergeDependencies(&config.Dependencies, workflow.Dependencies)
@@ -105,15 +148,15 @@ func (r *registry) Resolve(name string, config api.MultiStageTestConfiguration)
 	if config.Workflow != nil {
 		stack.push(stackRecordForTest("workflow/"+*config.Workflow, nil, nil))
 	}
-	pre, errs := r.process(config.Pre, sets.NewString(), stack)
+	pre, errs := preSource.process(config.Pre, sets.NewString(), stack)
 	expandedFlow.Pre = append(expandedFlow.Pre, pre...)
 	resolveErrors = append(resolveErrors, errs...)
 
-	test, errs := r.process(config.Test, sets.NewString(), stack)
+	test, errs := testSource.process(config.Test, sets.NewString(), stack)
 	expandedFlow.Test = append(expandedFlow.Test, test...)
 	resolveErrors = append(resolveErrors, errs...)
 
-	post, errs := r.process(config.Post, sets.NewString(), stack)
+	post, errs := postSource.process(config.Post, sets.NewString(), stack)
 	expandedFlow.Post = append(expandedFlow.Post, post...)
 	resolveErrors = append(resolveErrors, errs...)
 	resolveErrors = append(resolveErrors, stack.checkUnused(&stack.records[0])...)
@@ -222,23 +265,38 @@ func (r *registry) process(steps []api.TestStep, seen sets.String, stack stack)
 }
 
 func (r *registry) processChain(step *api.TestStep, seen sets.String, stack stack) ([]api.LiteralTestStep, []error) {
-	name := *step.Chain
-	chain, ok := r.chainsByName[name]
+	name, version := SplitVersion(*step.Chain)
+	source, pinErr := r.pinned(name, version, lookupChain)
+	if pinErr != nil {
+		return nil, []error{stack.errorf("invalid step chain: %s: %v", *step.Chain, pinErr)}
+	}
+	chain, ok := source.chainsByName[name]
 	if !ok {
-		return nil, []error{stack.errorf("unknown step chain: %s", name)}
+		return nil, []error{stack.errorf("unknown step chain: %s", *step.Chain)}
 	}
-	rec := stackRecordForStep("chain/"+name, chain.Environment, nil)
+	rec := stackRecordForStep("chain/"+*step.Chain, chain.Environment, nil)
 	stack.push(rec)
 	defer stack.pop()
-	ret, err := r.process(chain.Steps, seen, stack)
+	ret, err := source.process(chain.Steps, seen, stack)
 	err = append(err, stack.checkUnused(&rec)...)
+	if chain.Parallel {
+		// nested chains run in the same group as the outermost parallel chain
+		for i := range ret {
+			ret[i].ParallelGroup = name
+		}
+	}
 	return ret, err
 }
 
 func (r *registry) processStep(step *api.TestStep, seen sets.String, stack stack) (ret api.LiteralTestStep, err []error) {
 	if ref := step.Reference; ref != nil {
+		name, version := SplitVersion(*ref)
+		source, pinErr := r.pinned(name, version, lookupReference)
+		if pinErr != nil {
+			return api.LiteralTestStep{}, []error{stack.errorf("invalid step reference: %s: %v", *ref, pinErr)}
+		}
 		var ok bool
-		ret, ok = r.stepsByName[*ref]
+		ret, ok = source.stepsByName[name]
 		if !ok {
 			return api.LiteralTestStep{}, []error{stack.errorf("invalid step reference: %s", *ref)}
 		}
@@ -259,6 +317,9 @@ func (r *registry) processStep(step *api.TestStep, seen sets.String, stack stack
 		env := make([]api.StepParameter, 0, len(ret.Environment))
 		for _, e := range ret.Environment {
 			if v := stack.resolve(e.Name); v != nil {
+				if err := e.ValidateValue(*v); err != nil {
+					errs = append(errs, stack.errorf("step/%s: invalid value for parameter %s: %v", ret.As, e.Name, err))
+				}
 				e.Default = v
 			} else if e.Default == nil && !stack.partial {
 				errs = append(errs, stack.errorf("step/%s: unresolved parameter: %s", ret.As, e.Name))
@@ -444,6 +444,87 @@ func TestResolve(t *testing.T) {
 				}},
 			},
 		},
+	}, {
+		name: "Test with nested parallel chain",
+		config: api.MultiStageTestConfiguration{
+			ClusterProfile: api.ClusterProfileAWS,
+			Post: []api.TestStep{{
+				Chain: &nestedChains,
+			}, {
+				Reference: &teardownRef,
+			}},
+		},
+		stepMap: ReferenceByName{
+			teardownRef: {
+				As:       "teardown",
+				From:     "installer",
+				Commands: "openshift-cluster destroy",
+				Resources: api.ResourceRequirements{
+					Requests: api.ResourceList{"cpu": "1000m"},
+					Limits:   api.ResourceList{"memory": "2Gi"},
+				},
+			},
+		},
+		chainMap: ChainByName{
+			nestedChains: {
+				Parallel: true,
+				Steps: []api.TestStep{{
+					Chain: &chainInstall,
+				}, {
+					LiteralTestStep: &api.LiteralTestStep{
+						As:       "must-gather",
+						From:     "cli",
+						Commands: "gather",
+						Resources: api.ResourceRequirements{
+							Requests: api.ResourceList{"cpu": "1000m"},
+							Limits:   api.ResourceList{"memory": "2Gi"},
+						}},
+				}},
+			},
+			chainInstall: {
+				Parallel: true,
+				Steps: []api.TestStep{{
+					LiteralTestStep: &api.LiteralTestStep{
+						As:       "gather-audit-logs",
+						From:     "cli",
+						Commands: "gather audit",
+						Resources: api.ResourceRequirements{
+							Requests: api.ResourceList{"cpu": "1000m"},
+							Limits:   api.ResourceList{"memory": "2Gi"},
+						}},
+				}},
+			},
+		},
+		expectedRes: api.MultiStageTestConfigurationLiteral{
+			ClusterProfile: api.ClusterProfileAWS,
+			Post: []api.LiteralTestStep{{
+				As:            "gather-audit-logs",
+				From:          "cli",
+				Commands:      "gather audit",
+				ParallelGroup: nestedChains,
+				Resources: api.ResourceRequirements{
+					Requests: api.ResourceList{"cpu": "1000m"},
+					Limits:   api.ResourceList{"memory": "2Gi"},
+				},
+			}, {
+				As:            "must-gather",
+				From:          "cli",
+				Commands:      "gather",
+				ParallelGroup: nestedChains,
+				Resources: api.ResourceRequirements{
+					Requests: api.ResourceList{"cpu": "1000m"},
+					Limits:   api.ResourceList{"memory": "2Gi"},
+				},
+			}, {
+				As:       "teardown",
+				From:     "installer",
+				Commands: "openshift-cluster destroy",
+				Resources: api.ResourceRequirements{
+					Requests: api.ResourceList{"cpu": "1000m"},
+					Limits:   api.ResourceList{"memory": "2Gi"},
+				},
+			}},
+		},
 	}, {
 		name: "Test with duplicate names after unrolling chains",
 		config: api.MultiStageTestConfiguration{
@@ -967,6 +1048,18 @@ func TestResolveParameters(t *testing.T) {
 			}},
 		},
 		err: errors.New("test/test: step/step: unresolved parameter: UNRESOLVED"),
+	}, {
+		name: "invalid value for a typed parameter",
+		test: api.MultiStageTestConfiguration{
+			Test: []api.TestStep{{
+				LiteralTestStep: &api.LiteralTestStep{
+					As:          "step",
+					Environment: []api.StepParameter{{Name: "FIPS_ENABLED", Type: api.StepParameterTypeBool, Default: &defaultEmpty}},
+				},
+			}},
+			Environment: api.TestEnvironment{"FIPS_ENABLED": "ture"},
+		},
+		err: errors.New(`test/test: step/step: invalid value for parameter FIPS_ENABLED: "ture" is not a bool, must be true or false`),
 	}} {
 		t.Run(tc.name, func(t *testing.T) {
 			ret, err := NewResolver(refs, chains, workflows, observers).Resolve("test", tc.test)
@@ -1116,3 +1209,129 @@ func TestResolveLeasesCopy(t *testing.T) {
 	expected := []api.StepLease{{Count: 42}, {Count: 0}}
 	testhelper.Diff(t, "leases", leases, expected)
 }
+
+func TestResolvePinned(t *testing.T) {
+	step := func(as, commands string) api.LiteralTestStep {
+		return api.LiteralTestStep{As: as, From: "cli", Commands: commands}
+	}
+	install, teardown := "ipi-install", "ipi-teardown"
+	chain, workflow := "ipi", "ipi-aws"
+	current := Version{
+		References: ReferenceByName{install: step(install, "install v4"), teardown: step(teardown, "teardown v4")},
+		Chains:     ChainByName{chain: {As: chain, Steps: []api.TestStep{{Reference: &install}}}},
+		Workflows:  WorkflowByName{workflow: {Pre: []api.TestStep{{Chain: &chain}}, Post: []api.TestStep{{Reference: &teardown}}}},
+	}
+	v3 := Version{
+		References: ReferenceByName{install: step(install, "install v3"), teardown: step(teardown, "teardown v3")},
+		Chains:     ChainByName{chain: {As: chain, Steps: []api.TestStep{{Reference: &install}}}},
+		Workflows:  WorkflowByName{workflow: {Pre: []api.TestStep{{Chain: &chain}}, Post: []api.TestStep{{Reference: &teardown}}}},
+	}
+	digest, err := Digest(v3.References[install], v3)
+	if err != nil {
+		t.Fatalf("failed to determine digest: %v", err)
+	}
+	chainDigest, err := Digest(v3.Chains[chain], v3)
+	if err != nil {
+		t.Fatalf("failed to determine digest: %v", err)
+	}
+	workflowDigest, err := Digest(v3.Workflows[workflow], v3)
+	if err != nil {
+		t.Fatalf("failed to determine digest: %v", err)
+	}
+	pin := func(name, version string) *string {
+		pinned := name + VersionSeparator + version
+		return &pinned
+	}
+
+	for _, tc := range []struct {
+		name        string
+		config      api.MultiStageTestConfiguration
+		expected    api.MultiStageTestConfigurationLiteral
+		expectedErr error
+	}{{
+		name:     "unpinned names resolve to the current version",
+		config:   api.MultiStageTestConfiguration{Workflow: &workflow},
+		expected: api.MultiStageTestConfigurationLiteral{Pre: []api.LiteralTestStep{step(install, "install v4")}, Post: []api.LiteralTestStep{step(teardown, "teardown v4")}},
+	}, {
+		name:     "pinned workflow resolves its steps in the same version",
+		config:   api.MultiStageTestConfiguration{Workflow: pin(workflow, "v3")},
+		expected: api.MultiStageTestConfigurationLiteral{Pre: []api.LiteralTestStep{step(install, "install v3")}, Post: []api.LiteralTestStep{step(teardown, "teardown v3")}},
+	}, {
+		name:     "steps overriding a pinned workflow resolve to the current version",
+		config:   api.MultiStageTestConfiguration{Workflow: pin(workflow, "v3"), Post: []api.TestStep{{Reference: &teardown}}},
+		expected: api.MultiStageTestConfigurationLiteral{Pre: []api.LiteralTestStep{step(install, "install v3")}, Post: []api.LiteralTestStep{step(teardown, "teardown v4")}},
+	}, {
+		name:     "pinned chain and reference",
+		config:   api.MultiStageTestConfiguration{Pre: []api.TestStep{{Chain: pin(chain, "v3")}}, Post: []api.TestStep{{Reference: pin(teardown, "v3")}}},
+		expected: api.MultiStageTestConfigurationLiteral{Pre: []api.LiteralTestStep{step(install, "install v3")}, Post: []api.LiteralTestStep{step(teardown, "teardown v3")}},
+	}, {
+		name:     "reference pinned by digest",
+		config:   api.MultiStageTestConfiguration{Test: []api.TestStep{{Reference: pin(install, digest)}}},
+		expected: api.MultiStageTestConfigurationLiteral{Test: []api.LiteralTestStep{step(install, "install v3")}},
+	}, {
+		// the chain is the same in both versions, only the step it refers to
+		// was edited since
+		name:     "chain pinned by digest resolves the steps it was hashed with",
+		config:   api.MultiStageTestConfiguration{Test: []api.TestStep{{Chain: pin(chain, chainDigest)}}},
+		expected: api.MultiStageTestConfigurationLiteral{Test: []api.LiteralTestStep{step(install, "install v3")}},
+	}, {
+		name:     "workflow pinned by digest resolves the steps it was hashed with",
+		config:   api.MultiStageTestConfiguration{Workflow: pin(workflow, workflowDigest)},
+		expected: api.MultiStageTestConfigurationLiteral{Pre: []api.LiteralTestStep{step(install, "install v3")}, Post: []api.LiteralTestStep{step(teardown, "teardown v3")}},
+	}, {
+		name:        "unknown version",
+		config:      api.MultiStageTestConfiguration{Test: []api.TestStep{{Reference: pin(install, "v1")}}},
+		expectedErr: errors.New("test/pinned: invalid step reference: ipi-install@v1: unknown registry version v1"),
+	}, {
+		name:        "unknown digest",
+		config:      api.MultiStageTestConfiguration{Test: []api.TestStep{{Chain: pin(chain, "sha256:0000")}}},
+		expectedErr: errors.New("test/pinned: invalid step chain: ipi@sha256:0000: no version of ipi has the digest sha256:0000"),
+	}, {
+		name:        "component missing from the version",
+		config:      api.MultiStageTestConfiguration{Test: []api.TestStep{{Reference: pin("ipi-conf", "v3")}}},
+		expectedErr: errors.New("test/pinned: invalid step reference: ipi-conf@v3"),
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			resolver := NewVersionedResolver(current.References, current.Chains, current.Workflows, nil, VersionByName{"v3": v3})
+			actual, err := resolver.Resolve("pinned", tc.config)
+			if diff := cmp.Diff(tc.expectedErr, err, testhelper.EquateErrorMessage); diff != "" {
+				t.Fatalf("unexpected error: %s", diff)
+			}
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("unexpected result: %s", diff)
+			}
+		})
+	}
+}
+
+func TestValidatePinned(t *testing.T) {
+	install, pinnedInstall := "ipi-install", "ipi-install@v3"
+	chain, pinnedChain := "ipi", "ipi@v3"
+	refs := ReferenceByName{install: {As: install, From: "cli", Commands: "install"}}
+	for _, tc := range []struct {
+		name      string
+		chains    ChainByName
+		workflows WorkflowByName
+		expected  error
+	}{{
+		name:      "unpinned components",
+		chains:    ChainByName{chain: {As: chain, Steps: []api.TestStep{{Reference: &install}}}},
+		workflows: WorkflowByName{"ipi-aws": {Pre: []api.TestStep{{Chain: &chain}}}},
+	}, {
+		name:     "chain pinning a reference",
+		chains:   ChainByName{chain: {As: chain, Steps: []api.TestStep{{Reference: &pinnedInstall}}}},
+		expected: errors.New("chain/ipi: reference ipi-install@v3: only tests in ci-operator configurations can pin registry components to a version"),
+	}, {
+		name:      "workflow pinning a chain",
+		chains:    ChainByName{chain: {As: chain, Steps: []api.TestStep{{Reference: &install}}}},
+		workflows: WorkflowByName{"ipi-aws": {Pre: []api.TestStep{{Chain: &pinnedChain}}}},
+		expected:  errors.New("workflow/ipi-aws: chain ipi@v3: only tests in ci-operator configurations can pin registry components to a version"),
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Validate(refs, tc.chains, tc.workflows, nil)
+			if diff := cmp.Diff(tc.expected, err, testhelper.EquateErrorMessage); diff != "" {
+				t.Errorf("unexpected error: %s", diff)
+			}
+		})
+	}
+}
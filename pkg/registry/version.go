@@ -0,0 +1,283 @@
+package registry
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+const (
+	// VersionSeparator separates the name of a registry component from the
+	// version it is pinned to, as in `ipi-install@v3`.
+	VersionSeparator = "@"
+	// DigestPrefix marks a version which pins the content of a component
+	// instead of a named version of the registry.
+	DigestPrefix = "sha256:"
+)
+
+// Version holds the components of the registry at a historical version.
+type Version struct {
+	References ReferenceByName
+	Chains     ChainByName
+	Workflows  WorkflowByName
+	Observers  ObserverByName
+}
+
+type VersionByName map[string]Version
+
+// SplitVersion splits the name of a registry component into the plain name
+// and the version it is pinned to, which is empty for unpinned names.
+func SplitVersion(name string) (string, string) {
+	if i := strings.LastIndex(name, VersionSeparator); i != -1 {
+		return name[:i], name[i+len(VersionSeparator):]
+	}
+	return name, ""
+}
+
+// Digest determines the content hash a reference, chain or workflow can be
+// pinned to with `name@sha256:<hex>`. The components chains and workflows
+// refer to are looked up in the version and hashed with them, so a pin
+// covers every step the component expands to.
+func Digest(component interface{}, version Version) (string, error) {
+	return (&registry{}).withVersion(version).digest(component)
+}
+
+func (r *registry) digest(component interface{}) (string, error) {
+	expanded, err := r.expand(component, sets.NewString())
+	if err != nil {
+		return "", err
+	}
+	raw, err := json.Marshal(expanded)
+	if err != nil {
+		return "", fmt.Errorf("could not serialize component: %w", err)
+	}
+	return fmt.Sprintf("%s%x", DigestPrefix, sha256.Sum256(raw)), nil
+}
+
+// expandedChain is a chain with the components its steps refer to
+type expandedChain struct {
+	Chain api.RegistryChain `json:"chain"`
+	Steps []interface{}     `json:"steps"`
+}
+
+// expandedWorkflow is a workflow with the components its steps refer to
+type expandedWorkflow struct {
+	Workflow api.MultiStageTestConfiguration `json:"workflow"`
+	Pre      []interface{}                   `json:"pre"`
+	Test     []interface{}                   `json:"test"`
+	Post     []interface{}                   `json:"post"`
+}
+
+// expand replaces the names of the components a chain or workflow refers to
+// with their content in this registry, recursively. The chains being expanded
+// are tracked to reject cycles.
+func (r *registry) expand(component interface{}, chains sets.String) (interface{}, error) {
+	switch c := component.(type) {
+	case api.LiteralTestStep:
+		return c, nil
+	case api.RegistryChain:
+		steps, err := r.expandSteps(c.Steps, chains)
+		if err != nil {
+			return nil, err
+		}
+		return expandedChain{Chain: c, Steps: steps}, nil
+	case api.MultiStageTestConfiguration:
+		expanded := expandedWorkflow{Workflow: c}
+		for _, phase := range []struct {
+			steps []api.TestStep
+			into  *[]interface{}
+		}{{c.Pre, &expanded.Pre}, {c.Test, &expanded.Test}, {c.Post, &expanded.Post}} {
+			steps, err := r.expandSteps(phase.steps, chains)
+			if err != nil {
+				return nil, err
+			}
+			*phase.into = steps
+		}
+		return expanded, nil
+	default:
+		return nil, fmt.Errorf("unexpected component type %T", component)
+	}
+}
+
+func (r *registry) expandSteps(steps []api.TestStep, chains sets.String) ([]interface{}, error) {
+	var ret []interface{}
+	for _, step := range steps {
+		switch {
+		case step.Reference != nil:
+			ref, ok := r.stepsByName[*step.Reference]
+			if !ok {
+				return nil, fmt.Errorf("unknown step reference: %s", *step.Reference)
+			}
+			ret = append(ret, ref)
+		case step.Chain != nil:
+			chain, ok := r.chainsByName[*step.Chain]
+			if !ok {
+				return nil, fmt.Errorf("unknown step chain: %s", *step.Chain)
+			}
+			if chains.Has(*step.Chain) {
+				return nil, fmt.Errorf("cycle in step chain: %s", *step.Chain)
+			}
+			chains.Insert(*step.Chain)
+			expanded, err := r.expand(chain, chains)
+			chains.Delete(*step.Chain)
+			if err != nil {
+				return nil, err
+			}
+			ret = append(ret, expanded)
+		case step.LiteralTestStep != nil:
+			ret = append(ret, *step.LiteralTestStep)
+		}
+	}
+	return ret, nil
+}
+
+// checkUnpinned rejects steps of chains and workflows which are pinned to
+// a version: versions of the registry are only known when resolving tests,
+// components in the registry always refer to components of their version.
+func checkUnpinned(name string, phases ...[]api.TestStep) (ret []error) {
+	for _, steps := range phases {
+		for _, step := range steps {
+			var kind, pinned string
+			switch {
+			case step.Reference != nil:
+				kind, pinned = "reference", *step.Reference
+			case step.Chain != nil:
+				kind, pinned = "chain", *step.Chain
+			default:
+				continue
+			}
+			if _, version := SplitVersion(pinned); version != "" {
+				ret = append(ret, fmt.Errorf("%s: %s %s: only tests in ci-operator configurations can pin registry components to a version", name, kind, pinned))
+			}
+		}
+	}
+	return ret
+}
+
+// withVersion returns a registry serving the components of a version,
+// which resolves unpinned names in the components in the same version
+func (r *registry) withVersion(version Version) *registry {
+	return &registry{
+		stepsByName:     version.References,
+		chainsByName:    version.Chains,
+		workflowsByName: version.Workflows,
+		observersByName: version.Observers,
+		versions:        r.versions,
+	}
+}
+
+// pinned returns the registry holding the version of a component a name
+// is pinned to. Named versions are looked up directly, content digests
+// are searched for in this registry and all known versions, in order.
+func (r *registry) pinned(name, version string, lookup func(*registry, string) (interface{}, bool)) (*registry, error) {
+	if version == "" {
+		return r, nil
+	}
+	if !strings.HasPrefix(version, DigestPrefix) {
+		v, ok := r.versions[version]
+		if !ok {
+			return nil, fmt.Errorf("unknown registry version %s", version)
+		}
+		return r.withVersion(v), nil
+	}
+	candidates := []*registry{r}
+	for _, v := range sets.StringKeySet(r.versions).List() {
+		candidates = append(candidates, r.withVersion(r.versions[v]))
+	}
+	for _, candidate := range candidates {
+		component, ok := lookup(candidate, name)
+		if !ok {
+			continue
+		}
+		if digest, err := candidate.digest(component); err == nil && digest == version {
+			return candidate, nil
+		}
+	}
+	return nil, fmt.Errorf("no version of %s has the digest %s", name, version)
+}
+
+func lookupReference(r *registry, name string) (interface{}, bool) {
+	ref, ok := r.stepsByName[name]
+	return ref, ok
+}
+
+func lookupChain(r *registry, name string) (interface{}, bool) {
+	chain, ok := r.chainsByName[name]
+	return chain, ok
+}
+
+func lookupWorkflow(r *registry, name string) (interface{}, bool) {
+	workflow, ok := r.workflowsByName[name]
+	return workflow, ok
+}
+
+// LookupDigest returns the digest of the component a pin refers to, looking
+// up the version it is pinned to among the current components and the
+// versions of the registry. Pins without a version refer to the current
+// component.
+func LookupDigest(pin Pin, current Version, versions VersionByName) (string, bool) {
+	var lookup func(*registry, string) (interface{}, bool)
+	switch pin.Type {
+	case "reference":
+		lookup = lookupReference
+	case "chain":
+		lookup = lookupChain
+	case "workflow":
+		lookup = lookupWorkflow
+	default:
+		return "", false
+	}
+	r := &registry{versions: versions}
+	r = r.withVersion(current)
+	source, err := r.pinned(pin.Name, pin.Version, lookup)
+	if err != nil {
+		return "", false
+	}
+	component, ok := lookup(source, pin.Name)
+	if !ok {
+		return "", false
+	}
+	digest, err := source.digest(component)
+	if err != nil {
+		return "", false
+	}
+	return digest, true
+}
+
+// Pin is a registry component a test is pinned to a version of.
+type Pin struct {
+	// Type is the type of the component: reference, chain or workflow
+	Type    string
+	Name    string
+	Version string
+}
+
+// PinsIn lists the pinned components a test references directly, in the
+// order they appear.
+func PinsIn(config api.MultiStageTestConfiguration) []Pin {
+	var pins []Pin
+	add := func(kind, name string) {
+		if plain, version := SplitVersion(name); version != "" {
+			pins = append(pins, Pin{Type: kind, Name: plain, Version: version})
+		}
+	}
+	if config.Workflow != nil {
+		add("workflow", *config.Workflow)
+	}
+	for _, steps := range [][]api.TestStep{config.Pre, config.Test, config.Post} {
+		for _, step := range steps {
+			switch {
+			case step.Reference != nil:
+				add("reference", *step.Reference)
+			case step.Chain != nil:
+				add("chain", *step.Chain)
+			}
+		}
+	}
+	return pins
+}
@@ -623,34 +623,6 @@ func selectJobsForRegistryStep(node registry.Node, configs []*config.DataWithInf
 	return selectedPresubmits, selectedPeriodics
 }
 
-// getAffectedNodes returns a sorted list of all nodes affected by a seed list
-// of changed nodes. Affected node is either a directly changed node or any of
-// its ancestors. Each node is present at most once.
-func getAffectedNodes(changed []registry.Node) []registry.Node {
-	all := changed
-	for _, node := range changed {
-		all = append(all, node.Ancestors()...)
-	}
-
-	var worklist []registry.Node
-	seen := sets.NewString()
-	keyFunc := func(node registry.Node) string { return fmt.Sprintf("type=%d name=%s", node.Type(), node.Name()) }
-	for _, node := range all {
-		key := keyFunc(node)
-		if !seen.Has(key) {
-			seen.Insert(key)
-			worklist = append(worklist, node)
-		}
-	}
-	sort.Slice(worklist, func(i, j int) bool {
-		if worklist[i].Name() == worklist[j].Name() {
-			return worklist[i].Type() < worklist[j].Type()
-		}
-		return worklist[i].Name() < worklist[j].Name()
-	})
-	return worklist
-}
-
 func SelectJobsForChangedRegistry(regSteps []registry.Node, allPresubmits presubmitsByRepo, allPeriodics []prowconfig.Periodic, ciopConfigs config.DataByFilename, loggers Loggers) (config.Presubmits, config.Periodics) {
 	// We need a sorted index of ci-operator configs for deterministic behavior
 	var sortedConfigs []*config.DataWithInfo
@@ -664,7 +636,7 @@ func SelectJobsForChangedRegistry(regSteps []registry.Node, allPresubmits presub
 		return sortedConfigs[i].Info.Filename > sortedConfigs[j].Info.Filename
 	})
 
-	stepWorklist := getAffectedNodes(regSteps)
+	stepWorklist := registry.AffectedNodes(regSteps)
 
 	presubmitIndex := presubmitsByName{}
 	for _, jobs := range allPresubmits {
@@ -0,0 +1,250 @@
+// Package resolver determines the pull specs of the releases a job is
+// configured with. The release controllers and Cincinnati are queried
+// through a shared layer, which can cache their answers, fall back to
+// the last known good answer when they are unavailable and be replaced
+// by a file for offline use.
+package resolver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/release"
+	"github.com/openshift/ci-tools/pkg/release/candidate"
+	"github.com/openshift/ci-tools/pkg/release/official"
+	"github.com/openshift/ci-tools/pkg/release/prerelease"
+	"github.com/openshift/ci-tools/pkg/results"
+)
+
+const (
+	// ReasonUnavailable is the reason for errors when a release could not
+	// be resolved, neither by the services nor from previous answers.
+	ReasonUnavailable results.Reason = "release_resolution_unavailable"
+	// ReasonNotFound is the reason for errors when a release is not
+	// known to the file used for offline resolution.
+	ReasonNotFound results.Reason = "release_resolution_not_found"
+)
+
+// Resolver determines the pull spec of a candidate, prerelease or
+// official release.
+type Resolver interface {
+	Resolve(release api.UnresolvedRelease) (string, error)
+}
+
+// Entry records the pull spec a release was resolved to.
+type Entry struct {
+	Release  api.UnresolvedRelease `json:"release"`
+	PullSpec string                `json:"pull_spec"`
+	Resolved time.Time             `json:"resolved"`
+}
+
+// key identifies a release in the recorded entries
+func key(release api.UnresolvedRelease) (string, error) {
+	raw, err := json.Marshal(release)
+	if err != nil {
+		return "", fmt.Errorf("could not serialize release: %w", err)
+	}
+	return string(raw), nil
+}
+
+func describe(release api.UnresolvedRelease) string {
+	switch {
+	case release.Candidate != nil:
+		return fmt.Sprintf("candidate %s %s from stream %s", release.Candidate.Product, release.Candidate.Version, release.Candidate.Stream)
+	case release.Prerelease != nil:
+		return fmt.Sprintf("prerelease %s in %s", release.Prerelease.Product, release.Prerelease.VersionBounds.Query())
+	case release.Release != nil:
+		return fmt.Sprintf("release %s from channel %s", release.Release.Version, release.Release.Channel)
+	}
+	return "release"
+}
+
+type liveResolver struct {
+	client release.HTTPClient
+}
+
+// NewLive returns a resolver asking the release controllers and Cincinnati
+// on every call.
+func NewLive(client release.HTTPClient) Resolver {
+	return &liveResolver{client: client}
+}
+
+func (r *liveResolver) Resolve(release api.UnresolvedRelease) (string, error) {
+	switch {
+	case release.Candidate != nil:
+		return candidate.ResolvePullSpec(r.client, *release.Candidate)
+	case release.Prerelease != nil:
+		return prerelease.ResolvePullSpec(r.client, *release.Prerelease)
+	case release.Release != nil:
+		pullSpec, _, err := official.ResolvePullSpecAndVersion(r.client, *release.Release)
+		return pullSpec, err
+	}
+	return "", errors.New("release has neither a candidate, a prerelease nor a release to resolve")
+}
+
+// loadEntries reads the entries recorded in a file, which does not have to exist
+func loadEntries(path string) (map[string]Entry, error) {
+	entries := map[string]Entry{}
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return entries, nil
+		}
+		return nil, fmt.Errorf("could not read release resolutions from %s: %w", path, err)
+	}
+	var list []Entry
+	if err := json.Unmarshal(raw, &list); err != nil {
+		return nil, fmt.Errorf("could not parse release resolutions from %s: %w", path, err)
+	}
+	for _, entry := range list {
+		k, err := key(entry.Release)
+		if err != nil {
+			return nil, err
+		}
+		entries[k] = entry
+	}
+	return entries, nil
+}
+
+type fileResolver struct {
+	path    string
+	entries map[string]Entry
+}
+
+// NewFromFile returns a resolver which only answers with the entries
+// recorded in a file, without any network access. The file holds a
+// JSON list of entries, in the format the caching resolver persists.
+func NewFromFile(path string) (Resolver, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("could not read release resolutions from %s: %w", path, err)
+	}
+	entries, err := loadEntries(path)
+	if err != nil {
+		return nil, err
+	}
+	return &fileResolver{path: path, entries: entries}, nil
+}
+
+func (r *fileResolver) Resolve(release api.UnresolvedRelease) (string, error) {
+	k, err := key(release)
+	if err != nil {
+		return "", err
+	}
+	entry, ok := r.entries[k]
+	if !ok {
+		return "", results.ForReason(ReasonNotFound).ForError(fmt.Errorf("%s is not recorded in %s", describe(release), r.path))
+	}
+	return entry.PullSpec, nil
+}
+
+type cachingResolver struct {
+	delegate Resolver
+	// path is where the entries are persisted, if set
+	path string
+	// ttl is how long an answer is used without asking again
+	ttl time.Duration
+	// fallback is how old an answer may be to be used when
+	// the delegate fails, none are used when it is zero
+	fallback time.Duration
+	now      func() time.Time
+
+	lock    sync.Mutex
+	entries map[string]Entry
+}
+
+// NewCaching returns a resolver which remembers the answers of the delegate.
+// Answers younger than the TTL are reused without asking the delegate again.
+// When the delegate fails, the last known good answer is used if it is no
+// older than the fallback. When a path is given, the answers are persisted
+// in the file and survive the process.
+func NewCaching(delegate Resolver, path string, ttl, fallback time.Duration) (Resolver, error) {
+	entries := map[string]Entry{}
+	if path != "" {
+		var err error
+		if entries, err = loadEntries(path); err != nil {
+			return nil, err
+		}
+	}
+	return &cachingResolver{
+		delegate: delegate,
+		path:     path,
+		ttl:      ttl,
+		fallback: fallback,
+		now:      time.Now,
+		entries:  entries,
+	}, nil
+}
+
+func (r *cachingResolver) Resolve(release api.UnresolvedRelease) (string, error) {
+	k, err := key(release)
+	if err != nil {
+		return "", err
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	now := r.now()
+	cached, hasCached := r.entries[k]
+	if hasCached && now.Sub(cached.Resolved) < r.ttl {
+		logrus.Debugf("Using the pull spec %s resolved at %s for %s", cached.PullSpec, cached.Resolved.Format(time.RFC3339), describe(release))
+		return cached.PullSpec, nil
+	}
+
+	pullSpec, err := r.delegate.Resolve(release)
+	if err != nil {
+		if hasCached && now.Sub(cached.Resolved) < r.fallback {
+			logrus.WithError(err).Warnf("Failed to resolve %s, using the last known pull spec %s resolved at %s.", describe(release), cached.PullSpec, cached.Resolved.Format(time.RFC3339))
+			return cached.PullSpec, nil
+		}
+		return "", results.ForReason(ReasonUnavailable).ForError(err)
+	}
+
+	r.entries[k] = Entry{Release: release, PullSpec: pullSpec, Resolved: now}
+	if err := r.persist(); err != nil {
+		logrus.WithError(err).Warn("Failed to persist release resolutions.")
+	}
+	return pullSpec, nil
+}
+
+// persist writes the entries to the file, replacing it atomically so that
+// concurrent readers never observe a partial write
+func (r *cachingResolver) persist() error {
+	if r.path == "" {
+		return nil
+	}
+	var keys []string
+	for k := range r.entries {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	list := make([]Entry, 0, len(keys))
+	for _, k := range keys {
+		list = append(list, r.entries[k])
+	}
+	raw, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not serialize release resolutions: %w", err)
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(r.path), filepath.Base(r.path))
+	if err != nil {
+		return fmt.Errorf("could not create temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(raw); err != nil {
+		tmp.Close()
+		return fmt.Errorf("could not write release resolutions: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("could not write release resolutions: %w", err)
+	}
+	return os.Rename(tmp.Name(), r.path)
+}
@@ -0,0 +1,151 @@
+package resolver
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/results"
+)
+
+type fakeResolver struct {
+	pullSpec string
+	err      error
+	calls    int
+}
+
+func (f *fakeResolver) Resolve(api.UnresolvedRelease) (string, error) {
+	f.calls++
+	return f.pullSpec, f.err
+}
+
+func TestCachingResolver(t *testing.T) {
+	release := api.UnresolvedRelease{Candidate: &api.Candidate{Product: api.ReleaseProductOCP, Stream: api.ReleaseStreamNightly, Version: "4.10"}}
+	resolved := time.Date(2021, 10, 1, 12, 0, 0, 0, time.UTC)
+	cached := map[string]Entry{}
+	k, err := key(release)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cached[k] = Entry{Release: release, PullSpec: "cached", Resolved: resolved}
+
+	for _, tc := range []struct {
+		name     string
+		entries  map[string]Entry
+		delegate *fakeResolver
+		ttl      time.Duration
+		fallback time.Duration
+		now      time.Time
+
+		expected       string
+		expectedCalls  int
+		expectedReason []string
+	}{{
+		name:          "nothing cached, the delegate is asked",
+		entries:       map[string]Entry{},
+		delegate:      &fakeResolver{pullSpec: "live"},
+		now:           resolved,
+		expected:      "live",
+		expectedCalls: 1,
+	}, {
+		name:     "cached answer within the TTL is reused",
+		entries:  cached,
+		delegate: &fakeResolver{pullSpec: "live"},
+		ttl:      time.Hour,
+		now:      resolved.Add(30 * time.Minute),
+		expected: "cached",
+	}, {
+		name:          "cached answer past the TTL is refreshed",
+		entries:       cached,
+		delegate:      &fakeResolver{pullSpec: "live"},
+		ttl:           time.Hour,
+		now:           resolved.Add(2 * time.Hour),
+		expected:      "live",
+		expectedCalls: 1,
+	}, {
+		name:          "failure falls back to the last known good answer",
+		entries:       cached,
+		delegate:      &fakeResolver{err: errors.New("release controller is down")},
+		fallback:      24 * time.Hour,
+		now:           resolved.Add(2 * time.Hour),
+		expected:      "cached",
+		expectedCalls: 1,
+	}, {
+		name:           "failure without a recent enough answer is reported",
+		entries:        cached,
+		delegate:       &fakeResolver{err: errors.New("release controller is down")},
+		fallback:       time.Hour,
+		now:            resolved.Add(2 * time.Hour),
+		expectedCalls:  1,
+		expectedReason: []string{"release_resolution_unavailable"},
+	}, {
+		name:           "failure without fallback is reported",
+		entries:        cached,
+		delegate:       &fakeResolver{err: errors.New("release controller is down")},
+		now:            resolved,
+		expectedCalls:  1,
+		expectedReason: []string{"release_resolution_unavailable"},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			entries := map[string]Entry{}
+			for k, v := range tc.entries {
+				entries[k] = v
+			}
+			r := &cachingResolver{
+				delegate: tc.delegate,
+				ttl:      tc.ttl,
+				fallback: tc.fallback,
+				now:      func() time.Time { return tc.now },
+				entries:  entries,
+			}
+			pullSpec, err := r.Resolve(release)
+			if diff := cmp.Diff(tc.expectedReason, results.Reasons(err)); diff != "" {
+				t.Errorf("unexpected error reasons: %s", diff)
+			}
+			if pullSpec != tc.expected {
+				t.Errorf("expected pull spec %q, got %q", tc.expected, pullSpec)
+			}
+			if tc.delegate.calls != tc.expectedCalls {
+				t.Errorf("expected %d calls to the delegate, got %d", tc.expectedCalls, tc.delegate.calls)
+			}
+		})
+	}
+}
+
+func TestCachingResolverPersistsForFileResolver(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "resolutions.json")
+	candidate := api.UnresolvedRelease{Candidate: &api.Candidate{Product: api.ReleaseProductOCP, Stream: api.ReleaseStreamNightly, Version: "4.10"}}
+	official := api.UnresolvedRelease{Release: &api.Release{Version: "4.9", Channel: api.ReleaseChannelStable}}
+
+	caching, err := NewCaching(&fakeResolver{pullSpec: "live"}, path, 0, 0)
+	if err != nil {
+		t.Fatalf("failed to create caching resolver: %v", err)
+	}
+	if _, err := caching.Resolve(candidate); err != nil {
+		t.Fatalf("failed to resolve: %v", err)
+	}
+
+	offline, err := NewFromFile(path)
+	if err != nil {
+		t.Fatalf("failed to create file resolver: %v", err)
+	}
+	pullSpec, err := offline.Resolve(candidate)
+	if err != nil {
+		t.Errorf("failed to resolve recorded release: %v", err)
+	}
+	if pullSpec != "live" {
+		t.Errorf("expected the recorded pull spec, got %q", pullSpec)
+	}
+	_, err = offline.Resolve(official)
+	if diff := cmp.Diff([]string{"release_resolution_not_found"}, results.Reasons(err)); diff != "" {
+		t.Errorf("unexpected error reasons: %s", diff)
+	}
+
+	if _, err := NewFromFile(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("expected an error for a missing file, got none")
+	}
+}
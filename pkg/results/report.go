@@ -77,6 +77,24 @@ type Request struct {
 	Reason string `json:"reason"`
 }
 
+// LeaseRequest holds the data used to report a lease acquisition to an
+// aggregation server
+type LeaseRequest struct {
+	// JobName is the name of the job which acquired the lease
+	JobName string `json:"job_name"`
+	// Org and Repo identify on whose behalf the lease was acquired
+	Org  string `json:"org"`
+	Repo string `json:"repo"`
+	// PriorityClass is the priority class of the job
+	PriorityClass string `json:"priority_class,omitempty"`
+	// ResourceType is the type of the leased resource
+	ResourceType string `json:"resource_type"`
+	// WaitSeconds is how long the acquisition took
+	WaitSeconds float64 `json:"wait_seconds"`
+	// Denied is set when the acquisition was held back by the lease quota
+	Denied bool `json:"denied"`
+}
+
 const (
 	StateSucceeded string = "succeeded"
 	StateFailed    string = "failed"
@@ -87,11 +105,15 @@ type Reporter interface {
 	// This action is best-effort and errors are logged but not exposed.
 	// Err may be nil in which case a success is reported.
 	Report(err error)
+	// ReportLease sends a report for a lease acquisition to an aggregation
+	// server, on the same terms as Report.
+	ReportLease(request LeaseRequest)
 }
 
 type noopReporter struct{}
 
-func (r *noopReporter) Report(err error) {}
+func (r *noopReporter) Report(err error)                 {}
+func (r *noopReporter) ReportLease(request LeaseRequest) {}
 
 type reporter struct {
 	client             *http.Client
@@ -122,20 +144,29 @@ func (r *reporter) Report(err error) {
 	}
 }
 
-func (r *reporter) report(request Request) {
-	data, err := json.Marshal(request)
-	if err != nil {
-		logrus.Tracef("could not marshal request: %v", err)
-		return
-	}
+func (r *reporter) ReportLease(request LeaseRequest) {
+	request.JobName = r.spec.Job
+	logrus.Debugf("Reporting acquisition of %s lease after %.0fs", request.ResourceType, request.WaitSeconds)
+	r.post("lease", request)
+}
 
+func (r *reporter) report(request Request) {
 	reportMsg := fmt.Sprintf("Reporting job state '%s'", request.State)
 	if request.State != StateSucceeded {
 		reportMsg = fmt.Sprintf("Reporting job state '%s' with reason '%s'", request.State, request.Reason)
 	}
 
 	logrus.Debugf(reportMsg)
-	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/result", r.address), bytes.NewReader(data))
+	r.post("result", request)
+}
+
+func (r *reporter) post(path string, request interface{}) {
+	data, err := json.Marshal(request)
+	if err != nil {
+		logrus.Tracef("could not marshal request: %v", err)
+		return
+	}
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/%s", r.address, path), bytes.NewReader(data))
 	if err != nil {
 		logrus.Tracef("could not create report request: %v", err)
 		return
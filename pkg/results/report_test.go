@@ -104,6 +104,38 @@ func TestReporter_Report(t *testing.T) {
 	}
 }
 
+func TestReporter_ReportLease(t *testing.T) {
+	var actual string
+	testServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/lease" {
+			t.Errorf("incorrect path to report a lease: %s", r.URL.Path)
+			http.Error(w, "400 Bad Request", http.StatusBadRequest)
+			return
+		}
+		raw, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("failed to read report body: %v", err)
+		}
+		actual = string(raw)
+	}))
+	defer testServer.Close()
+
+	reporter := reporter{
+		client: &http.Client{
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+			},
+		},
+		address: testServer.URL,
+		spec:    &api.JobSpec{JobSpec: downwardapi.JobSpec{Job: "runme", Type: v1.PeriodicJob}},
+	}
+	reporter.ReportLease(LeaseRequest{Org: "org", Repo: "repo", ResourceType: "aws-quota-slice", WaitSeconds: 90, Denied: true})
+	expected := `{"job_name":"runme","org":"org","repo":"repo","resource_type":"aws-quota-slice","wait_seconds":90,"denied":true}`
+	if actual != expected {
+		t.Errorf("got incorrect report: expected %v, got %v", expected, actual)
+	}
+}
+
 func TestOptions_Reporter(t *testing.T) {
 	// this simulates the flow for ci-operator while we migrate to using the tool
 	options := Options{} // no flags set
@@ -4,6 +4,8 @@ import (
 	"context"
 	"io"
 
+	"github.com/sirupsen/logrus"
+
 	"k8s.io/client-go/rest"
 
 	buildapi "github.com/openshift/api/build/v1"
@@ -12,21 +14,54 @@ import (
 	"github.com/openshift/ci-tools/pkg/steps/loggingclient"
 )
 
+// BuildBackend determines how images are built.
+type BuildBackend string
+
+const (
+	// BuildBackendOpenShift runs builds with the OpenShift Build API.
+	BuildBackendOpenShift BuildBackend = "openshift"
+	// BuildBackendBuildah runs builds in pods with buildah, for clusters
+	// which do not serve the Build API.
+	BuildBackendBuildah BuildBackend = "buildah"
+)
+
+const (
+	// LayerCacheAnnotation is set on builds which reuse the layers of earlier
+	// builds and holds the repository the layers are cached in.
+	LayerCacheAnnotation = "ci.openshift.io/layer-cache"
+	// LayerCacheSecretAnnotation is set on builds which refresh the layer
+	// cache and names the secret with the credentials to push to it.
+	LayerCacheSecretAnnotation = "ci.openshift.io/layer-cache-secret"
+)
+
 type BuildClient interface {
 	loggingclient.LoggingClient
+	ManifestListPusher
+	// Build runs the build to completion, pushing its output into the
+	// image stream tag the build is configured with.
+	Build(ctx context.Context, build *buildapi.Build) error
 	Logs(namespace, name string, options *buildapi.BuildLogOptions) (io.ReadCloser, error)
 }
 
 type buildClient struct {
 	loggingclient.LoggingClient
+	ManifestListPusher
 	client rest.Interface
 }
 
-func NewBuildClient(client loggingclient.LoggingClient, restClient rest.Interface) BuildClient {
+func NewBuildClient(client loggingclient.LoggingClient, restClient rest.Interface, manifestListPusher ManifestListPusher) BuildClient {
 	return &buildClient{
-		LoggingClient: client,
-		client:        restClient,
+		LoggingClient:      client,
+		ManifestListPusher: manifestListPusher,
+		client:             restClient,
+	}
+}
+
+func (c *buildClient) Build(ctx context.Context, build *buildapi.Build) error {
+	if _, ok := build.Annotations[LayerCacheAnnotation]; ok {
+		logrus.Infof("The Build API cannot reuse cached layers, building %s without them.", build.Name)
 	}
+	return handleBuild(ctx, c, build)
 }
 
 func (c *buildClient) Logs(namespace, name string, options *buildapi.BuildLogOptions) (io.ReadCloser, error) {
@@ -0,0 +1,348 @@
+package steps
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	coreapi "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	buildapi "github.com/openshift/api/build/v1"
+	imagev1 "github.com/openshift/api/image/v1"
+
+	"github.com/openshift/ci-tools/pkg/events"
+)
+
+const (
+	buildahImage = "quay.io/buildah/stable:v1.28.0"
+	// buildContainerName matches the name of the container in the pods of
+	// OpenShift builds, so build logs and resource usage look the same for
+	// both backends
+	buildContainerName = "docker"
+	// buildServiceAccount is allowed to pull from and push to the image
+	// streams in the namespace. OpenShift creates it in every namespace, on
+	// other clusters it has to be provisioned for the buildah backend.
+	buildServiceAccount = "builder"
+
+	buildahWorkDir        = "/tmp/build"
+	buildahCertDir        = "/tmp/certs"
+	buildahStorageDir     = "/var/lib/containers"
+	buildahSecretsDir     = "/var/run/secrets/build"
+	buildahPullSecretDir  = "/var/run/secrets/pull"
+	buildahCacheSecretDir = "/var/run/secrets/layer-cache"
+	serviceAccountDir     = "/var/run/secrets/kubernetes.io/serviceaccount"
+)
+
+type podBuildClient struct {
+	PodClient
+	ManifestListPusher
+}
+
+// NewPodBuildClient creates a client which runs builds in pods with buildah
+// instead of using the OpenShift Build API. The builds are described as for
+// the Build API and translated into pods, which push their output into the
+// integrated registry. The pods run as the builder service account, which
+// has to exist in the namespace and be allowed to push to the registry.
+func NewPodBuildClient(podClient PodClient, manifestListPusher ManifestListPusher) BuildClient {
+	return &podBuildClient{
+		PodClient:          podClient,
+		ManifestListPusher: manifestListPusher,
+	}
+}
+
+// buildPodName mirrors the naming of the pods of OpenShift builds.
+func buildPodName(build string) string {
+	return fmt.Sprintf("%s-build", build)
+}
+
+func (c *podBuildClient) Build(ctx context.Context, build *buildapi.Build) error {
+	pod, err := c.buildPod(ctx, build)
+	if err != nil {
+		return fmt.Errorf("could not create pod for build %s: %w", build.Name, err)
+	}
+	if _, err := RunPod(ctx, c.PodClient, pod); err != nil {
+		return fmt.Errorf("the build %s failed: %w", build.Name, err)
+	}
+	if to := build.Spec.Output.To; to != nil {
+		events.Record(ctx, events.Event{Type: events.ImagePushed, Details: map[string]string{"build": build.Name, "image": to.Name}})
+	}
+	if err := gatherSuccessfulBuildLog(c, build.Namespace, build.Name); err != nil {
+		// log error but do not fail successful build
+		logrus.WithError(err).Warnf("Failed gathering successful build %s logs into artifacts.", build.Name)
+	}
+	return nil
+}
+
+func (c *podBuildClient) Logs(namespace, name string, options *buildapi.BuildLogOptions) (io.ReadCloser, error) {
+	return c.GetLogs(namespace, buildPodName(name), &coreapi.PodLogOptions{
+		Container:  buildContainerName,
+		Timestamps: options.Timestamps,
+	}).Stream(context.TODO())
+}
+
+func (c *podBuildClient) buildPod(ctx context.Context, build *buildapi.Build) (*coreapi.Pod, error) {
+	script, err := buildahScript(build, func(ref coreapi.ObjectReference) (string, error) {
+		return resolvePullSpec(ctx, c, build.Namespace, ref)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return podForBuild(build, script), nil
+}
+
+// resolvePullSpec determines the pull spec of an image referenced by a build.
+func resolvePullSpec(ctx context.Context, client ctrlruntimeclient.Client, namespace string, ref coreapi.ObjectReference) (string, error) {
+	switch ref.Kind {
+	case "DockerImage":
+		return ref.Name, nil
+	case "ImageStreamTag":
+		parts := strings.SplitN(ref.Name, ":", 2)
+		if len(parts) != 2 {
+			return "", fmt.Errorf("invalid image stream tag %s", ref.Name)
+		}
+		if ref.Namespace != "" {
+			namespace = ref.Namespace
+		}
+		stream := &imagev1.ImageStream{}
+		if err := client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: namespace, Name: parts[0]}, stream); err != nil {
+			return "", fmt.Errorf("could not get image stream %s/%s: %w", namespace, parts[0], err)
+		}
+		if stream.Status.DockerImageRepository == "" {
+			return "", fmt.Errorf("image stream %s/%s has no repository in the integrated registry", namespace, parts[0])
+		}
+		return fmt.Sprintf("%s:%s", stream.Status.DockerImageRepository, parts[1]), nil
+	default:
+		return "", fmt.Errorf("unsupported image reference kind %s", ref.Kind)
+	}
+}
+
+// buildahScript generates the script which reproduces the build with
+// buildah: it assembles the context directory from the image and secret
+// sources, builds the Dockerfile and pushes the image.
+func buildahScript(build *buildapi.Build, resolve func(coreapi.ObjectReference) (string, error)) (string, error) {
+	source, strategy := build.Spec.Source, build.Spec.Strategy.DockerStrategy
+	if strategy == nil {
+		return "", fmt.Errorf("only builds with the docker strategy are supported")
+	}
+	if source.Git != nil || source.Binary != nil {
+		return "", fmt.Errorf("git and binary build sources are not supported")
+	}
+	if build.Spec.Output.To == nil {
+		return "", fmt.Errorf("the build has no output")
+	}
+
+	var authFile string
+	if strategy.PullSecret != nil {
+		authFile = path.Join(buildahPullSecretDir, coreapi.DockerConfigJsonKey)
+	}
+	// images in the integrated registry are accessed with the token of the
+	// service account, others with the pull secret, if any
+	auth := func(ref coreapi.ObjectReference) string {
+		if ref.Kind == "ImageStreamTag" {
+			return fmt.Sprintf(`--creds "serviceaccount:${token}" --cert-dir %s `, buildahCertDir)
+		}
+		if authFile != "" {
+			return fmt.Sprintf("--authfile %s ", authFile)
+		}
+		return ""
+	}
+	contextDir := path.Join(buildahWorkDir, source.ContextDir)
+	dockerfile := path.Join(contextDir, "Dockerfile")
+	if strategy.DockerfilePath != "" {
+		dockerfile = path.Join(contextDir, strategy.DockerfilePath)
+	}
+
+	lines := []string{
+		"set -o errexit -o nounset -o pipefail",
+		fmt.Sprintf("mkdir -p %s %s", buildahWorkDir, buildahCertDir),
+		// only OpenShift injects the CA of the integrated registry
+		fmt.Sprintf("if [[ -f %s/service-ca.crt ]]; then cp %s/service-ca.crt %s/; fi", serviceAccountDir, serviceAccountDir, buildahCertDir),
+		fmt.Sprintf(`token="$(cat %s/token)"`, serviceAccountDir),
+	}
+	for _, image := range source.Images {
+		pullSpec, err := resolve(image.From)
+		if err != nil {
+			return "", err
+		}
+		if len(image.Paths) > 0 {
+			lines = append(lines,
+				fmt.Sprintf(`container="$(buildah from --pull-always %s%s)"`, auth(image.From), shellQuote(pullSpec)),
+				`mount="$(buildah mount "${container}")"`,
+			)
+			for _, p := range image.Paths {
+				destination := path.Join(buildahWorkDir, p.DestinationDir)
+				lines = append(lines,
+					fmt.Sprintf("mkdir -p %s", shellQuote(destination)),
+					fmt.Sprintf(`cp -a "${mount}"%s %s`, shellQuote(p.SourcePath), shellQuote(destination)),
+				)
+			}
+			lines = append(lines, `buildah umount "${container}"`, `buildah rm "${container}"`)
+		}
+		if len(image.As) > 0 {
+			lines = append(lines, fmt.Sprintf("buildah pull %s%s", auth(image.From), shellQuote(pullSpec)))
+			for _, as := range image.As {
+				lines = append(lines, fmt.Sprintf("buildah tag %s %s", shellQuote(pullSpec), shellQuote(as)))
+			}
+		}
+	}
+	for _, secret := range source.Secrets {
+		destination := path.Join(buildahWorkDir, secret.DestinationDir)
+		lines = append(lines,
+			fmt.Sprintf("mkdir -p %s", shellQuote(destination)),
+			// skip the internal links of the secret volume
+			fmt.Sprintf(`find %s -mindepth 1 -maxdepth 1 ! -name '..*' -exec cp -rL {} %s \;`, shellQuote(path.Join(buildahSecretsDir, secret.Secret.Name)), shellQuote(destination)),
+		)
+	}
+	if source.Dockerfile != nil {
+		lines = append(lines,
+			fmt.Sprintf("mkdir -p %s", shellQuote(contextDir)),
+			fmt.Sprintf("printf '%%s' %s > %s", shellQuote(*source.Dockerfile), shellQuote(dockerfile)),
+		)
+	}
+	if strategy.From != nil {
+		// like the Build API, replace the image of the last stage
+		pullSpec, err := resolve(*strategy.From)
+		if err != nil {
+			return "", err
+		}
+		lines = append(lines,
+			fmt.Sprintf("buildah pull %s%s", auth(*strategy.From), shellQuote(pullSpec)),
+			fmt.Sprintf(`buildah tag %s "$(awk 'toupper($1) == "FROM" { image = $2 } END { print image }' %s)"`, shellQuote(pullSpec), shellQuote(dockerfile)),
+		)
+	}
+	if len(strategy.Env) > 0 {
+		// like the Build API, set the environment in every stage
+		var env []string
+		for _, e := range strategy.Env {
+			env = append(env, fmt.Sprintf("%s=%s", e.Name, dockerfileQuote(e.Value)))
+		}
+		lines = append(lines,
+			fmt.Sprintf(`ENV_INSTRUCTION=%s awk '{ print } toupper($1) == "FROM" { print ENVIRON["ENV_INSTRUCTION"] }' %s > %s.env`, shellQuote("ENV "+strings.Join(env, " ")), shellQuote(dockerfile), shellQuote(dockerfile)),
+			fmt.Sprintf("mv %s.env %s", shellQuote(dockerfile), shellQuote(dockerfile)),
+		)
+	}
+
+	output, err := resolve(*build.Spec.Output.To)
+	if err != nil {
+		return "", err
+	}
+	bud := []string{"buildah bud"}
+	if cache, ok := build.Annotations[LayerCacheAnnotation]; ok {
+		// the cache is keyed by the layers of the base image and the
+		// instructions leading up to a layer, so it can always be used
+		bud = append(bud, "--layers", "--cache-from", shellQuote(cache))
+		if _, ok := build.Annotations[LayerCacheSecretAnnotation]; ok {
+			// buildah reads a single authfile, the one allowed to push to
+			// the cache also needs to be allowed to pull the images
+			authFile = path.Join(buildahCacheSecretDir, coreapi.DockerConfigJsonKey)
+			bud = append(bud, "--cache-to", shellQuote(cache))
+		}
+	} else if strategy.NoCache {
+		bud = append(bud, "--no-cache")
+	}
+	if authFile != "" {
+		bud = append(bud, "--authfile", authFile)
+	}
+	for _, arg := range strategy.BuildArgs {
+		bud = append(bud, "--build-arg", shellQuote(fmt.Sprintf("%s=%s", arg.Name, arg.Value)))
+	}
+	for _, label := range build.Spec.Output.ImageLabels {
+		bud = append(bud, "--label", shellQuote(fmt.Sprintf("%s=%s", label.Name, label.Value)))
+	}
+	bud = append(bud, "--file", shellQuote(dockerfile), "--tag", shellQuote(output), shellQuote(contextDir))
+	lines = append(lines,
+		strings.Join(bud, " "),
+		fmt.Sprintf("buildah push %s%s %s", auth(*build.Spec.Output.To), shellQuote(output), shellQuote("docker://"+output)),
+	)
+	return strings.Join(lines, "\n") + "\n", nil
+}
+
+// podForBuild creates the pod which runs the build script. The pod carries
+// the labels of the build, so it is treated like the pods of the steps.
+func podForBuild(build *buildapi.Build, script string) *coreapi.Pod {
+	labels := map[string]string{}
+	for k, v := range build.Labels {
+		labels[k] = v
+	}
+	labels[buildapi.BuildLabel] = build.Name
+	annotations := map[string]string{}
+	for k, v := range build.Annotations {
+		annotations[k] = v
+	}
+	privileged := true
+	pod := &coreapi.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            buildPodName(build.Name),
+			Namespace:       build.Namespace,
+			Labels:          labels,
+			Annotations:     annotations,
+			OwnerReferences: build.OwnerReferences,
+		},
+		Spec: coreapi.PodSpec{
+			RestartPolicy:      coreapi.RestartPolicyNever,
+			ServiceAccountName: buildServiceAccount,
+			NodeSelector:       build.Spec.NodeSelector,
+			Containers: []coreapi.Container{{
+				Name:    buildContainerName,
+				Image:   buildahImage,
+				Command: []string{"/bin/bash", "-c", script},
+				Env: []coreapi.EnvVar{
+					{Name: "STORAGE_DRIVER", Value: "vfs"},
+					{Name: "BUILDAH_ISOLATION", Value: "chroot"},
+				},
+				Resources:       build.Spec.Resources,
+				SecurityContext: &coreapi.SecurityContext{Privileged: &privileged},
+				VolumeMounts: []coreapi.VolumeMount{
+					{Name: "build", MountPath: buildahWorkDir},
+					{Name: "storage", MountPath: buildahStorageDir},
+				},
+			}},
+			Volumes: []coreapi.Volume{
+				{Name: "build", VolumeSource: coreapi.VolumeSource{EmptyDir: &coreapi.EmptyDirVolumeSource{}}},
+				{Name: "storage", VolumeSource: coreapi.VolumeSource{EmptyDir: &coreapi.EmptyDirVolumeSource{}}},
+			},
+		},
+	}
+	container := &pod.Spec.Containers[0]
+	if pullSecret := build.Spec.Strategy.DockerStrategy.PullSecret; pullSecret != nil {
+		container.VolumeMounts = append(container.VolumeMounts, coreapi.VolumeMount{Name: "pull-secret", MountPath: buildahPullSecretDir, ReadOnly: true})
+		pod.Spec.Volumes = append(pod.Spec.Volumes, coreapi.Volume{
+			Name:         "pull-secret",
+			VolumeSource: coreapi.VolumeSource{Secret: &coreapi.SecretVolumeSource{SecretName: pullSecret.Name}},
+		})
+	}
+	if _, cached := build.Annotations[LayerCacheAnnotation]; cached {
+		if secret, ok := build.Annotations[LayerCacheSecretAnnotation]; ok {
+			container.VolumeMounts = append(container.VolumeMounts, coreapi.VolumeMount{Name: "layer-cache-secret", MountPath: buildahCacheSecretDir, ReadOnly: true})
+			pod.Spec.Volumes = append(pod.Spec.Volumes, coreapi.Volume{
+				Name:         "layer-cache-secret",
+				VolumeSource: coreapi.VolumeSource{Secret: &coreapi.SecretVolumeSource{SecretName: secret}},
+			})
+		}
+	}
+	for i, secret := range build.Spec.Source.Secrets {
+		name := fmt.Sprintf("build-secret-%d", i)
+		container.VolumeMounts = append(container.VolumeMounts, coreapi.VolumeMount{Name: name, MountPath: path.Join(buildahSecretsDir, secret.Secret.Name), ReadOnly: true})
+		pod.Spec.Volumes = append(pod.Spec.Volumes, coreapi.Volume{
+			Name:         name,
+			VolumeSource: coreapi.VolumeSource{Secret: &coreapi.SecretVolumeSource{SecretName: secret.Secret.Name}},
+		})
+	}
+	return pod
+}
+
+// shellQuote quotes the value for bash.
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'"'"'`) + "'"
+}
+
+// dockerfileQuote quotes the value for an ENV instruction.
+func dockerfileQuote(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `\$`).Replace(value) + `"`
+}
@@ -0,0 +1,125 @@
+package steps
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	coreapi "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	prowapi "k8s.io/test-infra/prow/apis/prowjobs/v1"
+	"k8s.io/test-infra/prow/pod-utils/downwardapi"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	buildapi "github.com/openshift/api/build/v1"
+	imagev1 "github.com/openshift/api/image/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/steps/loggingclient"
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestBuildPod(t *testing.T) {
+	jobSpec := &api.JobSpec{
+		JobSpec: downwardapi.JobSpec{
+			Job:       "job",
+			BuildID:   "buildId",
+			ProwJobID: "prowJobId",
+			Refs: &prowapi.Refs{
+				Org:     "org",
+				Repo:    "repo",
+				BaseRef: "master",
+				BaseSHA: "masterSHA",
+			},
+		},
+	}
+	jobSpec.SetNamespace("namespace")
+	pullSecret := &coreapi.Secret{ObjectMeta: meta.ObjectMeta{Name: PullSecretName}}
+	dockerfile := "FROM pipeline:root\nRUN make\n"
+
+	var testCases = []struct {
+		name        string
+		build       *buildapi.Build
+		expectedErr string
+	}{
+		{
+			name: "project image with inputs and build args",
+			build: buildFromSource(jobSpec, api.PipelineImageStreamTagReferenceRoot, "component", buildapi.BuildSource{
+				Type:       buildapi.BuildSourceImage,
+				ContextDir: "images/component",
+				Images: []buildapi.ImageSource{
+					{
+						From:  coreapi.ObjectReference{Kind: "ImageStreamTag", Name: "pipeline:src"},
+						Paths: []buildapi.ImageSourcePath{{SourcePath: "/go/src/github.com/org/repo/.", DestinationDir: "."}},
+					},
+					{
+						From: coreapi.ObjectReference{Kind: "ImageStreamTag", Name: "pipeline:base"},
+						As:   []string{"registry.ci.openshift.org/ocp/4.10:base"},
+					},
+				},
+			}, "sha256:root", "Dockerfile.rhel", api.ResourceConfiguration{"*": {Requests: map[string]string{"cpu": "100m"}}}, pullSecret, []api.BuildArg{{Name: "TAGS", Value: "release's"}}),
+		},
+		{
+			name: "inline Dockerfile with secrets and environment",
+			build: func() *buildapi.Build {
+				build := buildFromSource(jobSpec, "", api.PipelineImageStreamTagReferenceSource, buildapi.BuildSource{
+					Type:       buildapi.BuildSourceDockerfile,
+					Dockerfile: &dockerfile,
+					Images: []buildapi.ImageSource{{
+						From:  coreapi.ObjectReference{Kind: "DockerImage", Name: "quay.io/org/clonerefs:latest"},
+						Paths: []buildapi.ImageSourcePath{{SourcePath: "/clonerefs", DestinationDir: "."}},
+					}},
+					Secrets: []buildapi.SecretBuildSource{{Secret: coreapi.LocalObjectReference{Name: "ssh-nykd6bfg"}, DestinationDir: "ssh"}},
+				}, "", "", api.ResourceConfiguration{"*": {Requests: map[string]string{"cpu": "100m"}}}, nil, nil)
+				build.Spec.Strategy.DockerStrategy.From = &coreapi.ObjectReference{Kind: "ImageStreamTag", Name: "pipeline:root"}
+				build.Spec.Strategy.DockerStrategy.Env = append(build.Spec.Strategy.DockerStrategy.Env, coreapi.EnvVar{Name: "CLONEREFS_OPTIONS", Value: `{"src_root":"$GOPATH"}`})
+				return build
+			}(),
+		},
+		{
+			name: "project image refreshing its layer cache",
+			build: func() *buildapi.Build {
+				build := buildFromSource(jobSpec, api.PipelineImageStreamTagReferenceRoot, "component", buildapi.BuildSource{
+					Type: buildapi.BuildSourceImage,
+					Images: []buildapi.ImageSource{{
+						From:  coreapi.ObjectReference{Kind: "ImageStreamTag", Name: "pipeline:src"},
+						Paths: []buildapi.ImageSourcePath{{SourcePath: "/go/src/github.com/org/repo/.", DestinationDir: "."}},
+					}},
+				}, "sha256:root", "", api.ResourceConfiguration{"*": {Requests: map[string]string{"cpu": "100m"}}}, pullSecret, nil)
+				build.Annotations[LayerCacheAnnotation] = "registry.ci.openshift.org/build-cache/org-repo-master-component"
+				build.Annotations[LayerCacheSecretAnnotation] = api.RegistryPushCredentialsCICentralSecret
+				return build
+			}(),
+		},
+		{
+			name: "git source is not supported",
+			build: buildFromSource(jobSpec, "", api.PipelineImageStreamTagReferenceRoot, buildapi.BuildSource{
+				Type: buildapi.BuildSourceGit,
+				Git:  &buildapi.GitBuildSource{URI: "https://github.com/org/repo.git"},
+			}, "", "", api.ResourceConfiguration{"*": {Requests: map[string]string{"cpu": "100m"}}}, nil, nil),
+			expectedErr: "git and binary build sources are not supported",
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			client := &podBuildClient{PodClient: &fakePodClient{fakePodExecutor: &fakePodExecutor{LoggingClient: loggingclient.New(
+				fakectrlruntimeclient.NewClientBuilder().WithObjects(&imagev1.ImageStream{
+					ObjectMeta: meta.ObjectMeta{Namespace: "namespace", Name: api.PipelineImageStream},
+					Status:     imagev1.ImageStreamStatus{DockerImageRepository: "image-registry.openshift-image-registry.svc:5000/namespace/pipeline"},
+				}).Build(),
+			)}}}
+			pod, err := client.buildPod(context.Background(), testCase.build)
+			var actualErr string
+			if err != nil {
+				actualErr = err.Error()
+			}
+			if diff := cmp.Diff(testCase.expectedErr, actualErr); diff != "" {
+				t.Fatalf("unexpected error: %s", diff)
+			}
+			if err == nil {
+				testhelper.CompareWithFixture(t, pod)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package steps
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+
+	coreapi "k8s.io/api/core/v1"
+	"k8s.io/client-go/util/retry"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	imagev1 "github.com/openshift/api/image/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/junit"
+	"github.com/openshift/ci-tools/pkg/steps/loggingclient"
+)
+
+// resultCacheAnnotationPrefix prefixes the annotations on the pipeline image
+// stream which record the input hash for which a step passed.
+const resultCacheAnnotationPrefix = "ci-operator.openshift.io/passed."
+
+// cachedStep wraps another step and skips it when it has already passed for
+// the same inputs.
+type cachedStep struct {
+	wrapped api.Step
+	client  loggingclient.LoggingClient
+	jobSpec *api.JobSpec
+	cached  bool
+}
+
+// CachedStep wraps a step so that it is only run when it has not passed for
+// the same inputs before. Passing results are recorded on the pipeline image
+// stream, keyed by the input hash of the job.
+func CachedStep(wrapped api.Step, client loggingclient.LoggingClient, jobSpec *api.JobSpec) api.Step {
+	return &cachedStep{wrapped: wrapped, client: client, jobSpec: jobSpec}
+}
+
+func (s *cachedStep) Inputs() (api.InputDefinition, error) { return s.wrapped.Inputs() }
+func (s *cachedStep) Validate() error                      { return s.wrapped.Validate() }
+func (s *cachedStep) Name() string                         { return s.wrapped.Name() }
+func (s *cachedStep) Description() string                  { return s.wrapped.Description() }
+func (s *cachedStep) Requires() []api.StepLink             { return s.wrapped.Requires() }
+func (s *cachedStep) Creates() []api.StepLink              { return s.wrapped.Creates() }
+func (s *cachedStep) Provides() api.ParameterMap           { return s.wrapped.Provides() }
+func (s *cachedStep) Objects() []ctrlruntimeclient.Object  { return s.wrapped.Objects() }
+
+func (s *cachedStep) SubTests() []*junit.TestCase {
+	if s.cached {
+		return []*junit.TestCase{{
+			Name:      s.Description(),
+			SystemOut: fmt.Sprintf("Passed in a previous run with the same inputs (%s), the result was reused.", s.jobSpec.InputHash()),
+		}}
+	}
+	if subTests, ok := s.wrapped.(subtestReporter); ok {
+		return subTests.SubTests()
+	}
+	return nil
+}
+
+func (s *cachedStep) SubSteps() []api.CIOperatorStepDetailInfo {
+	if subSteps, ok := s.wrapped.(SubStepReporter); ok && !s.cached {
+		return subSteps.SubSteps()
+	}
+	return nil
+}
+
+func (s *cachedStep) PlannedPods() ([]coreapi.Pod, error) {
+	if planner, ok := s.wrapped.(PodPlanner); ok {
+		return planner.PlannedPods()
+	}
+	return nil, nil
+}
+
+func (s *cachedStep) Run(ctx context.Context) error {
+	key := resultCacheAnnotationPrefix + s.Name()
+	inputHash := s.jobSpec.InputHash()
+	is := &imagev1.ImageStream{}
+	if err := s.client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: s.jobSpec.Namespace(), Name: api.PipelineImageStream}, is); err != nil {
+		logrus.WithError(err).Warnf("Could not determine cached result for %s, running it.", s.Name())
+	} else if inputHash != "" && is.Annotations[key] == inputHash {
+		logrus.Infof("Step %s already passed for inputs %s, reusing the result.", s.Name(), inputHash)
+		s.cached = true
+		return nil
+	}
+	if err := s.wrapped.Run(ctx); err != nil {
+		return err
+	}
+	if inputHash == "" {
+		return nil
+	}
+	if err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if err := s.client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: s.jobSpec.Namespace(), Name: api.PipelineImageStream}, is); err != nil {
+			return err
+		}
+		if is.Annotations == nil {
+			is.Annotations = map[string]string{}
+		}
+		is.Annotations[key] = inputHash
+		return s.client.Update(ctx, is)
+	}); err != nil {
+		// the step passed, failing to cache that is not a reason to fail it
+		logrus.WithError(err).Warnf("Could not cache the result of %s.", s.Name())
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package steps
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	imagev1 "github.com/openshift/api/image/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/steps/loggingclient"
+)
+
+func TestCachedStep(t *testing.T) {
+	for _, tc := range []struct {
+		name                string
+		annotations         map[string]string
+		runErr              error
+		expectedRuns        int
+		expectedErr         bool
+		expectedAnnotations map[string]string
+	}{{
+		name:                "no cached result, step runs and its result is cached",
+		expectedRuns:        1,
+		expectedAnnotations: map[string]string{"ci-operator.openshift.io/passed.unit": "hash"},
+	}, {
+		name:                "cached result for the same inputs, step does not run",
+		annotations:         map[string]string{"ci-operator.openshift.io/passed.unit": "hash"},
+		expectedAnnotations: map[string]string{"ci-operator.openshift.io/passed.unit": "hash"},
+	}, {
+		name:                "cached result for other inputs, step runs",
+		annotations:         map[string]string{"ci-operator.openshift.io/passed.unit": "other"},
+		expectedRuns:        1,
+		expectedAnnotations: map[string]string{"ci-operator.openshift.io/passed.unit": "hash"},
+	}, {
+		name:         "failure is not cached",
+		runErr:       errors.New("oops"),
+		expectedRuns: 1,
+		expectedErr:  true,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			client := loggingclient.New(fakectrlruntimeclient.NewFakeClient(&imagev1.ImageStream{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: api.PipelineImageStream, Annotations: tc.annotations},
+			}))
+			jobSpec := &api.JobSpec{}
+			jobSpec.SetNamespace("ns")
+			jobSpec.SetInputHash("hash")
+			wrapped := &fakeStep{name: "unit", runErr: tc.runErr}
+			step := CachedStep(wrapped, client, jobSpec)
+			if err := step.Run(context.Background()); (err != nil) != tc.expectedErr {
+				t.Fatalf("expected error %t, got %v", tc.expectedErr, err)
+			}
+			if wrapped.numRuns != tc.expectedRuns {
+				t.Errorf("expected %d runs, got %d", tc.expectedRuns, wrapped.numRuns)
+			}
+			is := &imagev1.ImageStream{}
+			if err := client.Get(context.Background(), ctrlruntimeclient.ObjectKey{Namespace: "ns", Name: api.PipelineImageStream}, is); err != nil {
+				t.Fatalf("failed to get pipeline image stream: %v", err)
+			}
+			if diff := cmp.Diff(tc.expectedAnnotations, is.Annotations); diff != "" {
+				t.Errorf("unexpected annotations: %s", diff)
+			}
+		})
+	}
+}
@@ -7,6 +7,9 @@ import (
 
 	"github.com/sirupsen/logrus"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
 	coreapi "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -19,6 +22,7 @@ import (
 	"github.com/openshift/ci-tools/pkg/results"
 	"github.com/openshift/ci-tools/pkg/steps/loggingclient"
 	"github.com/openshift/ci-tools/pkg/steps/utils"
+	"github.com/openshift/ci-tools/pkg/tracing"
 	"github.com/openshift/ci-tools/pkg/util"
 )
 
@@ -97,7 +101,8 @@ func (s *inputImageTagStep) run(ctx context.Context) error {
 	// Wait image is ready
 	importCtx, cancel := context.WithTimeout(ctx, 35*time.Minute)
 	defer cancel()
-	if err := wait.PollImmediateUntil(10*time.Second, func() (bool, error) {
+	_, span := tracing.Start(ctx, "import "+ist.Name, trace.WithAttributes(attribute.String("from", ist.Tag.From.Name)))
+	err := wait.PollImmediateUntil(10*time.Second, func() (bool, error) {
 		pipeline := &imagev1.ImageStream{}
 		if err := s.client.Get(importCtx, ctrlruntimeclient.ObjectKey{Namespace: s.jobSpec.Namespace(), Name: api.PipelineImageStream}, pipeline); err != nil {
 			return false, err
@@ -107,7 +112,9 @@ func (s *inputImageTagStep) run(ctx context.Context) error {
 			logrus.Debugf("Waiting to import %s ...", ist.ObjectMeta.Name)
 		}
 		return exists, nil
-	}, importCtx.Done()); err != nil {
+	}, importCtx.Done())
+	tracing.End(span, err)
+	if err != nil {
 		logrus.WithError(err).Errorf("Could not resolve tag %s in imagestream %s.", s.config.To, api.PipelineImageStream)
 		return err
 	}
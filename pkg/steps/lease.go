@@ -9,13 +9,19 @@ import (
 
 	"github.com/sirupsen/logrus"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	coreapi "k8s.io/api/core/v1"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/events"
 	"github.com/openshift/ci-tools/pkg/junit"
 	"github.com/openshift/ci-tools/pkg/lease"
 	"github.com/openshift/ci-tools/pkg/results"
+	"github.com/openshift/ci-tools/pkg/tracing"
 )
 
 var NoLeaseClientErr = errors.New("step needs a lease but no lease client provided")
@@ -64,6 +70,21 @@ func (s *leaseStep) Requires() []api.StepLink            { return s.wrapped.Requ
 func (s *leaseStep) Creates() []api.StepLink             { return s.wrapped.Creates() }
 func (s *leaseStep) Objects() []ctrlruntimeclient.Object { return s.wrapped.Objects() }
 
+func (s *leaseStep) Leases() []api.StepLease {
+	var ret []api.StepLease
+	for _, l := range s.leases {
+		ret = append(ret, l.StepLease)
+	}
+	return ret
+}
+
+func (s *leaseStep) PlannedPods() ([]coreapi.Pod, error) {
+	if planner, ok := s.wrapped.(PodPlanner); ok {
+		return planner.PlannedPods()
+	}
+	return nil, nil
+}
+
 func (s *leaseStep) Provides() api.ParameterMap {
 	parameters := s.wrapped.Provides()
 	if parameters == nil {
@@ -148,15 +169,21 @@ func acquireLeases(
 	for _, i := range sorted {
 		l := &leases[i]
 		logrus.Debugf("Acquiring %d lease(s) for %s", l.Count, l.ResourceType)
+		_, span := tracing.Start(ctx, "acquire lease", trace.WithAttributes(attribute.String("resource_type", l.ResourceType), attribute.Int("count", int(l.Count))))
 		names, err := client.Acquire(l.ResourceType, l.Count, ctx, cancel)
+		tracing.End(span, err)
 		if err != nil {
+			reason := results.Reason("acquiring_lease")
 			if err == lease.ErrNotFound {
 				printResourceMetrics(client, l.ResourceType)
+			} else if errors.Is(err, lease.ErrQuotaExceeded) {
+				reason = "lease_quota_exceeded"
 			}
-			errs = append(errs, results.ForReason(results.Reason("acquiring_lease")).WithError(err).Errorf("failed to acquire lease for %q: %v", l.ResourceType, err))
+			errs = append(errs, results.ForReason(reason).WithError(err).Errorf("failed to acquire lease for %q: %v", l.ResourceType, err))
 			break
 		}
 		logrus.Infof("Acquired %d lease(s) for %s: %v", l.Count, l.ResourceType, names)
+		events.Record(ctx, events.Event{Type: events.LeaseAcquired, Details: map[string]string{"resource_type": l.ResourceType, "leases": strings.Join(names, " ")}})
 		l.resources = names
 	}
 	if errs != nil {
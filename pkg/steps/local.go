@@ -0,0 +1,59 @@
+package steps
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+// LocalImages maps the image stream tags that multi-stage test steps would
+// normally resolve in the test namespace (like pipeline:src or stable:cli) to
+// pull specs. They are used when running against a plain Kubernetes cluster
+// which has no image API to resolve image stream tags with.
+type LocalImages map[string]string
+
+// ParseLocalImages parses NAME=PULLSPEC pairs into LocalImages. A NAME that
+// does not name an image stream refers to a tag in the pipeline image stream.
+func ParseLocalImages(values []string) (LocalImages, error) {
+	images := LocalImages{}
+	for _, value := range values {
+		parts := strings.SplitN(value, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("could not parse local image %q: not in the format NAME=PULLSPEC", value)
+		}
+		name := parts[0]
+		if !strings.Contains(name, ":") {
+			name = fmt.Sprintf("%s:%s", api.PipelineImageStream, name)
+		}
+		images[name] = parts[1]
+	}
+	return images, nil
+}
+
+// PullSpecFor returns the pull spec to use for the image stream tag.
+func (l LocalImages) PullSpecFor(stream, tag string) (string, error) {
+	name := fmt.Sprintf("%s:%s", stream, tag)
+	pullSpec, ok := l[name]
+	if !ok {
+		return "", fmt.Errorf("no pull spec was provided for image %s, add --local-image=%s=PULLSPEC", name, name)
+	}
+	return pullSpec, nil
+}
+
+// LocalMultiStageTestStep creates a multi-stage test step that runs its pods
+// with the provided images instead of resolving them from image streams. The
+// step does not require any other steps in the graph to provide images.
+func LocalMultiStageTestStep(
+	testConfig api.TestStepConfiguration,
+	config *api.ReleaseBuildConfiguration,
+	params api.Parameters,
+	client PodClient,
+	jobSpec *api.JobSpec,
+	leases []api.StepLease,
+	images LocalImages,
+) api.Step {
+	step := newMultiStageTestStep(testConfig, config, params, client, jobSpec, leases)
+	step.localImages = images
+	return step
+}
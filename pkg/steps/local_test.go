@@ -0,0 +1,121 @@
+package steps
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	coreapi "k8s.io/api/core/v1"
+	prowapi "k8s.io/test-infra/prow/apis/prowjobs/v1"
+	prowdapi "k8s.io/test-infra/prow/pod-utils/downwardapi"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+func TestParseLocalImages(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		values      []string
+		expected    LocalImages
+		expectedErr bool
+	}{{
+		name:     "no images",
+		expected: LocalImages{},
+	}, {
+		name:   "pipeline and image stream tags",
+		values: []string{"src=quay.io/org/src:latest", "stable:cli=quay.io/org/cli@sha256:0123"},
+		expected: LocalImages{
+			"pipeline:src": "quay.io/org/src:latest",
+			"stable:cli":   "quay.io/org/cli@sha256:0123",
+		},
+	}, {
+		name:        "missing pull spec",
+		values:      []string{"src="},
+		expectedErr: true,
+	}, {
+		name:        "not a pair",
+		values:      []string{"src"},
+		expectedErr: true,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			images, err := ParseLocalImages(tc.values)
+			if (err != nil) != tc.expectedErr {
+				t.Fatalf("expected error %t, got %v", tc.expectedErr, err)
+			}
+			if diff := cmp.Diff(tc.expected, images); diff != "" {
+				t.Errorf("unexpected images: %s", diff)
+			}
+		})
+	}
+}
+
+func TestLocalMultiStageTestStep(t *testing.T) {
+	config := api.ReleaseBuildConfiguration{
+		Tests: []api.TestStepConfiguration{{
+			As: "test",
+			MultiStageTestConfigurationLiteral: &api.MultiStageTestConfigurationLiteral{
+				Test: []api.LiteralTestStep{{
+					As: "step0", From: "src", Commands: "command0", Cli: "latest",
+					Dependencies: []api.StepDependency{{Name: "installer", Env: "INSTALLER"}},
+				}},
+			},
+		}},
+	}
+	jobSpec := api.JobSpec{
+		JobSpec: prowdapi.JobSpec{
+			Job:       "job",
+			BuildID:   "build id",
+			ProwJobID: "prow job id",
+			Type:      "periodic",
+			DecorationConfig: &prowapi.DecorationConfig{
+				UtilityImages: &prowapi.UtilityImages{
+					Sidecar:    "sidecar",
+					Entrypoint: "entrypoint",
+				},
+			},
+		},
+	}
+	jobSpec.SetNamespace("namespace")
+	steps := config.Tests[0].MultiStageTestConfigurationLiteral.Test
+
+	images := LocalImages{
+		"pipeline:src":     "quay.io/org/src:latest",
+		"stable:installer": "quay.io/org/installer:latest",
+		"stable:cli":       "quay.io/org/cli:latest",
+	}
+	step := LocalMultiStageTestStep(config.Tests[0], &config, nil, nil, &jobSpec, nil, images).(*multiStageTestStep)
+	if requires := step.Requires(); len(requires) != 0 {
+		t.Errorf("expected no requirements when running locally, got %v", requires)
+	}
+	pods, _, err := step.generatePods(steps, nil, false, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to generate pods: %v", err)
+	}
+	pod := pods[0]
+	if image := pod.Spec.Containers[0].Image; image != "quay.io/org/src:latest" {
+		t.Errorf("expected test container to use the local image, got %s", image)
+	}
+	var cliImage string
+	for _, container := range pod.Spec.InitContainers {
+		if container.Name == "inject-cli" {
+			cliImage = container.Image
+		}
+	}
+	if cliImage != "quay.io/org/cli:latest" {
+		t.Errorf("expected cli to be injected from the local image, got %q", cliImage)
+	}
+	var installer *coreapi.EnvVar
+	for i, env := range pod.Spec.Containers[0].Env {
+		if env.Name == "INSTALLER" {
+			installer = &pod.Spec.Containers[0].Env[i]
+		}
+	}
+	if installer == nil || installer.Value != "quay.io/org/installer:latest" {
+		t.Errorf("expected dependency to be resolved to the local image, got %v", installer)
+	}
+
+	delete(images, "pipeline:src")
+	if _, _, err := step.generatePods(steps, nil, false, nil, nil); err == nil {
+		t.Error("expected an error when an image is missing, got none")
+	}
+}
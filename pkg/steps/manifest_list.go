@@ -0,0 +1,187 @@
+package steps
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/distribution"
+	"github.com/docker/distribution/manifest/manifestlist"
+	"github.com/docker/distribution/manifest/schema2"
+	"github.com/opencontainers/go-digest"
+	imagespecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	coreclientset "k8s.io/client-go/kubernetes/typed/core/v1"
+	utilpointer "k8s.io/utils/pointer"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+// ManifestListPusher assembles the images built for different architectures
+// into a manifest list.
+type ManifestListPusher interface {
+	// PushManifestList tags a manifest list of the images, identified by their
+	// digests and keyed by architecture, in the repository, which is in the
+	// form registry/namespace/name.
+	PushManifestList(ctx context.Context, repository, tag string, images map[api.ReleaseArchitecture]string) error
+}
+
+type registryManifestListPusher struct {
+	client *http.Client
+	token  TokenSource
+}
+
+// TokenSource provides the token to authenticate to the registry with.
+type TokenSource func(ctx context.Context) (string, error)
+
+// BuilderTokenSource requests short-lived tokens for the service account
+// builds run as in the namespace of the job. The namespace is only determined
+// when a token is needed, as it may not exist yet when the source is created.
+func BuilderTokenSource(client coreclientset.ServiceAccountsGetter, namespace func() string) TokenSource {
+	return func(ctx context.Context) (string, error) {
+		request, err := client.ServiceAccounts(namespace()).CreateToken(ctx, buildServiceAccount, &authenticationv1.TokenRequest{
+			Spec: authenticationv1.TokenRequestSpec{ExpirationSeconds: utilpointer.Int64Ptr(600)},
+		}, metav1.CreateOptions{})
+		if err != nil {
+			return "", fmt.Errorf("could not request a token for service account %s: %w", buildServiceAccount, err)
+		}
+		return request.Status.Token, nil
+	}
+}
+
+// NewManifestListPusher creates a pusher which uses the registry API directly,
+// authenticating with tokens from the source. The integrated registry accepts
+// the token of any user that can push to the image stream, such as the
+// builder service account of the namespace.
+func NewManifestListPusher(token TokenSource) ManifestListPusher {
+	return &registryManifestListPusher{client: &http.Client{Timeout: time.Minute}, token: token}
+}
+
+func (p *registryManifestListPusher) PushManifestList(ctx context.Context, repository, tag string, images map[api.ReleaseArchitecture]string) error {
+	parts := strings.SplitN(repository, "/", 2)
+	if len(parts) != 2 {
+		return fmt.Errorf("invalid repository %s", repository)
+	}
+	registry, name := parts[0], parts[1]
+	token, err := p.token(ctx)
+	if err != nil {
+		return err
+	}
+	scheme, err := p.scheme(ctx, registry)
+	if err != nil {
+		return err
+	}
+	r := registryRequester{pusher: p, scheme: scheme, registry: registry, name: name, token: token}
+	var architectures []string
+	for architecture := range images {
+		architectures = append(architectures, string(architecture))
+	}
+	sort.Strings(architectures)
+	var descriptors []manifestlist.ManifestDescriptor
+	for _, architecture := range architectures {
+		descriptor, err := r.descriptor(ctx, images[api.ReleaseArchitecture(architecture)])
+		if err != nil {
+			return fmt.Errorf("could not resolve the %s image: %w", architecture, err)
+		}
+		descriptors = append(descriptors, manifestlist.ManifestDescriptor{
+			Descriptor: descriptor,
+			Platform:   manifestlist.PlatformSpec{OS: "linux", Architecture: architecture},
+		})
+	}
+	list, err := manifestlist.FromDescriptors(descriptors)
+	if err != nil {
+		return fmt.Errorf("could not assemble manifest list: %w", err)
+	}
+	mediaType, payload, err := list.Payload()
+	if err != nil {
+		return fmt.Errorf("could not serialize manifest list: %w", err)
+	}
+	resp, err := r.do(ctx, http.MethodPut, tag, payload, func(req *http.Request) {
+		req.Header.Set("Content-Type", mediaType)
+	})
+	if err != nil {
+		return fmt.Errorf("could not push manifest list: %w", err)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// descriptor determines the media type and size of the manifest of an image,
+// which the manifest list must record along with its digest.
+func (r registryRequester) descriptor(ctx context.Context, imageDigest string) (distribution.Descriptor, error) {
+	dgst, err := digest.Parse(imageDigest)
+	if err != nil {
+		return distribution.Descriptor{}, err
+	}
+	resp, err := r.do(ctx, http.MethodHead, imageDigest, nil, func(req *http.Request) {
+		req.Header.Add("Accept", schema2.MediaTypeManifest)
+		req.Header.Add("Accept", imagespecv1.MediaTypeImageManifest)
+	})
+	if err != nil {
+		return distribution.Descriptor{}, err
+	}
+	resp.Body.Close()
+	mediaType := resp.Header.Get("Content-Type")
+	if mediaType != schema2.MediaTypeManifest && mediaType != imagespecv1.MediaTypeImageManifest {
+		return distribution.Descriptor{}, fmt.Errorf("image %s has a manifest of unsupported type %q", imageDigest, mediaType)
+	}
+	size, err := strconv.ParseInt(resp.Header.Get("Content-Length"), 10, 64)
+	if err != nil {
+		return distribution.Descriptor{}, fmt.Errorf("could not determine the size of the manifest of image %s: %w", imageDigest, err)
+	}
+	return distribution.Descriptor{MediaType: mediaType, Size: size, Digest: dgst}, nil
+}
+
+// scheme determines whether the registry is served over TLS. Registries
+// which answer TLS handshakes with plain HTTP, like those of local clusters,
+// are used over HTTP.
+func (p *registryManifestListPusher) scheme(ctx context.Context, registry string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("https://%s/v2/", registry), nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		if strings.Contains(err.Error(), "server gave HTTP response to HTTPS client") {
+			return "http", nil
+		}
+		return "", fmt.Errorf("could not reach registry %s: %w", registry, err)
+	}
+	resp.Body.Close()
+	return "https", nil
+}
+
+// registryRequester sends requests for the manifests of a repository.
+type registryRequester struct {
+	pusher                        *registryManifestListPusher
+	scheme, registry, name, token string
+}
+
+func (r registryRequester) do(ctx context.Context, method, reference string, body []byte, mutate func(*http.Request)) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, fmt.Sprintf("%s://%s/v2/%s/manifests/%s", r.scheme, r.registry, r.name, reference), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if r.token != "" {
+		req.SetBasicAuth("ci-operator", r.token)
+	}
+	mutate(req)
+	resp, err := r.pusher.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		raw, _ := ioutil.ReadAll(resp.Body)
+		return nil, fmt.Errorf("%s %s: registry responded with %d: %s", method, req.URL, resp.StatusCode, string(raw))
+	}
+	return resp, nil
+}
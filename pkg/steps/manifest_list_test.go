@@ -0,0 +1,116 @@
+package steps
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/docker/distribution/manifest/manifestlist"
+	"github.com/docker/distribution/manifest/schema2"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+func TestPushManifestList(t *testing.T) {
+	const (
+		amd64 = "sha256:0000000000000000000000000000000000000000000000000000000000000001"
+		arm64 = "sha256:0000000000000000000000000000000000000000000000000000000000000002"
+	)
+	var pushed manifestlist.ManifestList
+	var contentType string
+	registry := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, password, _ := r.BasicAuth(); password != "token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case r.Method == http.MethodHead && r.URL.Path == "/v2/ns/pipeline/manifests/"+amd64:
+			w.Header().Set("Content-Type", schema2.MediaTypeManifest)
+			w.Header().Set("Content-Length", "1000")
+		case r.Method == http.MethodHead && r.URL.Path == "/v2/ns/pipeline/manifests/"+arm64:
+			w.Header().Set("Content-Type", schema2.MediaTypeManifest)
+			w.Header().Set("Content-Length", "2000")
+		case r.Method == http.MethodPut && r.URL.Path == "/v2/ns/pipeline/manifests/image":
+			contentType = r.Header.Get("Content-Type")
+			raw, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				t.Errorf("failed to read manifest list: %v", err)
+			}
+			if err := json.Unmarshal(raw, &pushed); err != nil {
+				t.Errorf("failed to unmarshal manifest list: %v", err)
+			}
+			w.WriteHeader(http.StatusCreated)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer registry.Close()
+
+	pusher := &registryManifestListPusher{client: registry.Client(), token: func(context.Context) (string, error) { return "token", nil }}
+	repository := strings.TrimPrefix(registry.URL, "https://") + "/ns/pipeline"
+	if err := pusher.PushManifestList(context.Background(), repository, "image", map[api.ReleaseArchitecture]string{
+		api.ReleaseArchitectureARM64: arm64,
+		api.ReleaseArchitectureAMD64: amd64,
+	}); err != nil {
+		t.Fatalf("failed to push manifest list: %v", err)
+	}
+	if contentType != manifestlist.MediaTypeManifestList {
+		t.Errorf("expected a manifest list to be pushed, got %s", contentType)
+	}
+	var actual []string
+	for _, manifest := range pushed.Manifests {
+		actual = append(actual, manifest.Platform.Architecture+" "+manifest.Digest.String()+" "+manifest.MediaType)
+	}
+	expected := []string{"amd64 " + amd64 + " " + schema2.MediaTypeManifest, "arm64 " + arm64 + " " + schema2.MediaTypeManifest}
+	if diff := cmp.Diff(expected, actual); diff != "" {
+		t.Errorf("unexpected manifests: %s", diff)
+	}
+	if size := pushed.Manifests[1].Size; size != 2000 {
+		t.Errorf("expected the size of the manifest to be recorded, got %d", size)
+	}
+
+	if err := pusher.PushManifestList(context.Background(), repository, "image", map[api.ReleaseArchitecture]string{
+		api.ReleaseArchitectureS390x: "sha256:0000000000000000000000000000000000000000000000000000000000000003",
+	}); err == nil || !strings.Contains(err.Error(), "could not resolve the s390x image") {
+		t.Errorf("expected missing image to fail, got %v", err)
+	}
+}
+
+func TestRegistryScheme(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+	secure := httptest.NewTLSServer(handler)
+	defer secure.Close()
+	insecure := httptest.NewServer(handler)
+	defer insecure.Close()
+	for _, tc := range []struct {
+		name     string
+		server   *httptest.Server
+		expected string
+	}{{
+		name:     "registry served over TLS",
+		server:   secure,
+		expected: "https",
+	}, {
+		name:     "registry served over plain HTTP",
+		server:   insecure,
+		expected: "http",
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			pusher := &registryManifestListPusher{client: secure.Client()}
+			scheme, err := pusher.scheme(context.Background(), tc.server.Listener.Addr().String())
+			if err != nil {
+				t.Fatalf("failed to determine scheme: %v", err)
+			}
+			if scheme != tc.expected {
+				t.Errorf("expected %s, got %s", tc.expected, scheme)
+			}
+		})
+	}
+}
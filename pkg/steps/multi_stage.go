@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"path"
 	"path/filepath"
+	"regexp"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -74,6 +76,18 @@ type multiStageTestStep struct {
 	allowBestEffortPostSteps *bool
 	leases                   []api.StepLease
 	clusterClaim             *api.ClusterClaim
+	// stepResults records the outcome of each step, by name, as the test
+	// progresses so that later steps can be conditional on them
+	stepResults map[string]api.StepResult
+	// localImages, when set, provides pull specs for the images the steps
+	// use instead of resolving them from image streams
+	localImages LocalImages
+	// planning is set while generating pods for a plan, when image pull
+	// specs cannot be resolved from the cluster
+	planning bool
+	// lock guards subTests, subSteps and stepResults, which are written by
+	// steps running in parallel
+	lock sync.Mutex
 }
 
 func MultiStageTestStep(
@@ -111,6 +125,7 @@ func newMultiStageTestStep(
 		allowBestEffortPostSteps: ms.AllowBestEffortPostSteps,
 		leases:                   leases,
 		clusterClaim:             testConfig.ClusterClaim,
+		stepResults:              map[string]api.StepResult{},
 	}
 }
 
@@ -175,6 +190,9 @@ func (s *multiStageTestStep) SubSteps() []api.CIOperatorStepDetailInfo {
 }
 
 func (s *multiStageTestStep) Requires() (ret []api.StepLink) {
+	if s.localImages != nil {
+		return nil
+	}
 	var claimRelease *api.ClaimRelease
 	if s.clusterClaim != nil {
 		claimRelease = s.clusterClaim.ClaimRelease(s.name)
@@ -239,6 +257,24 @@ func (s *multiStageTestStep) Provides() api.ParameterMap {
 }
 func (s *multiStageTestStep) SubTests() []*junit.TestCase { return s.subTests }
 
+// PlannedPods generates the pods for all steps in the test. Parameters that
+// are only known while the test runs, like leased resources, are omitted.
+func (s *multiStageTestStep) PlannedPods() ([]coreapi.Pod, error) {
+	s.planning = true
+	defer func() { s.planning = false }()
+	var ret []coreapi.Pod
+	var errs []error
+	for _, phase := range [][]api.LiteralTestStep{s.pre, s.test, s.post} {
+		pods, _, err := s.generatePods(phase, nil, false, nil, nil)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		ret = append(ret, pods...)
+	}
+	return ret, utilerrors.NewAggregate(errs)
+}
+
 func (s *multiStageTestStep) setupRBAC(ctx context.Context) error {
 	labels := map[string]string{MultiStageTestLabel: s.name}
 	m := meta.ObjectMeta{Namespace: s.jobSpec.Namespace(), Name: s.name, Labels: labels}
@@ -459,13 +495,15 @@ func (s *multiStageTestStep) generatePods(steps []api.LiteralTestStep, env []cor
 			logrus.Infof(fmt.Sprintf("Skipping optional step %s", name))
 			continue
 		}
-		image := step.From
-		if link, ok := step.FromImageTag(); ok {
-			image = fmt.Sprintf("%s:%s", api.PipelineImageStream, link)
-		} else {
-			dep := api.StepDependency{Name: image}
-			stream, tag, _ := s.config.DependencyParts(dep, claimRelease)
-			image = fmt.Sprintf("%s:%s", stream, tag)
+		stream, tag := api.PipelineImageStream, step.From
+		if _, ok := step.FromImageTag(); !ok {
+			dep := api.StepDependency{Name: step.From}
+			stream, tag, _ = s.config.DependencyParts(dep, claimRelease)
+		}
+		image, err := s.imageFor(stream, tag)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("could not determine image for step %s: %w", step.As, err))
+			continue
 		}
 		resources, err := resourcesFor(step.Resources)
 		if err != nil {
@@ -573,7 +611,12 @@ func (s *multiStageTestStep) generatePods(steps []api.LiteralTestStep, env []cor
 		if step.Cli != "" {
 			dependency := api.StepDependency{Name: fmt.Sprintf("%s:cli", api.ReleaseStreamFor(step.Cli))}
 			imagestream, _, _ := s.config.DependencyParts(dependency, claimRelease)
-			addCliInjector(imagestream, pod)
+			cliImage, err := s.imageFor(imagestream, "cli")
+			if err != nil {
+				errs = append(errs, fmt.Errorf("could not determine cli image for step %s: %w", step.As, err))
+				continue
+			}
+			addCliInjector(cliImage, pod)
 		}
 		addSharedDirSecret(s.name, pod)
 		addCredentials(step.Credentials, pod)
@@ -636,6 +679,17 @@ func (s *multiStageTestStep) envForDependencies(step api.LiteralTestStep) ([]cor
 		// correctly as it could possibly point to an external registry that ci-operator will itself not have access to.
 		if dependency.PullSpec != "" {
 			ref = dependency.PullSpec
+		} else if s.localImages != nil {
+			imageStream, name, _ := s.config.DependencyParts(dependency, claimRelease)
+			depRef, err := s.localImages.PullSpecFor(imageStream, name)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("could not determine image pull spec for image %s on step %s: %w", dependency.Name, step.As, err))
+				continue
+			}
+			ref = depRef
+		} else if s.planning {
+			imageStream, name, _ := s.config.DependencyParts(dependency, claimRelease)
+			ref = fmt.Sprintf("%s:%s", imageStream, name)
 		} else {
 			imageStream, name, _ := s.config.DependencyParts(dependency, claimRelease)
 			depRef, err := utils.ImageDigestFor(s.client, s.jobSpec.Namespace, imageStream, name)()
@@ -679,17 +733,27 @@ func addSecretWrapper(pod *coreapi.Pod) {
 
 func (s *multiStageTestStep) generateParams(env []api.StepParameter) []coreapi.EnvVar {
 	var ret []coreapi.EnvVar
-	for _, env := range env {
-		value := ""
-		if env.Default != nil {
-			value = *env.Default
+	for _, param := range env {
+		ret = append(ret, coreapi.EnvVar{Name: param.Name, Value: s.parameterValue(env, param.Name)})
+	}
+	return ret
+}
+
+// parameterValue resolves the value of a step parameter, preferring the test
+// environment over the default.
+func (s *multiStageTestStep) parameterValue(env []api.StepParameter, name string) string {
+	for _, param := range env {
+		if param.Name != name {
+			continue
 		}
-		if v, ok := s.env[env.Name]; ok {
-			value = v
+		if v, ok := s.env[name]; ok {
+			return v
+		}
+		if param.Default != nil {
+			return *param.Default
 		}
-		ret = append(ret, coreapi.EnvVar{Name: env.Name, Value: value})
 	}
-	return ret
+	return ""
 }
 
 func addSharedDirSecret(secret string, pod *coreapi.Pod) {
@@ -789,7 +853,15 @@ func addCommandScript(name string, pod *coreapi.Pod) {
 	})
 }
 
-func addCliInjector(imagestream string, pod *coreapi.Pod) {
+// imageFor determines the image reference a pod uses for an image stream tag.
+func (s *multiStageTestStep) imageFor(stream, tag string) (string, error) {
+	if s.localImages != nil {
+		return s.localImages.PullSpecFor(stream, tag)
+	}
+	return fmt.Sprintf("%s:%s", stream, tag), nil
+}
+
+func addCliInjector(image string, pod *coreapi.Pod) {
 	volumeName := "cli"
 	pod.Spec.Volumes = append(pod.Spec.Volumes, coreapi.Volume{
 		Name: volumeName,
@@ -799,7 +871,7 @@ func addCliInjector(imagestream string, pod *coreapi.Pod) {
 	})
 	pod.Spec.InitContainers = append(pod.Spec.InitContainers, coreapi.Container{
 		Name:    "inject-cli",
-		Image:   fmt.Sprintf("%s:cli", imagestream),
+		Image:   image,
 		Command: []string{"/bin/cp"},
 		Args:    []string{"/usr/bin/oc", CliMountPath},
 		VolumeMounts: []coreapi.VolumeMount{{
@@ -820,20 +892,239 @@ func addCliInjector(imagestream string, pod *coreapi.Pod) {
 
 func (s *multiStageTestStep) runPods(ctx context.Context, pods []coreapi.Pod, shortCircuit bool, isBestEffort func(string) bool) error {
 	var errs []error
+	for _, group := range s.parallelGroups(pods) {
+		groupErrs := s.runPodGroup(ctx, group, isBestEffort)
+		errs = append(errs, groupErrs...)
+		if len(groupErrs) != 0 && shortCircuit {
+			break
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// parallelGroups splits the pods into groups which are run one after another.
+// Consecutive pods whose steps share a parallel group are grouped together,
+// every other pod forms a group of its own.
+func (s *multiStageTestStep) parallelGroups(pods []coreapi.Pod) [][]coreapi.Pod {
+	var groups [][]coreapi.Pod
+	var last string
 	for _, pod := range pods {
-		err := s.runPod(ctx, &pod, NewTestCaseNotifier(NopNotifier))
+		var group string
+		if step := s.literalStepFor(pod.Name); step != nil {
+			group = step.ParallelGroup
+		}
+		if group != "" && group == last {
+			groups[len(groups)-1] = append(groups[len(groups)-1], pod)
+		} else {
+			groups = append(groups, []coreapi.Pod{pod})
+		}
+		last = group
+	}
+	return groups
+}
+
+// runPodGroup runs the pods of a group concurrently and waits for all of them
+// to finish, so a failure does not interrupt the other steps in the group.
+// Conditions are evaluated for every step before any of them is started.
+func (s *multiStageTestStep) runPodGroup(ctx context.Context, pods []coreapi.Pod, isBestEffort func(string) bool) []error {
+	var errs []error
+	var toRun []coreapi.Pod
+	for _, pod := range pods {
+		step := s.literalStepFor(pod.Name)
+		if step == nil {
+			toRun = append(toRun, pod)
+			continue
+		}
+		unmet, err := s.unmetCondition(ctx, *step)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if unmet != "" {
+			s.skipPod(pod.Name, unmet)
+			s.recordResult(step.As, api.StepResultSkipped)
+			continue
+		}
+		toRun = append(toRun, pod)
+	}
+	if len(toRun) > 1 {
+		logrus.Infof("Running steps %s in parallel.", strings.Join(podNames(toRun), ", "))
+	}
+	runErrs := make([]error, len(toRun))
+	var wg sync.WaitGroup
+	for i := range toRun {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			runErrs[i] = s.runStepPod(ctx, toRun[i], isBestEffort)
+		}(i)
+	}
+	wg.Wait()
+	for _, err := range runErrs {
 		if err != nil {
-			if isBestEffort(pod.Name) {
-				logrus.Infof("Pod %s is running in best-effort mode, ignoring the failure...", pod.Name)
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// runStepPod runs the pod for a step and records its result.
+func (s *multiStageTestStep) runStepPod(ctx context.Context, pod coreapi.Pod, isBestEffort func(string) bool) error {
+	err := s.runPodWithRetries(ctx, pod)
+	if step := s.literalStepFor(pod.Name); step != nil {
+		result := api.StepResultSucceeded
+		if err != nil {
+			result = api.StepResultFailed
+		}
+		s.recordResult(step.As, result)
+	}
+	if err != nil && isBestEffort(pod.Name) {
+		logrus.Infof("Pod %s is running in best-effort mode, ignoring the failure...", pod.Name)
+		return nil
+	}
+	return err
+}
+
+func (s *multiStageTestStep) recordResult(step string, result api.StepResult) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.stepResults[step] = result
+}
+
+func podNames(pods []coreapi.Pod) []string {
+	var names []string
+	for _, pod := range pods {
+		names = append(names, pod.Name)
+	}
+	return names
+}
+
+// runPodWithRetries runs the pod for a step and, when the step defines a retry
+// policy, re-creates it under a suffixed name for as long as the failure is
+// retryable and attempts remain. Every attempt is reported as a separate test.
+func (s *multiStageTestStep) runPodWithRetries(ctx context.Context, pod coreapi.Pod) error {
+	var policy *api.StepRetryPolicy
+	if step := s.literalStepFor(pod.Name); step != nil {
+		policy = step.Retry
+	}
+	attempts := 1
+	if policy != nil && policy.Attempts > 1 {
+		attempts = policy.Attempts
+	}
+	for attempt := 1; ; attempt++ {
+		current := pod.DeepCopy()
+		if attempt > 1 {
+			current.Name = fmt.Sprintf("%s-retry-%d", pod.Name, attempt-1)
+		}
+		err := s.runPod(ctx, current, NewTestCaseNotifier(NopNotifier))
+		if err == nil || attempt == attempts || ctx.Err() != nil {
+			return err
+		}
+		if !isRetryableFailure(policy, current) {
+			logrus.Infof("Step %s failed for a reason that is not retryable.", current.Name)
+			return err
+		}
+		var backoff time.Duration
+		if policy.Backoff != nil {
+			backoff = policy.Backoff.Duration
+		}
+		logrus.Infof("Step %s failed, retrying in %s (attempt %d/%d).", current.Name, backoff, attempt+1, attempts)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+	}
+}
+
+// literalStepFor returns the step which generated the pod.
+func (s *multiStageTestStep) literalStepFor(podName string) *api.LiteralTestStep {
+	for _, steps := range [][]api.LiteralTestStep{s.pre, s.test, s.post} {
+		for i := range steps {
+			if fmt.Sprintf("%s-%s", s.name, steps[i].As) == podName {
+				return &steps[i]
+			}
+		}
+	}
+	return nil
+}
+
+// unmetCondition evaluates the `when` conditions of a step and describes the
+// first one which is not met. An empty description means the step should run.
+func (s *multiStageTestStep) unmetCondition(ctx context.Context, step api.LiteralTestStep) (string, error) {
+	if step.When == nil {
+		return "", nil
+	}
+	for _, condition := range step.When.Env {
+		if value := s.parameterValue(step.Environment, condition.Name); value != condition.Equals {
+			return fmt.Sprintf("parameter %s is %q, not %q", condition.Name, value, condition.Equals), nil
+		}
+	}
+	if len(step.When.SharedDirFiles) != 0 {
+		secret := &coreapi.Secret{}
+		if err := s.client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: s.jobSpec.Namespace(), Name: s.name}, secret); err != nil {
+			return "", fmt.Errorf("could not read shared directory %q: %w", s.name, err)
+		}
+		for _, file := range step.When.SharedDirFiles {
+			if _, ok := secret.Data[file]; !ok {
+				return fmt.Sprintf("file %s does not exist in the shared directory", file), nil
+			}
+		}
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, condition := range step.When.Steps {
+		result, ok := s.stepResults[condition.Name]
+		if !ok {
+			return fmt.Sprintf("step %s did not run", condition.Name), nil
+		}
+		if result != condition.Result {
+			return fmt.Sprintf("step %s %s, not %s", condition.Name, result, condition.Result), nil
+		}
+	}
+	return "", nil
+}
+
+// skipPod records a step which was not executed as a skipped test.
+func (s *multiStageTestStep) skipPod(podName, reason string) {
+	logrus.Infof("Skipping step %s: %s.", podName, reason)
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.subTests = append(s.subTests, &junit.TestCase{
+		Name:        fmt.Sprintf("%s - %s container %s", s.Description(), podName, multiStageTestStepContainerName),
+		SkipMessage: &junit.SkipMessage{Message: reason},
+	})
+}
+
+// isRetryableFailure determines whether the failure of a completed pod
+// matches the exit codes or log patterns of the retry policy.
+func isRetryableFailure(policy *api.StepRetryPolicy, pod *coreapi.Pod) bool {
+	if len(policy.ExitCodes) == 0 && len(policy.LogPatterns) == 0 {
+		return true
+	}
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name != multiStageTestStepContainerName || status.State.Terminated == nil {
+			continue
+		}
+		terminated := status.State.Terminated
+		for _, code := range policy.ExitCodes {
+			if terminated.ExitCode == code {
+				return true
+			}
+		}
+		for _, pattern := range policy.LogPatterns {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				// should never happen, validation rejects invalid patterns
+				logrus.WithError(err).Warnf("Ignoring invalid retry log pattern %q.", pattern)
 				continue
 			}
-			errs = append(errs, err)
-			if shortCircuit {
-				break
+			if re.MatchString(terminated.Message) {
+				return true
 			}
 		}
 	}
-	return utilerrors.NewAggregate(errs)
+	return false
 }
 
 func (s *multiStageTestStep) runPod(ctx context.Context, pod *coreapi.Pod, notifier *TestCaseNotifier) error {
@@ -845,7 +1136,7 @@ func (s *multiStageTestStep) runPod(ctx context.Context, pod *coreapi.Pod, notif
 	}
 	newPod, err := waitForPodCompletion(ctx, client, pod.Namespace, pod.Name, notifier, false)
 	if newPod != nil {
-		pod = newPod
+		*pod = *newPod
 	}
 	finished := time.Now()
 	duration := finished.Sub(start)
@@ -854,6 +1145,7 @@ func (s *multiStageTestStep) runPod(ctx context.Context, pod *coreapi.Pod, notif
 		verb = "failed"
 	}
 	logrus.Infof("Step %s %s after %s.", pod.Name, verb, duration.Truncate(time.Second))
+	s.lock.Lock()
 	s.subSteps = append(s.subSteps, api.CIOperatorStepDetailInfo{
 		StepName:    pod.Name,
 		Description: fmt.Sprintf("Run pod %s", pod.Name),
@@ -864,9 +1156,10 @@ func (s *multiStageTestStep) runPod(ctx context.Context, pod *coreapi.Pod, notif
 		Manifests:   client.Objects(),
 	})
 	s.subTests = append(s.subTests, notifier.SubTests(fmt.Sprintf("%s - %s ", s.Description(), pod.Name))...)
+	s.lock.Unlock()
 	if err != nil {
 		linksText := strings.Builder{}
-		linksText.WriteString(fmt.Sprintf("Link to step on registry info site: https://steps.ci.openshift.org/reference/%s", strings.TrimPrefix(pod.Name, s.name+"-")))
+		linksText.WriteString(fmt.Sprintf("Link to step on registry info site: https://steps.ci.openshift.org/reference/%s", pod.Labels[LabelMetadataStep]))
 		linksText.WriteString(fmt.Sprintf("\nLink to job on registry info site: https://steps.ci.openshift.org/job?org=%s&repo=%s&branch=%s&test=%s", s.config.Metadata.Org, s.config.Metadata.Repo, s.config.Metadata.Branch, s.name))
 		if s.config.Metadata.Variant != "" {
 			linksText.WriteString(fmt.Sprintf("&variant=%s", s.config.Metadata.Variant))
@@ -7,6 +7,7 @@ import (
 	"path"
 	"reflect"
 	"sort"
+	"sync"
 	"testing"
 	"time"
 
@@ -21,6 +22,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/sets"
 	prowapi "k8s.io/test-infra/prow/apis/prowjobs/v1"
 	prowdapi "k8s.io/test-infra/prow/pod-utils/downwardapi"
+	utilpointer "k8s.io/utils/pointer"
 	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
 	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
 
@@ -32,6 +34,9 @@ import (
 // the multiStageTestStep implements the subStepReporter interface
 var _ SubStepReporter = &multiStageTestStep{}
 
+// the multiStageTestStep implements the PodPlanner interface
+var _ PodPlanner = &multiStageTestStep{}
+
 func TestRequires(t *testing.T) {
 	for _, tc := range []struct {
 		name         string
@@ -187,6 +192,56 @@ func TestGeneratePods(t *testing.T) {
 	testhelper.CompareWithFixture(t, ret)
 }
 
+func TestPlannedPods(t *testing.T) {
+	config := api.ReleaseBuildConfiguration{
+		Tests: []api.TestStepConfiguration{{
+			As: "test",
+			MultiStageTestConfigurationLiteral: &api.MultiStageTestConfigurationLiteral{
+				Pre:  []api.LiteralTestStep{{As: "pre", From: "src", Commands: "pre"}},
+				Test: []api.LiteralTestStep{{As: "test", From: "src", Commands: "test", Dependencies: []api.StepDependency{{Name: "installer", Env: "INSTALLER"}}}},
+				Post: []api.LiteralTestStep{{As: "post", From: "src", Commands: "post"}},
+			},
+		}},
+	}
+	jobSpec := api.JobSpec{
+		JobSpec: prowdapi.JobSpec{
+			Job:       "job",
+			BuildID:   "build id",
+			ProwJobID: "prow job id",
+			Type:      "periodic",
+			DecorationConfig: &prowapi.DecorationConfig{
+				UtilityImages: &prowapi.UtilityImages{Sidecar: "sidecar", Entrypoint: "entrypoint"},
+			},
+		},
+	}
+	jobSpec.SetNamespace("namespace")
+	// no client is needed to plan, image pull specs are not resolved
+	step := newMultiStageTestStep(config.Tests[0], &config, nil, nil, &jobSpec, nil)
+	pods, err := step.PlannedPods()
+	if err != nil {
+		t.Fatalf("failed to plan pods: %v", err)
+	}
+	var names []string
+	for _, pod := range pods {
+		names = append(names, pod.Name)
+	}
+	if diff := cmp.Diff([]string{"test-pre", "test-test", "test-post"}, names); diff != "" {
+		t.Errorf("unexpected pods: %s", diff)
+	}
+	var installer string
+	for _, env := range pods[1].Spec.Containers[0].Env {
+		if env.Name == "INSTALLER" {
+			installer = env.Value
+		}
+	}
+	if installer != "stable:installer" {
+		t.Errorf("expected the dependency to refer to the image stream tag, got %q", installer)
+	}
+	if step.planning {
+		t.Error("expected planning to be reset")
+	}
+}
+
 func TestGeneratePodsEnvironment(t *testing.T) {
 	value := "test"
 	defValue := "default"
@@ -338,8 +393,11 @@ func TestGeneratePodBestEffort(t *testing.T) {
 
 type fakePodExecutor struct {
 	loggingclient.LoggingClient
-	failures    sets.String
+	failures sets.String
+	// messages are the termination messages of failing pods, by name
+	messages    map[string]string
 	createdPods []*coreapi.Pod
+	lock        sync.Mutex
 }
 
 func (f *fakePodExecutor) Create(ctx context.Context, o ctrlruntimeclient.Object, opts ...ctrlruntimeclient.CreateOption) error {
@@ -347,7 +405,9 @@ func (f *fakePodExecutor) Create(ctx context.Context, o ctrlruntimeclient.Object
 		if pod.Namespace == "" {
 			return errors.New("pod had no namespace set")
 		}
+		f.lock.Lock()
 		f.createdPods = append(f.createdPods, pod.DeepCopy())
+		f.lock.Unlock()
 		pod.Status.Phase = coreapi.PodPending
 	}
 	return f.LoggingClient.Create(ctx, o, opts...)
@@ -368,6 +428,7 @@ func (f *fakePodExecutor) Get(ctx context.Context, n ctrlruntimeclient.ObjectKey
 			terminated := &coreapi.ContainerStateTerminated{}
 			if fail {
 				terminated.ExitCode = 1
+				terminated.Message = f.messages[n.Name]
 			}
 			pod.Status.ContainerStatuses = append(pod.Status.ContainerStatuses, coreapi.ContainerStatus{
 				Name:  container.Name,
@@ -561,6 +622,343 @@ func TestJUnit(t *testing.T) {
 	}
 }
 
+func TestRunRetry(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		retry         *api.StepRetryPolicy
+		failures      sets.String
+		messages      map[string]string
+		expectedErr   bool
+		expectedPods  []string
+		expectedTests []string
+	}{{
+		name:         "no retry policy, failure is final",
+		failures:     sets.NewString("test-test0"),
+		expectedErr:  true,
+		expectedPods: []string{"test-test0"},
+		expectedTests: []string{
+			"Run multi-stage test test - test-test0 container test",
+		},
+	}, {
+		name:         "retryable failure succeeds on a later attempt",
+		retry:        &api.StepRetryPolicy{Attempts: 3},
+		failures:     sets.NewString("test-test0", "test-test0-retry-1"),
+		expectedPods: []string{"test-test0", "test-test0-retry-1", "test-test0-retry-2"},
+		expectedTests: []string{
+			"Run multi-stage test test - test-test0 container test",
+			"Run multi-stage test test - test-test0-retry-1 container test",
+			"Run multi-stage test test - test-test0-retry-2 container test",
+		},
+	}, {
+		name:         "attempts are exhausted",
+		retry:        &api.StepRetryPolicy{Attempts: 2, ExitCodes: []int32{1}},
+		failures:     sets.NewString("test-test0", "test-test0-retry-1"),
+		expectedErr:  true,
+		expectedPods: []string{"test-test0", "test-test0-retry-1"},
+		expectedTests: []string{
+			"Run multi-stage test test - test-test0 container test",
+			"Run multi-stage test test - test-test0-retry-1 container test",
+		},
+	}, {
+		name:         "exit code is not retryable",
+		retry:        &api.StepRetryPolicy{Attempts: 3, ExitCodes: []int32{2}},
+		failures:     sets.NewString("test-test0"),
+		expectedErr:  true,
+		expectedPods: []string{"test-test0"},
+		expectedTests: []string{
+			"Run multi-stage test test - test-test0 container test",
+		},
+	}, {
+		name:         "log pattern matches",
+		retry:        &api.StepRetryPolicy{Attempts: 3, ExitCodes: []int32{2}, LogPatterns: []string{"Throttling: Rate exceeded"}},
+		failures:     sets.NewString("test-test0"),
+		messages:     map[string]string{"test-test0": "level=error msg=\"Throttling: Rate exceeded\""},
+		expectedPods: []string{"test-test0", "test-test0-retry-1"},
+		expectedTests: []string{
+			"Run multi-stage test test - test-test0 container test",
+			"Run multi-stage test test - test-test0-retry-1 container test",
+		},
+	}, {
+		name:         "log pattern does not match",
+		retry:        &api.StepRetryPolicy{Attempts: 3, LogPatterns: []string{"Throttling: Rate exceeded"}},
+		failures:     sets.NewString("test-test0"),
+		messages:     map[string]string{"test-test0": "level=error msg=\"no space left on device\""},
+		expectedErr:  true,
+		expectedPods: []string{"test-test0"},
+		expectedTests: []string{
+			"Run multi-stage test test - test-test0 container test",
+		},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			sa := &coreapi.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "ns", Labels: map[string]string{"ci.openshift.io/multi-stage-test": "test"}}}
+			crclient := &fakePodExecutor{LoggingClient: loggingclient.New(fakectrlruntimeclient.NewFakeClient(sa.DeepCopyObject())), failures: tc.failures, messages: tc.messages}
+			jobSpec := api.JobSpec{
+				JobSpec: prowdapi.JobSpec{
+					Job:       "job",
+					BuildID:   "build_id",
+					ProwJobID: "prow_job_id",
+					Type:      prowapi.PeriodicJob,
+					DecorationConfig: &prowapi.DecorationConfig{
+						Timeout:     &prowapi.Duration{Duration: time.Minute},
+						GracePeriod: &prowapi.Duration{Duration: time.Second},
+						UtilityImages: &prowapi.UtilityImages{
+							Sidecar:    "sidecar",
+							Entrypoint: "entrypoint",
+						},
+					},
+				},
+			}
+			jobSpec.SetNamespace("ns")
+			step := MultiStageTestStep(api.TestStepConfiguration{
+				As: "test",
+				MultiStageTestConfigurationLiteral: &api.MultiStageTestConfigurationLiteral{
+					Test: []api.LiteralTestStep{{As: "test0", Retry: tc.retry}},
+				},
+			}, &api.ReleaseBuildConfiguration{}, nil, &fakePodClient{fakePodExecutor: crclient}, &jobSpec, nil)
+			if err := step.Run(context.Background()); (err != nil) != tc.expectedErr {
+				t.Errorf("expected error: %t, got error: %v", tc.expectedErr, err)
+			}
+			var pods []string
+			for _, pod := range crclient.createdPods {
+				pods = append(pods, pod.Name)
+			}
+			if diff := cmp.Diff(tc.expectedPods, pods); diff != "" {
+				t.Errorf("did not execute correct pods: %s", diff)
+			}
+			var tests []string
+			for _, t := range step.(subtestReporter).SubTests() {
+				tests = append(tests, t.Name)
+			}
+			if diff := cmp.Diff(tc.expectedTests, tests); diff != "" {
+				t.Errorf("did not report correct tests: %s", diff)
+			}
+		})
+	}
+}
+
+func TestRunConditions(t *testing.T) {
+	enabled := "true"
+	for _, tc := range []struct {
+		name          string
+		env           api.TestEnvironment
+		sharedDir     map[string][]byte
+		failures      sets.String
+		test          []api.LiteralTestStep
+		expectedPods  []string
+		expectedSkips map[string]string
+	}{{
+		name: "env condition met",
+		test: []api.LiteralTestStep{{
+			As:          "fips",
+			Environment: []api.StepParameter{{Name: "FIPS_ENABLED", Default: &enabled}},
+			When:        &api.StepCondition{Env: []api.StepEnvCondition{{Name: "FIPS_ENABLED", Equals: "true"}}},
+		}},
+		expectedPods: []string{"test-fips"},
+	}, {
+		name: "env condition overridden by the test environment",
+		env:  api.TestEnvironment{"FIPS_ENABLED": "false"},
+		test: []api.LiteralTestStep{{
+			As:          "fips",
+			Environment: []api.StepParameter{{Name: "FIPS_ENABLED", Default: &enabled}},
+			When:        &api.StepCondition{Env: []api.StepEnvCondition{{Name: "FIPS_ENABLED", Equals: "true"}}},
+		}},
+		expectedSkips: map[string]string{
+			"Run multi-stage test test - test-fips container test": `parameter FIPS_ENABLED is "false", not "true"`,
+		},
+	}, {
+		name:      "shared dir file exists",
+		sharedDir: map[string][]byte{"kubeconfig": []byte("config")},
+		test: []api.LiteralTestStep{{
+			As:   "e2e",
+			When: &api.StepCondition{SharedDirFiles: []string{"kubeconfig"}},
+		}},
+		expectedPods: []string{"test-e2e"},
+	}, {
+		name: "shared dir file is missing",
+		test: []api.LiteralTestStep{{
+			As:   "e2e",
+			When: &api.StepCondition{SharedDirFiles: []string{"kubeconfig"}},
+		}},
+		expectedSkips: map[string]string{
+			"Run multi-stage test test - test-e2e container test": "file kubeconfig does not exist in the shared directory",
+		},
+	}, {
+		name:     "conditions on results of previous steps",
+		failures: sets.NewString("test-first"),
+		test: []api.LiteralTestStep{
+			{As: "first", BestEffort: utilpointer.BoolPtr(true)},
+			{As: "on-failure", When: &api.StepCondition{Steps: []api.StepResultCondition{{Name: "first", Result: api.StepResultFailed}}}},
+			{As: "on-success", When: &api.StepCondition{Steps: []api.StepResultCondition{{Name: "first", Result: api.StepResultSucceeded}}}},
+			{As: "on-skip", When: &api.StepCondition{Steps: []api.StepResultCondition{{Name: "on-success", Result: api.StepResultSkipped}}}},
+			{As: "on-unknown", When: &api.StepCondition{Steps: []api.StepResultCondition{{Name: "unknown", Result: api.StepResultSucceeded}}}},
+		},
+		expectedPods: []string{"test-first", "test-on-failure", "test-on-skip"},
+		expectedSkips: map[string]string{
+			"Run multi-stage test test - test-on-success container test": "step first failed, not succeeded",
+			"Run multi-stage test test - test-on-unknown container test": "step unknown did not run",
+		},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			sa := &coreapi.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "ns", Labels: map[string]string{"ci.openshift.io/multi-stage-test": "test"}}}
+			crclient := &fakePodExecutor{LoggingClient: loggingclient.New(fakectrlruntimeclient.NewFakeClient(sa.DeepCopyObject())), failures: tc.failures}
+			jobSpec := api.JobSpec{
+				JobSpec: prowdapi.JobSpec{
+					Job:       "job",
+					BuildID:   "build_id",
+					ProwJobID: "prow_job_id",
+					Type:      prowapi.PeriodicJob,
+					DecorationConfig: &prowapi.DecorationConfig{
+						Timeout:     &prowapi.Duration{Duration: time.Minute},
+						GracePeriod: &prowapi.Duration{Duration: time.Second},
+						UtilityImages: &prowapi.UtilityImages{
+							Sidecar:    "sidecar",
+							Entrypoint: "entrypoint",
+						},
+					},
+				},
+			}
+			jobSpec.SetNamespace("ns")
+			step := newMultiStageTestStep(api.TestStepConfiguration{
+				As: "test",
+				MultiStageTestConfigurationLiteral: &api.MultiStageTestConfigurationLiteral{
+					Test:                     tc.test,
+					Environment:              tc.env,
+					AllowBestEffortPostSteps: utilpointer.BoolPtr(true),
+				},
+			}, &api.ReleaseBuildConfiguration{}, nil, &fakePodClient{fakePodExecutor: crclient}, &jobSpec, nil)
+			if err := step.createSharedDirSecret(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			if tc.sharedDir != nil {
+				secret := &coreapi.Secret{}
+				if err := crclient.Get(context.Background(), ctrlruntimeclient.ObjectKey{Namespace: "ns", Name: "test"}, secret); err != nil {
+					t.Fatal(err)
+				}
+				secret.Data = tc.sharedDir
+				if err := crclient.Update(context.Background(), secret); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := step.runSteps(context.Background(), step.test, nil, true, false, nil, nil); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			var pods []string
+			for _, pod := range crclient.createdPods {
+				pods = append(pods, pod.Name)
+			}
+			if diff := cmp.Diff(tc.expectedPods, pods); diff != "" {
+				t.Errorf("did not execute correct pods: %s", diff)
+			}
+			skips := map[string]string{}
+			for _, test := range step.SubTests() {
+				if test.SkipMessage != nil {
+					skips[test.Name] = test.SkipMessage.Message
+				}
+			}
+			if tc.expectedSkips == nil {
+				tc.expectedSkips = map[string]string{}
+			}
+			if diff := cmp.Diff(tc.expectedSkips, skips); diff != "" {
+				t.Errorf("did not skip correct steps: %s", diff)
+			}
+		})
+	}
+}
+
+func TestRunParallel(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		failures      sets.String
+		expectedErr   bool
+		expectedPods  []string
+		expectedTests []string
+	}{{
+		name: "all steps succeed",
+		expectedPods: []string{
+			"test-conformance-parallel", "test-conformance-serial", "test-e2e",
+			"test-audit-logs", "test-must-gather",
+		},
+		expectedTests: []string{
+			"Run multi-stage test test - test-audit-logs container test",
+			"Run multi-stage test test - test-conformance-parallel container test",
+			"Run multi-stage test test - test-conformance-serial container test",
+			"Run multi-stage test test - test-e2e container test",
+			"Run multi-stage test test - test-must-gather container test",
+		},
+	}, {
+		name:        "failure in a group lets the group finish but short-circuits the phase",
+		failures:    sets.NewString("test-conformance-parallel"),
+		expectedErr: true,
+		expectedPods: []string{
+			"test-conformance-parallel", "test-conformance-serial",
+			"test-audit-logs", "test-must-gather",
+		},
+		expectedTests: []string{
+			"Run multi-stage test test - test-audit-logs container test",
+			"Run multi-stage test test - test-conformance-parallel container test",
+			"Run multi-stage test test - test-conformance-serial container test",
+			"Run multi-stage test test - test-must-gather container test",
+		},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			sa := &coreapi.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "ns", Labels: map[string]string{"ci.openshift.io/multi-stage-test": "test"}}}
+			crclient := &fakePodExecutor{LoggingClient: loggingclient.New(fakectrlruntimeclient.NewFakeClient(sa.DeepCopyObject())), failures: tc.failures}
+			jobSpec := api.JobSpec{
+				JobSpec: prowdapi.JobSpec{
+					Job:       "job",
+					BuildID:   "build_id",
+					ProwJobID: "prow_job_id",
+					Type:      prowapi.PeriodicJob,
+					DecorationConfig: &prowapi.DecorationConfig{
+						Timeout:     &prowapi.Duration{Duration: time.Minute},
+						GracePeriod: &prowapi.Duration{Duration: time.Second},
+						UtilityImages: &prowapi.UtilityImages{
+							Sidecar:    "sidecar",
+							Entrypoint: "entrypoint",
+						},
+					},
+				},
+			}
+			jobSpec.SetNamespace("ns")
+			step := MultiStageTestStep(api.TestStepConfiguration{
+				As: "test",
+				MultiStageTestConfigurationLiteral: &api.MultiStageTestConfigurationLiteral{
+					Test: []api.LiteralTestStep{
+						{As: "conformance-parallel", ParallelGroup: "conformance"},
+						{As: "conformance-serial", ParallelGroup: "conformance"},
+						{As: "e2e"},
+					},
+					Post: []api.LiteralTestStep{
+						{As: "audit-logs", ParallelGroup: "gather"},
+						{As: "must-gather", ParallelGroup: "gather"},
+					},
+				},
+			}, &api.ReleaseBuildConfiguration{}, nil, &fakePodClient{fakePodExecutor: crclient}, &jobSpec, nil)
+			if err := step.Run(context.Background()); (err != nil) != tc.expectedErr {
+				t.Errorf("expected error: %t, got error: %v", tc.expectedErr, err)
+			}
+			var pods []string
+			for _, pod := range crclient.createdPods {
+				pods = append(pods, pod.Name)
+			}
+			// steps in a group run in any order, but groups run one after another
+			sort.Strings(pods[:2])
+			sort.Strings(pods[len(pods)-2:])
+			if diff := cmp.Diff(tc.expectedPods, pods); diff != "" {
+				t.Errorf("did not execute correct pods: %s", diff)
+			}
+			var tests []string
+			for _, t := range step.(subtestReporter).SubTests() {
+				tests = append(tests, t.Name)
+			}
+			sort.Strings(tests)
+			if diff := cmp.Diff(tc.expectedTests, tests); diff != "" {
+				t.Errorf("did not report correct tests: %s", diff)
+			}
+		})
+	}
+}
+
 func TestAddCredentials(t *testing.T) {
 	var testCases = []struct {
 		name        string
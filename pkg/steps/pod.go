@@ -80,19 +80,9 @@ func (s *podStep) run(ctx context.Context) error {
 	if !s.config.SkipLogs {
 		logrus.Infof("Executing %s %s", s.name, s.config.As)
 	}
-	containerResources, err := resourcesFor(s.resources.RequirementsForStep(s.config.As))
-	if err != nil {
-		return fmt.Errorf("unable to calculate %s pod resources for %s: %w", s.name, s.config.As, err)
-	}
-
-	if s.config.From.Namespace != "" {
-		return errors.New("pod step does not support an image stream tag reference outside the namespace")
-	}
-	image := fmt.Sprintf("%s:%s", s.config.From.Name, s.config.From.Tag)
-
-	pod, err := s.generatePodForStep(image, containerResources, s.config.Clone)
+	pod, err := s.generatePod()
 	if err != nil {
-		return fmt.Errorf("pod step was invalid: %w", err)
+		return err
 	}
 	testCaseNotifier := NewTestCaseNotifier(NopNotifier)
 
@@ -123,6 +113,33 @@ func (s *podStep) run(ctx context.Context) error {
 	return nil
 }
 
+// generatePod generates the pod the step runs.
+func (s *podStep) generatePod() (*coreapi.Pod, error) {
+	containerResources, err := resourcesFor(s.resources.RequirementsForStep(s.config.As))
+	if err != nil {
+		return nil, fmt.Errorf("unable to calculate %s pod resources for %s: %w", s.name, s.config.As, err)
+	}
+
+	if s.config.From.Namespace != "" {
+		return nil, errors.New("pod step does not support an image stream tag reference outside the namespace")
+	}
+	image := fmt.Sprintf("%s:%s", s.config.From.Name, s.config.From.Tag)
+
+	pod, err := s.generatePodForStep(image, containerResources, s.config.Clone)
+	if err != nil {
+		return nil, fmt.Errorf("pod step was invalid: %w", err)
+	}
+	return pod, nil
+}
+
+func (s *podStep) PlannedPods() ([]coreapi.Pod, error) {
+	pod, err := s.generatePod()
+	if err != nil {
+		return nil, err
+	}
+	return []coreapi.Pod{*pod}, nil
+}
+
 func (s *podStep) SubTests() []*junit.TestCase {
 	return s.subTests
 }
@@ -5,8 +5,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"path"
+	"sync"
+
+	"github.com/sirupsen/logrus"
 
 	coreapi "k8s.io/api/core/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
 
 	buildapi "github.com/openshift/api/build/v1"
@@ -14,6 +18,7 @@ import (
 	imagev1 "github.com/openshift/api/image/v1"
 
 	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/events"
 	"github.com/openshift/ci-tools/pkg/results"
 	"github.com/openshift/ci-tools/pkg/steps/utils"
 )
@@ -25,6 +30,7 @@ type projectDirectoryImageBuildStep struct {
 	client             BuildClient
 	jobSpec            *api.JobSpec
 	pullSecret         *coreapi.Secret
+	layerCacheSecret   *coreapi.Secret
 }
 
 func (s *projectDirectoryImageBuildStep) Inputs() (api.InputDefinition, error) {
@@ -61,7 +67,77 @@ func (s *projectDirectoryImageBuildStep) run(ctx context.Context) error {
 		s.pullSecret,
 		s.config.BuildArgs,
 	)
-	return handleBuild(ctx, s.client, build)
+	s.configureLayerCache(build)
+	if len(s.config.Architectures) == 0 {
+		return s.client.Build(ctx, build)
+	}
+	return s.buildArchitectures(ctx, build)
+}
+
+// configureLayerCache marks the build to reuse the cached layers of the image
+// and, when the step holds the credentials to push to the cache, to refresh it.
+func (s *projectDirectoryImageBuildStep) configureLayerCache(build *buildapi.Build) {
+	if !s.config.UseBuildCache {
+		return
+	}
+	build.Annotations[LayerCacheAnnotation] = api.LayerCacheFor(s.releaseBuildConfig.Metadata, s.config.To)
+	if s.layerCacheSecret != nil {
+		build.Annotations[LayerCacheSecretAnnotation] = s.layerCacheSecret.Name
+	}
+}
+
+// buildArchitectures builds the image once for each architecture and tags a
+// manifest list of the results as the output of the step.
+func (s *projectDirectoryImageBuildStep) buildArchitectures(ctx context.Context, build *buildapi.Build) error {
+	errs := make([]error, len(s.config.Architectures))
+	var wg sync.WaitGroup
+	for i, architecture := range s.config.Architectures {
+		wg.Add(1)
+		go func(i int, architecture api.ReleaseArchitecture) {
+			defer wg.Done()
+			errs[i] = s.client.Build(ctx, buildForArchitecture(build, s.config.To, architecture))
+		}(i, architecture)
+	}
+	wg.Wait()
+	if err := utilerrors.NewAggregate(errs); err != nil {
+		return err
+	}
+
+	images := map[api.ReleaseArchitecture]string{}
+	for _, architecture := range s.config.Architectures {
+		digest, err := resolvePipelineImageStreamTagReference(ctx, s.client, api.ArchitectureImage(s.config.To, architecture), s.jobSpec)
+		if err != nil {
+			return err
+		}
+		images[architecture] = digest
+	}
+	pipeline := &imagev1.ImageStream{}
+	if err := s.client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: s.jobSpec.Namespace(), Name: api.PipelineImageStream}, pipeline); err != nil {
+		return fmt.Errorf("could not get pipeline imagestream: %w", err)
+	}
+	repository := pipeline.Status.PublicDockerImageRepository
+	if repository == "" {
+		repository = pipeline.Status.DockerImageRepository
+	}
+	logrus.Infof("Tagging a manifest list of %s images as %s", s.config.To, s.config.To)
+	if err := s.client.PushManifestList(ctx, repository, string(s.config.To), images); err != nil {
+		return fmt.Errorf("could not tag %s: %w", s.config.To, err)
+	}
+	events.Record(ctx, events.Event{Type: events.ImagePushed, Details: map[string]string{"image": fmt.Sprintf("%s:%s", api.PipelineImageStream, s.config.To)}})
+	return nil
+}
+
+// buildForArchitecture derives the build of the variant of an image for an
+// architecture, which runs on nodes of that architecture and is tagged
+// separately.
+func buildForArchitecture(build *buildapi.Build, to api.PipelineImageStreamTagReference, architecture api.ReleaseArchitecture) *buildapi.Build {
+	image := api.ArchitectureImage(to, architecture)
+	build = build.DeepCopy()
+	build.Name = string(image)
+	build.Labels[CreatesLabel] = string(image)
+	build.Spec.NodeSelector = buildapi.OptionalNodeSelector{coreapi.LabelArchStable: string(architecture)}
+	build.Spec.Output.To.Name = fmt.Sprintf("%s:%s", api.PipelineImageStream, image)
+	return build
 }
 
 type workingDir func(tag string) (string, error)
@@ -161,7 +237,10 @@ func (s *projectDirectoryImageBuildStep) Objects() []ctrlruntimeclient.Object {
 	return s.client.Objects()
 }
 
-func ProjectDirectoryImageBuildStep(config api.ProjectDirectoryImageBuildStepConfiguration, releaseBuildConfig *api.ReleaseBuildConfiguration, resources api.ResourceConfiguration, buildClient BuildClient, jobSpec *api.JobSpec, pullSecret *coreapi.Secret) api.Step {
+// ProjectDirectoryImageBuildStep builds an image from the repository under
+// test. When the layer cache secret is set, the build refreshes the layer
+// cache of the image, if it uses one.
+func ProjectDirectoryImageBuildStep(config api.ProjectDirectoryImageBuildStepConfiguration, releaseBuildConfig *api.ReleaseBuildConfiguration, resources api.ResourceConfiguration, buildClient BuildClient, jobSpec *api.JobSpec, pullSecret, layerCacheSecret *coreapi.Secret) api.Step {
 	return &projectDirectoryImageBuildStep{
 		config:             config,
 		releaseBuildConfig: releaseBuildConfig,
@@ -169,5 +248,6 @@ func ProjectDirectoryImageBuildStep(config api.ProjectDirectoryImageBuildStepCon
 		client:             buildClient,
 		jobSpec:            jobSpec,
 		pullSecret:         pullSecret,
+		layerCacheSecret:   layerCacheSecret,
 	}
 }
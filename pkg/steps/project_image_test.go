@@ -7,6 +7,7 @@ import (
 	"github.com/google/go-cmp/cmp"
 
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	buildapi "github.com/openshift/api/build/v1"
 
@@ -266,3 +267,74 @@ func TestImagesFor(t *testing.T) {
 		})
 	}
 }
+
+func TestBuildForArchitecture(t *testing.T) {
+	build := &buildapi.Build{
+		ObjectMeta: metav1.ObjectMeta{Name: "image", Namespace: "ns", Labels: map[string]string{CreatesLabel: "image"}},
+		Spec: buildapi.BuildSpec{CommonSpec: buildapi.CommonSpec{
+			Output: buildapi.BuildOutput{To: &corev1.ObjectReference{Kind: "ImageStreamTag", Namespace: "ns", Name: "pipeline:image"}},
+		}},
+	}
+	expected := &buildapi.Build{
+		ObjectMeta: metav1.ObjectMeta{Name: "image-arm64", Namespace: "ns", Labels: map[string]string{CreatesLabel: "image-arm64"}},
+		Spec: buildapi.BuildSpec{CommonSpec: buildapi.CommonSpec{
+			NodeSelector: buildapi.OptionalNodeSelector{"kubernetes.io/arch": "arm64"},
+			Output:       buildapi.BuildOutput{To: &corev1.ObjectReference{Kind: "ImageStreamTag", Namespace: "ns", Name: "pipeline:image-arm64"}},
+		}},
+	}
+	original := build.DeepCopy()
+	if diff := cmp.Diff(expected, buildForArchitecture(build, "image", api.ReleaseArchitectureARM64)); diff != "" {
+		t.Errorf("unexpected build: %s", diff)
+	}
+	if diff := cmp.Diff(original, build); diff != "" {
+		t.Errorf("the original build was modified: %s", diff)
+	}
+}
+
+func TestConfigureLayerCache(t *testing.T) {
+	metadata := api.Metadata{Org: "org", Repo: "repo", Branch: "master"}
+	var testCases = []struct {
+		name             string
+		useBuildCache    bool
+		layerCacheSecret *corev1.Secret
+		expected         map[string]string
+	}{
+		{
+			name:     "no layer cache",
+			expected: map[string]string{},
+		},
+		{
+			name:          "layer cache is used",
+			useBuildCache: true,
+			expected:      map[string]string{LayerCacheAnnotation: "registry.ci.openshift.org/build-cache/org-repo-master-image"},
+		},
+		{
+			name:             "layer cache is refreshed",
+			useBuildCache:    true,
+			layerCacheSecret: &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: api.RegistryPushCredentialsCICentralSecret}},
+			expected: map[string]string{
+				LayerCacheAnnotation:       "registry.ci.openshift.org/build-cache/org-repo-master-image",
+				LayerCacheSecretAnnotation: api.RegistryPushCredentialsCICentralSecret,
+			},
+		},
+		{
+			name:             "layer cache secret without layer cache",
+			layerCacheSecret: &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: api.RegistryPushCredentialsCICentralSecret}},
+			expected:         map[string]string{},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			s := &projectDirectoryImageBuildStep{
+				config:             api.ProjectDirectoryImageBuildStepConfiguration{To: "image", UseBuildCache: testCase.useBuildCache},
+				releaseBuildConfig: &api.ReleaseBuildConfiguration{Metadata: metadata},
+				layerCacheSecret:   testCase.layerCacheSecret,
+			}
+			build := &buildapi.Build{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{}}}
+			s.configureLayerCache(build)
+			if diff := cmp.Diff(testCase.expected, build.Annotations); diff != "" {
+				t.Errorf("unexpected annotations: %s", diff)
+			}
+		})
+	}
+}
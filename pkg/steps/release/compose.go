@@ -0,0 +1,223 @@
+package release
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	coreapi "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	imagev1 "github.com/openshift/api/image/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/results"
+	"github.com/openshift/ci-tools/pkg/steps/loggingclient"
+	"github.com/openshift/ci-tools/pkg/steps/utils"
+)
+
+// releaseCompositionStep composes the images of a release from the images
+// of a base release, which was imported or snapshot before, and overrides
+// for some of its components taken from integration streams or literal pull
+// specs. The composed images are tagged into the stable stream of the
+// release, from which the payload is then assembled.
+type releaseCompositionStep struct {
+	name      string
+	overrides []api.ComponentOverride
+	client    loggingclient.LoggingClient
+	jobSpec   *api.JobSpec
+}
+
+func (s *releaseCompositionStep) Inputs() (api.InputDefinition, error) {
+	return nil, nil
+}
+
+func (*releaseCompositionStep) Validate() error { return nil }
+
+func (s *releaseCompositionStep) Run(ctx context.Context) error {
+	return results.ForReason("composing_release").ForError(s.run(ctx))
+}
+
+func (s *releaseCompositionStep) run(ctx context.Context) error {
+	namespace := s.jobSpec.Namespace()
+	baseName := api.ReleaseStreamFor(api.CompositionBaseName(s.name))
+	base := &imagev1.ImageStream{}
+	if err := wait.PollImmediate(3*time.Second, 15*time.Minute, func() (bool, error) {
+		if err := s.client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: namespace, Name: baseName}, base); err != nil {
+			return false, fmt.Errorf("could not resolve imagestream %s: %w", baseName, err)
+		}
+		missing := missingStatusTags(base)
+		if len(missing) > 0 {
+			logrus.Debugf("Waiting for the images of the base release to be imported: %s", strings.Join(missing, ", "))
+		}
+		return len(missing) == 0, nil
+	}); err != nil {
+		return fmt.Errorf("the images of the base release %s were not imported: %w", baseName, err)
+	}
+
+	sources := map[string]*imagev1.ImageStream{}
+	for _, override := range s.overrides {
+		if override.PullSpec != "" {
+			continue
+		}
+		key := fmt.Sprintf("%s/%s", override.Namespace, override.Name)
+		if _, ok := sources[key]; ok {
+			continue
+		}
+		source := &imagev1.ImageStream{}
+		if err := s.client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: override.Namespace, Name: override.Name}, source); err != nil {
+			return fmt.Errorf("could not resolve source imagestream %s for release %s: %w", key, s.name, err)
+		}
+		sources[key] = source
+	}
+
+	composed, err := composeStream(base, sources, s.overrides)
+	if err != nil {
+		return err
+	}
+	composed.Namespace = namespace
+	composed.Name = api.ReleaseStreamFor(s.name)
+	if err := s.client.Create(ctx, composed.DeepCopy()); err != nil && !kerrors.IsAlreadyExists(err) {
+		return fmt.Errorf("could not create composed imagestream %s: %w", composed.Name, err)
+	}
+
+	// images overridden by pull specs need to be imported before
+	// the payload can be assembled from the stream
+	if err := wait.PollImmediate(3*time.Second, 15*time.Minute, func() (bool, error) {
+		if err := s.client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: namespace, Name: composed.Name}, composed); err != nil {
+			return false, fmt.Errorf("could not resolve imagestream %s: %w", composed.Name, err)
+		}
+		return len(missingStatusTags(composed)) == 0, nil
+	}); err != nil {
+		return fmt.Errorf("the following overridden images could not be imported to %s: %s", composed.Name, strings.Join(missingStatusTags(composed), ", "))
+	}
+	logrus.Infof("Composed release %s from %d images of the base release and %d overridden components.", s.name, len(composed.Spec.Tags)-overriddenCount(s.overrides), overriddenCount(s.overrides))
+	return nil
+}
+
+// missingStatusTags lists the tags in the spec of the stream which have not
+// been imported yet
+func missingStatusTags(stream *imagev1.ImageStream) []string {
+	var missing []string
+	for _, tag := range stream.Spec.Tags {
+		if ref, _ := utils.FindStatusTag(stream, tag.Name); ref == nil {
+			missing = append(missing, tag.Name)
+		}
+	}
+	return missing
+}
+
+func overriddenCount(overrides []api.ComponentOverride) int {
+	var count int
+	for _, override := range overrides {
+		count += len(override.Components)
+	}
+	return count
+}
+
+// composeStream creates the stream of a composed release: every image of the
+// base release, with the images of the overridden components replaced from
+// their sources. Components which are not in the base release are added.
+func composeStream(base *imagev1.ImageStream, sources map[string]*imagev1.ImageStream, overrides []api.ComponentOverride) (*imagev1.ImageStream, error) {
+	composed := &imagev1.ImageStream{
+		ObjectMeta: meta.ObjectMeta{
+			Annotations: map[string]string{},
+		},
+		Spec: imagev1.ImageStreamSpec{
+			LookupPolicy: imagev1.ImageLookupPolicy{
+				Local: true,
+			},
+		},
+	}
+	if raw, ok := base.ObjectMeta.Annotations[releaseConfigAnnotation]; ok {
+		composed.ObjectMeta.Annotations[releaseConfigAnnotation] = raw
+	}
+
+	replacements := map[string]*coreapi.ObjectReference{}
+	var missing []string
+	for _, override := range overrides {
+		for _, component := range override.Components {
+			if override.PullSpec != "" {
+				replacements[component] = &coreapi.ObjectReference{Kind: "DockerImage", Name: override.PullSpec}
+				continue
+			}
+			key := fmt.Sprintf("%s/%s", override.Namespace, override.Name)
+			ref, _ := utils.FindStatusTag(sources[key], component)
+			if ref == nil {
+				missing = append(missing, fmt.Sprintf("%s:%s", key, component))
+				continue
+			}
+			replacements[component] = ref
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("the following images to override components with do not exist: %s", strings.Join(missing, ", "))
+	}
+
+	seen := sets.NewString()
+	addTag := func(name string, from *coreapi.ObjectReference) {
+		seen.Insert(name)
+		composed.Spec.Tags = append(composed.Spec.Tags, imagev1.TagReference{
+			Name:            name,
+			From:            from,
+			ReferencePolicy: imagev1.TagReferencePolicy{Type: imagev1.LocalTagReferencePolicy},
+		})
+	}
+	for _, tag := range base.Status.Tags {
+		if replacement, ok := replacements[tag.Tag]; ok {
+			addTag(tag.Tag, replacement)
+			continue
+		}
+		if valid, _ := utils.FindStatusTag(base, tag.Tag); valid != nil {
+			addTag(tag.Tag, valid)
+		}
+	}
+	for _, component := range sets.StringKeySet(replacements).List() {
+		if !seen.Has(component) {
+			addTag(component, replacements[component])
+		}
+	}
+	return composed, nil
+}
+
+func (s *releaseCompositionStep) Name() string {
+	return fmt.Sprintf("[release-composition:%s]", s.name)
+}
+
+func (s *releaseCompositionStep) Description() string {
+	return fmt.Sprintf("Compose the images of the release %q from its base release and overrides in the %s stream", s.name, api.ReleaseStreamFor(s.name))
+}
+
+func (s *releaseCompositionStep) Requires() []api.StepLink {
+	return []api.StepLink{api.ReleaseImagesLink(api.CompositionBaseName(s.name))}
+}
+
+func (s *releaseCompositionStep) Creates() []api.StepLink {
+	return []api.StepLink{api.ReleaseImagesLink(s.name)}
+}
+
+func (s *releaseCompositionStep) Provides() api.ParameterMap {
+	return nil
+}
+
+func (s *releaseCompositionStep) Objects() []ctrlruntimeclient.Object {
+	return s.client.Objects()
+}
+
+// ReleaseCompositionStep composes the images of a release from its base
+// release and the overrides of its components.
+func ReleaseCompositionStep(release string, overrides []api.ComponentOverride, client loggingclient.LoggingClient, jobSpec *api.JobSpec) api.Step {
+	return &releaseCompositionStep{
+		name:      release,
+		overrides: overrides,
+		client:    client,
+		jobSpec:   jobSpec,
+	}
+}
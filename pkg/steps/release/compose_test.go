@@ -0,0 +1,62 @@
+package release
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	imagev1 "github.com/openshift/api/image/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestComposeStream(t *testing.T) {
+	stream := func(namespace, name string, tags ...string) *imagev1.ImageStream {
+		is := &imagev1.ImageStream{ObjectMeta: meta.ObjectMeta{Namespace: namespace, Name: name}}
+		for _, tag := range tags {
+			is.Status.Tags = append(is.Status.Tags, imagev1.NamedTagEventList{
+				Tag:   tag,
+				Items: []imagev1.TagEvent{{Image: "sha256:" + name + "-" + tag}},
+			})
+		}
+		return is
+	}
+	base := stream("ci-op-1234", "stable-latest-base", "cli", "installer", "machine-os-content")
+	base.Annotations = map[string]string{releaseConfigAnnotation: `{"name":"4.10.0-0.nightly"}`}
+	sources := map[string]*imagev1.ImageStream{
+		"team/4.10": stream("team", "4.10", "machine-os-content", "new-operator"),
+	}
+
+	for _, tc := range []struct {
+		name        string
+		overrides   []api.ComponentOverride
+		expectedErr error
+	}{{
+		name: "components are overridden and added",
+		overrides: []api.ComponentOverride{
+			{Components: []string{"machine-os-content", "new-operator"}, Namespace: "team", Name: "4.10"},
+			{Components: []string{"installer"}, PullSpec: "quay.io/team/installer:latest"},
+		},
+	}, {
+		name: "overriding with missing images fails",
+		overrides: []api.ComponentOverride{
+			{Components: []string{"cli"}, Namespace: "team", Name: "4.10"},
+		},
+		expectedErr: errors.New("the following images to override components with do not exist: team/4.10:cli"),
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			composed, err := composeStream(base, sources, tc.overrides)
+			if diff := cmp.Diff(tc.expectedErr, err, testhelper.EquateErrorMessage); diff != "" {
+				t.Fatalf("unexpected error: %s", diff)
+			}
+			if err != nil {
+				return
+			}
+			testhelper.CompareWithFixture(t, composed)
+		})
+	}
+}
@@ -0,0 +1,376 @@
+package release
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	coreapi "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	imagev1 "github.com/openshift/api/image/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/junit"
+	"github.com/openshift/ci-tools/pkg/results"
+	"github.com/openshift/ci-tools/pkg/secrets"
+	"github.com/openshift/ci-tools/pkg/steps"
+	"github.com/openshift/ci-tools/pkg/steps/utils"
+)
+
+const (
+	// commitAnnotation and sourceAnnotation are set on the references
+	// of a release payload by the builds of its components
+	commitAnnotation = "io.openshift.build.commit.id"
+	sourceAnnotation = "io.openshift.build.source-location"
+)
+
+// releaseInfo is the subset of the output of `oc adm release info -o json`
+// which is compared between payloads
+type releaseInfo struct {
+	Digest   string `json:"digest"`
+	Metadata struct {
+		Version string `json:"version"`
+	} `json:"metadata"`
+	References      imagev1.ImageStream         `json:"references"`
+	DisplayVersions map[string]componentVersion `json:"displayVersions,omitempty"`
+}
+
+type componentVersion struct {
+	Version     string `json:"Version"`
+	DisplayName string `json:"DisplayName,omitempty"`
+}
+
+// payloadSummary identifies one of the compared payloads
+type payloadSummary struct {
+	Name    string
+	Version string
+	Digest  string
+}
+
+// componentChange describes how the image of a component differs between
+// two payloads. Added and removed components have no digest on one side.
+type componentChange struct {
+	Name       string
+	FromDigest string
+	ToDigest   string
+	FromCommit string
+	ToCommit   string
+	Source     string
+}
+
+// CompareURL links to the commits the component moved by, if it is built
+// from a GitHub repository.
+func (c componentChange) CompareURL() string {
+	if c.FromCommit == "" || c.ToCommit == "" || c.FromCommit == c.ToCommit || !strings.HasPrefix(c.Source, "https://github.com/") {
+		return ""
+	}
+	return fmt.Sprintf("%s/compare/%s...%s", strings.TrimSuffix(c.Source, "/"), c.FromCommit, c.ToCommit)
+}
+
+func (c componentChange) String() string {
+	switch {
+	case c.FromDigest == "":
+		return fmt.Sprintf("component %s was added at %s", c.Name, c.ToDigest)
+	case c.ToDigest == "":
+		return fmt.Sprintf("component %s was removed, it was at %s", c.Name, c.FromDigest)
+	}
+	description := fmt.Sprintf("component %s changed from %s to %s", c.Name, c.FromDigest, c.ToDigest)
+	if url := c.CompareURL(); url != "" {
+		description += fmt.Sprintf(", commits: %s", url)
+	} else if c.FromCommit != c.ToCommit {
+		description += fmt.Sprintf(", commit %s to %s", c.FromCommit, c.ToCommit)
+	}
+	return description
+}
+
+// versionChange describes how a displayed version, like the one of RHCOS,
+// differs between two payloads
+type versionChange struct {
+	Name        string
+	DisplayName string
+	From        string
+	To          string
+}
+
+func (c versionChange) String() string {
+	name := c.Name
+	if c.DisplayName != "" {
+		name = c.DisplayName
+	}
+	return fmt.Sprintf("%s changed from %s to %s", name, c.From, c.To)
+}
+
+// releaseDiff describes the differences between two release payloads
+type releaseDiff struct {
+	From       payloadSummary
+	To         payloadSummary
+	Components []componentChange
+	Versions   []versionChange
+}
+
+func digestOfTag(tag imagev1.TagReference) string {
+	if tag.From == nil {
+		return ""
+	}
+	return digestOf(tag.From.Name)
+}
+
+// diffReleases compares the component images and displayed versions of two
+// payloads. Only differences are recorded.
+func diffReleases(fromName string, from releaseInfo, toName string, to releaseInfo) releaseDiff {
+	diff := releaseDiff{
+		From: payloadSummary{Name: fromName, Version: from.Metadata.Version, Digest: from.Digest},
+		To:   payloadSummary{Name: toName, Version: to.Metadata.Version, Digest: to.Digest},
+	}
+
+	fromTags := map[string]imagev1.TagReference{}
+	toTags := map[string]imagev1.TagReference{}
+	names := sets.NewString()
+	for _, tag := range from.References.Spec.Tags {
+		fromTags[tag.Name] = tag
+		names.Insert(tag.Name)
+	}
+	for _, tag := range to.References.Spec.Tags {
+		toTags[tag.Name] = tag
+		names.Insert(tag.Name)
+	}
+	for _, name := range names.List() {
+		fromTag, toTag := fromTags[name], toTags[name]
+		change := componentChange{
+			Name:       name,
+			FromDigest: digestOfTag(fromTag),
+			ToDigest:   digestOfTag(toTag),
+			FromCommit: fromTag.Annotations[commitAnnotation],
+			ToCommit:   toTag.Annotations[commitAnnotation],
+			Source:     toTag.Annotations[sourceAnnotation],
+		}
+		if change.FromDigest == change.ToDigest {
+			continue
+		}
+		if change.Source == "" {
+			change.Source = fromTag.Annotations[sourceAnnotation]
+		}
+		diff.Components = append(diff.Components, change)
+	}
+
+	var versions []string
+	for name := range to.DisplayVersions {
+		versions = append(versions, name)
+	}
+	sort.Strings(versions)
+	for _, name := range versions {
+		fromVersion, toVersion := from.DisplayVersions[name], to.DisplayVersions[name]
+		if fromVersion.Version == toVersion.Version {
+			continue
+		}
+		diff.Versions = append(diff.Versions, versionChange{Name: name, DisplayName: toVersion.DisplayName, From: fromVersion.Version, To: toVersion.Version})
+	}
+	return diff
+}
+
+// testCases reports every difference as a passing test case, so the changes
+// show up next to the failures of the tests they might have caused
+func (d releaseDiff) testCases() []*junit.TestCase {
+	prefix := fmt.Sprintf("Release %s to %s:", d.From.Name, d.To.Name)
+	var tests []*junit.TestCase
+	for _, change := range d.Components {
+		tests = append(tests, &junit.TestCase{
+			Name:      fmt.Sprintf("%s component %s changed", prefix, change.Name),
+			SystemOut: change.String(),
+		})
+	}
+	for _, change := range d.Versions {
+		tests = append(tests, &junit.TestCase{
+			Name:      fmt.Sprintf("%s version of %s changed", prefix, change.Name),
+			SystemOut: change.String(),
+		})
+	}
+	return tests
+}
+
+var releaseDiffTemplate = template.Must(template.New("release-diff").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="UTF-8">
+<title>Release {{ .From.Name }} to {{ .To.Name }}</title>
+<style>
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; font-family: monospace; }
+</style>
+</head>
+<body>
+<h1>Release {{ .From.Name }} to {{ .To.Name }}</h1>
+<table>
+<tr><th></th><th>Version</th><th>Digest</th></tr>
+<tr><td>{{ .From.Name }}</td><td>{{ .From.Version }}</td><td>{{ .From.Digest }}</td></tr>
+<tr><td>{{ .To.Name }}</td><td>{{ .To.Version }}</td><td>{{ .To.Digest }}</td></tr>
+</table>
+<h2>Versions</h2>
+{{ if .Versions }}<table>
+<tr><th>Name</th><th>{{ .From.Name }}</th><th>{{ .To.Name }}</th></tr>
+{{ range .Versions }}<tr><td>{{ if .DisplayName }}{{ .DisplayName }}{{ else }}{{ .Name }}{{ end }}</td><td>{{ .From }}</td><td>{{ .To }}</td></tr>
+{{ end }}</table>{{ else }}<p>No displayed versions changed.</p>{{ end }}
+<h2>Components</h2>
+{{ if .Components }}<table>
+<tr><th>Component</th><th>{{ .From.Name }}</th><th>{{ .To.Name }}</th><th>Commits</th></tr>
+{{ range .Components }}<tr><td>{{ .Name }}</td><td>{{ .FromDigest }}</td><td>{{ .ToDigest }}</td><td>{{ with .CompareURL }}<a href="{{ . }}">{{ . }}</a>{{ else }}{{ .FromCommit }} {{ .ToCommit }}{{ end }}</td></tr>
+{{ end }}</table>{{ else }}<p>No component images changed.</p>{{ end }}
+</body>
+</html>
+`))
+
+// releaseDiffStep compares two release payloads from the `release` image
+// stream. It reports the components whose images changed, the commits they
+// moved by and the changes to the displayed versions like the one of RHCOS,
+// both as JUnit test cases and as an HTML artifact, so that failures of
+// upgrade tests can be tied to the components that changed.
+type releaseDiffStep struct {
+	from       string
+	to         string
+	resources  api.ResourceConfiguration
+	client     steps.PodClient
+	jobSpec    *api.JobSpec
+	pullSecret *coreapi.Secret
+	censor     *secrets.DynamicCensor
+
+	subTests []*junit.TestCase
+}
+
+func (s *releaseDiffStep) Inputs() (api.InputDefinition, error) {
+	return nil, nil
+}
+
+func (*releaseDiffStep) Validate() error { return nil }
+
+func (s *releaseDiffStep) Run(ctx context.Context) error {
+	return results.ForReason("diffing_releases").ForError(s.run(ctx))
+}
+
+func (s *releaseDiffStep) run(ctx context.Context) error {
+	var pullSpecs []string
+	for _, name := range []string{s.from, s.to} {
+		pullSpec, err := utils.ImageDigestFor(s.client, s.jobSpec.Namespace, api.ReleaseImageStream, name)()
+		if err != nil {
+			return fmt.Errorf("could not resolve release %s: %w", name, err)
+		}
+		pullSpecs = append(pullSpecs, pullSpec)
+	}
+
+	var secrets []*api.Secret
+	if s.pullSecret != nil {
+		secrets = []*api.Secret{{
+			Name:      s.pullSecret.Name,
+			MountPath: "/pull",
+		}}
+	}
+	target := fmt.Sprintf("release-diff-%s-%s", s.from, s.to)
+	commands := fmt.Sprintf(`
+set -euo pipefail
+export HOME=/tmp
+mkdir -p $HOME/.docker
+if [[ -d /pull ]]; then
+	cp /pull/.dockerconfigjson $HOME/.docker/config.json
+fi
+oc registry login
+oc adm release info --output=json %q > ${ARTIFACT_DIR}/release-info-%s.json
+oc adm release info --output=json %q > ${ARTIFACT_DIR}/release-info-%s.json
+if oc get configmap %s; then
+	oc delete configmap %s
+fi
+oc create configmap %s --from-file=%s.json=${ARTIFACT_DIR}/release-info-%s.json --from-file=%s.json=${ARTIFACT_DIR}/release-info-%s.json
+`, pullSpecs[0], s.from, pullSpecs[1], s.to, target, target, target, s.from, s.from, s.to, s.to)
+
+	podConfig := steps.PodStepConfiguration{
+		SkipLogs: true,
+		As:       target,
+		From: api.ImageStreamTagReference{
+			Name: api.ReleaseStreamFor(s.to),
+			Tag:  "cli",
+		},
+		ServiceAccountName: "ci-operator",
+		Secrets:            secrets,
+		Commands:           commands,
+	}
+	resources := s.resources
+	if _, ok := resources[podConfig.As]; !ok {
+		copied := make(api.ResourceConfiguration)
+		for k, v := range resources {
+			copied[k] = v
+		}
+		copied[podConfig.As] = api.ResourceRequirements{Requests: api.ResourceList{"cpu": "50m", "memory": "400Mi"}}
+		resources = copied
+	}
+	step := steps.PodStep("release", podConfig, resources, s.client, s.jobSpec, nil)
+	if err := step.Run(ctx); err != nil {
+		return err
+	}
+
+	var configMap coreapi.ConfigMap
+	if err := s.client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: s.jobSpec.Namespace(), Name: target}, &configMap); err != nil {
+		return fmt.Errorf("could not fetch release information %s: %w", target, err)
+	}
+	infos := map[string]releaseInfo{}
+	for _, name := range []string{s.from, s.to} {
+		raw, ok := configMap.Data[fmt.Sprintf("%s.json", name)]
+		if !ok {
+			return fmt.Errorf("no release information found for release %s in configMap %s", name, target)
+		}
+		var info releaseInfo
+		if err := json.Unmarshal([]byte(raw), &info); err != nil {
+			return fmt.Errorf("unable to decode release information for release %s: %w", name, err)
+		}
+		infos[name] = info
+	}
+
+	diff := diffReleases(s.from, infos[s.from], s.to, infos[s.to])
+	s.subTests = diff.testCases()
+	logrus.Infof("Release %s differs from release %s in %d components and %d versions.", s.to, s.from, len(diff.Components), len(diff.Versions))
+
+	var page bytes.Buffer
+	if err := releaseDiffTemplate.Execute(&page, diff); err != nil {
+		return fmt.Errorf("could not render release diff: %w", err)
+	}
+	return api.SaveArtifact(s.censor, fmt.Sprintf("%s/%s.html", api.ReleaseImageStream, target), page.Bytes())
+}
+
+func (s *releaseDiffStep) SubTests() []*junit.TestCase { return s.subTests }
+
+func (s *releaseDiffStep) Requires() []api.StepLink {
+	return []api.StepLink{api.ReleasePayloadImageLink(s.from), api.ReleasePayloadImageLink(s.to)}
+}
+
+func (s *releaseDiffStep) Creates() []api.StepLink { return nil }
+
+func (s *releaseDiffStep) Provides() api.ParameterMap { return nil }
+
+func (s *releaseDiffStep) Name() string { return api.ReleaseDiffTarget }
+
+func (s *releaseDiffStep) Description() string {
+	return fmt.Sprintf("Compare the release payloads %q and %q", s.from, s.to)
+}
+
+func (s *releaseDiffStep) Objects() []ctrlruntimeclient.Object {
+	return s.client.Objects()
+}
+
+// ReleaseDiffStep compares two release payloads which were imported or assembled
+// by the job.
+func ReleaseDiffStep(from, to string, resources api.ResourceConfiguration, client steps.PodClient, jobSpec *api.JobSpec, pullSecret *coreapi.Secret, censor *secrets.DynamicCensor) api.Step {
+	return &releaseDiffStep{
+		from:       from,
+		to:         to,
+		resources:  resources,
+		client:     client,
+		jobSpec:    jobSpec,
+		pullSecret: pullSecret,
+		censor:     censor,
+	}
+}
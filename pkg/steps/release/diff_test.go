@@ -0,0 +1,71 @@
+package release
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	coreapi "k8s.io/api/core/v1"
+
+	imagev1 "github.com/openshift/api/image/v1"
+
+	"github.com/openshift/ci-tools/pkg/junit"
+)
+
+func TestDiffReleases(t *testing.T) {
+	tag := func(name, digest, commit string) imagev1.TagReference {
+		return imagev1.TagReference{
+			Name: name,
+			Annotations: map[string]string{
+				commitAnnotation: commit,
+				sourceAnnotation: "https://github.com/openshift/" + name,
+			},
+			From: &coreapi.ObjectReference{Kind: "DockerImage", Name: "quay.io/openshift-release-dev/ocp-v4.0-art-dev@" + digest},
+		}
+	}
+	from := releaseInfo{
+		Digest:     "sha256:initial",
+		References: imagev1.ImageStream{Spec: imagev1.ImageStreamSpec{Tags: []imagev1.TagReference{tag("cli", "sha256:cli", "a"), tag("installer", "sha256:installer-1", "b"), tag("removed", "sha256:removed", "c")}}},
+		DisplayVersions: map[string]componentVersion{
+			"kubernetes": {Version: "1.22.1"},
+			"machine-os": {Version: "49.84.202110081407-0", DisplayName: "Red Hat Enterprise Linux CoreOS"},
+		},
+	}
+	from.Metadata.Version = "4.9.0-0.nightly-2021-10-08-000000"
+	to := releaseInfo{
+		Digest:     "sha256:latest",
+		References: imagev1.ImageStream{Spec: imagev1.ImageStreamSpec{Tags: []imagev1.TagReference{tag("cli", "sha256:cli", "a"), tag("installer", "sha256:installer-2", "d"), tag("added", "sha256:added", "e")}}},
+		DisplayVersions: map[string]componentVersion{
+			"kubernetes": {Version: "1.22.1"},
+			"machine-os": {Version: "49.84.202110121215-0", DisplayName: "Red Hat Enterprise Linux CoreOS"},
+		},
+	}
+	to.Metadata.Version = "4.9.0-0.ci.test-2021-10-12-000000"
+
+	diff := diffReleases("initial", from, "latest", to)
+	expected := releaseDiff{
+		From: payloadSummary{Name: "initial", Version: "4.9.0-0.nightly-2021-10-08-000000", Digest: "sha256:initial"},
+		To:   payloadSummary{Name: "latest", Version: "4.9.0-0.ci.test-2021-10-12-000000", Digest: "sha256:latest"},
+		Components: []componentChange{
+			{Name: "added", ToDigest: "sha256:added", ToCommit: "e", Source: "https://github.com/openshift/added"},
+			{Name: "installer", FromDigest: "sha256:installer-1", ToDigest: "sha256:installer-2", FromCommit: "b", ToCommit: "d", Source: "https://github.com/openshift/installer"},
+			{Name: "removed", FromDigest: "sha256:removed", FromCommit: "c", Source: "https://github.com/openshift/removed"},
+		},
+		Versions: []versionChange{
+			{Name: "machine-os", DisplayName: "Red Hat Enterprise Linux CoreOS", From: "49.84.202110081407-0", To: "49.84.202110121215-0"},
+		},
+	}
+	if d := cmp.Diff(expected, diff); d != "" {
+		t.Errorf("unexpected diff: %s", d)
+	}
+
+	expectedTests := []*junit.TestCase{
+		{Name: "Release initial to latest: component added changed", SystemOut: "component added was added at sha256:added"},
+		{Name: "Release initial to latest: component installer changed", SystemOut: "component installer changed from sha256:installer-1 to sha256:installer-2, commits: https://github.com/openshift/installer/compare/b...d"},
+		{Name: "Release initial to latest: component removed changed", SystemOut: "component removed was removed, it was at sha256:removed"},
+		{Name: "Release initial to latest: version of machine-os changed", SystemOut: "Red Hat Enterprise Linux CoreOS changed from 49.84.202110081407-0 to 49.84.202110121215-0"},
+	}
+	if d := cmp.Diff(expectedTests, diff.testCases()); d != "" {
+		t.Errorf("unexpected test cases: %s", d)
+	}
+}
@@ -11,6 +11,9 @@ import (
 
 	"github.com/sirupsen/logrus"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
 	coreapi "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -26,6 +29,7 @@ import (
 	"github.com/openshift/ci-tools/pkg/results"
 	"github.com/openshift/ci-tools/pkg/steps"
 	"github.com/openshift/ci-tools/pkg/steps/utils"
+	"github.com/openshift/ci-tools/pkg/tracing"
 )
 
 // importReleaseStep is responsible for importing release images from
@@ -126,7 +130,8 @@ func (s *importReleaseStep) run(ctx context.Context) error {
 			},
 		},
 	}
-	if err := wait.ExponentialBackoff(wait.Backoff{Steps: 4, Duration: 1 * time.Second, Factor: 2}, func() (bool, error) {
+	_, span := tracing.Start(ctx, "import release image", trace.WithAttributes(attribute.String("pull_spec", s.pullSpec)))
+	err = wait.ExponentialBackoff(wait.Backoff{Steps: 4, Duration: 1 * time.Second, Factor: 2}, func() (bool, error) {
 		if err := s.client.Create(ctx, streamImport); err != nil {
 			if kerrors.IsConflict(err) {
 				return false, nil
@@ -144,7 +149,9 @@ func (s *importReleaseStep) run(ctx context.Context) error {
 		}
 		pullSpec = streamImport.Status.Images[0].Image.DockerImageReference
 		return true, nil
-	}); err != nil {
+	})
+	tracing.End(span, err)
+	if err != nil {
 		return fmt.Errorf("unable to import %s release image: %w", s.name, err)
 	}
 
@@ -264,7 +271,8 @@ oc create configmap release-%s --from-file=%s.yaml=${ARTIFACT_DIR}/%s
 	// tag fails
 	var waiting map[string]int64
 	stable := &imagev1.ImageStream{}
-	if err := wait.Poll(3*time.Second, 15*time.Minute, func() (bool, error) {
+	_, span = tracing.Start(ctx, "import release images", trace.WithAttributes(attribute.Int("images", len(releaseIS.Spec.Tags))))
+	err = wait.Poll(3*time.Second, 15*time.Minute, func() (bool, error) {
 		if err := s.client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: s.jobSpec.Namespace(), Name: streamName}, stable); err != nil {
 			return false, fmt.Errorf("could not resolve imagestream %s: %w", streamName, err)
 		}
@@ -302,7 +310,9 @@ oc create configmap release-%s --from-file=%s.yaml=${ARTIFACT_DIR}/%s
 		}
 		waiting = generations
 		return false, nil
-	}); err != nil {
+	})
+	tracing.End(span, err)
+	if err != nil {
 		if len(waiting) == 0 || err != wait.ErrWaitTimeout {
 			return fmt.Errorf("unable to import image stream %s: %w", streamName, err)
 		}
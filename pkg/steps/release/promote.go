@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/sirupsen/logrus"
 
@@ -17,12 +18,17 @@ import (
 	"k8s.io/apimachinery/pkg/util/sets"
 	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/retry"
+	utilpointer "k8s.io/utils/pointer"
 	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
 
 	imagev1 "github.com/openshift/api/image/v1"
+	"github.com/openshift/library-go/pkg/image/reference"
 
 	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/events"
 	"github.com/openshift/ci-tools/pkg/kubernetes/pkg/credentialprovider"
+	"github.com/openshift/ci-tools/pkg/promotion/history"
 	"github.com/openshift/ci-tools/pkg/results"
 	"github.com/openshift/ci-tools/pkg/steps"
 )
@@ -35,6 +41,7 @@ type promotionStep struct {
 	jobSpec        *api.JobSpec
 	client         steps.PodClient
 	pushSecret     *coreapi.Secret
+	signingSecret  *coreapi.Secret
 }
 
 func targetName(config api.PromotionConfiguration) string {
@@ -62,6 +69,9 @@ func (s *promotionStep) run(ctx context.Context) error {
 	}
 
 	logrus.Infof("Promoting tags to %s: %s", targetName(*s.configuration.PromotionConfiguration), strings.Join(names.List(), ", "))
+	for _, target := range s.configuration.PromotionConfiguration.Targets {
+		logrus.Infof("Promoting tags to %s/%s:%s", target.Registry, target.Repository, target.Image(api.ComponentFormatReplacement).Tag)
+	}
 	pipeline := &imagev1.ImageStream{}
 	if err := s.client.Get(ctx, ctrlruntimeclient.ObjectKey{
 		Namespace: s.jobSpec.Namespace(),
@@ -71,40 +81,300 @@ func (s *promotionStep) run(ctx context.Context) error {
 	}
 
 	imageMirrorTarget, namespaces := getImageMirrorTarget(tags, pipeline, registryDomain(s.configuration.PromotionConfiguration))
-	if len(imageMirrorTarget) == 0 {
+	targetMirrors := getTargetImageMirrors(PromotedTargetImagesWithRequiredImages(s.configuration, s.requiredImages), pipeline)
+	if len(imageMirrorTarget) == 0 && len(targetMirrors) == 0 {
 		logrus.Info("Nothing to promote, skipping...")
 		return nil
 	}
 
+	// the history of promotions into the central registry allows to roll them back, so
+	// we record the digests the tags point to before we overwrite them
+	var historyClient ctrlruntimeclient.Client
+	var previous map[ctrlruntimeclient.ObjectKey]map[string]string
+	streams := promotedStreams(tags, pipeline)
+	if s.configuration.PromotionConfiguration.RegistryOverride == "" && len(streams) > 0 {
+		if client, err := s.appCIClient(); err != nil {
+			logrus.WithError(err).Warn("Failed to create a client to record the promotion history.")
+		} else {
+			historyClient = client
+			previous = currentDigests(ctx, historyClient, streams)
+		}
+	}
+
 	// in some cases like when we are called by the ci-chat-bot we may need to create namespaces
 	// in general, we do not expect to be able to do this, so we only do it best-effort
-	if err := s.ensureNamespaces(ctx, namespaces); err != nil {
-		logrus.WithError(err).Warn("Failed to ensure namespaces to promote to in central registry.")
+	if len(namespaces) > 0 {
+		if err := s.ensureNamespaces(ctx, namespaces); err != nil {
+			logrus.WithError(err).Warn("Failed to ensure namespaces to promote to in central registry.")
+		}
+	}
+
+	for credentials := range targetMirrors {
+		if err := s.createTargetSecret(ctx, credentials); err != nil {
+			return fmt.Errorf("could not create credentials to promote to external registries: %w", err)
+		}
 	}
 
-	if _, err := steps.RunPod(ctx, s.client, getPromotionPod(imageMirrorTarget, s.jobSpec.Namespace())); err != nil {
+	if _, err := steps.RunPod(ctx, s.client, getPromotionPod(imageMirrorTarget, targetMirrors, s.jobSpec.Namespace(), hasManifestLists(s.configuration.Images, names))); err != nil {
 		return fmt.Errorf("unable to run promotion pod: %w", err)
 	}
+	for _, target := range sets.StringKeySet(imageMirrorTarget).List() {
+		events.Record(ctx, events.Event{Type: events.ImagePushed, Details: map[string]string{"image": target, "source": imageMirrorTarget[target]}})
+	}
+	if historyClient != nil {
+		if err := recordHistory(ctx, historyClient, streams, previous, history.RecordFor(&s.jobSpec.JobSpec, meta.Now())); err != nil {
+			logrus.WithError(err).Warn("Failed to record the promotion history.")
+		}
+	}
+	for _, credentials := range sortedCredentials(targetMirrors) {
+		for _, target := range sets.StringKeySet(targetMirrors[credentials]).List() {
+			events.Record(ctx, events.Event{Type: events.ImagePushed, Details: map[string]string{"image": target, "source": targetMirrors[credentials][target]}})
+		}
+	}
+	if s.signingSecret == nil {
+		return nil
+	}
+	if err := s.sign(ctx, pipeline, imageMirrorTarget, targetMirrors); err != nil {
+		return fmt.Errorf("unable to sign promoted images: %w", err)
+	}
 	return nil
 }
 
-func (s *promotionStep) ensureNamespaces(ctx context.Context, namespaces sets.String) error {
-	// Used primarily (only?) by the chatbot and we likely do not have the permission to create
-	// namespaces (nor are we expected to).
-	if s.configuration.PromotionConfiguration.RegistryOverride != "" {
-		return nil
+// sign signs the promoted images and attaches an SBOM describing their
+// inputs to them, so that their provenance can be verified with cosign.
+// Images promoted to external registries are signed with the credentials
+// they were pushed with.
+func (s *promotionStep) sign(ctx context.Context, pipeline *imagev1.ImageStream, imageMirrorTarget map[string]string, targetMirrors map[api.PromotionCredentials]map[string]string) error {
+	sbom, err := json.Marshal(sbomFor(s.configuration, s.jobSpec, pipeline, time.Now()))
+	if err != nil {
+		return fmt.Errorf("could not serialize SBOM: %w", err)
+	}
+	configMap := &coreapi.ConfigMap{
+		ObjectMeta: meta.ObjectMeta{Name: promotionSBOMConfigMap, Namespace: s.jobSpec.Namespace()},
+		Data:       map[string]string{sbomFilename: string(sbom)},
 	}
+	if err := s.client.Create(ctx, configMap); err != nil {
+		if !apierrors.IsAlreadyExists(err) {
+			return fmt.Errorf("could not create SBOM configmap: %w", err)
+		}
+		if err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			existing := &coreapi.ConfigMap{}
+			if err := s.client.Get(ctx, ctrlruntimeclient.ObjectKeyFromObject(configMap), existing); err != nil {
+				return err
+			}
+			existing.Data = configMap.Data
+			return s.client.Update(ctx, existing)
+		}); err != nil {
+			return fmt.Errorf("could not update SBOM configmap: %w", err)
+		}
+	}
+	var pods []*coreapi.Pod
+	if len(imageMirrorTarget) > 0 {
+		targets, err := signingTargets(imageMirrorTarget)
+		if err != nil {
+			return err
+		}
+		pods = append(pods, getSigningPod("promotion-signing", api.RegistryPushCredentialsCICentralSecret, targets, s.jobSpec.Namespace(), provenanceFor(s.jobSpec)))
+	}
+	for _, credentials := range sortedCredentials(targetMirrors) {
+		targets, err := signingTargets(targetMirrors[credentials])
+		if err != nil {
+			return err
+		}
+		secret := targetSecretName(credentials)
+		pods = append(pods, getSigningPod(secret+"-signing", secret, targets, s.jobSpec.Namespace(), provenanceFor(s.jobSpec)))
+	}
+	for _, pod := range pods {
+		if _, err := steps.RunPod(ctx, s.client, pod); err != nil {
+			return fmt.Errorf("unable to run signing pod %s: %w", pod.Name, err)
+		}
+	}
+	return nil
+}
+
+// signingTargets resolves the destinations of mirrored images to the digests
+// of their sources in the pipeline image stream. Mirroring preserves digests,
+// so signing by digest covers exactly the promoted images, even when another
+// promotion moves the destination tags before the signing pods run.
+func signingTargets(mirrors map[string]string) ([]string, error) {
+	targets := sets.NewString()
+	for dst, src := range mirrors {
+		source, err := reference.Parse(src)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse source image %s: %w", src, err)
+		}
+		if source.ID == "" {
+			return nil, fmt.Errorf("source image %s for %s is not pinned by digest", src, dst)
+		}
+		target, err := reference.Parse(dst)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse target image %s: %w", dst, err)
+		}
+		target = target.AsRepository()
+		target.ID = source.ID
+		targets.Insert(target.Exact())
+	}
+	return targets.List(), nil
+}
+
+// targetSecretName is the name of the secret in the test namespace which
+// holds the credentials used to push to promotion targets
+func targetSecretName(credentials api.PromotionCredentials) string {
+	return fmt.Sprintf("promotion-target-%s-%s", credentials.Namespace, credentials.Name)
+}
+
+// createTargetSecret copies the credentials for a promotion target into the
+// test namespace. The push secret is merged into them, as the promoted
+// images are pulled from the build farm with its credentials.
+func (s *promotionStep) createTargetSecret(ctx context.Context, credentials api.PromotionCredentials) error {
+	if credentials.Namespace != api.PromotionCredentialsNamespace {
+		return fmt.Errorf("credentials for promotion targets must be in namespace %s, not %s", api.PromotionCredentialsNamespace, credentials.Namespace)
+	}
+	source := &coreapi.Secret{}
+	if err := s.client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: credentials.Namespace, Name: credentials.Name}, source); err != nil {
+		return fmt.Errorf("could not read source secret %s/%s: %w", credentials.Namespace, credentials.Name, err)
+	}
+	dockercfg, err := mergeDockerConfigs(s.pushSecret.Data[coreapi.DockerConfigJsonKey], source.Data[coreapi.DockerConfigJsonKey])
+	if err != nil {
+		return fmt.Errorf("could not merge credentials from secret %s/%s: %w", credentials.Namespace, credentials.Name, err)
+	}
+	secret := &coreapi.Secret{
+		ObjectMeta: meta.ObjectMeta{Name: targetSecretName(credentials), Namespace: s.jobSpec.Namespace()},
+		Type:       coreapi.SecretTypeDockerConfigJson,
+		Data:       map[string][]byte{coreapi.DockerConfigJsonKey: dockercfg},
+	}
+	if err := s.client.Create(ctx, secret); err != nil && !apierrors.IsAlreadyExists(err) {
+		return fmt.Errorf("could not create secret %s: %w", secret.Name, err)
+	}
+	return nil
+}
+
+// mergeDockerConfigs adds the registries from the overlay to the base
+// configuration, overriding the credentials for registries in both.
+func mergeDockerConfigs(base, overlay []byte) ([]byte, error) {
+	merged := credentialprovider.DockerConfigJSON{Auths: credentialprovider.DockerConfig{}}
+	for _, raw := range [][]byte{base, overlay} {
+		if len(raw) == 0 {
+			continue
+		}
+		var dockercfg credentialprovider.DockerConfigJSON
+		if err := json.Unmarshal(raw, &dockercfg); err != nil {
+			return nil, fmt.Errorf("failed to deserialize dockercfg: %w", err)
+		}
+		for registry, entry := range dockercfg.Auths {
+			merged.Auths[registry] = entry
+		}
+	}
+	return json.Marshal(merged)
+}
+
+// appCIConfig uses the token in the push secret to access the cluster which
+// hosts the central registry.
+func (s *promotionStep) appCIConfig() (*rest.Config, error) {
 	var dockercfg credentialprovider.DockerConfigJSON
 	if err := json.Unmarshal(s.pushSecret.Data[coreapi.DockerConfigJsonKey], &dockercfg); err != nil {
-		return fmt.Errorf("failed to deserialize push secret: %w", err)
+		return nil, fmt.Errorf("failed to deserialize push secret: %w", err)
 	}
 
 	appCIDockercfg, hasAppCIDockercfg := dockercfg.Auths[api.ServiceDomainAPPCIRegistry]
 	if !hasAppCIDockercfg {
-		return fmt.Errorf("push secret has no entry for %s", api.ServiceDomainAPPCIRegistry)
+		return nil, fmt.Errorf("push secret has no entry for %s", api.ServiceDomainAPPCIRegistry)
+	}
+
+	return &rest.Config{Host: api.APPCIKubeAPIURL, BearerToken: appCIDockercfg.Password}, nil
+}
+
+func (s *promotionStep) appCIClient() (ctrlruntimeclient.Client, error) {
+	appCIKubeconfig, err := s.appCIConfig()
+	if err != nil {
+		return nil, err
+	}
+	return ctrlruntimeclient.New(appCIKubeconfig, ctrlruntimeclient.Options{})
+}
+
+// promotedStreams groups the promoted tags by the ImageStream they are in and
+// maps each of them to the pull spec of the image promoted to it.
+func promotedStreams(tags map[string][]api.ImageStreamTagReference, pipeline *imagev1.ImageStream) map[ctrlruntimeclient.ObjectKey]map[string]string {
+	streams := map[ctrlruntimeclient.ObjectKey]map[string]string{}
+	for src, dsts := range tags {
+		dockerImageReference := findDockerImageReference(pipeline, src)
+		if dockerImageReference == "" {
+			continue
+		}
+		dockerImageReference = getPublicImageReference(dockerImageReference, pipeline.Status.PublicDockerImageRepository)
+		for _, dst := range dsts {
+			key := ctrlruntimeclient.ObjectKey{Namespace: dst.Namespace, Name: dst.Name}
+			if streams[key] == nil {
+				streams[key] = map[string]string{}
+			}
+			streams[key][dst.Tag] = dockerImageReference
+		}
 	}
+	return streams
+}
 
-	appCIKubeconfig := &rest.Config{Host: api.APPCIKubeAPIURL, BearerToken: appCIDockercfg.Password}
+// currentDigests determines the digests the tags of the streams point to.
+// Streams which cannot be read are skipped, as they most likely do not exist.
+func currentDigests(ctx context.Context, client ctrlruntimeclient.Client, streams map[ctrlruntimeclient.ObjectKey]map[string]string) map[ctrlruntimeclient.ObjectKey]map[string]string {
+	digests := map[ctrlruntimeclient.ObjectKey]map[string]string{}
+	for key := range streams {
+		stream := &imagev1.ImageStream{}
+		if err := client.Get(ctx, key, stream); err != nil {
+			if !apierrors.IsNotFound(err) {
+				logrus.WithError(err).Debugf("Could not determine the current digests in %s.", key)
+			}
+			continue
+		}
+		digests[key] = history.CurrentDigests(stream)
+	}
+	return digests
+}
+
+// recordHistory records the promotion into each of the streams, with the
+// digests the promoted tags pointed to before.
+func recordHistory(ctx context.Context, client ctrlruntimeclient.Client, streams, previous map[ctrlruntimeclient.ObjectKey]map[string]string, record history.Record) error {
+	var errs []error
+	for key, sources := range streams {
+		if err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			stream := &imagev1.ImageStream{}
+			if err := client.Get(ctx, key, stream); err != nil {
+				return err
+			}
+			current := history.CurrentDigests(stream)
+			streamRecord := record
+			streamRecord.Tags = map[string]history.TagRecord{}
+			for tag, source := range sources {
+				digest := current[tag]
+				if digest == "" {
+					// the promotion failed for this tag
+					continue
+				}
+				streamRecord.Tags[tag] = history.TagRecord{Source: source, Digest: digest, PreviousDigest: previous[key][tag]}
+			}
+			if len(streamRecord.Tags) == 0 {
+				return nil
+			}
+			if err := history.AddRecord(stream, streamRecord); err != nil {
+				return err
+			}
+			return client.Update(ctx, stream)
+		}); err != nil {
+			errs = append(errs, fmt.Errorf("could not record promotion into %s: %w", key, err))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+func (s *promotionStep) ensureNamespaces(ctx context.Context, namespaces sets.String) error {
+	// Used primarily (only?) by the chatbot and we likely do not have the permission to create
+	// namespaces (nor are we expected to).
+	if s.configuration.PromotionConfiguration.RegistryOverride != "" {
+		return nil
+	}
+	appCIKubeconfig, err := s.appCIConfig()
+	if err != nil {
+		return err
+	}
 	client, err := corev1client.NewForConfig(appCIKubeconfig)
 	if err != nil {
 		return fmt.Errorf("failed to construct kubeconfig: %w", err)
@@ -162,6 +432,43 @@ func getImageMirrorTarget(tags map[string][]api.ImageStreamTagReference, pipelin
 	return imageMirror, namespaces
 }
 
+// getTargetImageMirrors maps the images promoted to promotion targets to
+// their sources, grouped by the credentials used to push them.
+func getTargetImageMirrors(images map[string][]api.PromotionTargetImage, pipeline *imagev1.ImageStream) map[api.PromotionCredentials]map[string]string {
+	if pipeline == nil {
+		return nil
+	}
+	mirrors := map[api.PromotionCredentials]map[string]string{}
+	for src, dsts := range images {
+		dockerImageReference := findDockerImageReference(pipeline, src)
+		if dockerImageReference == "" {
+			continue
+		}
+		dockerImageReference = getPublicImageReference(dockerImageReference, pipeline.Status.PublicDockerImageRepository)
+		for _, dst := range dsts {
+			if mirrors[dst.Credentials] == nil {
+				mirrors[dst.Credentials] = map[string]string{}
+			}
+			mirrors[dst.Credentials][dst.PullSpec()] = dockerImageReference
+		}
+	}
+	if len(mirrors) == 0 {
+		return nil
+	}
+	return mirrors
+}
+
+func sortedCredentials(mirrors map[api.PromotionCredentials]map[string]string) []api.PromotionCredentials {
+	var credentials []api.PromotionCredentials
+	for c := range mirrors {
+		credentials = append(credentials, c)
+	}
+	sort.Slice(credentials, func(i, j int) bool {
+		return targetSecretName(credentials[i]) < targetSecretName(credentials[j])
+	})
+	return credentials
+}
+
 func getPublicImageReference(dockerImageReference, publicDockerImageRepository string) string {
 	if !strings.Contains(dockerImageReference, ":5000") {
 		return dockerImageReference
@@ -182,7 +489,20 @@ func getPublicImageReference(dockerImageReference, publicDockerImageRepository s
 	return strings.Replace(dockerImageReference, splits[0], publicHost, 1)
 }
 
-func getPromotionPod(imageMirrorTarget map[string]string, namespace string) *coreapi.Pod {
+// hasManifestLists determines whether any of the promoted images is built for
+// multiple architectures, in which case the whole manifest list is mirrored.
+func hasManifestLists(images []api.ProjectDirectoryImageBuildStepConfiguration, names sets.String) bool {
+	for _, image := range images {
+		if len(image.Architectures) > 0 && names.Has(string(image.To)) {
+			return true
+		}
+	}
+	return false
+}
+
+// mirrorCommand mirrors the images with the credentials from the registry
+// config.
+func mirrorCommand(registryConfig string, imageMirrorTarget map[string]string, keepManifestList bool) string {
 	keys := make([]string, 0, len(imageMirrorTarget))
 	for k := range imageMirrorTarget {
 		keys = append(keys, k)
@@ -193,8 +513,50 @@ func getPromotionPod(imageMirrorTarget map[string]string, namespace string) *cor
 	for _, k := range keys {
 		images = append(images, fmt.Sprintf("%s=%s", imageMirrorTarget[k], k))
 	}
+	var flags string
+	if keepManifestList {
+		flags = " --keep-manifest-list=true"
+	}
+	return fmt.Sprintf("oc image mirror --registry-config=%s --continue-on-error=true --max-per-registry=20%s %s", registryConfig, flags, strings.Join(images, " "))
+}
+
+// getPromotionPod creates the pod which mirrors the images to the central
+// registry and to the promotion targets, each group of targets with its own
+// credentials.
+func getPromotionPod(imageMirrorTarget map[string]string, targetMirrors map[api.PromotionCredentials]map[string]string, namespace string, keepManifestList bool) *coreapi.Pod {
+	var commands []string
+	if len(imageMirrorTarget) > 0 {
+		commands = append(commands, mirrorCommand(filepath.Join(api.RegistryPushCredentialsCICentralSecretMountPath, coreapi.DockerConfigJsonKey), imageMirrorTarget, keepManifestList))
+	}
+	mounts := []coreapi.VolumeMount{
+		{
+			Name:      "push-secret",
+			MountPath: "/etc/push-secret",
+			ReadOnly:  true,
+		},
+	}
+	volumes := []coreapi.Volume{
+		{
+			Name: "push-secret",
+			VolumeSource: coreapi.VolumeSource{
+				Secret: &coreapi.SecretVolumeSource{SecretName: api.RegistryPushCredentialsCICentralSecret},
+			},
+		},
+	}
+	for i, credentials := range sortedCredentials(targetMirrors) {
+		name := fmt.Sprintf("target-secret-%d", i)
+		mountPath := filepath.Join("/etc/promotion-targets", targetSecretName(credentials))
+		commands = append(commands, mirrorCommand(filepath.Join(mountPath, coreapi.DockerConfigJsonKey), targetMirrors[credentials], keepManifestList))
+		mounts = append(mounts, coreapi.VolumeMount{Name: name, MountPath: mountPath, ReadOnly: true})
+		volumes = append(volumes, coreapi.Volume{
+			Name: name,
+			VolumeSource: coreapi.VolumeSource{
+				Secret: &coreapi.SecretVolumeSource{SecretName: targetSecretName(credentials)},
+			},
+		})
+	}
 	command := []string{"/bin/sh", "-c"}
-	args := []string{fmt.Sprintf("oc image mirror --registry-config=%s --continue-on-error=true --max-per-registry=20 %s", filepath.Join(api.RegistryPushCredentialsCICentralSecretMountPath, coreapi.DockerConfigJsonKey), strings.Join(images, " "))}
+	args := []string{strings.Join(commands, " && ")}
 	return &coreapi.Pod{
 		ObjectMeta: meta.ObjectMeta{
 			Name:      "promotion",
@@ -204,24 +566,114 @@ func getPromotionPod(imageMirrorTarget map[string]string, namespace string) *cor
 			RestartPolicy: coreapi.RestartPolicyNever,
 			Containers: []coreapi.Container{
 				{
-					Name:    "promotion",
-					Image:   fmt.Sprintf("%s/ocp/4.8:cli", api.DomainForService(api.ServiceRegistry)),
-					Command: command,
-					Args:    args,
-					VolumeMounts: []coreapi.VolumeMount{
-						{
-							Name:      "push-secret",
-							MountPath: "/etc/push-secret",
-							ReadOnly:  true,
-						},
-					},
+					Name:         "promotion",
+					Image:        fmt.Sprintf("%s/ocp/4.8:cli", api.DomainForService(api.ServiceRegistry)),
+					Command:      command,
+					Args:         args,
+					VolumeMounts: mounts,
+				},
+			},
+			Volumes: volumes,
+		},
+	}
+}
+
+const (
+	// cosignImage runs the signing of promoted images
+	cosignImage = "gcr.io/projectsigstore/cosign:v1.2.1"
+	// promotionSBOMConfigMap holds the SBOM attached to promoted images
+	promotionSBOMConfigMap = "promotion-sbom"
+	// provenanceAnnotationPrefix prefixes the annotations of the signatures
+	// of promoted images, which record the job that built them
+	provenanceAnnotationPrefix = "ci.openshift.io/"
+)
+
+// provenanceFor determines the annotations recorded in the signatures of
+// the images the job promotes, which can be required when verifying them.
+func provenanceFor(jobSpec *api.JobSpec) map[string]string {
+	provenance := map[string]string{
+		provenanceAnnotationPrefix + "job":      jobSpec.Job,
+		provenanceAnnotationPrefix + "build-id": jobSpec.BuildID,
+	}
+	if jobSpec.ProwJobID != "" {
+		provenance[provenanceAnnotationPrefix+"prowjob-id"] = jobSpec.ProwJobID
+	}
+	if jobSpec.Refs != nil {
+		provenance[provenanceAnnotationPrefix+"repo"] = fmt.Sprintf("%s/%s", jobSpec.Refs.Org, jobSpec.Refs.Repo)
+		provenance[provenanceAnnotationPrefix+"refs"] = jobSpec.Refs.String()
+	}
+	return provenance
+}
+
+// getSigningPod creates the pod which signs the promoted images and attests
+// their SBOM with the key from the signing secret. Both are pushed to the
+// registry next to the images, as cosign does, with the dockercfg in the
+// named secret.
+func getSigningPod(name, dockerConfigSecret string, targets []string, namespace string, provenance map[string]string) *coreapi.Pod {
+	key := filepath.Join(api.ImageSigningKeySecretMountPath, api.ImageSigningKeyFilename)
+	sign := []string{"sign", "--key", key}
+	for _, annotation := range sets.StringKeySet(provenance).List() {
+		sign = append(sign, "-a", fmt.Sprintf("%s=%s", annotation, provenance[annotation]))
+	}
+	attest := []string{"attest", "--key", key, "--type", "spdx", "--predicate", filepath.Join("/etc/sbom", sbomFilename)}
+	env := []coreapi.EnvVar{
+		{
+			Name: "COSIGN_PASSWORD",
+			ValueFrom: &coreapi.EnvVarSource{SecretKeyRef: &coreapi.SecretKeySelector{
+				LocalObjectReference: coreapi.LocalObjectReference{Name: api.ImageSigningKeySecret},
+				Key:                  api.ImageSigningPasswordFilename,
+				Optional:             utilpointer.BoolPtr(true),
+			}},
+		},
+		{Name: "DOCKER_CONFIG", Value: "/etc/docker-config"},
+	}
+	mounts := []coreapi.VolumeMount{
+		{Name: "signing-key", MountPath: api.ImageSigningKeySecretMountPath, ReadOnly: true},
+		{Name: "push-secret", MountPath: "/etc/docker-config", ReadOnly: true},
+	}
+	return &coreapi.Pod{
+		ObjectMeta: meta.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: coreapi.PodSpec{
+			RestartPolicy: coreapi.RestartPolicyNever,
+			Containers: []coreapi.Container{
+				{
+					Name:         "sign",
+					Image:        cosignImage,
+					Args:         append(sign, targets...),
+					Env:          env,
+					VolumeMounts: mounts,
+				},
+				{
+					Name:         "attest",
+					Image:        cosignImage,
+					Args:         append(attest, targets...),
+					Env:          env,
+					VolumeMounts: append(mounts, coreapi.VolumeMount{Name: "sbom", MountPath: "/etc/sbom", ReadOnly: true}),
 				},
 			},
 			Volumes: []coreapi.Volume{
+				{
+					Name: "signing-key",
+					VolumeSource: coreapi.VolumeSource{
+						Secret: &coreapi.SecretVolumeSource{SecretName: api.ImageSigningKeySecret},
+					},
+				},
 				{
 					Name: "push-secret",
 					VolumeSource: coreapi.VolumeSource{
-						Secret: &coreapi.SecretVolumeSource{SecretName: api.RegistryPushCredentialsCICentralSecret},
+						Secret: &coreapi.SecretVolumeSource{
+							SecretName: dockerConfigSecret,
+							Items:      []coreapi.KeyToPath{{Key: coreapi.DockerConfigJsonKey, Path: "config.json"}},
+						},
+					},
+				},
+				{
+					Name: "sbom",
+					VolumeSource: coreapi.VolumeSource{
+						ConfigMap: &coreapi.ConfigMapVolumeSource{LocalObjectReference: coreapi.LocalObjectReference{Name: promotionSBOMConfigMap}},
 					},
 				},
 			},
@@ -324,6 +776,41 @@ func PromotedTagsWithRequiredImages(configuration *api.ReleaseBuildConfiguration
 	return promotedTags, names
 }
 
+// PromotedTargetImages returns the images that are being promoted to the promotion targets
+// of the given ReleaseBuildConfiguration
+func PromotedTargetImages(configuration *api.ReleaseBuildConfiguration) []api.PromotionTargetImage {
+	var images []api.PromotionTargetImage
+	for _, dest := range PromotedTargetImagesWithRequiredImages(configuration, sets.NewString()) {
+		images = append(images, dest...)
+	}
+	sort.Slice(images, func(i, j int) bool {
+		return images[i].PullSpec() < images[j].PullSpec()
+	})
+	return images
+}
+
+// PromotedTargetImagesWithRequiredImages returns the images that are being promoted to the promotion targets
+// of the given ReleaseBuildConfiguration accounting for the list of required images. Like the promoted tags,
+// images are mapped by the source tag in the pipeline ImageStream. The build cache is not promoted to targets.
+func PromotedTargetImagesWithRequiredImages(configuration *api.ReleaseBuildConfiguration, requiredImages sets.String) map[string][]api.PromotionTargetImage {
+	if configuration == nil || configuration.PromotionConfiguration == nil || configuration.PromotionConfiguration.Disabled || len(configuration.PromotionConfiguration.Targets) == 0 {
+		return nil
+	}
+	tags, _ := toPromote(*configuration.PromotionConfiguration, configuration.Images, requiredImages)
+	images := map[string][]api.PromotionTargetImage{}
+	for dst, src := range tags {
+		for _, target := range configuration.PromotionConfiguration.Targets {
+			images[src] = append(images[src], target.Image(dst))
+		}
+	}
+	for _, dests := range images {
+		sort.Slice(dests, func(i, j int) bool {
+			return dests[i].PullSpec() < dests[j].PullSpec()
+		})
+	}
+	return images
+}
+
 func (s *promotionStep) Requires() []api.StepLink {
 	return []api.StepLink{api.AllStepsLink()}
 }
@@ -347,13 +834,15 @@ func (s *promotionStep) Objects() []ctrlruntimeclient.Object {
 }
 
 // PromotionStep copies tags from the pipeline image stream to the destination defined in the promotion config.
-// If the source tag does not exist it is silently skipped.
-func PromotionStep(configuration *api.ReleaseBuildConfiguration, requiredImages sets.String, jobSpec *api.JobSpec, client steps.PodClient, pushSecret *coreapi.Secret) api.Step {
+// If the source tag does not exist it is silently skipped. When a signing secret is provided, the promoted
+// images are signed and attested with it.
+func PromotionStep(configuration *api.ReleaseBuildConfiguration, requiredImages sets.String, jobSpec *api.JobSpec, client steps.PodClient, pushSecret, signingSecret *coreapi.Secret) api.Step {
 	return &promotionStep{
 		configuration:  configuration,
 		requiredImages: requiredImages,
 		jobSpec:        jobSpec,
 		client:         client,
 		pushSecret:     pushSecret,
+		signingSecret:  signingSecret,
 	}
 }
@@ -1,18 +1,32 @@
 package release
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 
 	coreapi "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/kubernetes/scheme"
+	prowapi "k8s.io/test-infra/prow/apis/prowjobs/v1"
+	"k8s.io/test-infra/prow/pod-utils/downwardapi"
 	"k8s.io/utils/diff"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	imageapi "github.com/openshift/api/image/v1"
 
 	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/kubernetes/pkg/credentialprovider"
+	"github.com/openshift/ci-tools/pkg/promotion/history"
+	"github.com/openshift/ci-tools/pkg/steps"
+	"github.com/openshift/ci-tools/pkg/steps/loggingclient"
 	"github.com/openshift/ci-tools/pkg/testhelper"
 )
 
@@ -538,10 +552,12 @@ func TestBuildCacheFor(t *testing.T) {
 
 func TestGetPromotionPod(t *testing.T) {
 	var testCases = []struct {
-		name        string
-		imageMirror map[string]string
-		namespace   string
-		expected    *coreapi.Pod
+		name             string
+		imageMirror      map[string]string
+		targetMirrors    map[api.PromotionCredentials]map[string]string
+		namespace        string
+		keepManifestList bool
+		expected         *coreapi.Pod
 	}{
 		{
 			name: "basic case",
@@ -551,11 +567,43 @@ func TestGetPromotionPod(t *testing.T) {
 			},
 			namespace: "ci-op-zyvwvffx",
 		},
+		{
+			name: "manifest lists",
+			imageMirror: map[string]string{
+				"registy.ci.openshift.org/ci/applyconfig:latest": "docker-registry.default.svc:5000/ci-op-y2n8rsh3/pipeline@sha256:afd71aa3cbbf7d2e00cd8696747b2abf164700147723c657919c20b13d13ec62",
+			},
+			namespace:        "ci-op-zyvwvffx",
+			keepManifestList: true,
+		},
+		{
+			name: "promotion targets",
+			imageMirror: map[string]string{
+				"registy.ci.openshift.org/ci/applyconfig:latest": "docker-registry.default.svc:5000/ci-op-y2n8rsh3/pipeline@sha256:afd71aa3cbbf7d2e00cd8696747b2abf164700147723c657919c20b13d13ec62",
+			},
+			targetMirrors: map[api.PromotionCredentials]map[string]string{
+				{Namespace: "test-credentials", Name: "quay"}: {
+					"quay.io/org/applyconfig:latest": "docker-registry.default.svc:5000/ci-op-y2n8rsh3/pipeline@sha256:afd71aa3cbbf7d2e00cd8696747b2abf164700147723c657919c20b13d13ec62",
+				},
+				{Namespace: "test-credentials", Name: "docker-hub"}: {
+					"docker.io/org/applyconfig:v1": "docker-registry.default.svc:5000/ci-op-y2n8rsh3/pipeline@sha256:afd71aa3cbbf7d2e00cd8696747b2abf164700147723c657919c20b13d13ec62",
+				},
+			},
+			namespace: "ci-op-zyvwvffx",
+		},
+		{
+			name: "only promotion targets",
+			targetMirrors: map[api.PromotionCredentials]map[string]string{
+				{Namespace: "test-credentials", Name: "quay"}: {
+					"quay.io/org/applyconfig:latest": "docker-registry.default.svc:5000/ci-op-y2n8rsh3/pipeline@sha256:afd71aa3cbbf7d2e00cd8696747b2abf164700147723c657919c20b13d13ec62",
+				},
+			},
+			namespace: "ci-op-zyvwvffx",
+		},
 	}
 
 	for _, testCase := range testCases {
 		t.Run(testCase.name, func(t *testing.T) {
-			testhelper.CompareWithFixture(t, getPromotionPod(testCase.imageMirror, testCase.namespace))
+			testhelper.CompareWithFixture(t, getPromotionPod(testCase.imageMirror, testCase.targetMirrors, testCase.namespace, testCase.keepManifestList))
 		})
 	}
 }
@@ -667,6 +715,202 @@ func TestGetImageMirror(t *testing.T) {
 	}
 }
 
+func TestPromotedTargetImagesWithRequiredImages(t *testing.T) {
+	credentials := api.PromotionCredentials{Namespace: "test-credentials", Name: "quay"}
+	config := &api.ReleaseBuildConfiguration{
+		Images: []api.ProjectDirectoryImageBuildStepConfiguration{
+			{To: "foo"},
+			{To: "bar"},
+			{To: "optional", Optional: true},
+		},
+		BinaryBuildCommands: "make",
+		PromotionConfiguration: &api.PromotionConfiguration{
+			Namespace:        "roger",
+			Name:             "fred",
+			ExcludedImages:   []string{"bar"},
+			AdditionalImages: map[string]string{"baz": "src"},
+			Targets: []api.PromotionTarget{
+				{Registry: "quay.io", Repository: "org/${component}", Credentials: credentials},
+				{Registry: "quay.io", Repository: "org/images", Tag: "${component}-v1", Credentials: credentials},
+			},
+		},
+	}
+	var testCases = []struct {
+		name           string
+		config         *api.ReleaseBuildConfiguration
+		requiredImages sets.String
+		expected       map[string][]api.PromotionTargetImage
+	}{
+		{
+			name:   "no promotion",
+			config: &api.ReleaseBuildConfiguration{},
+		},
+		{
+			name:   "no targets",
+			config: &api.ReleaseBuildConfiguration{Images: config.Images, PromotionConfiguration: &api.PromotionConfiguration{Namespace: "roger", Name: "fred"}},
+		},
+		{
+			name:   "images are promoted to every target",
+			config: config,
+			expected: map[string][]api.PromotionTargetImage{
+				"foo": {
+					{Registry: "quay.io", Repository: "org/foo", Tag: "latest", Credentials: credentials},
+					{Registry: "quay.io", Repository: "org/images", Tag: "foo-v1", Credentials: credentials},
+				},
+				"src": {
+					{Registry: "quay.io", Repository: "org/baz", Tag: "latest", Credentials: credentials},
+					{Registry: "quay.io", Repository: "org/images", Tag: "baz-v1", Credentials: credentials},
+				},
+			},
+		},
+		{
+			name:           "required optional images are promoted",
+			config:         config,
+			requiredImages: sets.NewString("optional"),
+			expected: map[string][]api.PromotionTargetImage{
+				"foo": {
+					{Registry: "quay.io", Repository: "org/foo", Tag: "latest", Credentials: credentials},
+					{Registry: "quay.io", Repository: "org/images", Tag: "foo-v1", Credentials: credentials},
+				},
+				"optional": {
+					{Registry: "quay.io", Repository: "org/images", Tag: "optional-v1", Credentials: credentials},
+					{Registry: "quay.io", Repository: "org/optional", Tag: "latest", Credentials: credentials},
+				},
+				"src": {
+					{Registry: "quay.io", Repository: "org/baz", Tag: "latest", Credentials: credentials},
+					{Registry: "quay.io", Repository: "org/images", Tag: "baz-v1", Credentials: credentials},
+				},
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			if diff := cmp.Diff(testCase.expected, PromotedTargetImagesWithRequiredImages(testCase.config, testCase.requiredImages)); diff != "" {
+				t.Errorf("unexpected target images: %s", diff)
+			}
+		})
+	}
+}
+
+func TestGetTargetImageMirrors(t *testing.T) {
+	quay := api.PromotionCredentials{Namespace: "test-credentials", Name: "quay"}
+	dockerHub := api.PromotionCredentials{Namespace: "test-credentials", Name: "docker-hub"}
+	pipeline := &imageapi.ImageStream{
+		Status: imageapi.ImageStreamStatus{
+			PublicDockerImageRepository: "registry.ci.openshift.org/ci-op-y2n8rsh3/pipeline",
+			Tags: []imageapi.NamedTagEventList{
+				{Tag: "foo", Items: []imageapi.TagEvent{{DockerImageReference: "image-registry.openshift-image-registry.svc:5000/ci-op-y2n8rsh3/pipeline@sha256:aaa"}}},
+			},
+		},
+	}
+	images := map[string][]api.PromotionTargetImage{
+		"foo": {
+			{Registry: "quay.io", Repository: "org/foo", Tag: "latest", Credentials: quay},
+			{Registry: "docker.io", Repository: "org/foo", Tag: "latest", Credentials: dockerHub},
+		},
+		"missing": {
+			{Registry: "quay.io", Repository: "org/missing", Tag: "latest", Credentials: quay},
+		},
+	}
+	expected := map[api.PromotionCredentials]map[string]string{
+		quay:      {"quay.io/org/foo:latest": "registry.ci.openshift.org/ci-op-y2n8rsh3/pipeline@sha256:aaa"},
+		dockerHub: {"docker.io/org/foo:latest": "registry.ci.openshift.org/ci-op-y2n8rsh3/pipeline@sha256:aaa"},
+	}
+	if diff := cmp.Diff(expected, getTargetImageMirrors(images, pipeline)); diff != "" {
+		t.Errorf("unexpected mirrors: %s", diff)
+	}
+	if mirrors := getTargetImageMirrors(images, nil); mirrors != nil {
+		t.Errorf("expected no mirrors without a pipeline, got %v", mirrors)
+	}
+}
+
+func TestMergeDockerConfigs(t *testing.T) {
+	base := []byte(`{"auths":{"registry.ci.openshift.org":{"auth":"Y2k6Y2k="},"quay.io":{"auth":"Y2k6Y2k="}}}`)
+	overlay := []byte(`{"auths":{"quay.io":{"auth":"b3JnOnNlY3JldA=="}}}`)
+	merged, err := mergeDockerConfigs(base, overlay)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var dockercfg credentialprovider.DockerConfigJSON
+	if err := json.Unmarshal(merged, &dockercfg); err != nil {
+		t.Fatalf("failed to deserialize merged config: %v", err)
+	}
+	if entry := dockercfg.Auths["registry.ci.openshift.org"]; entry.Username != "ci" || entry.Password != "ci" {
+		t.Errorf("expected credentials from the base to be kept, got %v", entry)
+	}
+	if entry := dockercfg.Auths["quay.io"]; entry.Username != "org" || entry.Password != "secret" {
+		t.Errorf("expected credentials from the overlay to take precedence, got %v", entry)
+	}
+	if _, err := mergeDockerConfigs(base, []byte("not json")); err == nil {
+		t.Error("expected an error for an invalid overlay")
+	}
+}
+
+func TestCreateTargetSecretOutsideOfAllowedNamespace(t *testing.T) {
+	s := &promotionStep{}
+	err := s.createTargetSecret(context.Background(), api.PromotionCredentials{Namespace: "ci", Name: "registry-push-credentials-ci-central"})
+	if err == nil || err.Error() != "credentials for promotion targets must be in namespace test-credentials, not ci" {
+		t.Errorf("expected credentials outside of test-credentials to be rejected, got %v", err)
+	}
+}
+
+func TestRecordHistory(t *testing.T) {
+	if err := imageapi.AddToScheme(scheme.Scheme); err != nil {
+		t.Fatalf("failed to register imagev1 scheme: %v", err)
+	}
+	stream := &imageapi.ImageStream{
+		ObjectMeta: meta.ObjectMeta{Namespace: "ocp", Name: "4.10"},
+		Status: imageapi.ImageStreamStatus{
+			Tags: []imageapi.NamedTagEventList{
+				{Tag: "cli", Items: []imageapi.TagEvent{{Image: "sha256:new"}}},
+				{Tag: "installer", Items: []imageapi.TagEvent{{Image: "sha256:installer"}}},
+			},
+		},
+	}
+	client := fakectrlruntimeclient.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(stream).Build()
+	key := ctrlruntimeclient.ObjectKey{Namespace: "ocp", Name: "4.10"}
+	streams := map[ctrlruntimeclient.ObjectKey]map[string]string{
+		key: {
+			"cli":       "registry.ci.openshift.org/ci-op-1/pipeline@sha256:new",
+			"installer": "registry.ci.openshift.org/ci-op-1/pipeline@sha256:installer",
+			"failed":    "registry.ci.openshift.org/ci-op-1/pipeline@sha256:failed",
+		},
+	}
+	previous := map[ctrlruntimeclient.ObjectKey]map[string]string{key: {"cli": "sha256:old"}}
+	now := meta.NewTime(time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC))
+	record := history.Record{Time: now, Job: "branch-ci-org-repo-master-images", BuildID: "1", Tags: map[string]history.TagRecord{}}
+	if err := recordHistory(context.Background(), client, streams, previous, record); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	updated := &imageapi.ImageStream{}
+	if err := client.Get(context.Background(), key, updated); err != nil {
+		t.Fatalf("failed to get stream: %v", err)
+	}
+	records, err := history.Load(updated)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []history.Record{{
+		Time:    now,
+		Job:     "branch-ci-org-repo-master-images",
+		BuildID: "1",
+		Tags: map[string]history.TagRecord{
+			"cli":       {Source: "registry.ci.openshift.org/ci-op-1/pipeline@sha256:new", Digest: "sha256:new", PreviousDigest: "sha256:old"},
+			"installer": {Source: "registry.ci.openshift.org/ci-op-1/pipeline@sha256:installer", Digest: "sha256:installer"},
+		},
+	}}
+	if diff := cmp.Diff(expected, records); diff != "" {
+		t.Errorf("unexpected history: %s", diff)
+	}
+
+	missing := map[ctrlruntimeclient.ObjectKey]map[string]string{{Namespace: "ocp", Name: "missing"}: {"cli": "pull-spec"}}
+	if err := recordHistory(context.Background(), client, missing, nil, record); err == nil {
+		t.Error("expected an error recording into a missing stream")
+	}
+}
+
 func TestRegistryDomain(t *testing.T) {
 	var testCases = []struct {
 		name     string
@@ -716,3 +960,91 @@ func TestGetPublicImageReference(t *testing.T) {
 		})
 	}
 }
+
+func TestGetSigningPod(t *testing.T) {
+	jobSpec := &api.JobSpec{JobSpec: downwardapi.JobSpec{
+		Job:       "branch-ci-org-repo-master-images",
+		BuildID:   "1234",
+		ProwJobID: "uuid",
+		Refs:      &prowapi.Refs{Org: "org", Repo: "repo", BaseRef: "master", BaseSHA: "sha"},
+	}}
+	targets := []string{"registy.ci.openshift.org/ci/applyconfig@sha256:applyconfig", "registy.ci.openshift.org/ci/bin@sha256:bin"}
+	t.Run("central registry", func(t *testing.T) {
+		testhelper.CompareWithFixture(t, getSigningPod("promotion-signing", api.RegistryPushCredentialsCICentralSecret, targets, "ci-op-zyvwvffx", provenanceFor(jobSpec)))
+	})
+	t.Run("external registry", func(t *testing.T) {
+		secret := targetSecretName(api.PromotionCredentials{Namespace: "test-credentials", Name: "quay-push"})
+		testhelper.CompareWithFixture(t, getSigningPod(secret+"-signing", secret, []string{"quay.io/org/bin@sha256:bin"}, "ci-op-zyvwvffx", provenanceFor(jobSpec)))
+	})
+}
+
+func TestSigningTargets(t *testing.T) {
+	cliDigest := "sha256:aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	installerDigest := "sha256:bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+	var testCases = []struct {
+		name     string
+		mirrors  map[string]string
+		expected []string
+		err      error
+	}{
+		{
+			name: "destinations are resolved to the digests of their sources",
+			mirrors: map[string]string{
+				"registry.ci.openshift.org/ocp/4.10:cli":       "registry.ci.openshift.org/ci-op-1/pipeline@" + cliDigest,
+				"registry.ci.openshift.org/ocp/4.10:installer": "registry.ci.openshift.org/ci-op-1/pipeline@" + installerDigest,
+				"quay.io/org/cli:latest":                       "registry.ci.openshift.org/ci-op-1/pipeline@" + cliDigest,
+			},
+			expected: []string{
+				"quay.io/org/cli@" + cliDigest,
+				"registry.ci.openshift.org/ocp/4.10@" + cliDigest,
+				"registry.ci.openshift.org/ocp/4.10@" + installerDigest,
+			},
+		},
+		{
+			name:    "source without a digest",
+			mirrors: map[string]string{"registry.ci.openshift.org/ocp/4.10:cli": "registry.ci.openshift.org/ci-op-1/pipeline:cli"},
+			err:     errors.New("source image registry.ci.openshift.org/ci-op-1/pipeline:cli for registry.ci.openshift.org/ocp/4.10:cli is not pinned by digest"),
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			actual, err := signingTargets(testCase.mirrors)
+			if diff := cmp.Diff(testCase.err, err, testhelper.EquateErrorMessage); diff != "" {
+				t.Fatalf("unexpected error: %s", diff)
+			}
+			if diff := cmp.Diff(testCase.expected, actual); diff != "" {
+				t.Errorf("unexpected targets: %s", diff)
+			}
+		})
+	}
+}
+
+func TestSignUpdatesExistingSBOM(t *testing.T) {
+	jobSpec := &api.JobSpec{JobSpec: downwardapi.JobSpec{
+		Job:       "branch-ci-org-repo-master-images",
+		BuildID:   "1234",
+		ProwJobID: "uuid",
+		Refs:      &prowapi.Refs{Org: "org", Repo: "repo", BaseRef: "master", BaseSHA: "sha"},
+	}}
+	jobSpec.SetNamespace("ci-op-zyvwvffx")
+	existing := &coreapi.ConfigMap{
+		ObjectMeta: meta.ObjectMeta{Namespace: "ci-op-zyvwvffx", Name: promotionSBOMConfigMap},
+		Data:       map[string]string{sbomFilename: "stale"},
+	}
+	client := fakectrlruntimeclient.NewClientBuilder().WithObjects(existing).Build()
+	s := &promotionStep{
+		configuration: &api.ReleaseBuildConfiguration{},
+		jobSpec:       jobSpec,
+		client:        steps.NewPodClient(loggingclient.New(client), nil, nil),
+	}
+	if err := s.sign(context.Background(), &imageapi.ImageStream{}, nil, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	updated := &coreapi.ConfigMap{}
+	if err := client.Get(context.Background(), ctrlruntimeclient.ObjectKeyFromObject(existing), updated); err != nil {
+		t.Fatalf("failed to get SBOM configmap: %v", err)
+	}
+	if updated.Data[sbomFilename] == "stale" {
+		t.Error("expected the existing SBOM to be replaced")
+	}
+}
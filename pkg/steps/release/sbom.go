@@ -0,0 +1,155 @@
+package release
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	prowv1 "k8s.io/test-infra/prow/apis/prowjobs/v1"
+
+	imagev1 "github.com/openshift/api/image/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+// sbomFilename is the key of the SBOM in the ConfigMap it is mounted from
+const sbomFilename = "sbom.spdx.json"
+
+// spdxDocument is an SPDX 2.2 document, serialized as JSON.
+type spdxDocument struct {
+	SPDXVersion       string             `json:"spdxVersion"`
+	DataLicense       string             `json:"dataLicense"`
+	SPDXID            string             `json:"SPDXID"`
+	Name              string             `json:"name"`
+	DocumentNamespace string             `json:"documentNamespace"`
+	CreationInfo      spdxCreationInfo   `json:"creationInfo"`
+	Packages          []spdxPackage      `json:"packages"`
+	Relationships     []spdxRelationship `json:"relationships"`
+}
+
+type spdxCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+type spdxPackage struct {
+	SPDXID           string `json:"SPDXID"`
+	Name             string `json:"name"`
+	VersionInfo      string `json:"versionInfo,omitempty"`
+	DownloadLocation string `json:"downloadLocation"`
+	FilesAnalyzed    bool   `json:"filesAnalyzed"`
+	LicenseConcluded string `json:"licenseConcluded"`
+	LicenseDeclared  string `json:"licenseDeclared"`
+	CopyrightText    string `json:"copyrightText"`
+	Comment          string `json:"comment,omitempty"`
+}
+
+type spdxRelationship struct {
+	SPDXElementID      string `json:"spdxElementId"`
+	RelationshipType   string `json:"relationshipType"`
+	RelatedSPDXElement string `json:"relatedSpdxElement"`
+}
+
+const (
+	spdxDocumentID  = "SPDXRef-DOCUMENT"
+	spdxNoAssertion = "NOASSERTION"
+)
+
+var invalidSPDXIDCharacters = regexp.MustCompile(`[^a-zA-Z0-9.-]+`)
+
+func newSPDXPackage(id, name, version, location, comment string) spdxPackage {
+	if location == "" {
+		location = spdxNoAssertion
+	}
+	return spdxPackage{
+		SPDXID:           "SPDXRef-" + invalidSPDXIDCharacters.ReplaceAllString(id, "-"),
+		Name:             name,
+		VersionInfo:      version,
+		DownloadLocation: location,
+		LicenseConcluded: spdxNoAssertion,
+		LicenseDeclared:  spdxNoAssertion,
+		CopyrightText:    spdxNoAssertion,
+		Comment:          comment,
+	}
+}
+
+// sbomFor describes the inputs of the images built by a job: the revisions
+// of the repositories under test, the base images the images were built
+// from and the RPM repository built from the sources, if any. The same
+// document is attached to every image the job promotes.
+func sbomFor(config *api.ReleaseBuildConfiguration, jobSpec *api.JobSpec, pipeline *imagev1.ImageStream, created time.Time) spdxDocument {
+	document := spdxDocument{
+		SPDXVersion:       "SPDX-2.2",
+		DataLicense:       "CC0-1.0",
+		SPDXID:            spdxDocumentID,
+		Name:              fmt.Sprintf("%s-%s", jobSpec.Job, jobSpec.BuildID),
+		DocumentNamespace: fmt.Sprintf("https://%s/spdx/%s/%s", api.DomainForService(api.ServiceProw), jobSpec.Job, jobSpec.BuildID),
+		CreationInfo: spdxCreationInfo{
+			Created:  created.UTC().Format(time.RFC3339),
+			Creators: []string{"Tool: ci-operator"},
+		},
+	}
+
+	var sources []spdxPackage
+	refs := jobSpec.ExtraRefs
+	if jobSpec.Refs != nil {
+		refs = append([]prowv1.Refs{*jobSpec.Refs}, refs...)
+	}
+	for _, ref := range refs {
+		repo := fmt.Sprintf("%s/%s", ref.Org, ref.Repo)
+		location := fmt.Sprintf("git+https://github.com/%s@%s", repo, ref.BaseSHA)
+		sources = append(sources, newSPDXPackage("Source-"+repo, repo, ref.BaseSHA, location, "base "+ref.BaseRef))
+		for _, pull := range ref.Pulls {
+			location := fmt.Sprintf("git+https://github.com/%s@%s", repo, pull.SHA)
+			sources = append(sources, newSPDXPackage(fmt.Sprintf("Source-%s-%d", repo, pull.Number), fmt.Sprintf("%s#%d", repo, pull.Number), pull.SHA, location, fmt.Sprintf("pull request #%d", pull.Number)))
+		}
+	}
+	for _, source := range sources {
+		document.Packages = append(document.Packages, source)
+		document.Relationships = append(document.Relationships, spdxRelationship{SPDXElementID: spdxDocumentID, RelationshipType: "DESCRIBES", RelatedSPDXElement: source.SPDXID})
+	}
+
+	inputs := map[string]string{}
+	for as, image := range config.BaseImages {
+		inputs[as] = image.ISTagName()
+	}
+	for as, image := range config.BaseRPMImages {
+		inputs[as] = image.ISTagName()
+	}
+	if config.BuildRootImage != nil && config.BuildRootImage.ImageStreamTagReference != nil {
+		inputs[string(api.PipelineImageStreamTagReferenceRoot)] = config.BuildRootImage.ImageStreamTagReference.ISTagName()
+	}
+	var names []string
+	for as := range inputs {
+		names = append(names, as)
+	}
+	sort.Strings(names)
+	var dependencies []spdxPackage
+	for _, as := range names {
+		pullSpec := findDockerImageReference(pipeline, as)
+		dependencies = append(dependencies, newSPDXPackage("Image-"+as, inputs[as], digestOf(pullSpec), pullSpec, "base image "+as))
+	}
+	if config.RpmBuildCommands != "" {
+		pullSpec := findDockerImageReference(pipeline, string(api.PipelineImageStreamTagReferenceRPMs))
+		dependencies = append(dependencies, newSPDXPackage("RPMs", string(api.PipelineImageStreamTagReferenceRPMs), digestOf(pullSpec), pullSpec, "RPM repository built from the sources"))
+	}
+	for _, dependency := range dependencies {
+		document.Packages = append(document.Packages, dependency)
+		if len(sources) == 0 {
+			document.Relationships = append(document.Relationships, spdxRelationship{SPDXElementID: spdxDocumentID, RelationshipType: "DESCRIBES", RelatedSPDXElement: dependency.SPDXID})
+			continue
+		}
+		document.Relationships = append(document.Relationships, spdxRelationship{SPDXElementID: dependency.SPDXID, RelationshipType: "BUILD_DEPENDENCY_OF", RelatedSPDXElement: sources[0].SPDXID})
+	}
+	return document
+}
+
+// digestOf extracts the digest from a pull spec by digest, if it is one.
+func digestOf(pullSpec string) string {
+	if i := strings.LastIndex(pullSpec, "@"); i != -1 {
+		return pullSpec[i+1:]
+	}
+	return ""
+}
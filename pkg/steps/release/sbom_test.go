@@ -0,0 +1,50 @@
+package release
+
+import (
+	"testing"
+	"time"
+
+	prowapi "k8s.io/test-infra/prow/apis/prowjobs/v1"
+	"k8s.io/test-infra/prow/pod-utils/downwardapi"
+
+	imagev1 "github.com/openshift/api/image/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestSBOMFor(t *testing.T) {
+	pipeline := &imagev1.ImageStream{Status: imagev1.ImageStreamStatus{Tags: []imagev1.NamedTagEventList{
+		{Tag: "base", Items: []imagev1.TagEvent{{DockerImageReference: "registry/ci-op/pipeline@sha256:base"}}},
+		{Tag: "root", Items: []imagev1.TagEvent{{DockerImageReference: "registry/ci-op/pipeline@sha256:root"}}},
+		{Tag: "rpms", Items: []imagev1.TagEvent{{DockerImageReference: "registry/ci-op/pipeline@sha256:rpms"}}},
+	}}}
+	config := &api.ReleaseBuildConfiguration{
+		InputConfiguration: api.InputConfiguration{
+			BaseImages:     map[string]api.ImageStreamTagReference{"base": {Namespace: "ocp", Name: "4.9", Tag: "base"}},
+			BaseRPMImages:  map[string]api.ImageStreamTagReference{"rpm-base": {Namespace: "ocp", Name: "4.9", Tag: "rpm-base"}},
+			BuildRootImage: &api.BuildRootImageConfiguration{ImageStreamTagReference: &api.ImageStreamTagReference{Namespace: "ci", Name: "builder", Tag: "golang"}},
+		},
+	}
+	config.RpmBuildCommands = "make rpms"
+	created := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		name    string
+		jobSpec *api.JobSpec
+	}{{
+		name: "presubmit",
+		jobSpec: &api.JobSpec{JobSpec: downwardapi.JobSpec{
+			Job:       "pull-ci-org-repo-master-images",
+			BuildID:   "1234",
+			Refs:      &prowapi.Refs{Org: "org", Repo: "repo", BaseRef: "master", BaseSHA: "base-sha", Pulls: []prowapi.Pull{{Number: 1, SHA: "pull-sha"}}},
+			ExtraRefs: []prowapi.Refs{{Org: "org", Repo: "other", BaseRef: "master", BaseSHA: "other-sha"}},
+		}},
+	}, {
+		name:    "periodic without sources",
+		jobSpec: &api.JobSpec{JobSpec: downwardapi.JobSpec{Job: "periodic-images", BuildID: "1234"}},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			testhelper.CompareWithFixture(t, sbomFor(config, tc.jobSpec, pipeline, created))
+		})
+	}
+}
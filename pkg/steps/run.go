@@ -6,9 +6,16 @@ import (
 	"sync"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	coreapi "k8s.io/api/core/v1"
+
 	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/events"
 	"github.com/openshift/ci-tools/pkg/junit"
 	"github.com/openshift/ci-tools/pkg/results"
+	"github.com/openshift/ci-tools/pkg/tracing"
 )
 
 type message struct {
@@ -17,6 +24,7 @@ type message struct {
 	err             error
 	additionalTests []*junit.TestCase
 	stepDetails     api.CIOperatorStepDetails
+	span            trace.SpanContext
 }
 
 func Run(ctx context.Context, graph []*api.StepNode) (*junit.TestSuites, []api.CIOperatorStepDetails, []error) {
@@ -34,8 +42,10 @@ func Run(ctx context.Context, graph []*api.StepNode) (*junit.TestSuites, []api.C
 
 	start := time.Now()
 	for _, root := range graph {
-		go runStep(ctx, root, executionResults)
+		go runStep(ctx, root, nil, executionResults)
 	}
+	// spans of the steps that finished, to link them to the steps they unblock
+	spans := map[*api.StepNode]trace.SpanContext{}
 
 	suites := &junit.TestSuites{
 		Suites: []*junit.TestSuite{
@@ -54,6 +64,7 @@ func Run(ctx context.Context, graph []*api.StepNode) (*junit.TestSuites, []api.C
 		case out := <-executionResults:
 			testCase := &junit.TestCase{Name: out.node.Step.Description(), Duration: out.duration.Seconds()}
 			stepDetails = append(stepDetails, out.stepDetails)
+			spans[out.node] = out.span
 			if out.err != nil {
 				testCase.FailureOutput = &junit.FailureOutput{Output: out.err.Error()}
 				executionErrors = append(executionErrors, results.ForReason("step_failed").WithError(out.err).Errorf("step %s failed: %v", out.node.Step.Name(), out.err))
@@ -68,7 +79,8 @@ func Run(ctx context.Context, graph []*api.StepNode) (*junit.TestSuites, []api.C
 						// when the last of its parents finishes.
 						if api.HasAllLinks(child.Step.Requires(), seen) {
 							wg.Add(1)
-							go runStep(ctx, child, executionResults)
+							parent, links := parentSpans(out.node, child, spans)
+							go runStep(trace.ContextWithSpanContext(ctx, parent), child, links, executionResults)
 						}
 					}
 				}
@@ -114,9 +126,42 @@ type SubStepReporter interface {
 	SubSteps() []api.CIOperatorStepDetailInfo
 }
 
-func runStep(ctx context.Context, node *api.StepNode, out chan<- message) {
+// PodPlanner allows steps to report the pods they would create when run,
+// without running them.
+type PodPlanner interface {
+	PlannedPods() ([]coreapi.Pod, error)
+}
+
+// LeaseReporter allows steps to report the leases they acquire when run.
+type LeaseReporter interface {
+	Leases() []api.StepLease
+}
+
+// parentSpans determines the parent of the span of a child step, which is the
+// span of the parent step that finished last, as that is the one it waited
+// for, and the links to the spans of its other parent steps.
+func parentSpans(last, child *api.StepNode, spans map[*api.StepNode]trace.SpanContext) (trace.SpanContext, []trace.Link) {
+	var links []trace.Link
+	for node, span := range spans {
+		if node == last {
+			continue
+		}
+		for _, c := range node.Children {
+			if c == child {
+				links = append(links, trace.Link{SpanContext: span})
+			}
+		}
+	}
+	return spans[last], links
+}
+
+func runStep(ctx context.Context, node *api.StepNode, links []trace.Link, out chan<- message) {
+	ctx = events.WithStep(ctx, node.Step.Name())
+	ctx, span := tracing.Start(ctx, node.Step.Name(), trace.WithLinks(links...), trace.WithAttributes(attribute.String("description", node.Step.Description())))
 	start := time.Now()
+	events.Record(ctx, events.Event{Time: start, Type: events.StepStarted})
 	err := node.Step.Run(ctx)
+	tracing.End(span, err)
 	var additionalTests []*junit.TestCase
 	if reporter, ok := node.Step.(subtestReporter); ok {
 		additionalTests = reporter.SubTests()
@@ -124,6 +169,7 @@ func runStep(ctx context.Context, node *api.StepNode, out chan<- message) {
 	duration := time.Since(start)
 	failed := err != nil
 	finishedAt := start.Add(duration)
+	events.Record(ctx, events.Event{Time: finishedAt, Type: events.StepFinished, Failed: failed, Reasons: results.Reasons(err)})
 
 	var subSteps []api.CIOperatorStepDetailInfo
 	if x, ok := node.Step.(SubStepReporter); ok {
@@ -135,6 +181,7 @@ func runStep(ctx context.Context, node *api.StepNode, out chan<- message) {
 		duration:        duration,
 		err:             err,
 		additionalTests: additionalTests,
+		span:            span.SpanContext(),
 		stepDetails: api.CIOperatorStepDetails{
 			CIOperatorStepDetailInfo: api.CIOperatorStepDetailInfo{
 				StepName:    node.Step.Name(),
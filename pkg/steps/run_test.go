@@ -8,12 +8,19 @@ import (
 	"sort"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
 	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/events"
 	"github.com/openshift/ci-tools/pkg/results"
 )
 
@@ -337,3 +344,79 @@ func TestStepsRun(t *testing.T) {
 		})
 	}
 }
+
+type eventCollector struct {
+	lock   sync.Mutex
+	events []events.Event
+}
+
+func (c *eventCollector) Record(event events.Event) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.events = append(c.events, event)
+}
+
+func TestStepsRunEvents(t *testing.T) {
+	root := &fakeStep{name: "root", creates: []api.StepLink{api.InternalImageLink(api.PipelineImageStreamTagReferenceRoot)}}
+	child := &fakeStep{name: "child", runErr: results.ForReason("oops").ForError(errors.New("failed")), requires: []api.StepLink{api.InternalImageLink(api.PipelineImageStreamTagReferenceRoot)}}
+	graph := api.BuildGraph([]api.Step{root, child})
+	collector := &eventCollector{}
+	Run(events.WithRecorder(context.Background(), collector), graph)
+	var actual []events.Event
+	for _, event := range collector.events {
+		event.Time = time.Time{}
+		actual = append(actual, event)
+	}
+	expected := []events.Event{
+		{Type: events.StepStarted, Step: "root"},
+		{Type: events.StepFinished, Step: "root"},
+		{Type: events.StepStarted, Step: "child"},
+		{Type: events.StepFinished, Step: "child", Failed: true, Reasons: []string{"oops"}},
+	}
+	if diff := cmp.Diff(expected, actual); diff != "" {
+		t.Errorf("unexpected events: %s", diff)
+	}
+}
+
+func TestStepsRunTraces(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(previous)
+
+	src := &fakeStep{name: "src", creates: []api.StepLink{api.InternalImageLink(api.PipelineImageStreamTagReferenceSource)}}
+	bin := &fakeStep{name: "bin", creates: []api.StepLink{api.InternalImageLink(api.PipelineImageStreamTagReferenceBinaries)}}
+	unit := &fakeStep{name: "unit", runErr: errors.New("failed"), requires: []api.StepLink{
+		api.InternalImageLink(api.PipelineImageStreamTagReferenceSource),
+		api.InternalImageLink(api.PipelineImageStreamTagReferenceBinaries),
+	}}
+	Run(context.Background(), api.BuildGraph([]api.Step{src, bin, unit}))
+
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = span
+	}
+	if len(spans) != 3 {
+		t.Fatalf("expected a span per step, got %v", spans)
+	}
+	for _, root := range []string{"src", "bin"} {
+		if spans[root].Parent().IsValid() {
+			t.Errorf("expected %s to have no parent", root)
+		}
+	}
+	// the span of the step is a child of the parent step that finished last
+	// and is linked to the others
+	related := []string{spans["unit"].Parent().SpanID().String()}
+	for _, link := range spans["unit"].Links() {
+		related = append(related, link.SpanContext.SpanID().String())
+	}
+	sort.Strings(related)
+	expected := []string{spans["src"].SpanContext().SpanID().String(), spans["bin"].SpanContext().SpanID().String()}
+	sort.Strings(expected)
+	if diff := cmp.Diff(expected, related); diff != "" {
+		t.Errorf("unexpected parent and links: %s", diff)
+	}
+	if status := spans["unit"].Status(); status.Code != codes.Error || status.Description != "failed" {
+		t.Errorf("expected the span of the failed step to have an error status, got %v", status)
+	}
+}
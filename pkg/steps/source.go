@@ -11,6 +11,8 @@ import (
 
 	"github.com/sirupsen/logrus"
 
+	"go.opentelemetry.io/otel/attribute"
+
 	corev1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
@@ -26,9 +28,11 @@ import (
 	imagev1 "github.com/openshift/api/image/v1"
 
 	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/events"
 	"github.com/openshift/ci-tools/pkg/results"
 	"github.com/openshift/ci-tools/pkg/steps/loggingclient"
 	"github.com/openshift/ci-tools/pkg/steps/utils"
+	"github.com/openshift/ci-tools/pkg/tracing"
 )
 
 const (
@@ -158,7 +162,7 @@ func (s *sourceStep) run(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
-	return handleBuild(ctx, s.client, createBuild(s.config, s.jobSpec, clonerefsRef, s.resources, s.cloneAuthConfig, s.pullSecret, fromDigest))
+	return s.client.Build(ctx, createBuild(s.config, s.jobSpec, clonerefsRef, s.resources, s.cloneAuthConfig, s.pullSecret, fromDigest))
 }
 
 func createBuild(config api.SourceStepConfiguration, jobSpec *api.JobSpec, clonerefsRef corev1.ObjectReference, resources api.ResourceConfiguration, cloneAuthConfig *CloneAuthConfig, pullSecret *corev1.Secret, fromDigest string) *buildapi.Build {
@@ -400,7 +404,11 @@ func handleBuild(ctx context.Context, buildClient BuildClient, build *buildapi.B
 		}
 	}
 	err := waitForBuildOrTimeout(ctx, buildClient, build.Namespace, build.Name)
+	traceBuild(ctx, buildClient, build.Namespace, build.Name)
 	if err == nil {
+		if to := build.Spec.Output.To; to != nil {
+			events.Record(ctx, events.Event{Type: events.ImagePushed, Details: map[string]string{"build": build.Name, "image": to.Name}})
+		}
 		if err := gatherSuccessfulBuildLog(buildClient, build.Namespace, build.Name); err != nil {
 			// log error but do not fail successful build
 			logrus.WithError(err).Warnf("Failed gathering successful build %s logs into artifacts.", build.Name)
@@ -411,6 +419,24 @@ func handleBuild(ctx context.Context, buildClient BuildClient, build *buildapi.B
 
 }
 
+// traceBuild records the stages of a finished build as spans. The build is
+// fetched again, as its stages are only known once it finished.
+func traceBuild(ctx context.Context, client ctrlruntimeclient.Client, namespace, name string) {
+	build := &buildapi.Build{}
+	if err := client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: namespace, Name: name}, build); err != nil {
+		logrus.WithError(err).Debugf("Could not get build %s to trace it.", name)
+		return
+	}
+	if build.Status.StartTimestamp == nil || build.Status.CompletionTimestamp == nil {
+		return
+	}
+	ctx = tracing.Record(ctx, "build "+name, build.Status.StartTimestamp.Time, build.Status.CompletionTimestamp.Time, attribute.String("phase", string(build.Status.Phase)))
+	for _, stage := range build.Status.Stages {
+		start := stage.StartTime.Time
+		tracing.Record(ctx, string(stage.Name), start, start.Add(time.Duration(stage.DurationMilliseconds)*time.Millisecond))
+	}
+}
+
 func waitForBuildDeletion(ctx context.Context, client ctrlruntimeclient.Client, ns, name string) error {
 	ch := make(chan error)
 	go func() {
@@ -14,6 +14,8 @@ import (
 
 	"github.com/sirupsen/logrus"
 
+	"go.opentelemetry.io/otel/attribute"
+
 	coreapi "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -28,10 +30,12 @@ import (
 	templateapi "github.com/openshift/api/template/v1"
 
 	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/events"
 	"github.com/openshift/ci-tools/pkg/junit"
 	"github.com/openshift/ci-tools/pkg/results"
 	"github.com/openshift/ci-tools/pkg/steps/loggingclient"
 	"github.com/openshift/ci-tools/pkg/steps/utils"
+	"github.com/openshift/ci-tools/pkg/tracing"
 )
 
 var (
@@ -491,6 +495,7 @@ func waitForPodCompletion(ctx context.Context, podClient PodClient, namespace, n
 	notifierDone := notifier.Done(name)
 	completed := make(map[string]time.Time)
 	var pod *coreapi.Pod
+	defer func() { tracePod(ctx, pod) }()
 	for {
 		newPod, err := waitForPodCompletionOrTimeout(ctx, podClient, namespace, name, completed, notifier, skipLogs)
 		if newPod != nil {
@@ -561,6 +566,7 @@ func waitForPodCompletionOrTimeout(ctx context.Context, podClient PodClient, nam
 	defer podCheckTicker.Stop()
 	podStartTimeout := 30 * time.Minute
 	var podSeenRunning bool
+	podSeenScheduled := recordPodScheduled(ctx, pod, false)
 
 	for {
 		select {
@@ -575,6 +581,7 @@ func waitForPodCompletionOrTimeout(ctx context.Context, podClient PodClient, nam
 				continue
 			}
 
+			podSeenScheduled = recordPodScheduled(ctx, pod, podSeenScheduled)
 			if !podSeenRunning {
 				if podHasStarted(pod) {
 					podSeenRunning = true
@@ -599,6 +606,59 @@ func waitForPodCompletionOrTimeout(ctx context.Context, podClient PodClient, nam
 	}
 }
 
+// recordPodScheduled records the event for the pod being scheduled, unless it
+// was already seen, and returns whether the pod has been scheduled.
+func recordPodScheduled(ctx context.Context, pod *coreapi.Pod, seen bool) bool {
+	if seen {
+		return true
+	}
+	for _, c := range pod.Status.Conditions {
+		if c.Type == coreapi.PodScheduled && c.Status == coreapi.ConditionTrue {
+			events.Record(ctx, events.Event{Time: c.LastTransitionTime.Time, Type: events.PodScheduled, Details: map[string]string{"pod": pod.Name, "node": pod.Spec.NodeName}})
+			return true
+		}
+	}
+	return false
+}
+
+// tracePod records the phases of a pod as spans, from the timestamps in its
+// status.
+func tracePod(ctx context.Context, pod *coreapi.Pod) {
+	if pod == nil {
+		return
+	}
+	start := pod.CreationTimestamp.Time
+	if pod.Status.StartTime != nil {
+		start = pod.Status.StartTime.Time
+	}
+	end := start.Add(podDuration(pod))
+	ctx = tracing.Record(ctx, "pod "+pod.Name, pod.CreationTimestamp.Time, end, attribute.String("phase", string(pod.Status.Phase)), attribute.String("node", pod.Spec.NodeName))
+	conditions := map[coreapi.PodConditionType]time.Time{}
+	for _, c := range pod.Status.Conditions {
+		if c.Status == coreapi.ConditionTrue {
+			conditions[c.Type] = c.LastTransitionTime.Time
+		}
+	}
+	phaseStart := pod.CreationTimestamp.Time
+	for _, phase := range []struct {
+		name string
+		end  coreapi.PodConditionType
+	}{
+		{name: "scheduling", end: coreapi.PodScheduled},
+		{name: "initializing", end: coreapi.PodInitialized},
+	} {
+		phaseEnd, ok := conditions[phase.end]
+		if !ok || phaseEnd.Before(phaseStart) {
+			continue
+		}
+		tracing.Record(ctx, phase.name, phaseStart, phaseEnd)
+		phaseStart = phaseEnd
+	}
+	if end.After(phaseStart) {
+		tracing.Record(ctx, "running", phaseStart, end)
+	}
+}
+
 // podHasStarted checks if a test pod can be considered as "running".
 // Init containers are also checked because they can be declared in template
 // tests, but those added by the test infrastructure are ignored.
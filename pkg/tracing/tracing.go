@@ -0,0 +1,142 @@
+// Package tracing exports OpenTelemetry traces of the execution of the step
+// graph, so that the time spent in each step and its phases can be analyzed.
+package tracing
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+const (
+	// instrumentationName identifies the spans created by ci-operator
+	instrumentationName = "github.com/openshift/ci-tools/pkg/steps"
+	// TracesFilename is the artifact traces are written to when there is no
+	// collector to export them to
+	TracesFilename = "ci-operator-traces.json"
+)
+
+// Options holds the configuration for exporting traces
+type Options struct {
+	endpoint string
+}
+
+// Bind adds flags for the options
+func (o *Options) Bind(flag *flag.FlagSet) {
+	flag.StringVar(&o.endpoint, "tracing-endpoint", "", "URL of the OTLP/HTTP collector to export traces to, like http://collector:4318. Defaults to the standard OTEL_EXPORTER_OTLP_ENDPOINT variables; without a collector, traces are written to $ARTIFACTS/"+TracesFilename+".")
+}
+
+// Setup configures the tracer provider used by the steps and returns the
+// function that flushes the spans that are pending export and closes the
+// traces file, if any. Nothing is traced when there is neither a collector
+// nor an artifact directory.
+func (o *Options) Setup(ctx context.Context, jobSpec *api.JobSpec) (func(context.Context) error, error) {
+	exporter, file, err := o.exporter(ctx)
+	if err != nil || exporter == nil {
+		return func(context.Context) error { return nil }, err
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL,
+			semconv.ServiceNameKey.String("ci-operator"),
+			attribute.String("ci.job", jobSpec.Job),
+			attribute.String("ci.build_id", jobSpec.BuildID),
+			attribute.String("ci.prowjob_id", jobSpec.ProwJobID),
+		)),
+	)
+	otel.SetTracerProvider(provider)
+	return func(ctx context.Context) error {
+		errs := []error{provider.Shutdown(ctx)}
+		if file != nil {
+			// the exporter does not own the file, the tail of the traces
+			// is only written when it is synced
+			if err := file.Sync(); err != nil {
+				errs = append(errs, fmt.Errorf("failed to sync traces file: %w", err))
+			}
+			if err := file.Close(); err != nil {
+				errs = append(errs, fmt.Errorf("failed to close traces file: %w", err))
+			}
+		}
+		return utilerrors.NewAggregate(errs)
+	}, nil
+}
+
+// exporter creates the exporter for the configured collector, or one
+// writing to the returned file in the artifact directory.
+func (o *Options) exporter(ctx context.Context) (sdktrace.SpanExporter, *os.File, error) {
+	switch {
+	case o.endpoint != "":
+		endpoint, err := url.Parse(o.endpoint)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid --tracing-endpoint: %w", err)
+		}
+		opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(endpoint.Host)}
+		if endpoint.Scheme == "http" {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		if endpoint.Path != "" && endpoint.Path != "/" {
+			opts = append(opts, otlptracehttp.WithURLPath(endpoint.Path))
+		}
+		exporter, err := otlptracehttp.New(ctx, opts...)
+		return exporter, nil, err
+	case os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" || os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") != "":
+		exporter, err := otlptracehttp.New(ctx)
+		return exporter, nil, err
+	}
+	artifactDir, set := api.Artifacts()
+	if !set {
+		return nil, nil, nil
+	}
+	f, err := os.Create(filepath.Join(artifactDir, TracesFilename))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create traces file: %w", err)
+	}
+	exporter, err := stdouttrace.New(stdouttrace.WithWriter(f))
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	return exporter, f, nil
+}
+
+// Start creates a span for an operation, as a child of the span in the
+// context.
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, opts...)
+}
+
+// End ends the span, recording the error the operation failed with, if any.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Record creates a span for an operation that already happened, like a
+// phase of a pod, from its timestamps. The returned context can be used to
+// record the spans of its own phases.
+func Record(ctx context.Context, name string, start, end time.Time, attrs ...attribute.KeyValue) context.Context {
+	ctx, span := Start(ctx, name, trace.WithTimestamp(start), trace.WithAttributes(attrs...))
+	span.End(trace.WithTimestamp(end))
+	return ctx
+}
@@ -0,0 +1,97 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	prowdapi "k8s.io/test-infra/prow/pod-utils/downwardapi"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestSetupWritesTracesFile(t *testing.T) {
+	artifactDir := t.TempDir()
+	t.Setenv("ARTIFACTS", artifactDir)
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "")
+	jobSpec := &api.JobSpec{JobSpec: prowdapi.JobSpec{Job: "job", BuildID: "1", ProwJobID: "prowjob"}}
+	shutdown, err := (&Options{}).Setup(context.Background(), jobSpec)
+	if err != nil {
+		t.Fatalf("failed to set up tracing: %v", err)
+	}
+
+	ctx, span := Start(context.Background(), "unit")
+	start := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	Record(ctx, "pending", start, start.Add(time.Minute))
+	End(span, errors.New("failed"))
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("failed to shut down tracing: %v", err)
+	}
+
+	raw, err := ioutil.ReadFile(filepath.Join(artifactDir, TracesFilename))
+	if err != nil {
+		t.Fatalf("failed to read traces: %v", err)
+	}
+	var names []string
+	decoder := json.NewDecoder(strings.NewReader(string(raw)))
+	for decoder.More() {
+		var span struct {
+			Name   string
+			Status struct{ Code string }
+		}
+		if err := decoder.Decode(&span); err != nil {
+			t.Fatalf("failed to decode span: %v", err)
+		}
+		names = append(names, span.Name+":"+span.Status.Code)
+	}
+	// spans are exported when they end
+	if diff := cmp.Diff([]string{"pending:Unset", "unit:Error"}, names); diff != "" {
+		t.Errorf("unexpected spans: %s", diff)
+	}
+}
+
+func TestSetup(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		options     Options
+		expectedErr error
+	}{{
+		name: "nothing is traced without a collector or artifact directory",
+	}, {
+		name:        "invalid endpoint",
+		options:     Options{endpoint: "http://collector:port"},
+		expectedErr: errors.New(`invalid --tracing-endpoint: parse "http://collector:port": invalid port ":port" after host`),
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+			t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "")
+			unsetArtifacts(t)
+			shutdown, err := tc.options.Setup(context.Background(), &api.JobSpec{})
+			if diff := cmp.Diff(tc.expectedErr, err, testhelper.EquateErrorMessage); diff != "" {
+				t.Fatalf("unexpected error: %s", diff)
+			}
+			if err := shutdown(context.Background()); err != nil {
+				t.Errorf("failed to shut down tracing: %v", err)
+			}
+		})
+	}
+}
+
+// unsetArtifacts removes the artifact directory from the environment for
+// the duration of the test.
+func unsetArtifacts(t *testing.T) {
+	t.Setenv("ARTIFACTS", "")
+	if err := os.Unsetenv("ARTIFACTS"); err != nil {
+		t.Fatalf("failed to unset ARTIFACTS: %v", err)
+	}
+}
@@ -235,6 +235,32 @@ func validateImages(ctx *configContext, images []api.ProjectDirectoryImageBuildS
 		if image.DockerfileLiteral != nil && (image.ContextDir != "" || image.DockerfilePath != "") {
 			validationErrors = append(validationErrors, ctxN.errorf("dockerfile_literal is mutually exclusive with context_dir and dockerfile_path"))
 		}
+		seen := sets.NewString()
+		for i, architecture := range image.Architectures {
+			ctxArch := ctxN.addField("architectures").addIndex(i)
+			if err := validateArchitecture(string(ctxArch.field), architecture); err != nil {
+				validationErrors = append(validationErrors, err)
+				continue
+			}
+			if seen.Has(string(architecture)) {
+				validationErrors = append(validationErrors, ctxArch.errorf("duplicate architecture %s", architecture))
+				continue
+			}
+			seen.Insert(string(architecture))
+			if err := ctxArch.addPipelineImage(api.ArchitectureImage(image.To, architecture)); err != nil {
+				validationErrors = append(validationErrors, err)
+			}
+		}
+		// images in the pipeline are imported or built for one architecture,
+		// so the variants for other architectures cannot be based on them
+		if len(image.Architectures) > 0 {
+			if image.From != "" {
+				validationErrors = append(validationErrors, ctxN.errorf("architectures cannot be combined with `from`, images in the pipeline only exist for one architecture: name the base image in the Dockerfile instead"))
+			}
+			if len(image.Inputs) > 0 {
+				validationErrors = append(validationErrors, ctxN.errorf("architectures cannot be combined with `inputs`, images in the pipeline only exist for one architecture"))
+			}
+		}
 	}
 	return validationErrors
 }
@@ -355,6 +381,31 @@ func validatePromotionConfiguration(fieldRoot string, input api.PromotionConfigu
 	if len(input.Name) != 0 && len(input.Tag) != 0 {
 		validationErrors = append(validationErrors, fmt.Errorf("%s: both name and tag defined", fieldRoot))
 	}
+
+	for i, target := range input.Targets {
+		validationErrors = append(validationErrors, validatePromotionTarget(fmt.Sprintf("%s.targets[%d]", fieldRoot, i), target)...)
+	}
+	return validationErrors
+}
+
+func validatePromotionTarget(fieldRoot string, input api.PromotionTarget) []error {
+	var validationErrors []error
+	if len(input.Registry) == 0 {
+		validationErrors = append(validationErrors, fmt.Errorf("%s.registry: value required but not provided", fieldRoot))
+	} else if strings.Contains(input.Registry, "/") {
+		validationErrors = append(validationErrors, fmt.Errorf("%s.registry: must be a domain, got %s", fieldRoot, input.Registry))
+	}
+	if len(input.Repository) == 0 {
+		validationErrors = append(validationErrors, fmt.Errorf("%s.repository: value required but not provided", fieldRoot))
+	}
+	if !strings.Contains(input.Repository, api.ComponentFormatReplacement) && !strings.Contains(input.Tag, api.ComponentFormatReplacement) {
+		validationErrors = append(validationErrors, fmt.Errorf("%s: one of repository or tag must contain %s, so that images are promoted to distinct tags", fieldRoot, api.ComponentFormatReplacement))
+	}
+	if len(input.Credentials.Namespace) == 0 || len(input.Credentials.Name) == 0 {
+		validationErrors = append(validationErrors, fmt.Errorf("%s.credentials: namespace and name must be set", fieldRoot))
+	} else if input.Credentials.Namespace != api.PromotionCredentialsNamespace {
+		validationErrors = append(validationErrors, fmt.Errorf("%s.credentials.namespace: must be %s, got %s", fieldRoot, api.PromotionCredentialsNamespace, input.Credentials.Namespace))
+	}
 	return validationErrors
 }
 
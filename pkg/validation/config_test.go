@@ -274,6 +274,31 @@ func TestValidatePromotion(t *testing.T) {
 			input:    api.PromotionConfiguration{Namespace: "foo", Name: "bar", Tag: "baz"},
 			expected: []error{errors.New("promotion: both name and tag defined")},
 		},
+		{
+			name: "valid targets",
+			input: api.PromotionConfiguration{Namespace: "foo", Name: "bar", Targets: []api.PromotionTarget{
+				{Registry: "quay.io", Repository: "org/${component}", Credentials: api.PromotionCredentials{Namespace: "test-credentials", Name: "quay"}},
+				{Registry: "quay.io", Repository: "org/images", Tag: "${component}-latest", Credentials: api.PromotionCredentials{Namespace: "test-credentials", Name: "quay"}},
+			}},
+		},
+		{
+			name: "invalid targets",
+			input: api.PromotionConfiguration{Namespace: "foo", Name: "bar", Targets: []api.PromotionTarget{
+				{Registry: "quay.io/org", Repository: "images"},
+				{},
+				{Registry: "quay.io", Repository: "org/${component}", Credentials: api.PromotionCredentials{Namespace: "ci", Name: "registry-push-credentials-ci-central"}},
+			}},
+			expected: []error{
+				errors.New("promotion.targets[0].registry: must be a domain, got quay.io/org"),
+				errors.New("promotion.targets[0]: one of repository or tag must contain ${component}, so that images are promoted to distinct tags"),
+				errors.New("promotion.targets[0].credentials: namespace and name must be set"),
+				errors.New("promotion.targets[1].registry: value required but not provided"),
+				errors.New("promotion.targets[1].repository: value required but not provided"),
+				errors.New("promotion.targets[1]: one of repository or tag must contain ${component}, so that images are promoted to distinct tags"),
+				errors.New("promotion.targets[1].credentials: namespace and name must be set"),
+				errors.New("promotion.targets[2].credentials.namespace: must be test-credentials, got ci"),
+			},
+		},
 	}
 	for _, test := range testCases {
 		t.Run(test.name, func(t *testing.T) {
@@ -353,6 +378,49 @@ func TestValidateImages(t *testing.T) {
 				errors.New("images[0]: dockerfile_literal is mutually exclusive with context_dir and dockerfile_path"),
 			},
 		},
+		{
+			name: "valid architectures",
+			input: []api.ProjectDirectoryImageBuildStepConfiguration{{
+				To:            "amsterdam",
+				Architectures: []api.ReleaseArchitecture{api.ReleaseArchitectureAMD64, api.ReleaseArchitectureARM64},
+			}},
+		},
+		{
+			name: "unknown and duplicate architectures",
+			input: []api.ProjectDirectoryImageBuildStepConfiguration{{
+				To:            "amsterdam",
+				Architectures: []api.ReleaseArchitecture{"mips", api.ReleaseArchitectureARM64, api.ReleaseArchitectureARM64},
+			}},
+			output: []error{
+				errors.New("images[0].architectures[0]: must be one of amd64, arm64, ppc64le, s390x"),
+				errors.New("images[0].architectures[2]: duplicate architecture arm64"),
+			},
+		},
+		{
+			name: "architectures with images from the pipeline",
+			input: []api.ProjectDirectoryImageBuildStepConfiguration{{
+				From:          "base",
+				To:            "amsterdam",
+				Architectures: []api.ReleaseArchitecture{api.ReleaseArchitectureARM64},
+				ProjectDirectoryImageBuildInputs: api.ProjectDirectoryImageBuildInputs{
+					Inputs: map[string]api.ImageBuildInputs{"bin": {Paths: []api.ImageSourcePath{{SourcePath: "/go/bin/tool", DestinationDir: "."}}}},
+				},
+			}},
+			output: []error{
+				errors.New("images[0]: architectures cannot be combined with `from`, images in the pipeline only exist for one architecture: name the base image in the Dockerfile instead"),
+				errors.New("images[0]: architectures cannot be combined with `inputs`, images in the pipeline only exist for one architecture"),
+			},
+		},
+		{
+			name: "image built for an architecture conflicts with another image",
+			input: []api.ProjectDirectoryImageBuildStepConfiguration{
+				{To: "amsterdam-arm64"},
+				{To: "amsterdam", Architectures: []api.ReleaseArchitecture{api.ReleaseArchitectureARM64}},
+			},
+			output: []error{
+				errors.New("images[1].architectures[0]: duplicate image name 'amsterdam-arm64' (previously defined by field 'images[0]')"),
+			},
+		},
 	}
 	for _, testCase := range testCases {
 		t.Run(testCase.name, func(t *testing.T) {
@@ -26,32 +26,83 @@ func validateReleases(fieldRoot string, releases map[string]api.UnresolvedReleas
 				}
 			}
 		}
-		var set int
-		if release.Integration != nil {
-			set = set + 1
-		}
-		if release.Candidate != nil {
-			set = set + 1
-		}
-		if release.Release != nil {
-			set = set + 1
+		validationErrors = append(validationErrors, validateUnresolvedRelease(fmt.Sprintf("%s.%s", fieldRoot, name), name, release)...)
+	}
+	return validationErrors
+}
+
+func validateUnresolvedRelease(fieldRoot, name string, release api.UnresolvedRelease) []error {
+	var validationErrors []error
+	var set int
+	if release.Integration != nil {
+		set = set + 1
+	}
+	if release.Candidate != nil {
+		set = set + 1
+	}
+	if release.Release != nil {
+		set = set + 1
+	}
+	if release.Prerelease != nil {
+		set = set + 1
+	}
+	if release.Composition != nil {
+		set = set + 1
+	}
+
+	if set > 1 {
+		validationErrors = append(validationErrors, fmt.Errorf("%s: cannot set more than one of integration, candidate, prerelease, release and composition", fieldRoot))
+	} else if set == 0 {
+		validationErrors = append(validationErrors, fmt.Errorf("%s: must set integration, candidate, prerelease, release or composition", fieldRoot))
+	} else if release.Integration != nil {
+		validationErrors = append(validationErrors, validateIntegration(fieldRoot, name, *release.Integration)...)
+	} else if release.Candidate != nil {
+		validationErrors = append(validationErrors, validateCandidate(fieldRoot, *release.Candidate)...)
+	} else if release.Release != nil {
+		validationErrors = append(validationErrors, validateRelease(fieldRoot, *release.Release)...)
+	} else if release.Prerelease != nil {
+		validationErrors = append(validationErrors, validatePrerelease(fieldRoot, *release.Prerelease)...)
+	} else if release.Composition != nil {
+		validationErrors = append(validationErrors, validateComposition(fieldRoot, name, *release.Composition)...)
+	}
+	return validationErrors
+}
+
+func validateComposition(fieldRoot, name string, composition api.ReleaseComposition) []error {
+	var validationErrors []error
+	validationErrors = append(validationErrors, validateUnresolvedRelease(fmt.Sprintf("%s.base", fieldRoot), api.CompositionBaseName(name), composition.Base.UnresolvedRelease())...)
+	if len(composition.Overrides) == 0 {
+		validationErrors = append(validationErrors, fmt.Errorf("%s.overrides: must override at least one component", fieldRoot))
+	}
+	seen := sets.NewString()
+	for i, override := range composition.Overrides {
+		overrideRoot := fmt.Sprintf("%s.overrides[%d]", fieldRoot, i)
+		if len(override.Components) == 0 {
+			validationErrors = append(validationErrors, fmt.Errorf("%s.components: must be set", overrideRoot))
 		}
-		if release.Prerelease != nil {
-			set = set + 1
+		for _, component := range override.Components {
+			if seen.Has(component) {
+				validationErrors = append(validationErrors, fmt.Errorf("%s.components: component %s is overridden more than once", overrideRoot, component))
+			}
+			seen.Insert(component)
 		}
-
-		if set > 1 {
-			validationErrors = append(validationErrors, fmt.Errorf("%s.%s: cannot set more than one of integration, candidate, prerelease and release", fieldRoot, name))
-		} else if set == 0 {
-			validationErrors = append(validationErrors, fmt.Errorf("%s.%s: must set integration, candidate, prerelease or release", fieldRoot, name))
-		} else if release.Integration != nil {
-			validationErrors = append(validationErrors, validateIntegration(fmt.Sprintf("%s.%s", fieldRoot, name), name, *release.Integration)...)
-		} else if release.Candidate != nil {
-			validationErrors = append(validationErrors, validateCandidate(fmt.Sprintf("%s.%s", fieldRoot, name), *release.Candidate)...)
-		} else if release.Release != nil {
-			validationErrors = append(validationErrors, validateRelease(fmt.Sprintf("%s.%s", fieldRoot, name), *release.Release)...)
-		} else if release.Prerelease != nil {
-			validationErrors = append(validationErrors, validatePrerelease(fmt.Sprintf("%s.%s", fieldRoot, name), *release.Prerelease)...)
+		fromStream := override.Namespace != "" || override.Name != ""
+		switch {
+		case fromStream && override.PullSpec != "":
+			validationErrors = append(validationErrors, fmt.Errorf("%s: cannot set both an integration stream and pull_spec", overrideRoot))
+		case override.PullSpec != "":
+			if len(override.Components) > 1 {
+				validationErrors = append(validationErrors, fmt.Errorf("%s: pull_spec can only override a single component", overrideRoot))
+			}
+		case fromStream:
+			if override.Namespace == "" {
+				validationErrors = append(validationErrors, fmt.Errorf("%s.namespace: must be set", overrideRoot))
+			}
+			if override.Name == "" {
+				validationErrors = append(validationErrors, fmt.Errorf("%s.name: must be set", overrideRoot))
+			}
+		default:
+			validationErrors = append(validationErrors, fmt.Errorf("%s: must set an integration stream or pull_spec", overrideRoot))
 		}
 	}
 	return validationErrors
@@ -91,7 +91,7 @@ func TestValidateReleases(t *testing.T) {
 				"latest": {},
 			},
 			output: []error{
-				errors.New("root.latest: must set integration, candidate, prerelease or release"),
+				errors.New("root.latest: must set integration, candidate, prerelease, release or composition"),
 			},
 		},
 		{
@@ -103,7 +103,7 @@ func TestValidateReleases(t *testing.T) {
 				},
 			},
 			output: []error{
-				errors.New("root.latest: cannot set more than one of integration, candidate, prerelease and release"),
+				errors.New("root.latest: cannot set more than one of integration, candidate, prerelease, release and composition"),
 			},
 		},
 		{
@@ -116,7 +116,7 @@ func TestValidateReleases(t *testing.T) {
 				},
 			},
 			output: []error{
-				errors.New("root.latest: cannot set more than one of integration, candidate, prerelease and release"),
+				errors.New("root.latest: cannot set more than one of integration, candidate, prerelease, release and composition"),
 			},
 		},
 		{
@@ -154,6 +154,54 @@ func TestValidateReleases(t *testing.T) {
 				errors.New("root.third.version_bounds.upper: must be set"),
 			},
 		},
+		{
+			name: "valid composition",
+			input: map[string]api.UnresolvedRelease{
+				"latest": {
+					Composition: &api.ReleaseComposition{
+						Base: api.ReleaseCompositionBase{
+							Candidate: &api.Candidate{Product: api.ReleaseProductOCP, Stream: api.ReleaseStreamNightly, Version: "4.10"},
+						},
+						Overrides: []api.ComponentOverride{
+							{Components: []string{"machine-config-operator", "machine-os-content"}, Namespace: "team", Name: "4.10"},
+							{Components: []string{"installer"}, PullSpec: "quay.io/team/installer:latest"},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "invalid composition",
+			input: map[string]api.UnresolvedRelease{
+				"latest": {
+					Composition: &api.ReleaseComposition{
+						Base: api.ReleaseCompositionBase{
+							Integration: &api.Integration{Namespace: "ocp", Name: "4.10", IncludeBuiltImages: true},
+						},
+						Overrides: []api.ComponentOverride{
+							{Components: []string{"installer", "cli"}, PullSpec: "quay.io/team/installer:latest"},
+							{Components: []string{"installer"}, Namespace: "team"},
+							{Components: []string{"tests"}},
+							{Namespace: "team", Name: "4.10", PullSpec: "quay.io/team/tests:latest"},
+						},
+					},
+				},
+				"empty": {
+					Composition: &api.ReleaseComposition{},
+				},
+			},
+			output: []error{
+				errors.New("root.empty.base: must set integration, candidate, prerelease, release or composition"),
+				errors.New("root.empty.overrides: must override at least one component"),
+				errors.New("root.latest.base: only the `latest` release can set `include_built_images`"),
+				errors.New("root.latest.overrides[0]: pull_spec can only override a single component"),
+				errors.New("root.latest.overrides[1].components: component installer is overridden more than once"),
+				errors.New("root.latest.overrides[1].name: must be set"),
+				errors.New("root.latest.overrides[2]: must set an integration stream or pull_spec"),
+				errors.New("root.latest.overrides[3].components: must be set"),
+				errors.New("root.latest.overrides[3]: cannot set both an integration stream and pull_spec"),
+			},
+		},
 	}
 
 	for _, testCase := range testCases {
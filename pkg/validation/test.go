@@ -571,6 +571,9 @@ func (v *Validator) validateTestConfigurationType(
 		for i, s := range testConfig.Post {
 			validationErrors = append(validationErrors, v.validateLiteralTestStep(context.addField("post").addIndex(i), testStagePost, s, claimRelease)...)
 		}
+		validationErrors = append(validationErrors, validateParallelGroups(context.addField("pre"), testConfig.Pre)...)
+		validationErrors = append(validationErrors, validateParallelGroups(context.addField("test"), testConfig.Test)...)
+		validationErrors = append(validationErrors, validateParallelGroups(context.addField("post"), testConfig.Post)...)
 	}
 	if typeCount == 0 {
 		validationErrors = append(validationErrors, fmt.Errorf("%s has no type, you may want to specify 'container' for a container based test", fieldRoot))
@@ -584,10 +587,40 @@ func (v *Validator) validateTestConfigurationType(
 	if clusterCount > 1 {
 		validationErrors = append(validationErrors, fmt.Errorf("%s installs more than one cluster, probably it defined both cluster_claim and cluster_profile", fieldRoot))
 	}
+	if test.CacheResults {
+		validationErrors = append(validationErrors, validateCacheResults(fieldRoot, test)...)
+	}
 
 	return validationErrors
 }
 
+// validateCacheResults ensures that results are only cached for tests whose
+// outcome is determined by their inputs, which excludes tests that use a
+// cluster or leased resources.
+func validateCacheResults(fieldRoot string, test api.TestStepConfiguration) (ret []error) {
+	var clusterProfile api.ClusterProfile
+	var leases []api.StepLease
+	switch {
+	case test.ContainerTestConfiguration != nil:
+	case test.MultiStageTestConfiguration != nil:
+		clusterProfile, leases = test.MultiStageTestConfiguration.ClusterProfile, test.MultiStageTestConfiguration.Leases
+	case test.MultiStageTestConfigurationLiteral != nil:
+		clusterProfile, leases = test.MultiStageTestConfigurationLiteral.ClusterProfile, api.LeasesForTest(test.MultiStageTestConfigurationLiteral)
+	default:
+		return []error{fmt.Errorf("%s.cache_results: can only be set for container and multi-stage tests", fieldRoot)}
+	}
+	if clusterProfile != "" {
+		ret = append(ret, fmt.Errorf("%s.cache_results: cannot be set for a test with a cluster_profile", fieldRoot))
+	}
+	if test.ClusterClaim != nil {
+		ret = append(ret, fmt.Errorf("%s.cache_results: cannot be set for a test with a cluster_claim", fieldRoot))
+	}
+	if len(leases) != 0 {
+		ret = append(ret, fmt.Errorf("%s.cache_results: cannot be set for a test which acquires leases", fieldRoot))
+	}
+	return ret
+}
+
 func (v *Validator) validateTestSteps(context *context, stage testStage, steps []api.TestStep, claimRelease *api.ClaimRelease) (ret []error) {
 	for i, s := range steps {
 		contextI := context.addIndex(i)
@@ -695,13 +728,20 @@ func (v *Validator) validateLiteralTestStep(context *context, stage testStage, s
 
 	ret = append(ret, validateResourceRequirements(string(context.field)+".resources", step.Resources)...)
 	ret = append(ret, validateCredentials(string(context.field), step.Credentials)...)
+	ret = append(ret, validateParameterDefinitions(context.addField("env"), step.Environment)...)
 	if context.env != nil {
-		if err := validateParameters(context, step.Environment); err != nil {
-			ret = append(ret, err)
-		}
+		ret = append(ret, validateParameters(context, step.Environment)...)
 	}
 	ret = append(ret, validateDependencies(string(context.field), step.Dependencies)...)
 	ret = append(ret, validateLeases(context.addField("leases"), step.Leases)...)
+	if step.Retry != nil {
+		ret = append(ret, validateRetryPolicy(context.addField("retry"), *step.Retry)...)
+	}
+	if step.When != nil {
+		ret = append(ret, validateStepCondition(context.addField("when"), step.Environment, *step.When)...)
+	}
+	ret = append(ret, validateSharedDirFiles(context.addField("produces"), step.Produces)...)
+	ret = append(ret, validateSharedDirFiles(context.addField("consumes"), step.Consumes)...)
 	switch stage {
 	case testStagePre, testStageTest:
 		if step.OptionalOnSuccess != nil {
@@ -765,20 +805,33 @@ func validateCredentials(fieldRoot string, credentials []api.CredentialReference
 	return errs
 }
 
-func validateParameters(context *context, params []api.StepParameter) error {
+func validateParameters(context *context, params []api.StepParameter) (ret []error) {
 	var missing []string
 	for _, param := range params {
-		if param.Default != nil {
-			continue
-		}
-		if _, ok := context.env[param.Name]; !ok {
+		value, ok := context.env[param.Name]
+		if ok {
+			if err := param.ValidateValue(value); err != nil {
+				ret = append(ret, context.errorf("invalid value for parameter %s: %v", param.Name, err))
+			}
+		} else if param.Default == nil {
 			missing = append(missing, param.Name)
 		}
 	}
 	if missing != nil {
-		return context.errorf("unresolved parameter(s): %s", missing)
+		ret = append(ret, context.errorf("unresolved parameter(s): %s", missing))
 	}
-	return nil
+	return ret
+}
+
+// validateParameterDefinitions ensures that the constraints of parameters
+// are consistent and that their defaults satisfy them.
+func validateParameterDefinitions(context *context, params []api.StepParameter) (ret []error) {
+	for i, param := range params {
+		if err := param.ValidateDefinition(); err != nil {
+			ret = append(ret, context.addIndex(i).errorf("parameter %s: %v", param.Name, err))
+		}
+	}
+	return ret
 }
 
 func validateDependencies(fieldRoot string, dependencies []api.StepDependency) []error {
@@ -812,6 +865,83 @@ func validateDNSConfig(fieldRoot string, dnsConfig []api.StepDNSConfig) (ret []e
 	return errs
 }
 
+// validateParallelGroups ensures that the steps of each parallel group are
+// consecutive, since groups are run one after another.
+func validateParallelGroups(context *context, steps []api.LiteralTestStep) (ret []error) {
+	finished := sets.NewString()
+	var last string
+	for i, step := range steps {
+		if step.ParallelGroup != last && finished.Has(step.ParallelGroup) {
+			ret = append(ret, context.addIndex(i).errorf("steps in parallel group %q must be consecutive", step.ParallelGroup))
+		}
+		if last != "" {
+			finished.Insert(last)
+		}
+		last = step.ParallelGroup
+	}
+	return
+}
+
+func validateRetryPolicy(context *context, policy api.StepRetryPolicy) (ret []error) {
+	if policy.Attempts < 1 {
+		ret = append(ret, context.addField("attempts").errorf("must be at least 1, got %d", policy.Attempts))
+	}
+	if policy.Backoff != nil && policy.Backoff.Duration < 0 {
+		ret = append(ret, context.addField("backoff").errorf("cannot be negative"))
+	}
+	for i, pattern := range policy.LogPatterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			ret = append(ret, context.addField("log_patterns").addIndex(i).errorf("invalid regular expression: %v", err))
+		}
+	}
+	return
+}
+
+func validateStepCondition(context *context, params []api.StepParameter, condition api.StepCondition) (ret []error) {
+	declared := sets.NewString()
+	for _, param := range params {
+		declared.Insert(param.Name)
+	}
+	for i, env := range condition.Env {
+		if env.Name == "" {
+			ret = append(ret, context.addField("env").addIndex(i).errorf("'name' cannot be empty"))
+		} else if !declared.Has(env.Name) {
+			ret = append(ret, context.addField("env").addIndex(i).errorf("%q is not a parameter of this step", env.Name))
+		}
+	}
+	for i, file := range condition.SharedDirFiles {
+		if errs := validation.IsConfigMapKey(file); len(errs) != 0 {
+			ret = append(ret, context.addField("shared_dir_files").addIndex(i).errorf("%q is not a valid file name: %s", file, strings.Join(errs, ", ")))
+		}
+	}
+	for i, step := range condition.Steps {
+		if step.Name == "" {
+			ret = append(ret, context.addField("steps").addIndex(i).errorf("'name' cannot be empty"))
+		}
+		switch step.Result {
+		case api.StepResultSucceeded, api.StepResultFailed, api.StepResultSkipped:
+		default:
+			ret = append(ret, context.addField("steps").addIndex(i).errorf("invalid result %q, must be one of %s, %s or %s", step.Result, api.StepResultSucceeded, api.StepResultFailed, api.StepResultSkipped))
+		}
+	}
+	return
+}
+
+// validateSharedDirFiles validates the names of files a step produces or
+// consumes in the shared directory
+func validateSharedDirFiles(context *context, files []string) (ret []error) {
+	seen := sets.NewString()
+	for i, file := range files {
+		if errs := validation.IsConfigMapKey(file); len(errs) != 0 {
+			ret = append(ret, context.addIndex(i).errorf("%q is not a valid file name: %s", file, strings.Join(errs, ", ")))
+		} else if seen.Has(file) {
+			ret = append(ret, context.addIndex(i).errorf("duplicate file %q", file))
+		}
+		seen.Insert(file)
+	}
+	return
+}
+
 func validateLeases(context *context, leases []api.StepLease) (ret []error) {
 	for i, l := range leases {
 		if l.ResourceType == "" {
@@ -604,6 +604,7 @@ func TestValidateTestSteps(t *testing.T) {
 	myReference := "my-reference"
 	asReference := "as"
 	yes := true
+	disabled := "false"
 	defaultDuration := &prowv1.Duration{Duration: 1 * time.Minute}
 	for _, tc := range []struct {
 		name         string
@@ -943,6 +944,106 @@ func TestValidateTestSteps(t *testing.T) {
 		errs: []error{
 			errors.New("test best-effort contains best_effort without timeout"),
 		},
+	}, {
+		name: "step with valid retry policy",
+		steps: []api.TestStep{{
+			LiteralTestStep: &api.LiteralTestStep{
+				As:        "install",
+				From:      "installer",
+				Commands:  "openshift-install create cluster",
+				Resources: resources,
+				Retry: &api.StepRetryPolicy{
+					Attempts:    3,
+					Backoff:     defaultDuration,
+					ExitCodes:   []int32{4},
+					LogPatterns: []string{"Throttling: Rate exceeded"},
+				},
+			},
+		}},
+	}, {
+		name: "step with invalid retry policy",
+		steps: []api.TestStep{{
+			LiteralTestStep: &api.LiteralTestStep{
+				As:        "install",
+				From:      "installer",
+				Commands:  "openshift-install create cluster",
+				Resources: resources,
+				Retry: &api.StepRetryPolicy{
+					Backoff:     &prowv1.Duration{Duration: -time.Second},
+					LogPatterns: []string{"("},
+				},
+			},
+		}},
+		errs: []error{
+			errors.New("test[0].retry.attempts: must be at least 1, got 0"),
+			errors.New("test[0].retry.backoff: cannot be negative"),
+			errors.New("test[0].retry.log_patterns[0]: invalid regular expression: error parsing regexp: missing closing ): `(`"),
+		},
+	}, {
+		name: "step with valid conditions",
+		steps: []api.TestStep{{
+			LiteralTestStep: &api.LiteralTestStep{
+				As:          "fips-check",
+				From:        "installer",
+				Commands:    "check-fips",
+				Resources:   resources,
+				Environment: []api.StepParameter{{Name: "FIPS_ENABLED", Default: &disabled}},
+				When: &api.StepCondition{
+					Env:            []api.StepEnvCondition{{Name: "FIPS_ENABLED", Equals: "true"}},
+					SharedDirFiles: []string{"kubeconfig"},
+					Steps:          []api.StepResultCondition{{Name: "install", Result: api.StepResultSucceeded}},
+				},
+			},
+		}},
+	}, {
+		name: "step with invalid conditions",
+		steps: []api.TestStep{{
+			LiteralTestStep: &api.LiteralTestStep{
+				As:        "fips-check",
+				From:      "installer",
+				Commands:  "check-fips",
+				Resources: resources,
+				When: &api.StepCondition{
+					Env:            []api.StepEnvCondition{{Name: "FIPS_ENABLED", Equals: "true"}},
+					SharedDirFiles: []string{"auth/kubeconfig"},
+					Steps:          []api.StepResultCondition{{Result: "done"}},
+				},
+			},
+		}},
+		errs: []error{
+			errors.New(`test[0].when.env[0]: "FIPS_ENABLED" is not a parameter of this step`),
+			errors.New(`test[0].when.shared_dir_files[0]: "auth/kubeconfig" is not a valid file name: a valid config key must consist of alphanumeric characters, '-', '_' or '.' (e.g. 'key.name',  or 'KEY_NAME',  or 'key-name', regex used for validation is '[-._a-zA-Z0-9]+')`),
+			errors.New("test[0].when.steps[0]: 'name' cannot be empty"),
+			errors.New(`test[0].when.steps[0]: invalid result "done", must be one of succeeded, failed or skipped`),
+		},
+	}, {
+		name: "step with valid shared directory files",
+		steps: []api.TestStep{{
+			LiteralTestStep: &api.LiteralTestStep{
+				As:        "install",
+				From:      "installer",
+				Commands:  "install",
+				Resources: resources,
+				Produces:  []string{"kubeconfig", "metadata.json"},
+				Consumes:  []string{"install-config.yaml"},
+			},
+		}},
+	}, {
+		name: "step with invalid shared directory files",
+		steps: []api.TestStep{{
+			LiteralTestStep: &api.LiteralTestStep{
+				As:        "install",
+				From:      "installer",
+				Commands:  "install",
+				Resources: resources,
+				Produces:  []string{"kubeconfig", "kubeconfig"},
+				Consumes:  []string{"auth/kubeconfig"},
+			},
+		}},
+		errs: []error{
+			errors.New(`test[0].produces[1]: duplicate file "kubeconfig"`),
+			errors.New(`test[0].consumes[0]: "auth/kubeconfig" is not a valid file name: a valid config key must consist of alphanumeric characters, '-', '_' or '.' (e.g. 'key.name',  or 'KEY_NAME',  or 'key-name', regex used for validation is '[-._a-zA-Z0-9]+')`),
+		},
 	}, {
 		name: "cluster claim release",
 		steps: []api.TestStep{{
@@ -1031,6 +1132,19 @@ func TestValidateParameters(t *testing.T) {
 		params: []api.StepParameter{{Name: "TEST0"}, {Name: "TEST1"}},
 		env:    api.TestEnvironment{"TEST0": "test0"},
 		err:    []error{errors.New("test: unresolved parameter(s): [TEST1]")},
+	}, {
+		name:   "typed parameter, valid value provided",
+		params: []api.StepParameter{{Name: "FIPS_ENABLED", Type: api.StepParameterTypeBool}},
+		env:    api.TestEnvironment{"FIPS_ENABLED": "true"},
+	}, {
+		name:   "typed parameter, invalid value provided",
+		params: []api.StepParameter{{Name: "FIPS_ENABLED", Type: api.StepParameterTypeBool}},
+		env:    api.TestEnvironment{"FIPS_ENABLED": "ture"},
+		err:    []error{errors.New(`test: invalid value for parameter FIPS_ENABLED: "ture" is not a bool, must be true or false`)},
+	}, {
+		name:   "enum parameter without allowed values",
+		params: []api.StepParameter{{Name: "NETWORK", Type: api.StepParameterTypeEnum, Default: &defaultStr}},
+		err:    []error{errors.New("test.env[0]: parameter NETWORK: `allowed_values` must be set for an enum")},
 	}} {
 		t.Run(tc.name, func(t *testing.T) {
 			v := NewValidator()
@@ -1304,6 +1418,52 @@ func TestValidateLeases(t *testing.T) {
 	}
 }
 
+func TestValidateParallelGroups(t *testing.T) {
+	step := func(name, group string) api.LiteralTestStep {
+		return api.LiteralTestStep{
+			As:            name,
+			From:          "from",
+			Commands:      "commands",
+			ParallelGroup: group,
+			Resources: api.ResourceRequirements{
+				Requests: api.ResourceList{"cpu": "1"},
+				Limits:   api.ResourceList{"memory": "1m"},
+			},
+		}
+	}
+	for _, tc := range []struct {
+		name string
+		test api.MultiStageTestConfigurationLiteral
+		err  []error
+	}{{
+		name: "consecutive groups",
+		test: api.MultiStageTestConfigurationLiteral{
+			Test: []api.LiteralTestStep{step("a", ""), step("b", "conformance"), step("c", "conformance"), step("d", ""), step("e", "must-gather")},
+			Post: []api.LiteralTestStep{step("f", "conformance"), step("g", "conformance")},
+		},
+	}, {
+		name: "group is split",
+		test: api.MultiStageTestConfigurationLiteral{
+			Test: []api.LiteralTestStep{step("a", "conformance"), step("b", ""), step("c", "conformance"), step("d", "other"), step("e", "conformance")},
+		},
+		err: []error{
+			errors.New(`tests[0].steps.test[2]: steps in parallel group "conformance" must be consecutive`),
+			errors.New(`tests[0].steps.test[4]: steps in parallel group "conformance" must be consecutive`),
+		},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			test := api.TestStepConfiguration{
+				MultiStageTestConfigurationLiteral: &tc.test,
+			}
+			v := NewValidator()
+			err := v.validateTestConfigurationType("tests[0]", test, nil, nil, make(testInputImages), true)
+			if diff := diff.ObjectReflectDiff(tc.err, err); diff != "<no diffs>" {
+				t.Errorf("unexpected error: %s", diff)
+			}
+		})
+	}
+}
+
 func TestValidateTestConfigurationType(t *testing.T) {
 	for _, tc := range []struct {
 		name     string
@@ -1460,6 +1620,53 @@ func TestValidateTestConfigurationType(t *testing.T) {
 				errors.New("test.cluster_claim cannot be set on a test which is not a multi-stage test"),
 			},
 		},
+		{
+			name: "cached results on a container test",
+			test: api.TestStepConfiguration{
+				ContainerTestConfiguration: &api.ContainerTestConfiguration{From: "src"},
+				CacheResults:               true,
+			},
+		},
+		{
+			name: "cached results on a multi-stage test without a cluster",
+			test: api.TestStepConfiguration{
+				MultiStageTestConfiguration: &api.MultiStageTestConfiguration{
+					Test: []api.TestStep{{LiteralTestStep: &api.LiteralTestStep{
+						As:        "unit",
+						Commands:  "make test",
+						From:      "src",
+						Resources: api.ResourceRequirements{Requests: api.ResourceList{"cpu": "1"}},
+					}}},
+				},
+				CacheResults: true,
+			},
+		},
+		{
+			name: "cached results on a test with a cluster profile and leases -> error",
+			test: api.TestStepConfiguration{
+				MultiStageTestConfigurationLiteral: &api.MultiStageTestConfigurationLiteral{
+					ClusterProfile: api.ClusterProfileAWS,
+					Leases:         []api.StepLease{{ResourceType: "some-quota", Env: "LEASED"}},
+				},
+				CacheResults: true,
+			},
+			expected: []error{
+				errors.New("test.cache_results: cannot be set for a test with a cluster_profile"),
+				errors.New("test.cache_results: cannot be set for a test which acquires leases"),
+			},
+		},
+		{
+			name: "cached results on a template test -> error",
+			test: api.TestStepConfiguration{
+				OpenshiftInstallerClusterTestConfiguration: &api.OpenshiftInstallerClusterTestConfiguration{
+					ClusterTestConfiguration: api.ClusterTestConfiguration{ClusterProfile: api.ClusterProfileAWS},
+				},
+				CacheResults: true,
+			},
+			expected: []error{
+				errors.New("test.cache_results: can only be set for container and multi-stage tests"),
+			},
+		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
 			v := NewValidator()
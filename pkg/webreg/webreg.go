@@ -7,6 +7,7 @@ import (
 	"html/template"
 	"net/http"
 	"path"
+	"sort"
 	"strings"
 	"time"
 
@@ -133,6 +134,9 @@ td {
       <li class="nav-item">
         <a class="nav-link" href="/search">Jobs</a>
       </li>
+      <li class="nav-item">
+        <a class="nav-link" href="/versions">Versions</a>
+      </li>
       <li class="nav-item">
         <a class="nav-link" href="http://docs.ci.openshift.org">Help</a>
       </li>
@@ -172,6 +176,8 @@ const referencePage = `
 <p id="image">{{ fromImageDescription .Reference.From .Reference.FromImage }}<d/p>
 <h3 id="environment"><a href="#environment">Environment</a></h3>
 {{ template "stepEnvironment" .Reference }}
+<h3 id="shared_dir" title="Files this step exchanges with other steps through the shared directory"><a href="#shared_dir">Shared Directory</a></h3>
+{{ template "stepSharedDir" .Reference }}
 <h3 id="source"><a href="#source">Source Code</a></h3>
 {{ syntaxedSource .Reference.Commands }}
 <h3 id="properties"><a href="#properties">Properties</a></h3>
@@ -183,13 +189,19 @@ const referencePage = `
 const chainPage = `
 <h2 id="title"><a href="#title">Chain:</a> <nobr style="font-family:monospace">{{ .Chain.As }}</nobr></h2>
 <p id="documentation">{{ .Chain.Documentation }}</p>
+{{ if .Chain.Parallel }}
+<h3 id="steps" title="Steps run by the chain, concurrently"><a href="#steps">Steps</a> (run in parallel)</h3>
+{{ else }}
 <h3 id="steps" title="Step run by the chain, in runtime order"><a href="#steps">Steps</a></h3>
+{{ end }}
 {{ template "stepTable" .Chain.Steps}}
 <h3 id="dependencies" title="Dependencies of steps involved in this chain"><a href="#dependencies">Dependencies</a></h3>
 {{ $depTable := "chain" }}
 {{ template "dependencyTable" .Chain.As }}
 <h3 id="environment" title="Environmental variables consumed through this chain"><a href="#environment">Environment</a></h3>
 {{ template "refEnvironment" .Chain.As }}
+<h3 id="shared_dir" title="Files steps in this chain exchange through the shared directory"><a href="#shared_dir">Shared Directory</a></h3>
+{{ template "sharedDirTable" .Chain.As }}
 <h3 id="graph" title="Visual representation of steps run by this chain"><a href="#graph">Step Graph</a></h3>
 {{ chainGraph .Chain.As }}
 <h3 id="github"><a href="#github">GitHub Link:</a></h3>{{ githubLink .Metadata.Path }}
@@ -217,6 +229,8 @@ const workflowJobPage = `
 {{ template "dependencyTable" .Workflow.As }}
 <h3 id="environment" title="Environmental variables consumed through this workflow"><a href="#environment">Environment</a></h3>
 {{ template "refEnvironment" .Workflow.As }}
+<h3 id="shared_dir" title="Files steps in this {{ toLower $type }} exchange through the shared directory"><a href="#shared_dir">Shared Directory</a></h3>
+{{ template "sharedDirTable" .Workflow.As }}
 <h3 id="graph" title="Visual representation of steps run by this {{ toLower $type }}"><a href="#graph">Step Graph</a></h3>
 {{ workflowGraph .Workflow.As .Workflow.Type }}
 {{ if eq $type "Workflow" }}
@@ -229,6 +243,39 @@ const jobSearchPage = `
 {{ template "jobTable" . }}
 `
 
+const versionsPage = `
+<h2 id="versions"><a href="#versions">Pinned Versions</a></h2>
+<p>Tests can pin a reference, chain or workflow with <span style="font-family:monospace">name@version</span>
+to a version of the registry{{ if .Versions }} (one of {{ range $i, $version := .Versions }}{{ if $i }}, {{ end }}<span style="font-family:monospace">{{ $version }}</span>{{ end }}){{ end }}
+or to the digest of its content. Components in a pinned chain or workflow are resolved in the same version.</p>
+<table class="table">
+	<thead>
+		<tr>
+			<th title="The pinned registry component" class="info">Component</th>
+			<th title="The version the component is pinned to" class="info">Version</th>
+			<th title="Whether the pinned version exists and differs from the current component" class="info">Status</th>
+			<th title="The tests pinning the component to this version" class="info">Tests</th>
+		</tr>
+	</thead>
+	<tbody>
+	{{ range .Pins }}
+		<tr>
+			<td>{{ template "nameWithLink" .Pin }}</td>
+			<td><span style="font-family:monospace">{{ .Version }}</span></td>
+			<td>{{ .Status }}</td>
+			<td>
+				<ul>
+				{{ range .Tests }}
+					<li><nobr style="font-family:monospace">{{ . }}</nobr></li>
+				{{ end }}
+				</ul>
+			</td>
+		</tr>
+	{{ end }}
+	</tbody>
+</table>
+`
+
 const templateDefinitions = `
 {{ define "nameWithLink" }}
 	<nobr><a href="/{{ .Type }}/{{ .Name }}" style="font-family:monospace">{{ .Name }}</a></nobr>
@@ -300,6 +347,24 @@ const templateDefinitions = `
       <td>This step's failure will not cause whole job to fail if the step is run in <span style="font-family:monospace">post</span> phase.</td>
     </tr>
   {{ end }}
+  {{ if .Retry }}
+    <tr>
+      <td>Retry attempts</td>
+      <td>{{ .Retry.Attempts }}</td>
+      <td>The step is re-run up to this many times in total when it fails with a retryable error{{ if .Retry.ExitCodes }} (exit codes: <span style="font-family:monospace">{{ range $i, $code := .Retry.ExitCodes }}{{ if $i }}, {{ end }}{{ $code }}{{ end }}</span>){{ end }}{{ if .Retry.LogPatterns }} (log patterns: {{ range $i, $pattern := .Retry.LogPatterns }}{{ if $i }}, {{ end }}<span style="font-family:monospace">{{ $pattern }}</span>{{ end }}){{ end }}{{ if .Retry.Backoff }}, waiting {{ .Retry.Backoff.String }} between attempts{{ end }}.</td>
+    </tr>
+  {{ end }}
+  {{ if .When }}
+    <tr>
+      <td>Run conditions</td>
+      <td>
+      {{ range .When.Env }}<nobr><span style="font-family:monospace">{{ .Name }}={{ .Equals }}</span></nobr><br>{{ end }}
+      {{ range .When.SharedDirFiles }}<nobr><span style="font-family:monospace">${SHARED_DIR}/{{ . }}</span> exists</nobr><br>{{ end }}
+      {{ range .When.Steps }}<nobr><span style="font-family:monospace">{{ .Name }}</span> {{ .Result }}</nobr><br>{{ end }}
+      </td>
+      <td>The step is skipped unless all of these conditions are met.</td>
+    </tr>
+  {{ end }}
   {{ if .Cli }}
     <tr>
       <td>Inject <span style="font-family:monospace">oc</span> CLI<sup>[<a href="https://docs.ci.openshift.org/docs/architecture/step-registry/#sharing-data-between-steps">?</a>]</sup></td>
@@ -379,6 +444,9 @@ const templateDefinitions = `
 			 (default: <span style="font-family:monospace">{{ $env.Default }}</span>)
 		   {{ end }}
 		   {{ end }}
+		   {{ with $env.Constraint }}
+			 (values: <span style="font-family:monospace">{{ . }}</span>)
+		   {{ end }}
 		 </td>
 		 <td>
              {{ range $i, $step := $env.Steps }}
@@ -424,6 +492,9 @@ const templateDefinitions = `
          (default: <span style="font-family:monospace">{{ $env.Default }}</span>)
        {{ end }}
        {{ end }}
+       {{ with $env.Constraint }}
+         (values: <span style="font-family:monospace">{{ . }}</span>)
+       {{ end }}
      </td>
    </tr>
    {{ end }}
@@ -445,6 +516,69 @@ const templateDefinitions = `
 {{ end }}
 {{ end }}
 
+{{ define "sharedDirTable" }}
+	{{ $data := getSharedDir . }}
+    {{ if eq 0 ( len ($data.Items)) }}
+      <p>No step in this {{ $data.Type }} declares files it produces or consumes in the shared directory.</p>
+    {{ else }}
+        <table class="table">
+        <thead>
+        <tr>
+         <th title="File in the shared directory" class="info">File</th>
+         <th title="Steps writing the file" class="info">Produced By Steps</th>
+         <th title="Steps reading the file" class="info">Consumed By Steps</th>
+        </tr>
+       </thead>
+       <tbody>
+       {{ range $name, $file := $data.Items }}
+       <tr>
+         <td style="font-family:monospace">{{ $name }}</td>
+         <td>
+             {{ range $i, $step := $file.ProducedBy }}
+               <a href="/reference/{{ $step }}">{{ $step }}</a>
+             {{ end }}
+         </td>
+         <td>
+             {{ range $i, $step := $file.ConsumedBy }}
+               <a href="/reference/{{ $step }}">{{ $step }}</a>
+             {{ end }}
+         </td>
+       </tr>
+       {{ end  }}
+       </tbody>
+       </table>
+    {{ end }}
+{{ end }}
+
+{{ define "stepSharedDir" }}
+{{ if and (eq (len .Produces) 0) (eq (len .Consumes) 0) }}
+  <p>Step declares no files it produces or consumes in the shared directory.</p>
+{{ else }}
+    <table class="table">
+    <thead>
+    <tr>
+     <th title="File in the shared directory" class="info">File</th>
+     <th title="How the step uses the file" class="info">Usage</th>
+    </tr>
+   </thead>
+   <tbody>
+   {{ range $idx, $file := .Consumes }}
+   <tr>
+     <td style="font-family:monospace">{{ $file }}</td>
+     <td>Consumed, must be produced by a step running before this one</td>
+   </tr>
+   {{ end }}
+   {{ range $idx, $file := .Produces }}
+   <tr>
+     <td style="font-family:monospace">{{ $file }}</td>
+     <td>Produced for steps running after this one</td>
+   </tr>
+   {{ end }}
+   </tbody>
+   </table>
+{{ end }}
+{{ end }}
+
 {{ define "stepTable" }}
 {{ if not . }}
 	<p>No test steps configured.</p>
@@ -657,6 +791,19 @@ type Variant struct {
 	Tests []string
 }
 
+const (
+	pinUnknown  = "unknown version"
+	pinCurrent  = "same as current"
+	pinOutdated = "differs from current"
+)
+
+// pinnedVersion is a version of a registry component and the tests pinned to it
+type pinnedVersion struct {
+	registry.Pin
+	Status string
+	Tests  []string
+}
+
 func repoSpan(r Repo, containsVariant bool) int {
 	if !containsVariant {
 		return len(r.Branches) + 1
@@ -730,6 +877,7 @@ func getBaseTemplate() (*template.Template, error) {
 			"getEnvironment": func(string) environmentData {
 				return environmentData{}
 			},
+			"getSharedDir": func(string) sharedDirData { return sharedDirData{} },
 
 			"testStepNameAndType": getTestStepNameAndType,
 			"noescape": func(str string) template.HTML {
@@ -872,7 +1020,9 @@ func setWorkflowGraph(t *template.Template, chains registry.ChainByName, workflo
 type environmentLine struct {
 	Documentation string
 	Default       *string
-	Steps         []string
+	// Constraint describes the values the parameter accepts
+	Constraint string
+	Steps      []string
 }
 
 type environmentData struct {
@@ -883,17 +1033,18 @@ type environmentData struct {
 func getEnvironmentDataItems(worklist []api.TestStep, registryRefs registry.ReferenceByName, registryChains registry.ChainByName) map[string]environmentLine {
 	data := map[string]environmentLine{}
 
-	add := func(name, documentation, step string, defaultVal *string) {
-		if _, ok := data[name]; !ok {
-			data[name] = environmentLine{
-				Documentation: documentation,
-				Default:       defaultVal,
+	add := func(env api.StepParameter, step string) {
+		if _, ok := data[env.Name]; !ok {
+			data[env.Name] = environmentLine{
+				Documentation: env.Documentation,
+				Default:       env.Default,
+				Constraint:    env.Constraint(),
 			}
 		}
 
-		line := data[name]
+		line := data[env.Name]
 		line.Steps = append(line.Steps, step)
-		data[name] = line
+		data[env.Name] = line
 	}
 
 	seenChains := sets.NewString()
@@ -909,7 +1060,7 @@ func getEnvironmentDataItems(worklist []api.TestStep, registryRefs registry.Refe
 				continue
 			}
 			for _, env := range ref.Environment {
-				add(env.Name, env.Documentation, ref.As, env.Default)
+				add(env, ref.As)
 			}
 		case step.Chain != nil:
 			chainName := *step.Chain
@@ -923,8 +1074,63 @@ func getEnvironmentDataItems(worklist []api.TestStep, registryRefs registry.Refe
 			}
 		case step.LiteralTestStep != nil:
 			for _, env := range step.Environment {
-				add(env.Name, env.Documentation, step.As, env.Default)
+				add(env, step.As)
+			}
+		}
+	}
+
+	return data
+}
+
+type sharedDirLine struct {
+	ProducedBy []string
+	ConsumedBy []string
+}
+
+type sharedDirData struct {
+	Items map[string]sharedDirLine
+	Type  string
+}
+
+func getSharedDirDataItems(worklist []api.TestStep, registryRefs registry.ReferenceByName, registryChains registry.ChainByName) map[string]sharedDirLine {
+	data := map[string]sharedDirLine{}
+	add := func(step api.LiteralTestStep) {
+		for _, file := range step.Produces {
+			line := data[file]
+			line.ProducedBy = append(line.ProducedBy, step.As)
+			data[file] = line
+		}
+		for _, file := range step.Consumes {
+			line := data[file]
+			line.ConsumedBy = append(line.ConsumedBy, step.As)
+			data[file] = line
+		}
+	}
+
+	seenChains := sets.NewString()
+	for len(worklist) != 0 {
+		step := worklist[0]
+		worklist = worklist[1:]
+		switch {
+		case step.Reference != nil:
+			ref, ok := registryRefs[*step.Reference]
+			if !ok {
+				logrus.WithField("step-name", *step.Reference).Error("failed to resolve step shared directory files, step not found in registry")
+				continue
+			}
+			add(ref)
+		case step.Chain != nil:
+			chainName := *step.Chain
+			if !seenChains.Has(chainName) {
+				seenChains.Insert(chainName)
+				chain, ok := registryChains[chainName]
+				if !ok {
+					logrus.WithField("chain-name", chainName).Error("failed to resolve chain shared directory files, chain not found in registry")
+				}
+				worklist = append(worklist, chain.Steps...)
 			}
+		case step.LiteralTestStep != nil:
+			add(*step.LiteralTestStep)
 		}
 	}
 
@@ -1091,6 +1297,51 @@ func setWorkflowEnvironment(t *template.Template, refs registry.ReferenceByName,
 		})
 }
 
+func setChainSharedDir(t *template.Template, refs registry.ReferenceByName, chains registry.ChainByName) *template.Template {
+	return t.Funcs(
+		template.FuncMap{
+			"getSharedDir": func(as string) sharedDirData {
+				ret := sharedDirData{
+					Type: "chain",
+				}
+
+				chain, ok := chains[as]
+				if !ok {
+					logrus.WithField("chain-name", as).Error("failed to resolve chain steps: step not found in registry")
+					return ret
+				}
+
+				ret.Items = getSharedDirDataItems(chain.Steps, refs, chains)
+				return ret
+			},
+		})
+}
+
+func setWorkflowSharedDir(t *template.Template, refs registry.ReferenceByName, chains registry.ChainByName, workflows registry.WorkflowByName) *template.Template {
+	return t.Funcs(
+		template.FuncMap{
+			"getSharedDir": func(as string) sharedDirData {
+				ret := sharedDirData{
+					Type: "workflow",
+				}
+
+				workflow, ok := workflows[as]
+				if !ok {
+					logrus.WithField("workflow-name", as).Error("failed to resolve workflow steps: workflow not found in registry")
+					return ret
+				}
+
+				var worklist []api.TestStep
+				for _, steps := range [][]api.TestStep{workflow.Pre, workflow.Test, workflow.Post} {
+					worklist = append(worklist, steps...)
+				}
+
+				ret.Items = getSharedDirDataItems(worklist, refs, chains)
+				return ret
+			},
+		})
+}
+
 func setChainGraph(t *template.Template, chains registry.ChainByName) *template.Template {
 	return t.Funcs(
 		template.FuncMap{
@@ -1148,6 +1399,8 @@ func WebRegHandler(regAgent agents.RegistryAgent, confAgent agents.ConfigAgent)
 				mainPageHandler(regAgent, mainPage, w, req)
 			case "search":
 				searchHandler(confAgent, w, req)
+			case "versions":
+				versionsHandler(regAgent, confAgent, w, req)
 			case "job":
 				jobHandler(regAgent, confAgent, w, req)
 			case "ci-operator-reference":
@@ -1387,6 +1640,10 @@ func referenceHandler(agent agents.RegistryAgent, w http.ResponseWriter, req *ht
 				OptionalOnSuccess: refs[name].OptionalOnSuccess,
 				BestEffort:        refs[name].BestEffort,
 				Cli:               refs[name].Cli,
+				Retry:             refs[name].Retry,
+				When:              refs[name].When,
+				Produces:          refs[name].Produces,
+				Consumes:          refs[name].Consumes,
 			},
 			Documentation: docs[name],
 		},
@@ -1411,6 +1668,7 @@ func chainHandler(agent agents.RegistryAgent, w http.ResponseWriter, req *http.R
 	page = setChainGraph(page, chains)
 	page = setChainDependencies(page, refs, chains)
 	page = setChainEnvironment(page, refs, chains)
+	page = setChainSharedDir(page, refs, chains)
 	if page, err = page.Parse(chainPage); err != nil {
 		writeErrorPage(w, fmt.Errorf("Failed to render page: %w", err), http.StatusInternalServerError)
 		return
@@ -1432,6 +1690,7 @@ func chainHandler(agent agents.RegistryAgent, w http.ResponseWriter, req *http.R
 			As:            name,
 			Documentation: docs[name],
 			Steps:         chains[name].Steps,
+			Parallel:      chains[name].Parallel,
 		},
 		Metadata: metadata[chainMetadataName],
 	}
@@ -1456,6 +1715,7 @@ func workflowHandler(agent agents.RegistryAgent, w http.ResponseWriter, req *htt
 	page = setChainGraph(page, chains)
 	page = setWorkflowDependencies(page, refs, chains, workflows)
 	page = setWorkflowEnvironment(page, refs, chains, workflows)
+	page = setWorkflowSharedDir(page, refs, chains, workflows)
 
 	if page, err = page.Parse(workflowJobPage); err != nil {
 		writeErrorPage(w, fmt.Errorf("Failed to render page: %w", err), http.StatusInternalServerError)
@@ -1718,6 +1978,82 @@ func searchJobs(jobs *Jobs, search string) *Jobs {
 	return matches
 }
 
+// getPinnedVersions lists the versions of registry components tests are
+// pinned to, and whether they differ from the current components
+func getPinnedVersions(configs load.ByOrgRepo, current registry.Version, versions registry.VersionByName) []pinnedVersion {
+	byPin := map[registry.Pin]*pinnedVersion{}
+	for _, orgConfigs := range configs {
+		for _, repoConfigs := range orgConfigs {
+			for _, releaseConfig := range repoConfigs {
+				for _, test := range releaseConfig.Tests {
+					if test.MultiStageTestConfiguration == nil {
+						continue
+					}
+					for _, pin := range registry.PinsIn(*test.MultiStageTestConfiguration) {
+						if _, ok := byPin[pin]; !ok {
+							byPin[pin] = &pinnedVersion{Pin: pin, Status: pinStatus(pin, current, versions)}
+						}
+						byPin[pin].Tests = append(byPin[pin].Tests, fmt.Sprintf("%s: %s", releaseConfig.Metadata.AsString(), test.As))
+					}
+				}
+			}
+		}
+	}
+	var pins []pinnedVersion
+	for _, pin := range byPin {
+		sort.Strings(pin.Tests)
+		pins = append(pins, *pin)
+	}
+	sort.Slice(pins, func(i, j int) bool {
+		if pins[i].Type != pins[j].Type {
+			return pins[i].Type < pins[j].Type
+		}
+		if pins[i].Name != pins[j].Name {
+			return pins[i].Name < pins[j].Name
+		}
+		return pins[i].Version < pins[j].Version
+	})
+	return pins
+}
+
+func pinStatus(pin registry.Pin, current registry.Version, versions registry.VersionByName) string {
+	pinnedDigest, ok := registry.LookupDigest(pin, current, versions)
+	if !ok {
+		return pinUnknown
+	}
+	latestDigest, ok := registry.LookupDigest(registry.Pin{Type: pin.Type, Name: pin.Name}, current, versions)
+	if !ok || pinnedDigest != latestDigest {
+		return pinOutdated
+	}
+	return pinCurrent
+}
+
+func versionsHandler(regAgent agents.RegistryAgent, confAgent agents.ConfigAgent, w http.ResponseWriter, _ *http.Request) {
+	start := time.Now()
+	defer func() { logrus.Infof("rendered in %s", time.Since(start)) }()
+	w.Header().Set("Content-Type", "text/html;charset=UTF-8")
+	refs, chains, workflows, _, _ := regAgent.GetRegistryComponents()
+	versions := regAgent.GetRegistryVersions()
+	page, err := baseTemplate.Clone()
+	if err != nil {
+		writeErrorPage(w, fmt.Errorf("Failed to render page: %w", err), http.StatusInternalServerError)
+		return
+	}
+	if page, err = page.Parse(versionsPage); err != nil {
+		writeErrorPage(w, fmt.Errorf("Failed to render page: %w", err), http.StatusInternalServerError)
+		return
+	}
+	current := registry.Version{References: refs, Chains: chains, Workflows: workflows}
+	data := struct {
+		Versions []string
+		Pins     []pinnedVersion
+	}{
+		Versions: sets.StringKeySet(versions).List(),
+		Pins:     getPinnedVersions(confAgent.GetAll(), current, versions),
+	}
+	writePage(w, "Pinned Versions Page", page, data)
+}
+
 func ciOpConfigRefHandler(w http.ResponseWriter) {
 	if _, err := w.Write(ciOperatorRefRendered); err != nil {
 		logrus.WithError(err).Error("Failed to write ci-operator config")
@@ -9,6 +9,7 @@ import (
 	"k8s.io/utils/pointer"
 
 	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/load"
 	"github.com/openshift/ci-tools/pkg/registry"
 )
 
@@ -196,6 +197,8 @@ func TestGetEnvironmentDataItems(t *testing.T) {
 				{
 					Name:          "var2",
 					Documentation: "var2 documentation",
+					Type:          api.StepParameterTypeEnum,
+					AllowedValues: []string{"a", "b"},
 				},
 			},
 		},
@@ -208,6 +211,8 @@ func TestGetEnvironmentDataItems(t *testing.T) {
 				{
 					Name:          "var2",
 					Documentation: "var2 documentation",
+					Type:          api.StepParameterTypeEnum,
+					AllowedValues: []string{"a", "b"},
 				},
 			},
 		},
@@ -281,6 +286,7 @@ func TestGetEnvironmentDataItems(t *testing.T) {
 				},
 				"var2": {
 					Documentation: "var2 documentation",
+					Constraint:    "one of a, b",
 					Steps:         []string{"step-2"},
 				},
 			},
@@ -296,6 +302,7 @@ func TestGetEnvironmentDataItems(t *testing.T) {
 				},
 				"var2": {
 					Documentation: "var2 documentation",
+					Constraint:    "one of a, b",
 					Steps:         []string{"step-2", "step-3"},
 				},
 			},
@@ -314,3 +321,68 @@ func TestGetEnvironmentDataItems(t *testing.T) {
 		})
 	}
 }
+
+func TestGetSharedDirDataItems(t *testing.T) {
+	install, gather := "install", "gather"
+	registrySteps := registry.ReferenceByName{
+		install: {As: install, Produces: []string{"kubeconfig", "metadata.json"}},
+		gather:  {As: gather, Consumes: []string{"kubeconfig"}},
+	}
+	registryChains := registry.ChainByName{
+		"teardown": {As: "teardown", Steps: []api.TestStep{{Reference: &gather}}},
+	}
+	inputSteps := []api.TestStep{
+		{Reference: &install},
+		{Chain: pointer.StringPtr("teardown")},
+		{LiteralTestStep: &api.LiteralTestStep{As: "destroy", Consumes: []string{"metadata.json"}}},
+	}
+	expected := map[string]sharedDirLine{
+		"kubeconfig":    {ProducedBy: []string{"install"}, ConsumedBy: []string{"gather"}},
+		"metadata.json": {ProducedBy: []string{"install"}, ConsumedBy: []string{"destroy"}},
+	}
+	if diff := cmp.Diff(expected, getSharedDirDataItems(inputSteps, registrySteps, registryChains)); diff != "" {
+		t.Errorf("data differs from expected:\n%s", diff)
+	}
+}
+
+func TestGetPinnedVersions(t *testing.T) {
+	install := "ipi-install"
+	current := registry.Version{
+		References: registry.ReferenceByName{install: {As: install, Commands: "install v4"}},
+		Workflows:  registry.WorkflowByName{"ipi-aws": {Pre: []api.TestStep{{Reference: &install}}}},
+	}
+	versions := registry.VersionByName{
+		"v3": {
+			References: registry.ReferenceByName{install: {As: install, Commands: "install v3"}},
+			Workflows:  registry.WorkflowByName{"ipi-aws": {Pre: []api.TestStep{{Reference: &install}}}},
+		},
+	}
+	test := func(as string, config api.MultiStageTestConfiguration) api.TestStepConfiguration {
+		return api.TestStepConfiguration{As: as, MultiStageTestConfiguration: &config}
+	}
+	configs := load.ByOrgRepo{
+		"org": {"repo": {{
+			Metadata: api.Metadata{Org: "org", Repo: "repo", Branch: "master"},
+			Tests: []api.TestStepConfiguration{
+				test("e2e", api.MultiStageTestConfiguration{Workflow: pointer.StringPtr("ipi-aws@v3")}),
+				test("e2e-upgrade", api.MultiStageTestConfiguration{Workflow: pointer.StringPtr("ipi-aws@v3"), Test: []api.TestStep{{Reference: pointer.StringPtr("ipi-install@v2")}}}),
+				test("unpinned", api.MultiStageTestConfiguration{Workflow: pointer.StringPtr("ipi-aws")}),
+				{As: "unit", ContainerTestConfiguration: &api.ContainerTestConfiguration{From: "src"}},
+			},
+		}, {
+			Metadata: api.Metadata{Org: "org", Repo: "repo", Branch: "master", Variant: "v"},
+			Tests: []api.TestStepConfiguration{
+				test("e2e", api.MultiStageTestConfiguration{Pre: []api.TestStep{{Reference: pointer.StringPtr("ipi-install@v3")}}}),
+			},
+		}}},
+	}
+	expected := []pinnedVersion{
+		{Pin: registry.Pin{Type: "reference", Name: install, Version: "v2"}, Status: pinUnknown, Tests: []string{"org/repo@master: e2e-upgrade"}},
+		{Pin: registry.Pin{Type: "reference", Name: install, Version: "v3"}, Status: pinOutdated, Tests: []string{"org/repo@master [v]: e2e"}},
+		// the workflow itself is unchanged, but the step it refers to is not
+		{Pin: registry.Pin{Type: "workflow", Name: "ipi-aws", Version: "v3"}, Status: pinOutdated, Tests: []string{"org/repo@master: e2e", "org/repo@master: e2e-upgrade"}},
+	}
+	if diff := cmp.Diff(expected, getPinnedVersions(configs, current, versions)); diff != "" {
+		t.Errorf("unexpected pinned versions: %s", diff)
+	}
+}
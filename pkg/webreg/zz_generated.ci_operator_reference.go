@@ -82,7 +82,16 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"# process. The name of each image is its \"to\" value\n" +
 	"# and can be used to build only a specific image.\n" +
 	"images:\n" +
-	"    - # BuildArgs contains build arguments that will be resolved in the Dockerfile.\n" +
+	"    - # Architectures are the architectures the image is built for. The\n" +
+	"      # image is built once per architecture, on nodes of that architecture,\n" +
+	"      # and `to` becomes a manifest list of the resulting images. When not\n" +
+	"      # set, the image is built once, for the architecture of the node the\n" +
+	"      # build runs on. Images in the pipeline only exist for one architecture,\n" +
+	"      # so images built for several cannot use `from` or `inputs`: the base\n" +
+	"      # image is named in the Dockerfile and pulled for each architecture.\n" +
+	"      architectures:\n" +
+	"        - \"\"\n" +
+	"      # BuildArgs contains build arguments that will be resolved in the Dockerfile.\n" +
 	"      # See https://docs.docker.com/engine/reference/builder/#/arg for more details.\n" +
 	"      build_args:\n" +
 	"        - # Name of the build arg.\n" +
@@ -176,6 +185,25 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"    # Tag is the ImageStreamTag tagged in for each\n" +
 	"    # build image's ImageStream.\n" +
 	"    tag: ' '\n" +
+	"    # Targets are additional destinations the images are promoted\n" +
+	"    # to, usually repositories in external registries. Each target\n" +
+	"    # is pushed to with its own credentials.\n" +
+	"    targets:\n" +
+	"        - # Credentials reference the secret holding the dockercfg\n" +
+	"          # credentials which can push to the repository.\n" +
+	"          credentials:\n" +
+	"            # Name is the name of the secret.\n" +
+	"            name: ' '\n" +
+	"            # Namespace is where the secret exists, which must be\n" +
+	"            # test-credentials.\n" +
+	"            namespace: ' '\n" +
+	"          # Registry is the domain of the registry, like quay.io.\n" +
+	"          registry: ' '\n" +
+	"          # Repository is the repository images are promoted to, like\n" +
+	"          # my-org/${component}.\n" +
+	"          repository: ' '\n" +
+	"          # Tag is the tag images are promoted as. Defaults to latest.\n" +
+	"          tag: ' '\n" +
 	"# RawSteps are literal Steps that should be\n" +
 	"# included in the final pipeline.\n" +
 	"raw_steps:\n" +
@@ -262,6 +290,15 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                      # SourcePath is a file or directory in the source image to copy from.\n" +
 	"                      source_path: ' '\n" +
 	"      project_directory_image_build_step:\n" +
+	"        # Architectures are the architectures the image is built for. The\n" +
+	"        # image is built once per architecture, on nodes of that architecture,\n" +
+	"        # and `to` becomes a manifest list of the resulting images. When not\n" +
+	"        # set, the image is built once, for the architecture of the node the\n" +
+	"        # build runs on. Images in the pipeline only exist for one architecture,\n" +
+	"        # so images built for several cannot use `from` or `inputs`: the base\n" +
+	"        # image is named in the Dockerfile and pulled for each architecture.\n" +
+	"        architectures:\n" +
+	"            - \"\"\n" +
 	"        # BuildArgs contains build arguments that will be resolved in the Dockerfile.\n" +
 	"        # See https://docs.docker.com/engine/reference/builder/#/arg for more details.\n" +
 	"        build_args:\n" +
@@ -320,6 +357,62 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"            stream: ' '\n" +
 	"            # Version is the minor version to search for\n" +
 	"            version: ' '\n" +
+	"        # Composition describes a payload composed from a release\n" +
+	"        # and images of components taken from other sources\n" +
+	"        composition:\n" +
+	"            # Base is the release the composed payload starts from.\n" +
+	"            base:\n" +
+	"                # Candidate describes a candidate release payload\n" +
+	"                candidate:\n" +
+	"                    # Architecture is the architecture for the product.\n" +
+	"                    # Defaults to amd64.\n" +
+	"                    architecture: ' '\n" +
+	"                    # Product is the name of the product being released\n" +
+	"                    product: ' '\n" +
+	"                    # ReleaseStream is the stream from which we pick the latest candidate\n" +
+	"                    stream: ' '\n" +
+	"                    # Version is the minor version to search for\n" +
+	"                    version: ' '\n" +
+	"                # Integration describes an integration stream which we can create a payload out of\n" +
+	"                integration:\n" +
+	"                    # Name is the name of the ImageStream\n" +
+	"                    name: ' '\n" +
+	"                    # Namespace is the namespace in which the integration stream lives.\n" +
+	"                    namespace: ' '\n" +
+	"                # Prerelease describes a yet-to-be released payload\n" +
+	"                prerelease:\n" +
+	"                    # Architecture is the architecture for the product.\n" +
+	"                    # Defaults to amd64.\n" +
+	"                    architecture: ' '\n" +
+	"                    # Product is the name of the product being released\n" +
+	"                    product: ' '\n" +
+	"                    # VersionBounds describe the allowable version bounds to search in\n" +
+	"                    version_bounds:\n" +
+	"                        lower: ' '\n" +
+	"                        upper: ' '\n" +
+	"                # Release describes a released payload\n" +
+	"                release:\n" +
+	"                    # Architecture is the architecture for the release.\n" +
+	"                    # Defaults to amd64.\n" +
+	"                    architecture: ' '\n" +
+	"                    # Channel is the release channel to search in\n" +
+	"                    channel: ' '\n" +
+	"                    # Version is the minor version to search for\n" +
+	"                    version: ' '\n" +
+	"            # Overrides replace the images of components of the base release.\n" +
+	"            overrides:\n" +
+	"                - # Components are the names of the components to override, as\n" +
+	"                  # they are tagged in the release.\n" +
+	"                  components:\n" +
+	"                    - \"\"\n" +
+	"                  # Name is the name of the integration stream the images are\n" +
+	"                  # taken from.\n" +
+	"                  name: ' '\n" +
+	"                  # Namespace is the namespace of the integration stream the\n" +
+	"                  # images are taken from, by the tags named like the components.\n" +
+	"                  namespace: ' '\n" +
+	"                  # PullSpec is the image to use for the single component.\n" +
+	"                  pull_spec: ' '\n" +
 	"        # Integration describes an integration stream which we can create a payload out of\n" +
 	"        integration:\n" +
 	"            # Name is the name of the ImageStream\n" +
@@ -480,6 +573,12 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                  cli: ' '\n" +
 	"                  # Commands is the command(s) that will be run inside the image.\n" +
 	"                  commands: ' '\n" +
+	"                  # Consumes lists the files in the shared directory this step needs,\n" +
+	"                  # which a step running before it must produce. The kubeconfig and\n" +
+	"                  # kubeadmin-password files may also be provided by ci-operator, as for\n" +
+	"                  # tests which claim a cluster.\n" +
+	"                  consumes:\n" +
+	"                    - \"\"\n" +
 	"                  # Credentials defines the credentials we'll mount into this step.\n" +
 	"                  credentials:\n" +
 	"                    - # MountPath is where the secret should be mounted.\n" +
@@ -505,12 +604,23 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                        - \"\"\n" +
 	"                  # Environment lists parameters that should be set by the test.\n" +
 	"                  env:\n" +
-	"                    - # Default if not set, optional, makes the parameter not required if set.\n" +
+	"                    - # AllowedValues lists the values the parameter can be set to,\n" +
+	"                      # required for `enum` parameters.\n" +
+	"                      allowed_values:\n" +
+	"                        - \"\"\n" +
+	"                      # Default if not set, optional, makes the parameter not required if set.\n" +
 	"                      default: \"\"\n" +
 	"                      # Documentation is a textual description of the parameter.\n" +
 	"                      documentation: ' '\n" +
 	"                      # Name of the environment variable.\n" +
 	"                      name: ' '\n" +
+	"                      # Pattern is a regular expression the values of a `string`\n" +
+	"                      # parameter must match.\n" +
+	"                      pattern: ' '\n" +
+	"                      # Type constrains the values of the parameter: one of `string`,\n" +
+	"                      # `bool`, `int`, `enum` and `duration`. Any string is allowed\n" +
+	"                      # when not set, `bool` parameters are either `true` or `false`.\n" +
+	"                      type: ' '\n" +
 	"                  # From is the container image that will be used for this step.\n" +
 	"                  from: ' '\n" +
 	"                  # FromImage is a literal ImageStreamTag reference to use for this step.\n" +
@@ -537,6 +647,14 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                  # flag is set to true in MultiStageTestConfiguration. This option is\n" +
 	"                  # applicable to `post` steps.\n" +
 	"                  optional_on_success: false\n" +
+	"                  # ParallelGroup names a group of consecutive steps which are run\n" +
+	"                  # concurrently. Steps of a parallel chain are put into a group named\n" +
+	"                  # after the chain.\n" +
+	"                  parallel_group: ' '\n" +
+	"                  # Produces lists the files this step writes to the shared directory\n" +
+	"                  # for later steps to use.\n" +
+	"                  produces:\n" +
+	"                    - \"\"\n" +
 	"                  # Resources defines the resource requirements for the step.\n" +
 	"                  resources:\n" +
 	"                    # Limits are resource limits applied to an individual step in the job.\n" +
@@ -547,11 +665,47 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                    # These are directly used in creating the Pods that execute the Job.\n" +
 	"                    requests:\n" +
 	"                        \"\": \"\"\n" +
+	"                  # Retry defines if and how this step should be retried when it fails\n" +
+	"                  # for a reason which is known to be transient.\n" +
+	"                  retry:\n" +
+	"                    # Attempts is the maximum number of times the step will be executed,\n" +
+	"                    # including the first execution.\n" +
+	"                    attempts: 0\n" +
+	"                    # Backoff is how long we will wait before starting the next attempt.\n" +
+	"                    backoff: 0s\n" +
+	"                    # ExitCodes lists the exit codes of the test container for which the\n" +
+	"                    # failure is considered retryable.\n" +
+	"                    exit_codes:\n" +
+	"                        - 0\n" +
+	"                    # LogPatterns lists regular expressions which make the failure retryable\n" +
+	"                    # when they match the termination message of the test container, which\n" +
+	"                    # contains the tail of its logs.\n" +
+	"                    log_patterns:\n" +
+	"                        - \"\"\n" +
 	"                  # RunAsScript defines if this step should be executed as a script mounted\n" +
 	"                  # in the test container instead of being executed directly via bash\n" +
 	"                  run_as_script: false\n" +
 	"                  # Timeout is how long the we will wait before aborting a job with SIGINT.\n" +
 	"                  timeout: 0s\n" +
+	"                  # When defines conditions which must all be met for this step to run.\n" +
+	"                  # Steps whose conditions are not met are skipped.\n" +
+	"                  when:\n" +
+	"                    # Env lists parameters which must have the given values.\n" +
+	"                    env:\n" +
+	"                        - # Equals is the value the parameter must have.\n" +
+	"                          equals: ' '\n" +
+	"                          # Name of the parameter.\n" +
+	"                          name: ' '\n" +
+	"                    # SharedDirFiles lists files which must exist in the shared directory.\n" +
+	"                    shared_dir_files:\n" +
+	"                        - \"\"\n" +
+	"                    # Steps lists previous steps which must have finished with the given\n" +
+	"                    # results.\n" +
+	"                    steps:\n" +
+	"                        - # Name of the previous step.\n" +
+	"                          name: ' '\n" +
+	"                          # Result the previous step must have finished with.\n" +
+	"                          result: ' '\n" +
 	"            # Pre is the array of test steps run to set up the environment for the test.\n" +
 	"            pre:\n" +
 	"                - # As is the name of the LiteralTestStep.\n" +
@@ -566,6 +720,12 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                  cli: ' '\n" +
 	"                  # Commands is the command(s) that will be run inside the image.\n" +
 	"                  commands: ' '\n" +
+	"                  # Consumes lists the files in the shared directory this step needs,\n" +
+	"                  # which a step running before it must produce. The kubeconfig and\n" +
+	"                  # kubeadmin-password files may also be provided by ci-operator, as for\n" +
+	"                  # tests which claim a cluster.\n" +
+	"                  consumes:\n" +
+	"                    - \"\"\n" +
 	"                  # Credentials defines the credentials we'll mount into this step.\n" +
 	"                  credentials:\n" +
 	"                    - # MountPath is where the secret should be mounted.\n" +
@@ -591,12 +751,23 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                        - \"\"\n" +
 	"                  # Environment lists parameters that should be set by the test.\n" +
 	"                  env:\n" +
-	"                    - # Default if not set, optional, makes the parameter not required if set.\n" +
+	"                    - # AllowedValues lists the values the parameter can be set to,\n" +
+	"                      # required for `enum` parameters.\n" +
+	"                      allowed_values:\n" +
+	"                        - \"\"\n" +
+	"                      # Default if not set, optional, makes the parameter not required if set.\n" +
 	"                      default: \"\"\n" +
 	"                      # Documentation is a textual description of the parameter.\n" +
 	"                      documentation: ' '\n" +
 	"                      # Name of the environment variable.\n" +
 	"                      name: ' '\n" +
+	"                      # Pattern is a regular expression the values of a `string`\n" +
+	"                      # parameter must match.\n" +
+	"                      pattern: ' '\n" +
+	"                      # Type constrains the values of the parameter: one of `string`,\n" +
+	"                      # `bool`, `int`, `enum` and `duration`. Any string is allowed\n" +
+	"                      # when not set, `bool` parameters are either `true` or `false`.\n" +
+	"                      type: ' '\n" +
 	"                  # From is the container image that will be used for this step.\n" +
 	"                  from: ' '\n" +
 	"                  # FromImage is a literal ImageStreamTag reference to use for this step.\n" +
@@ -623,6 +794,14 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                  # flag is set to true in MultiStageTestConfiguration. This option is\n" +
 	"                  # applicable to `post` steps.\n" +
 	"                  optional_on_success: false\n" +
+	"                  # ParallelGroup names a group of consecutive steps which are run\n" +
+	"                  # concurrently. Steps of a parallel chain are put into a group named\n" +
+	"                  # after the chain.\n" +
+	"                  parallel_group: ' '\n" +
+	"                  # Produces lists the files this step writes to the shared directory\n" +
+	"                  # for later steps to use.\n" +
+	"                  produces:\n" +
+	"                    - \"\"\n" +
 	"                  # Resources defines the resource requirements for the step.\n" +
 	"                  resources:\n" +
 	"                    # Limits are resource limits applied to an individual step in the job.\n" +
@@ -633,11 +812,47 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                    # These are directly used in creating the Pods that execute the Job.\n" +
 	"                    requests:\n" +
 	"                        \"\": \"\"\n" +
+	"                  # Retry defines if and how this step should be retried when it fails\n" +
+	"                  # for a reason which is known to be transient.\n" +
+	"                  retry:\n" +
+	"                    # Attempts is the maximum number of times the step will be executed,\n" +
+	"                    # including the first execution.\n" +
+	"                    attempts: 0\n" +
+	"                    # Backoff is how long we will wait before starting the next attempt.\n" +
+	"                    backoff: 0s\n" +
+	"                    # ExitCodes lists the exit codes of the test container for which the\n" +
+	"                    # failure is considered retryable.\n" +
+	"                    exit_codes:\n" +
+	"                        - 0\n" +
+	"                    # LogPatterns lists regular expressions which make the failure retryable\n" +
+	"                    # when they match the termination message of the test container, which\n" +
+	"                    # contains the tail of its logs.\n" +
+	"                    log_patterns:\n" +
+	"                        - \"\"\n" +
 	"                  # RunAsScript defines if this step should be executed as a script mounted\n" +
 	"                  # in the test container instead of being executed directly via bash\n" +
 	"                  run_as_script: false\n" +
 	"                  # Timeout is how long the we will wait before aborting a job with SIGINT.\n" +
 	"                  timeout: 0s\n" +
+	"                  # When defines conditions which must all be met for this step to run.\n" +
+	"                  # Steps whose conditions are not met are skipped.\n" +
+	"                  when:\n" +
+	"                    # Env lists parameters which must have the given values.\n" +
+	"                    env:\n" +
+	"                        - # Equals is the value the parameter must have.\n" +
+	"                          equals: ' '\n" +
+	"                          # Name of the parameter.\n" +
+	"                          name: ' '\n" +
+	"                    # SharedDirFiles lists files which must exist in the shared directory.\n" +
+	"                    shared_dir_files:\n" +
+	"                        - \"\"\n" +
+	"                    # Steps lists previous steps which must have finished with the given\n" +
+	"                    # results.\n" +
+	"                    steps:\n" +
+	"                        - # Name of the previous step.\n" +
+	"                          name: ' '\n" +
+	"                          # Result the previous step must have finished with.\n" +
+	"                          result: ' '\n" +
 	"            # Test is the array of test steps that define the actual test.\n" +
 	"            test:\n" +
 	"                - # As is the name of the LiteralTestStep.\n" +
@@ -652,6 +867,12 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                  cli: ' '\n" +
 	"                  # Commands is the command(s) that will be run inside the image.\n" +
 	"                  commands: ' '\n" +
+	"                  # Consumes lists the files in the shared directory this step needs,\n" +
+	"                  # which a step running before it must produce. The kubeconfig and\n" +
+	"                  # kubeadmin-password files may also be provided by ci-operator, as for\n" +
+	"                  # tests which claim a cluster.\n" +
+	"                  consumes:\n" +
+	"                    - \"\"\n" +
 	"                  # Credentials defines the credentials we'll mount into this step.\n" +
 	"                  credentials:\n" +
 	"                    - # MountPath is where the secret should be mounted.\n" +
@@ -677,12 +898,23 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                        - \"\"\n" +
 	"                  # Environment lists parameters that should be set by the test.\n" +
 	"                  env:\n" +
-	"                    - # Default if not set, optional, makes the parameter not required if set.\n" +
+	"                    - # AllowedValues lists the values the parameter can be set to,\n" +
+	"                      # required for `enum` parameters.\n" +
+	"                      allowed_values:\n" +
+	"                        - \"\"\n" +
+	"                      # Default if not set, optional, makes the parameter not required if set.\n" +
 	"                      default: \"\"\n" +
 	"                      # Documentation is a textual description of the parameter.\n" +
 	"                      documentation: ' '\n" +
 	"                      # Name of the environment variable.\n" +
 	"                      name: ' '\n" +
+	"                      # Pattern is a regular expression the values of a `string`\n" +
+	"                      # parameter must match.\n" +
+	"                      pattern: ' '\n" +
+	"                      # Type constrains the values of the parameter: one of `string`,\n" +
+	"                      # `bool`, `int`, `enum` and `duration`. Any string is allowed\n" +
+	"                      # when not set, `bool` parameters are either `true` or `false`.\n" +
+	"                      type: ' '\n" +
 	"                  # From is the container image that will be used for this step.\n" +
 	"                  from: ' '\n" +
 	"                  # FromImage is a literal ImageStreamTag reference to use for this step.\n" +
@@ -709,6 +941,14 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                  # flag is set to true in MultiStageTestConfiguration. This option is\n" +
 	"                  # applicable to `post` steps.\n" +
 	"                  optional_on_success: false\n" +
+	"                  # ParallelGroup names a group of consecutive steps which are run\n" +
+	"                  # concurrently. Steps of a parallel chain are put into a group named\n" +
+	"                  # after the chain.\n" +
+	"                  parallel_group: ' '\n" +
+	"                  # Produces lists the files this step writes to the shared directory\n" +
+	"                  # for later steps to use.\n" +
+	"                  produces:\n" +
+	"                    - \"\"\n" +
 	"                  # Resources defines the resource requirements for the step.\n" +
 	"                  resources:\n" +
 	"                    # Limits are resource limits applied to an individual step in the job.\n" +
@@ -719,11 +959,47 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                    # These are directly used in creating the Pods that execute the Job.\n" +
 	"                    requests:\n" +
 	"                        \"\": \"\"\n" +
+	"                  # Retry defines if and how this step should be retried when it fails\n" +
+	"                  # for a reason which is known to be transient.\n" +
+	"                  retry:\n" +
+	"                    # Attempts is the maximum number of times the step will be executed,\n" +
+	"                    # including the first execution.\n" +
+	"                    attempts: 0\n" +
+	"                    # Backoff is how long we will wait before starting the next attempt.\n" +
+	"                    backoff: 0s\n" +
+	"                    # ExitCodes lists the exit codes of the test container for which the\n" +
+	"                    # failure is considered retryable.\n" +
+	"                    exit_codes:\n" +
+	"                        - 0\n" +
+	"                    # LogPatterns lists regular expressions which make the failure retryable\n" +
+	"                    # when they match the termination message of the test container, which\n" +
+	"                    # contains the tail of its logs.\n" +
+	"                    log_patterns:\n" +
+	"                        - \"\"\n" +
 	"                  # RunAsScript defines if this step should be executed as a script mounted\n" +
 	"                  # in the test container instead of being executed directly via bash\n" +
 	"                  run_as_script: false\n" +
 	"                  # Timeout is how long the we will wait before aborting a job with SIGINT.\n" +
 	"                  timeout: 0s\n" +
+	"                  # When defines conditions which must all be met for this step to run.\n" +
+	"                  # Steps whose conditions are not met are skipped.\n" +
+	"                  when:\n" +
+	"                    # Env lists parameters which must have the given values.\n" +
+	"                    env:\n" +
+	"                        - # Equals is the value the parameter must have.\n" +
+	"                          equals: ' '\n" +
+	"                          # Name of the parameter.\n" +
+	"                          name: ' '\n" +
+	"                    # SharedDirFiles lists files which must exist in the shared directory.\n" +
+	"                    shared_dir_files:\n" +
+	"                        - \"\"\n" +
+	"                    # Steps lists previous steps which must have finished with the given\n" +
+	"                    # results.\n" +
+	"                    steps:\n" +
+	"                        - # Name of the previous step.\n" +
+	"                          name: ' '\n" +
+	"                          # Result the previous step must have finished with.\n" +
+	"                          result: ' '\n" +
 	"            # Override job timeout\n" +
 	"            timeout: 0s\n" +
 	"        openshift_ansible:\n" +
@@ -823,6 +1099,9 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                  # will be injected into this step.\n" +
 	"                  cli: ' '\n" +
 	"                  commands: ' '\n" +
+	"                  consumes:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - \"\"\n" +
 	"                  credentials:\n" +
 	"                    # LiteralTestStep is a full test step definition.\n" +
 	"                    - mount_path: ' '\n" +
@@ -842,9 +1121,14 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                        - \"\"\n" +
 	"                  env:\n" +
 	"                    # LiteralTestStep is a full test step definition.\n" +
-	"                    - default: \"\"\n" +
+	"                    - allowed_values:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        - \"\"\n" +
+	"                      default: \"\"\n" +
 	"                      documentation: ' '\n" +
 	"                      name: ' '\n" +
+	"                      pattern: ' '\n" +
+	"                      type: ' '\n" +
 	"                  from: ' '\n" +
 	"                  from_image:\n" +
 	"                    # LiteralTestStep is a full test step definition.\n" +
@@ -861,6 +1145,10 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                    # LiteralTestStep is a full test step definition.\n" +
 	"                    - \"\"\n" +
 	"                  optional_on_success: false\n" +
+	"                  parallel_group: ' '\n" +
+	"                  produces:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - \"\"\n" +
 	"                  # Reference is the name of a step reference.\n" +
 	"                  ref: \"\"\n" +
 	"                  # Resources defines the resource requirements for the step.\n" +
@@ -872,8 +1160,31 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                    requests:\n" +
 	"                        # LiteralTestStep is a full test step definition.\n" +
 	"                        \"\": \"\"\n" +
+	"                  retry:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    attempts: 0\n" +
+	"                    backoff: 0s\n" +
+	"                    exit_codes:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        - 0\n" +
+	"                    log_patterns:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        - \"\"\n" +
 	"                  run_as_script: false\n" +
 	"                  timeout: 0s\n" +
+	"                  when:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    env:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        - equals: ' '\n" +
+	"                          name: ' '\n" +
+	"                    shared_dir_files:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        - \"\"\n" +
+	"                    steps:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        - name: ' '\n" +
+	"                          result: ' '\n" +
 	"            # Pre is the array of test steps run to set up the environment for the test.\n" +
 	"            pre:\n" +
 	"                # LiteralTestStep is a full test step definition.\n" +
@@ -885,6 +1196,9 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                  # will be injected into this step.\n" +
 	"                  cli: ' '\n" +
 	"                  commands: ' '\n" +
+	"                  consumes:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - \"\"\n" +
 	"                  credentials:\n" +
 	"                    # LiteralTestStep is a full test step definition.\n" +
 	"                    - mount_path: ' '\n" +
@@ -904,9 +1218,14 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                        - \"\"\n" +
 	"                  env:\n" +
 	"                    # LiteralTestStep is a full test step definition.\n" +
-	"                    - default: \"\"\n" +
+	"                    - allowed_values:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        - \"\"\n" +
+	"                      default: \"\"\n" +
 	"                      documentation: ' '\n" +
 	"                      name: ' '\n" +
+	"                      pattern: ' '\n" +
+	"                      type: ' '\n" +
 	"                  from: ' '\n" +
 	"                  from_image:\n" +
 	"                    # LiteralTestStep is a full test step definition.\n" +
@@ -923,6 +1242,10 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                    # LiteralTestStep is a full test step definition.\n" +
 	"                    - \"\"\n" +
 	"                  optional_on_success: false\n" +
+	"                  parallel_group: ' '\n" +
+	"                  produces:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - \"\"\n" +
 	"                  # Reference is the name of a step reference.\n" +
 	"                  ref: \"\"\n" +
 	"                  # Resources defines the resource requirements for the step.\n" +
@@ -934,8 +1257,31 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                    requests:\n" +
 	"                        # LiteralTestStep is a full test step definition.\n" +
 	"                        \"\": \"\"\n" +
+	"                  retry:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    attempts: 0\n" +
+	"                    backoff: 0s\n" +
+	"                    exit_codes:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        - 0\n" +
+	"                    log_patterns:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        - \"\"\n" +
 	"                  run_as_script: false\n" +
 	"                  timeout: 0s\n" +
+	"                  when:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    env:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        - equals: ' '\n" +
+	"                          name: ' '\n" +
+	"                    shared_dir_files:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        - \"\"\n" +
+	"                    steps:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        - name: ' '\n" +
+	"                          result: ' '\n" +
 	"            # Test is the array of test steps that define the actual test.\n" +
 	"            test:\n" +
 	"                # LiteralTestStep is a full test step definition.\n" +
@@ -947,6 +1293,9 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                  # will be injected into this step.\n" +
 	"                  cli: ' '\n" +
 	"                  commands: ' '\n" +
+	"                  consumes:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - \"\"\n" +
 	"                  credentials:\n" +
 	"                    # LiteralTestStep is a full test step definition.\n" +
 	"                    - mount_path: ' '\n" +
@@ -966,9 +1315,14 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                        - \"\"\n" +
 	"                  env:\n" +
 	"                    # LiteralTestStep is a full test step definition.\n" +
-	"                    - default: \"\"\n" +
+	"                    - allowed_values:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        - \"\"\n" +
+	"                      default: \"\"\n" +
 	"                      documentation: ' '\n" +
 	"                      name: ' '\n" +
+	"                      pattern: ' '\n" +
+	"                      type: ' '\n" +
 	"                  from: ' '\n" +
 	"                  from_image:\n" +
 	"                    # LiteralTestStep is a full test step definition.\n" +
@@ -985,6 +1339,10 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                    # LiteralTestStep is a full test step definition.\n" +
 	"                    - \"\"\n" +
 	"                  optional_on_success: false\n" +
+	"                  parallel_group: ' '\n" +
+	"                  produces:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - \"\"\n" +
 	"                  # Reference is the name of a step reference.\n" +
 	"                  ref: \"\"\n" +
 	"                  # Resources defines the resource requirements for the step.\n" +
@@ -996,8 +1354,31 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                    requests:\n" +
 	"                        # LiteralTestStep is a full test step definition.\n" +
 	"                        \"\": \"\"\n" +
+	"                  retry:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    attempts: 0\n" +
+	"                    backoff: 0s\n" +
+	"                    exit_codes:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        - 0\n" +
+	"                    log_patterns:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        - \"\"\n" +
 	"                  run_as_script: false\n" +
 	"                  timeout: 0s\n" +
+	"                  when:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    env:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        - equals: ' '\n" +
+	"                          name: ' '\n" +
+	"                    shared_dir_files:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        - \"\"\n" +
+	"                    steps:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        - name: ' '\n" +
+	"                          result: ' '\n" +
 	"            # Workflow is the name of the workflow to be used for this configuration. For fields defined in both\n" +
 	"            # the config and the workflow, the fields from the config will override what is set in Workflow.\n" +
 	"            workflow: \"\"\n" +
@@ -1022,6 +1403,62 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"            stream: ' '\n" +
 	"            # Version is the minor version to search for\n" +
 	"            version: ' '\n" +
+	"        # Composition describes a payload composed from a release\n" +
+	"        # and images of components taken from other sources\n" +
+	"        composition:\n" +
+	"            # Base is the release the composed payload starts from.\n" +
+	"            base:\n" +
+	"                # Candidate describes a candidate release payload\n" +
+	"                candidate:\n" +
+	"                    # Architecture is the architecture for the product.\n" +
+	"                    # Defaults to amd64.\n" +
+	"                    architecture: ' '\n" +
+	"                    # Product is the name of the product being released\n" +
+	"                    product: ' '\n" +
+	"                    # ReleaseStream is the stream from which we pick the latest candidate\n" +
+	"                    stream: ' '\n" +
+	"                    # Version is the minor version to search for\n" +
+	"                    version: ' '\n" +
+	"                # Integration describes an integration stream which we can create a payload out of\n" +
+	"                integration:\n" +
+	"                    # Name is the name of the ImageStream\n" +
+	"                    name: ' '\n" +
+	"                    # Namespace is the namespace in which the integration stream lives.\n" +
+	"                    namespace: ' '\n" +
+	"                # Prerelease describes a yet-to-be released payload\n" +
+	"                prerelease:\n" +
+	"                    # Architecture is the architecture for the product.\n" +
+	"                    # Defaults to amd64.\n" +
+	"                    architecture: ' '\n" +
+	"                    # Product is the name of the product being released\n" +
+	"                    product: ' '\n" +
+	"                    # VersionBounds describe the allowable version bounds to search in\n" +
+	"                    version_bounds:\n" +
+	"                        lower: ' '\n" +
+	"                        upper: ' '\n" +
+	"                # Release describes a released payload\n" +
+	"                release:\n" +
+	"                    # Architecture is the architecture for the release.\n" +
+	"                    # Defaults to amd64.\n" +
+	"                    architecture: ' '\n" +
+	"                    # Channel is the release channel to search in\n" +
+	"                    channel: ' '\n" +
+	"                    # Version is the minor version to search for\n" +
+	"                    version: ' '\n" +
+	"            # Overrides replace the images of components of the base release.\n" +
+	"            overrides:\n" +
+	"                - # Components are the names of the components to override, as\n" +
+	"                  # they are tagged in the release.\n" +
+	"                  components:\n" +
+	"                    - \"\"\n" +
+	"                  # Name is the name of the integration stream the images are\n" +
+	"                  # taken from.\n" +
+	"                  name: ' '\n" +
+	"                  # Namespace is the namespace of the integration stream the\n" +
+	"                  # images are taken from, by the tags named like the components.\n" +
+	"                  namespace: ' '\n" +
+	"                  # PullSpec is the image to use for the single component.\n" +
+	"                  pull_spec: ' '\n" +
 	"        # Integration describes an integration stream which we can create a payload out of\n" +
 	"        integration:\n" +
 	"            # Name is the name of the ImageStream\n" +
@@ -1198,6 +1635,12 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"              cli: ' '\n" +
 	"              # Commands is the command(s) that will be run inside the image.\n" +
 	"              commands: ' '\n" +
+	"              # Consumes lists the files in the shared directory this step needs,\n" +
+	"              # which a step running before it must produce. The kubeconfig and\n" +
+	"              # kubeadmin-password files may also be provided by ci-operator, as for\n" +
+	"              # tests which claim a cluster.\n" +
+	"              consumes:\n" +
+	"                - \"\"\n" +
 	"              # Credentials defines the credentials we'll mount into this step.\n" +
 	"              credentials:\n" +
 	"                - # MountPath is where the secret should be mounted.\n" +
@@ -1223,12 +1666,23 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                    - \"\"\n" +
 	"              # Environment lists parameters that should be set by the test.\n" +
 	"              env:\n" +
-	"                - # Default if not set, optional, makes the parameter not required if set.\n" +
+	"                - # AllowedValues lists the values the parameter can be set to,\n" +
+	"                  # required for `enum` parameters.\n" +
+	"                  allowed_values:\n" +
+	"                    - \"\"\n" +
+	"                  # Default if not set, optional, makes the parameter not required if set.\n" +
 	"                  default: \"\"\n" +
 	"                  # Documentation is a textual description of the parameter.\n" +
 	"                  documentation: ' '\n" +
 	"                  # Name of the environment variable.\n" +
 	"                  name: ' '\n" +
+	"                  # Pattern is a regular expression the values of a `string`\n" +
+	"                  # parameter must match.\n" +
+	"                  pattern: ' '\n" +
+	"                  # Type constrains the values of the parameter: one of `string`,\n" +
+	"                  # `bool`, `int`, `enum` and `duration`. Any string is allowed\n" +
+	"                  # when not set, `bool` parameters are either `true` or `false`.\n" +
+	"                  type: ' '\n" +
 	"              # From is the container image that will be used for this step.\n" +
 	"              from: ' '\n" +
 	"              # FromImage is a literal ImageStreamTag reference to use for this step.\n" +
@@ -1255,6 +1709,14 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"              # flag is set to true in MultiStageTestConfiguration. This option is\n" +
 	"              # applicable to `post` steps.\n" +
 	"              optional_on_success: false\n" +
+	"              # ParallelGroup names a group of consecutive steps which are run\n" +
+	"              # concurrently. Steps of a parallel chain are put into a group named\n" +
+	"              # after the chain.\n" +
+	"              parallel_group: ' '\n" +
+	"              # Produces lists the files this step writes to the shared directory\n" +
+	"              # for later steps to use.\n" +
+	"              produces:\n" +
+	"                - \"\"\n" +
 	"              # Resources defines the resource requirements for the step.\n" +
 	"              resources:\n" +
 	"                # Limits are resource limits applied to an individual step in the job.\n" +
@@ -1265,11 +1727,47 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                # These are directly used in creating the Pods that execute the Job.\n" +
 	"                requests:\n" +
 	"                    \"\": \"\"\n" +
+	"              # Retry defines if and how this step should be retried when it fails\n" +
+	"              # for a reason which is known to be transient.\n" +
+	"              retry:\n" +
+	"                # Attempts is the maximum number of times the step will be executed,\n" +
+	"                # including the first execution.\n" +
+	"                attempts: 0\n" +
+	"                # Backoff is how long we will wait before starting the next attempt.\n" +
+	"                backoff: 0s\n" +
+	"                # ExitCodes lists the exit codes of the test container for which the\n" +
+	"                # failure is considered retryable.\n" +
+	"                exit_codes:\n" +
+	"                    - 0\n" +
+	"                # LogPatterns lists regular expressions which make the failure retryable\n" +
+	"                # when they match the termination message of the test container, which\n" +
+	"                # contains the tail of its logs.\n" +
+	"                log_patterns:\n" +
+	"                    - \"\"\n" +
 	"              # RunAsScript defines if this step should be executed as a script mounted\n" +
 	"              # in the test container instead of being executed directly via bash\n" +
 	"              run_as_script: false\n" +
 	"              # Timeout is how long the we will wait before aborting a job with SIGINT.\n" +
 	"              timeout: 0s\n" +
+	"              # When defines conditions which must all be met for this step to run.\n" +
+	"              # Steps whose conditions are not met are skipped.\n" +
+	"              when:\n" +
+	"                # Env lists parameters which must have the given values.\n" +
+	"                env:\n" +
+	"                    - # Equals is the value the parameter must have.\n" +
+	"                      equals: ' '\n" +
+	"                      # Name of the parameter.\n" +
+	"                      name: ' '\n" +
+	"                # SharedDirFiles lists files which must exist in the shared directory.\n" +
+	"                shared_dir_files:\n" +
+	"                    - \"\"\n" +
+	"                # Steps lists previous steps which must have finished with the given\n" +
+	"                # results.\n" +
+	"                steps:\n" +
+	"                    - # Name of the previous step.\n" +
+	"                      name: ' '\n" +
+	"                      # Result the previous step must have finished with.\n" +
+	"                      result: ' '\n" +
 	"        # Pre is the array of test steps run to set up the environment for the test.\n" +
 	"        pre:\n" +
 	"            - # As is the name of the LiteralTestStep.\n" +
@@ -1284,6 +1782,12 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"              cli: ' '\n" +
 	"              # Commands is the command(s) that will be run inside the image.\n" +
 	"              commands: ' '\n" +
+	"              # Consumes lists the files in the shared directory this step needs,\n" +
+	"              # which a step running before it must produce. The kubeconfig and\n" +
+	"              # kubeadmin-password files may also be provided by ci-operator, as for\n" +
+	"              # tests which claim a cluster.\n" +
+	"              consumes:\n" +
+	"                - \"\"\n" +
 	"              # Credentials defines the credentials we'll mount into this step.\n" +
 	"              credentials:\n" +
 	"                - # MountPath is where the secret should be mounted.\n" +
@@ -1309,12 +1813,23 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                    - \"\"\n" +
 	"              # Environment lists parameters that should be set by the test.\n" +
 	"              env:\n" +
-	"                - # Default if not set, optional, makes the parameter not required if set.\n" +
+	"                - # AllowedValues lists the values the parameter can be set to,\n" +
+	"                  # required for `enum` parameters.\n" +
+	"                  allowed_values:\n" +
+	"                    - \"\"\n" +
+	"                  # Default if not set, optional, makes the parameter not required if set.\n" +
 	"                  default: \"\"\n" +
 	"                  # Documentation is a textual description of the parameter.\n" +
 	"                  documentation: ' '\n" +
 	"                  # Name of the environment variable.\n" +
 	"                  name: ' '\n" +
+	"                  # Pattern is a regular expression the values of a `string`\n" +
+	"                  # parameter must match.\n" +
+	"                  pattern: ' '\n" +
+	"                  # Type constrains the values of the parameter: one of `string`,\n" +
+	"                  # `bool`, `int`, `enum` and `duration`. Any string is allowed\n" +
+	"                  # when not set, `bool` parameters are either `true` or `false`.\n" +
+	"                  type: ' '\n" +
 	"              # From is the container image that will be used for this step.\n" +
 	"              from: ' '\n" +
 	"              # FromImage is a literal ImageStreamTag reference to use for this step.\n" +
@@ -1341,6 +1856,14 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"              # flag is set to true in MultiStageTestConfiguration. This option is\n" +
 	"              # applicable to `post` steps.\n" +
 	"              optional_on_success: false\n" +
+	"              # ParallelGroup names a group of consecutive steps which are run\n" +
+	"              # concurrently. Steps of a parallel chain are put into a group named\n" +
+	"              # after the chain.\n" +
+	"              parallel_group: ' '\n" +
+	"              # Produces lists the files this step writes to the shared directory\n" +
+	"              # for later steps to use.\n" +
+	"              produces:\n" +
+	"                - \"\"\n" +
 	"              # Resources defines the resource requirements for the step.\n" +
 	"              resources:\n" +
 	"                # Limits are resource limits applied to an individual step in the job.\n" +
@@ -1351,11 +1874,47 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                # These are directly used in creating the Pods that execute the Job.\n" +
 	"                requests:\n" +
 	"                    \"\": \"\"\n" +
+	"              # Retry defines if and how this step should be retried when it fails\n" +
+	"              # for a reason which is known to be transient.\n" +
+	"              retry:\n" +
+	"                # Attempts is the maximum number of times the step will be executed,\n" +
+	"                # including the first execution.\n" +
+	"                attempts: 0\n" +
+	"                # Backoff is how long we will wait before starting the next attempt.\n" +
+	"                backoff: 0s\n" +
+	"                # ExitCodes lists the exit codes of the test container for which the\n" +
+	"                # failure is considered retryable.\n" +
+	"                exit_codes:\n" +
+	"                    - 0\n" +
+	"                # LogPatterns lists regular expressions which make the failure retryable\n" +
+	"                # when they match the termination message of the test container, which\n" +
+	"                # contains the tail of its logs.\n" +
+	"                log_patterns:\n" +
+	"                    - \"\"\n" +
 	"              # RunAsScript defines if this step should be executed as a script mounted\n" +
 	"              # in the test container instead of being executed directly via bash\n" +
 	"              run_as_script: false\n" +
 	"              # Timeout is how long the we will wait before aborting a job with SIGINT.\n" +
 	"              timeout: 0s\n" +
+	"              # When defines conditions which must all be met for this step to run.\n" +
+	"              # Steps whose conditions are not met are skipped.\n" +
+	"              when:\n" +
+	"                # Env lists parameters which must have the given values.\n" +
+	"                env:\n" +
+	"                    - # Equals is the value the parameter must have.\n" +
+	"                      equals: ' '\n" +
+	"                      # Name of the parameter.\n" +
+	"                      name: ' '\n" +
+	"                # SharedDirFiles lists files which must exist in the shared directory.\n" +
+	"                shared_dir_files:\n" +
+	"                    - \"\"\n" +
+	"                # Steps lists previous steps which must have finished with the given\n" +
+	"                # results.\n" +
+	"                steps:\n" +
+	"                    - # Name of the previous step.\n" +
+	"                      name: ' '\n" +
+	"                      # Result the previous step must have finished with.\n" +
+	"                      result: ' '\n" +
 	"        # Test is the array of test steps that define the actual test.\n" +
 	"        test:\n" +
 	"            - # As is the name of the LiteralTestStep.\n" +
@@ -1370,6 +1929,12 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"              cli: ' '\n" +
 	"              # Commands is the command(s) that will be run inside the image.\n" +
 	"              commands: ' '\n" +
+	"              # Consumes lists the files in the shared directory this step needs,\n" +
+	"              # which a step running before it must produce. The kubeconfig and\n" +
+	"              # kubeadmin-password files may also be provided by ci-operator, as for\n" +
+	"              # tests which claim a cluster.\n" +
+	"              consumes:\n" +
+	"                - \"\"\n" +
 	"              # Credentials defines the credentials we'll mount into this step.\n" +
 	"              credentials:\n" +
 	"                - # MountPath is where the secret should be mounted.\n" +
@@ -1395,12 +1960,23 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                    - \"\"\n" +
 	"              # Environment lists parameters that should be set by the test.\n" +
 	"              env:\n" +
-	"                - # Default if not set, optional, makes the parameter not required if set.\n" +
+	"                - # AllowedValues lists the values the parameter can be set to,\n" +
+	"                  # required for `enum` parameters.\n" +
+	"                  allowed_values:\n" +
+	"                    - \"\"\n" +
+	"                  # Default if not set, optional, makes the parameter not required if set.\n" +
 	"                  default: \"\"\n" +
 	"                  # Documentation is a textual description of the parameter.\n" +
 	"                  documentation: ' '\n" +
 	"                  # Name of the environment variable.\n" +
 	"                  name: ' '\n" +
+	"                  # Pattern is a regular expression the values of a `string`\n" +
+	"                  # parameter must match.\n" +
+	"                  pattern: ' '\n" +
+	"                  # Type constrains the values of the parameter: one of `string`,\n" +
+	"                  # `bool`, `int`, `enum` and `duration`. Any string is allowed\n" +
+	"                  # when not set, `bool` parameters are either `true` or `false`.\n" +
+	"                  type: ' '\n" +
 	"              # From is the container image that will be used for this step.\n" +
 	"              from: ' '\n" +
 	"              # FromImage is a literal ImageStreamTag reference to use for this step.\n" +
@@ -1427,6 +2003,14 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"              # flag is set to true in MultiStageTestConfiguration. This option is\n" +
 	"              # applicable to `post` steps.\n" +
 	"              optional_on_success: false\n" +
+	"              # ParallelGroup names a group of consecutive steps which are run\n" +
+	"              # concurrently. Steps of a parallel chain are put into a group named\n" +
+	"              # after the chain.\n" +
+	"              parallel_group: ' '\n" +
+	"              # Produces lists the files this step writes to the shared directory\n" +
+	"              # for later steps to use.\n" +
+	"              produces:\n" +
+	"                - \"\"\n" +
 	"              # Resources defines the resource requirements for the step.\n" +
 	"              resources:\n" +
 	"                # Limits are resource limits applied to an individual step in the job.\n" +
@@ -1437,11 +2021,47 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                # These are directly used in creating the Pods that execute the Job.\n" +
 	"                requests:\n" +
 	"                    \"\": \"\"\n" +
+	"              # Retry defines if and how this step should be retried when it fails\n" +
+	"              # for a reason which is known to be transient.\n" +
+	"              retry:\n" +
+	"                # Attempts is the maximum number of times the step will be executed,\n" +
+	"                # including the first execution.\n" +
+	"                attempts: 0\n" +
+	"                # Backoff is how long we will wait before starting the next attempt.\n" +
+	"                backoff: 0s\n" +
+	"                # ExitCodes lists the exit codes of the test container for which the\n" +
+	"                # failure is considered retryable.\n" +
+	"                exit_codes:\n" +
+	"                    - 0\n" +
+	"                # LogPatterns lists regular expressions which make the failure retryable\n" +
+	"                # when they match the termination message of the test container, which\n" +
+	"                # contains the tail of its logs.\n" +
+	"                log_patterns:\n" +
+	"                    - \"\"\n" +
 	"              # RunAsScript defines if this step should be executed as a script mounted\n" +
 	"              # in the test container instead of being executed directly via bash\n" +
 	"              run_as_script: false\n" +
 	"              # Timeout is how long the we will wait before aborting a job with SIGINT.\n" +
 	"              timeout: 0s\n" +
+	"              # When defines conditions which must all be met for this step to run.\n" +
+	"              # Steps whose conditions are not met are skipped.\n" +
+	"              when:\n" +
+	"                # Env lists parameters which must have the given values.\n" +
+	"                env:\n" +
+	"                    - # Equals is the value the parameter must have.\n" +
+	"                      equals: ' '\n" +
+	"                      # Name of the parameter.\n" +
+	"                      name: ' '\n" +
+	"                # SharedDirFiles lists files which must exist in the shared directory.\n" +
+	"                shared_dir_files:\n" +
+	"                    - \"\"\n" +
+	"                # Steps lists previous steps which must have finished with the given\n" +
+	"                # results.\n" +
+	"                steps:\n" +
+	"                    - # Name of the previous step.\n" +
+	"                      name: ' '\n" +
+	"                      # Result the previous step must have finished with.\n" +
+	"                      result: ' '\n" +
 	"        # Override job timeout\n" +
 	"        timeout: 0s\n" +
 	"      openshift_ansible:\n" +
@@ -1541,6 +2161,9 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"              # will be injected into this step.\n" +
 	"              cli: ' '\n" +
 	"              commands: ' '\n" +
+	"              consumes:\n" +
+	"                # LiteralTestStep is a full test step definition.\n" +
+	"                - \"\"\n" +
 	"              credentials:\n" +
 	"                # LiteralTestStep is a full test step definition.\n" +
 	"                - mount_path: ' '\n" +
@@ -1560,9 +2183,14 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                    - \"\"\n" +
 	"              env:\n" +
 	"                # LiteralTestStep is a full test step definition.\n" +
-	"                - default: \"\"\n" +
+	"                - allowed_values:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - \"\"\n" +
+	"                  default: \"\"\n" +
 	"                  documentation: ' '\n" +
 	"                  name: ' '\n" +
+	"                  pattern: ' '\n" +
+	"                  type: ' '\n" +
 	"              from: ' '\n" +
 	"              from_image:\n" +
 	"                # LiteralTestStep is a full test step definition.\n" +
@@ -1579,6 +2207,10 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                # LiteralTestStep is a full test step definition.\n" +
 	"                - \"\"\n" +
 	"              optional_on_success: false\n" +
+	"              parallel_group: ' '\n" +
+	"              produces:\n" +
+	"                # LiteralTestStep is a full test step definition.\n" +
+	"                - \"\"\n" +
 	"              # Reference is the name of a step reference.\n" +
 	"              ref: \"\"\n" +
 	"              # Resources defines the resource requirements for the step.\n" +
@@ -1590,8 +2222,31 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                requests:\n" +
 	"                    # LiteralTestStep is a full test step definition.\n" +
 	"                    \"\": \"\"\n" +
+	"              retry:\n" +
+	"                # LiteralTestStep is a full test step definition.\n" +
+	"                attempts: 0\n" +
+	"                backoff: 0s\n" +
+	"                exit_codes:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - 0\n" +
+	"                log_patterns:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - \"\"\n" +
 	"              run_as_script: false\n" +
 	"              timeout: 0s\n" +
+	"              when:\n" +
+	"                # LiteralTestStep is a full test step definition.\n" +
+	"                env:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - equals: ' '\n" +
+	"                      name: ' '\n" +
+	"                shared_dir_files:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - \"\"\n" +
+	"                steps:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - name: ' '\n" +
+	"                      result: ' '\n" +
 	"        # Pre is the array of test steps run to set up the environment for the test.\n" +
 	"        pre:\n" +
 	"            # LiteralTestStep is a full test step definition.\n" +
@@ -1603,6 +2258,9 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"              # will be injected into this step.\n" +
 	"              cli: ' '\n" +
 	"              commands: ' '\n" +
+	"              consumes:\n" +
+	"                # LiteralTestStep is a full test step definition.\n" +
+	"                - \"\"\n" +
 	"              credentials:\n" +
 	"                # LiteralTestStep is a full test step definition.\n" +
 	"                - mount_path: ' '\n" +
@@ -1622,9 +2280,14 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                    - \"\"\n" +
 	"              env:\n" +
 	"                # LiteralTestStep is a full test step definition.\n" +
-	"                - default: \"\"\n" +
+	"                - allowed_values:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - \"\"\n" +
+	"                  default: \"\"\n" +
 	"                  documentation: ' '\n" +
 	"                  name: ' '\n" +
+	"                  pattern: ' '\n" +
+	"                  type: ' '\n" +
 	"              from: ' '\n" +
 	"              from_image:\n" +
 	"                # LiteralTestStep is a full test step definition.\n" +
@@ -1641,6 +2304,10 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                # LiteralTestStep is a full test step definition.\n" +
 	"                - \"\"\n" +
 	"              optional_on_success: false\n" +
+	"              parallel_group: ' '\n" +
+	"              produces:\n" +
+	"                # LiteralTestStep is a full test step definition.\n" +
+	"                - \"\"\n" +
 	"              # Reference is the name of a step reference.\n" +
 	"              ref: \"\"\n" +
 	"              # Resources defines the resource requirements for the step.\n" +
@@ -1652,8 +2319,31 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                requests:\n" +
 	"                    # LiteralTestStep is a full test step definition.\n" +
 	"                    \"\": \"\"\n" +
+	"              retry:\n" +
+	"                # LiteralTestStep is a full test step definition.\n" +
+	"                attempts: 0\n" +
+	"                backoff: 0s\n" +
+	"                exit_codes:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - 0\n" +
+	"                log_patterns:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - \"\"\n" +
 	"              run_as_script: false\n" +
 	"              timeout: 0s\n" +
+	"              when:\n" +
+	"                # LiteralTestStep is a full test step definition.\n" +
+	"                env:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - equals: ' '\n" +
+	"                      name: ' '\n" +
+	"                shared_dir_files:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - \"\"\n" +
+	"                steps:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - name: ' '\n" +
+	"                      result: ' '\n" +
 	"        # Test is the array of test steps that define the actual test.\n" +
 	"        test:\n" +
 	"            # LiteralTestStep is a full test step definition.\n" +
@@ -1665,6 +2355,9 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"              # will be injected into this step.\n" +
 	"              cli: ' '\n" +
 	"              commands: ' '\n" +
+	"              consumes:\n" +
+	"                # LiteralTestStep is a full test step definition.\n" +
+	"                - \"\"\n" +
 	"              credentials:\n" +
 	"                # LiteralTestStep is a full test step definition.\n" +
 	"                - mount_path: ' '\n" +
@@ -1684,9 +2377,14 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                    - \"\"\n" +
 	"              env:\n" +
 	"                # LiteralTestStep is a full test step definition.\n" +
-	"                - default: \"\"\n" +
+	"                - allowed_values:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - \"\"\n" +
+	"                  default: \"\"\n" +
 	"                  documentation: ' '\n" +
 	"                  name: ' '\n" +
+	"                  pattern: ' '\n" +
+	"                  type: ' '\n" +
 	"              from: ' '\n" +
 	"              from_image:\n" +
 	"                # LiteralTestStep is a full test step definition.\n" +
@@ -1703,6 +2401,10 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                # LiteralTestStep is a full test step definition.\n" +
 	"                - \"\"\n" +
 	"              optional_on_success: false\n" +
+	"              parallel_group: ' '\n" +
+	"              produces:\n" +
+	"                # LiteralTestStep is a full test step definition.\n" +
+	"                - \"\"\n" +
 	"              # Reference is the name of a step reference.\n" +
 	"              ref: \"\"\n" +
 	"              # Resources defines the resource requirements for the step.\n" +
@@ -1714,8 +2416,31 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                requests:\n" +
 	"                    # LiteralTestStep is a full test step definition.\n" +
 	"                    \"\": \"\"\n" +
+	"              retry:\n" +
+	"                # LiteralTestStep is a full test step definition.\n" +
+	"                attempts: 0\n" +
+	"                backoff: 0s\n" +
+	"                exit_codes:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - 0\n" +
+	"                log_patterns:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - \"\"\n" +
 	"              run_as_script: false\n" +
 	"              timeout: 0s\n" +
+	"              when:\n" +
+	"                # LiteralTestStep is a full test step definition.\n" +
+	"                env:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - equals: ' '\n" +
+	"                      name: ' '\n" +
+	"                shared_dir_files:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - \"\"\n" +
+	"                steps:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - name: ' '\n" +
+	"                      result: ' '\n" +
 	"        # Workflow is the name of the workflow to be used for this configuration. For fields defined in both\n" +
 	"        # the config and the workflow, the fields from the config will override what is set in Workflow.\n" +
 	"        workflow: \"\"\n" +
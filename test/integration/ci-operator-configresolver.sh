@@ -28,6 +28,9 @@ os::integration::configresolver::check_log
 os::cmd::expect_success "curl 'http://127.0.0.1:8080/configWithInjectedTest?org=openshift&repo=installer&branch=release-4.2&injectTestFromOrg=openshift&injectTestFromRepo=release&injectTestFromBranch=master&injectTestFromVariant=ci-4.9&injectTest=e2e' >${actual}/openshift-installer-release-4.2-injected.json"
 os::integration::compare "${actual}/openshift-installer-release-4.2-injected.json" "${expected}/openshift-installer-release-4.2-injected.json"
 os::integration::configresolver::check_log
+os::cmd::expect_success "curl 'http://127.0.0.1:8080/impact?step=ipi-install-install&type=reference' >${actual}/impact-ipi-install-install.json"
+os::integration::compare "${actual}/impact-ipi-install-install.json" "${expected}/impact-ipi-install-install.json"
+os::integration::configresolver::check_log
 
 generation="$( os::integration::configresolver::generation::config )"
 mv "${BASETMPDIR}/configs2/release-4.2/openshift-installer-release-4.2-golang111.yaml" "${BASETMPDIR}/configs/release-4.2/openshift-installer-release-4.2.yaml"
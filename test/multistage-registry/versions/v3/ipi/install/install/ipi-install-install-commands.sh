@@ -0,0 +1 @@
+openshift-install create cluster